@@ -0,0 +1,382 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/luciluz/psiconexo/internal/db"
+	"github.com/luciluz/psiconexo/internal/keyring"
+	"github.com/luciluz/psiconexo/internal/service"
+)
+
+// runCommand despacha los subcomandos administrativos (se ejecutan y terminan, sin levantar el servidor).
+func runCommand(name string, args []string) error {
+	switch name {
+	case "export-finances":
+		return runExportFinances(args)
+	case "load-price-index":
+		return runLoadPriceIndex(args)
+	case "apply-price-updates":
+		return runApplyPriceUpdates(args)
+	case "generate-note-key":
+		return runGenerateNoteKey(args)
+	case "rotate-note-keys":
+		return runRotateNoteKeys(args)
+	case "reindex-notes":
+		return runReindexNotes(args)
+	case "send-documentation-reminders":
+		return runSendDocumentationReminders(args)
+	case "set-password":
+		return runSetPassword(args)
+	case "reset-2fa":
+		return runResetTwoFactor(args)
+	case "create-organization":
+		return runCreateOrganization(args)
+	case "set-organization-2fa":
+		return runSetOrganizationTwoFactor(args)
+	case "add-organization-member":
+		return runAddOrganizationMember(args)
+	default:
+		return fmt.Errorf("comando desconocido: %s", name)
+	}
+}
+
+// psiconexo export-finances -professional 1 -from 2026-01-01 -to 2026-01-31 -format xlsx -out enero.xlsx
+func runExportFinances(args []string) error {
+	fs := flag.NewFlagSet("export-finances", flag.ExitOnError)
+	profID := fs.Int64("professional", 0, "ID del profesional (obligatorio)")
+	from := fs.String("from", "", "fecha desde YYYY-MM-DD (obligatorio)")
+	to := fs.String("to", "", "fecha hasta YYYY-MM-DD (obligatorio)")
+	format := fs.String("format", service.ExportFormatCSV, "csv o xlsx")
+	columns := fs.String("columns", "", "columnas separadas por coma (vacío = columnas por defecto)")
+	out := fs.String("out", "", "archivo de salida (vacío = stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *profID == 0 || *from == "" || *to == "" {
+		fs.Usage()
+		return fmt.Errorf("faltan parámetros requeridos: -professional, -from, -to")
+	}
+
+	start, err1 := time.Parse("2006-01-02", *from)
+	end, err2 := time.Parse("2006-01-02", *to)
+	if err1 != nil || err2 != nil {
+		return fmt.Errorf("formato de fecha inválido (use YYYY-MM-DD)")
+	}
+
+	var cols []string
+	if *columns != "" {
+		cols = strings.Split(*columns, ",")
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return fmt.Errorf("error creando %s: %w", *out, err)
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		w = f
+	}
+
+	conn := openDB()
+	defer func() {
+		_ = conn.Close()
+	}()
+	svc := service.NewService(db.New(conn), conn, serviceOptions()...)
+
+	return svc.ExportFinances(context.Background(), service.ExportFinancesRequest{
+		ProfessionalID: *profID,
+		StartDate:      start,
+		EndDate:        end,
+		Format:         *format,
+		Columns:        cols,
+	}, w)
+}
+
+// psiconexo load-price-index -series ipc -file ipc.csv
+func runLoadPriceIndex(args []string) error {
+	fs := flag.NewFlagSet("load-price-index", flag.ExitOnError)
+	series := fs.String("series", "", "nombre de la serie, ej: ipc (obligatorio)")
+	file := fs.String("file", "", "CSV con filas período;valor (obligatorio)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *series == "" || *file == "" {
+		fs.Usage()
+		return fmt.Errorf("faltan parámetros requeridos: -series, -file")
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return fmt.Errorf("error abriendo %s: %w", *file, err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	conn := openDB()
+	defer func() {
+		_ = conn.Close()
+	}()
+	svc := service.NewService(db.New(conn), conn, serviceOptions()...)
+
+	n, err := svc.LoadPriceIndexCSV(context.Background(), *series, f)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Serie %s: %d períodos cargados\n", *series, n)
+	return nil
+}
+
+// psiconexo apply-price-updates (correr a diario, ej. desde cron)
+func runApplyPriceUpdates(args []string) error {
+	fs := flag.NewFlagSet("apply-price-updates", flag.ExitOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	conn := openDB()
+	defer func() {
+		_ = conn.Close()
+	}()
+	svc := service.NewService(db.New(conn), conn, serviceOptions()...)
+
+	updates, err := svc.ApplyDuePriceUpdates(context.Background())
+	for _, u := range updates {
+		fmt.Printf("Profesional %d, %s %d min: $ %s (%d turnos, %d reglas)\n",
+			u.Entry.ProfessionalID, u.Entry.Modality, u.Entry.DurationMinutes,
+			u.Entry.Price, u.AppointmentsUpdated, u.RulesUpdated)
+	}
+	return err
+}
+
+// psiconexo generate-note-key: imprime una clave maestra nueva para agregar a NOTES_MASTER_KEYS
+func runGenerateNoteKey(args []string) error {
+	key, err := keyring.GenerateKey()
+	if err != nil {
+		return err
+	}
+	fmt.Println(key)
+	return nil
+}
+
+// psiconexo rotate-note-keys -batch 500
+// Antes de correrlo: agregar la clave nueva al keyring (manteniendo las anteriores) y
+// apuntar NOTES_KEY_VERSION a ella. Es idempotente: se puede relanzar si se corta.
+func runRotateNoteKeys(args []string) error {
+	fs := flag.NewFlagSet("rotate-note-keys", flag.ExitOnError)
+	batch := fs.Int("batch", 500, "notas por lote")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	conn := openDB()
+	defer func() {
+		_ = conn.Close()
+	}()
+	svc := service.NewService(db.New(conn), conn, serviceOptions()...)
+
+	stats, err := svc.RotateNoteKeys(context.Background(), *batch)
+	if stats != nil {
+		fmt.Printf("Registros re-envueltos: %d, cifrados por primera vez: %d, omitidos: %d\n",
+			stats.Rewrapped, stats.Encrypted, stats.Skipped)
+	}
+	return err
+}
+
+// psiconexo reindex-notes -batch 500
+// Reconstruye el índice ciego de búsqueda (requiere NOTES_INDEX_KEY, además de las claves maestras).
+func runReindexNotes(args []string) error {
+	fs := flag.NewFlagSet("reindex-notes", flag.ExitOnError)
+	batch := fs.Int("batch", 500, "notas por lote")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	conn := openDB()
+	defer func() {
+		_ = conn.Close()
+	}()
+	svc := service.NewService(db.New(conn), conn, serviceOptions()...)
+
+	indexed, err := svc.ReindexNotes(context.Background(), *batch)
+	fmt.Printf("Notas indexadas: %d\n", indexed)
+	return err
+}
+
+// psiconexo send-documentation-reminders -days 3 (pensado para cron semanal)
+func runSendDocumentationReminders(args []string) error {
+	fs := flag.NewFlagSet("send-documentation-reminders", flag.ExitOnError)
+	days := fs.Int("days", service.DefaultDraftAgeDays, "antigüedad mínima de los borradores a reclamar")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	conn := openDB()
+	defer func() {
+		_ = conn.Close()
+	}()
+	svc := service.NewService(db.New(conn), conn, serviceOptions()...)
+
+	stats, err := svc.SendDocumentationReminders(context.Background(), *days)
+	fmt.Printf("Recordatorios enviados: %d, sin pendientes: %d, fallidos: %d\n",
+		stats.Notified, stats.Skipped, stats.Failed)
+	return err
+}
+
+// psiconexo set-password -email ana@ejemplo.com < clave.txt
+// Fija la contraseña de un profesional (cuentas creadas antes del login). La contraseña se lee
+// de la entrada estándar para que no quede en el historial de la terminal.
+func runSetPassword(args []string) error {
+	fs := flag.NewFlagSet("set-password", flag.ExitOnError)
+	email := fs.String("email", "", "email del profesional (obligatorio)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" {
+		fs.Usage()
+		return fmt.Errorf("falta el parámetro requerido: -email")
+	}
+
+	fmt.Fprint(os.Stderr, "Contraseña: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	password := strings.TrimRight(line, "\r\n")
+
+	conn := openDB()
+	defer func() {
+		_ = conn.Close()
+	}()
+	svc := service.NewService(db.New(conn), conn, serviceOptions()...)
+
+	if err := svc.SetPassword(context.Background(), *email, password); err != nil {
+		return err
+	}
+	fmt.Println("Contraseña actualizada; se cerraron las sesiones abiertas.")
+	return nil
+}
+
+// psiconexo reset-2fa -email ana@ejemplo.com
+// Borra la verificación en dos pasos de quien perdió el teléfono y los códigos de recuperación
+// (verificar la identidad antes por otro medio). Cierra sus sesiones abiertas.
+func runResetTwoFactor(args []string) error {
+	fs := flag.NewFlagSet("reset-2fa", flag.ExitOnError)
+	email := fs.String("email", "", "email del profesional (obligatorio)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" {
+		fs.Usage()
+		return fmt.Errorf("falta el parámetro requerido: -email")
+	}
+
+	conn := openDB()
+	defer func() {
+		_ = conn.Close()
+	}()
+	svc := service.NewService(db.New(conn), conn, serviceOptions()...)
+
+	if err := svc.ResetTwoFactor(context.Background(), *email); err != nil {
+		return err
+	}
+	fmt.Println("Verificación en dos pasos desactivada; se cerraron las sesiones abiertas.")
+	return nil
+}
+
+// psiconexo create-organization -name "Centro Palermo" -require-2fa
+func runCreateOrganization(args []string) error {
+	fs := flag.NewFlagSet("create-organization", flag.ExitOnError)
+	name := fs.String("name", "", "nombre de la organización (obligatorio)")
+	require2FA := fs.Bool("require-2fa", false, "exigir verificación en dos pasos a todos sus profesionales")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if strings.TrimSpace(*name) == "" {
+		fs.Usage()
+		return fmt.Errorf("falta el parámetro requerido: -name")
+	}
+
+	conn := openDB()
+	defer func() {
+		_ = conn.Close()
+	}()
+	svc := service.NewService(db.New(conn), conn, serviceOptions()...)
+
+	org, err := svc.CreateOrganization(context.Background(), *name, *require2FA)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Organización creada: %d (%s)\n", org.ID, org.Name)
+	return nil
+}
+
+// psiconexo set-organization-2fa -org 1 -require=false
+func runSetOrganizationTwoFactor(args []string) error {
+	fs := flag.NewFlagSet("set-organization-2fa", flag.ExitOnError)
+	orgID := fs.Int64("org", 0, "ID de la organización (obligatorio)")
+	require := fs.Bool("require", true, "exigir verificación en dos pasos")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *orgID == 0 {
+		fs.Usage()
+		return fmt.Errorf("falta el parámetro requerido: -org")
+	}
+
+	conn := openDB()
+	defer func() {
+		_ = conn.Close()
+	}()
+	svc := service.NewService(db.New(conn), conn, serviceOptions()...)
+
+	if err := svc.SetOrganizationTwoFactor(context.Background(), *orgID, *require); err != nil {
+		return err
+	}
+	if *require {
+		fmt.Println("La organización exige verificación en dos pasos; quien no la tenga la configurará al iniciar sesión.")
+	} else {
+		fmt.Println("La organización ya no exige verificación en dos pasos.")
+	}
+	return nil
+}
+
+// psiconexo add-organization-member -org 1 -email ana@ejemplo.com -role admin
+func runAddOrganizationMember(args []string) error {
+	fs := flag.NewFlagSet("add-organization-member", flag.ExitOnError)
+	orgID := fs.Int64("org", 0, "ID de la organización (obligatorio)")
+	email := fs.String("email", "", "email del profesional (obligatorio)")
+	role := fs.String("role", "member", "rol en la organización: member o admin")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *orgID == 0 || *email == "" {
+		fs.Usage()
+		return fmt.Errorf("faltan parámetros requeridos: -org, -email")
+	}
+
+	conn := openDB()
+	defer func() {
+		_ = conn.Close()
+	}()
+	svc := service.NewService(db.New(conn), conn, serviceOptions()...)
+
+	if err := svc.AddOrganizationMember(context.Background(), *orgID, *email, *role); err != nil {
+		return err
+	}
+	fmt.Printf("%s agregado a la organización %d como %s.\n", *email, *orgID, *role)
+	return nil
+}
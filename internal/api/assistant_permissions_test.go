@@ -0,0 +1,107 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luciluz/psiconexo/internal/auth"
+)
+
+// Prueba de integración (ver TestCrossTenantAccessReturnsNotFound): un asistente con rol de
+// agenda maneja turnos, pero no ve la historia clínica ni toca las finanzas.
+func TestAssistantRolesAreEnforced(t *testing.T) {
+	dbSource := os.Getenv("TEST_DB_SOURCE")
+	if dbSource == "" {
+		t.Skip("TEST_DB_SOURCE no está definido; se omite la prueba de permisos de asistentes")
+	}
+
+	router := newTestRouter(t, dbSource)
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+	owner := registerProfessional(t, router, "owner-"+suffix+"@example.com")
+
+	clientID := mustCreate(t, router, owner, "/api/v1/clients", map[string]any{"name": "Paciente"})
+	noteID := mustCreate(t, router, owner, "/api/v1/clinical-notes", map[string]any{"client_id": clientID, "content": "Entrevista"})
+
+	assistantEmail := "secretaria-" + suffix + "@example.com"
+	assistantID := mustCreate(t, router, owner, "/api/v1/assistants", map[string]any{
+		"name": "Secretaria", "email": assistantEmail, "roles": []string{"agenda"},
+	})
+	secretary := acceptInvitation(t, router, assistantID, assistantEmail)
+
+	allowed := []struct {
+		method, path string
+		body         any
+		status       int
+	}{
+		{http.MethodGet, "/api/v1/clients", nil, http.StatusOK},
+		{http.MethodPost, "/api/v1/appointments", map[string]any{"client_id": clientID, "date": "2030-01-07", "start_time": "10:00", "duration": 50}, http.StatusCreated},
+		{http.MethodGet, "/api/v1/appointments?start_date=2030-01-01&end_date=2030-01-31", nil, http.StatusOK},
+	}
+	for _, tc := range allowed {
+		if status, body := doRequest(t, router, secretary, tc.method, tc.path, tc.body); status != tc.status {
+			t.Fatalf("%s %s: se esperaba %d y respondió %d: %s", tc.method, tc.path, tc.status, status, body)
+		}
+	}
+
+	forbidden := []struct {
+		method, path string
+		body         any
+	}{
+		{http.MethodGet, fmt.Sprintf("/api/v1/clinical-notes?client_id=%d", clientID), nil},
+		{http.MethodPut, fmt.Sprintf("/api/v1/clinical-notes/%d", noteID), map[string]any{"content": "x"}},
+		{http.MethodGet, "/api/v1/clinical-notes/search?q=entrevista", nil},
+		{http.MethodGet, fmt.Sprintf("/api/v1/attachments?client_id=%d", clientID), nil},
+		{http.MethodPost, fmt.Sprintf("/api/v1/clients/%d/clinical-record", clientID), map[string]any{}},
+		{http.MethodGet, "/api/v1/settings", nil},
+		{http.MethodGet, "/api/v1/me", nil},
+		{http.MethodPost, "/api/v1/payments", map[string]any{"client_id": clientID, "amount": 100}},
+		{http.MethodGet, "/api/v1/finances/summary", nil},
+	}
+	for _, tc := range forbidden {
+		if status, body := doRequest(t, router, secretary, tc.method, tc.path, tc.body); status != http.StatusForbidden {
+			t.Fatalf("%s %s: se esperaba 403 y respondió %d: %s", tc.method, tc.path, status, body)
+		}
+	}
+
+	// Al quitarle el acceso deja de entrar, ni siquiera a la agenda
+	if status, body := doRequest(t, router, owner, http.MethodDelete, fmt.Sprintf("/api/v1/assistants/%d", assistantID), nil); status != http.StatusNoContent {
+		t.Fatalf("error quitando asistente: %d %s", status, body)
+	}
+	if status, body := doRequest(t, router, secretary, http.MethodGet, "/api/v1/clients", nil); status != http.StatusBadRequest {
+		t.Fatalf("el asistente sin profesionales siguió entrando: %d %s", status, body)
+	}
+}
+
+// acceptInvitation firma la invitación con la misma clave que newTestRouter (el email no se envía).
+func acceptInvitation(t *testing.T, router *gin.Engine, assistantID int64, email string) string {
+	t.Helper()
+	tokens, err := auth.NewTokens(bytes.Repeat([]byte("s"), 32), time.Hour, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	invitation, _, err := tokens.IssueAssistantInvitation(assistantID, email)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, body := doRequest(t, router, "", http.MethodPost, "/api/v1/auth/assistant/accept-invitation", map[string]any{
+		"token": invitation, "password": "otra-clave-larga",
+	})
+	if status != http.StatusCreated {
+		t.Fatalf("error aceptando invitación: %d %s", status, body)
+	}
+	var session struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(body, &session); err != nil {
+		t.Fatal(err)
+	}
+	return session.AccessToken
+}
@@ -1,7 +1,10 @@
 package api
 
 import (
+	"errors"
+	"mime"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -9,23 +12,21 @@ import (
 )
 
 type createAppointmentDTO struct {
-	ProfessionalID int64   `json:"professional_id" binding:"required"`
-	ClientID       int64   `json:"client_id" binding:"required"`
-	Date           string  `json:"date" binding:"required"`
-	StartTime      string  `json:"start_time" binding:"required"`
-	Duration       int     `json:"duration" binding:"required,gt=0"`
-	Price          float64 `json:"price"`
-	Notes          string  `json:"notes"`
+	ClientID  int64    `json:"client_id" binding:"required"`
+	Date      string   `json:"date" binding:"required"`
+	StartTime string   `json:"start_time" binding:"required"`
+	Duration  int      `json:"duration" binding:"required,gt=0"`
+	Price     *float64 `json:"price" binding:"omitempty,gte=0"` // Sin price: el de lista
+	Notes     string   `json:"notes"`
 }
 
 type createRecurringRuleDTO struct {
-	ProfessionalID int64   `json:"professional_id" binding:"required"`
-	ClientID       int64   `json:"client_id" binding:"required"`
-	DayOfWeek      int     `json:"day_of_week" binding:"required,min=1,max=7"`
-	StartTime      string  `json:"start_time" binding:"required"`
-	Duration       int     `json:"duration" binding:"required,gt=0"`
-	Price          float64 `json:"price"`      // Nuevo campo
-	StartDate      string  `json:"start_date"` // Nuevo campo opcional
+	ClientID  int64    `json:"client_id" binding:"required"`
+	DayOfWeek int      `json:"day_of_week" binding:"required,min=1,max=7"`
+	StartTime string   `json:"start_time" binding:"required"`
+	Duration  int      `json:"duration" binding:"required,gt=0"`
+	Price     *float64 `json:"price" binding:"omitempty,gte=0"` // Sin price: el de lista
+	StartDate string   `json:"start_date"`                      // Nuevo campo opcional
 }
 
 func (h *Handler) CreateAppointment(c *gin.Context) {
@@ -42,7 +43,7 @@ func (h *Handler) CreateAppointment(c *gin.Context) {
 	}
 
 	appt, err := h.svc.CreateAppointment(c.Request.Context(), service.CreateAppointmentRequest{
-		ProfessionalID: req.ProfessionalID,
+		ProfessionalID: currentProfessionalID(c),
 		ClientID:       req.ClientID,
 		Date:           parsedDate,
 		StartTime:      req.StartTime,
@@ -52,6 +53,10 @@ func (h *Handler) CreateAppointment(c *gin.Context) {
 	})
 
 	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "paciente no encontrado"})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -61,15 +66,14 @@ func (h *Handler) CreateAppointment(c *gin.Context) {
 
 func (h *Handler) ListAppointments(c *gin.Context) {
 
-	if c.Query("professional_id") == "" || c.Query("start_date") == "" || c.Query("end_date") == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "faltan parámetros requeridos: professional_id, start_date, end_date"})
+	if c.Query("start_date") == "" || c.Query("end_date") == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "faltan parámetros requeridos: start_date, end_date"})
 		return
 	}
 
 	var req struct {
-		ProfessionalID int64  `form:"professional_id" binding:"required"`
-		StartDate      string `form:"start_date" binding:"required"`
-		EndDate        string `form:"end_date" binding:"required"`
+		StartDate string `form:"start_date" binding:"required"`
+		EndDate   string `form:"end_date" binding:"required"`
 	}
 
 	if err := c.ShouldBindQuery(&req); err != nil {
@@ -86,7 +90,7 @@ func (h *Handler) ListAppointments(c *gin.Context) {
 		return
 	}
 
-	appts, err := h.svc.ListAppointments(c.Request.Context(), req.ProfessionalID, start, end)
+	appts, err := h.svc.ListAppointments(c.Request.Context(), currentProfessionalID(c), start, end)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -114,7 +118,7 @@ func (h *Handler) CreateRecurringRule(c *gin.Context) {
 	}
 
 	rule, err := h.svc.CreateRecurringRule(c.Request.Context(), service.CreateRecurringRuleRequest{
-		ProfessionalID: req.ProfessionalID,
+		ProfessionalID: currentProfessionalID(c),
 		ClientID:       req.ClientID,
 		DayOfWeek:      req.DayOfWeek,
 		StartTime:      req.StartTime,
@@ -124,6 +128,10 @@ func (h *Handler) CreateRecurringRule(c *gin.Context) {
 	})
 
 	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "paciente no encontrado"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -132,20 +140,72 @@ func (h *Handler) CreateRecurringRule(c *gin.Context) {
 }
 
 func (h *Handler) ListRecurringRules(c *gin.Context) {
-	var req struct {
-		ProfessionalID int64 `form:"professional_id" binding:"required"`
+	rules, err := h.svc.ListRecurringRules(c.Request.Context(), currentProfessionalID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
-	if err := c.ShouldBindQuery(&req); err != nil {
+	c.JSON(http.StatusOK, rules)
+}
+
+// Un pedido de la página pública (status "requested") se aprueba con scheduled y se rechaza con cancelled.
+type updateAppointmentStatusDTO struct {
+	Status string `json:"status" binding:"required,oneof=scheduled cancelled completed"`
+}
+
+func (h *Handler) UpdateAppointmentStatus(c *gin.Context) {
+	apptID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de turno inválido"})
+		return
+	}
+
+	var req updateAppointmentStatusDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	rules, err := h.svc.ListRecurringRules(c.Request.Context(), req.ProfessionalID)
+	appt, err := h.svc.UpdateAppointmentStatus(c.Request.Context(), service.UpdateAppointmentStatusRequest{
+		ProfessionalID: currentProfessionalID(c),
+		AppointmentID:  apptID,
+		Status:         req.Status,
+	})
 	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "turno no encontrado"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, rules)
+	c.JSON(http.StatusOK, appt)
+}
+
+// DownloadPaymentProof devuelve el comprobante que subió el paciente desde el portal.
+func (h *Handler) DownloadPaymentProof(c *gin.Context) {
+	apptID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de turno inválido"})
+		return
+	}
+
+	proof, data, err := h.svc.DownloadPaymentProof(c.Request.Context(), currentProfessionalID(c), apptID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "el turno no tiene comprobante"})
+		case errors.Is(err, service.ErrStorageUnavailable):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": proof.Filename}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Data(http.StatusOK, proof.ContentType, data)
 }
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luciluz/psiconexo/internal/service"
+)
+
+type addAssistantDTO struct {
+	Name  string   `json:"name" binding:"required"`
+	Email string   `json:"email" binding:"required,email"`
+	Phone string   `json:"phone"`
+	Roles []string `json:"roles" binding:"required,min=1,dive,oneof=agenda finances read_only"`
+}
+
+type assistantRolesDTO struct {
+	Roles []string `json:"roles" binding:"required,min=1,dive,oneof=agenda finances read_only"`
+}
+
+type acceptInvitationDTO struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// AddAssistant da acceso a un asistente (lo crea e invita por email si no tenía cuenta).
+func (h *Handler) AddAssistant(c *gin.Context) {
+	var req addAssistantDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	assistant, err := h.svc.AddAssistant(c.Request.Context(), service.AddAssistantRequest{
+		ProfessionalID: currentProfessionalID(c),
+		Name:           req.Name,
+		Email:          req.Email,
+		Phone:          req.Phone,
+		Roles:          req.Roles,
+	})
+	if err != nil {
+		respondAssistantError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, assistant)
+}
+
+func (h *Handler) ListAssistants(c *gin.Context) {
+	assistants, err := h.svc.ListAssistants(c.Request.Context(), currentProfessionalID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if assistants == nil {
+		assistants = []service.AssistantSummary{}
+	}
+
+	c.JSON(http.StatusOK, assistants)
+}
+
+// UpdateAssistantRoles reemplaza los roles del asistente.
+func (h *Handler) UpdateAssistantRoles(c *gin.Context) {
+	assistantID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de asistente inválido"})
+		return
+	}
+
+	var req assistantRolesDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.UpdateAssistantRoles(c.Request.Context(), currentProfessionalID(c), assistantID, req.Roles); err != nil {
+		respondAssistantError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RemoveAssistant le quita el acceso; la cuenta del asistente sigue existiendo para otros profesionales.
+func (h *Handler) RemoveAssistant(c *gin.Context) {
+	assistantID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de asistente inválido"})
+		return
+	}
+
+	if err := h.svc.RemoveAssistant(c.Request.Context(), currentProfessionalID(c), assistantID); err != nil {
+		respondAssistantError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) ResendAssistantInvitation(c *gin.Context) {
+	assistantID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de asistente inválido"})
+		return
+	}
+
+	if err := h.svc.ResendAssistantInvitation(c.Request.Context(), currentProfessionalID(c), assistantID); err != nil {
+		respondAssistantError(c, err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// AcceptAssistantInvitation recibe el token del email y la contraseña elegida; abre la sesión.
+func (h *Handler) AcceptAssistantInvitation(c *gin.Context) {
+	var req acceptInvitationDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, err := h.svc.AcceptAssistantInvitation(c.Request.Context(), req.Token, req.Password)
+	if err != nil {
+		respondAuthError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, session)
+}
+
+func (h *Handler) AssistantLogin(c *gin.Context) {
+	var req loginDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, err := h.svc.AssistantLogin(c.Request.Context(), req.Email, req.Password)
+	if err != nil {
+		respondAuthError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+func (h *Handler) RefreshAssistantSession(c *gin.Context) {
+	var req refreshDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, err := h.svc.RefreshAssistantSession(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		respondAuthError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+func (h *Handler) AssistantLogout(c *gin.Context) {
+	var req refreshDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.AssistantLogout(c.Request.Context(), req.RefreshToken); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetAssistantMe devuelve la cuenta del asistente y para qué profesionales trabaja
+// (el ID de cada uno va en X-Professional-ID).
+func (h *Handler) GetAssistantMe(c *gin.Context) {
+	profile, err := h.svc.GetAssistantProfile(c.Request.Context(), currentAssistantID(c))
+	if err != nil {
+		respondAssistantError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+func respondAssistantError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "asistente no encontrado"})
+	default:
+		respondAuthError(c, err)
+	}
+}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luciluz/psiconexo/internal/db"
+	"github.com/luciluz/psiconexo/internal/service"
+)
+
+// UploadAttachment recibe un multipart/form-data con client_id o note_id, y file.
+func (h *Handler) UploadAttachment(c *gin.Context) {
+	// Margen de 1 MB para los demás campos del formulario
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, service.MaxAttachmentBytes+1<<20)
+
+	var req struct {
+		ClientID int64  `form:"client_id"`
+		NoteID   *int64 `form:"note_id"`
+	}
+	if err := c.ShouldBind(&req); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": service.ErrAttachmentTooLarge.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ClientID == 0 && req.NoteID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "client_id o note_id es requerido"})
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "falta el archivo (campo file)"})
+		return
+	}
+	f, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	data, err := io.ReadAll(io.LimitReader(f, service.MaxAttachmentBytes+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	attachment, err := h.svc.UploadAttachment(c.Request.Context(), service.UploadAttachmentRequest{
+		ProfessionalID: currentProfessionalID(c),
+		ClientID:       req.ClientID,
+		NoteID:         req.NoteID,
+		Filename:       header.Filename,
+		Data:           data,
+	})
+	if err != nil {
+		respondAttachmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// ListAttachments: ?client_id=2 (todos los del paciente) o ?note_id=3.
+func (h *Handler) ListAttachments(c *gin.Context) {
+	var req struct {
+		ClientID *int64 `form:"client_id"`
+		NoteID   *int64 `form:"note_id"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "parametros inválidos: " + err.Error()})
+		return
+	}
+	if req.ClientID == nil && req.NoteID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "client_id o note_id es requerido"})
+		return
+	}
+
+	attachments, err := h.svc.ListAttachments(c.Request.Context(), currentProfessionalID(c), req.ClientID, req.NoteID)
+	if err != nil {
+		respondAttachmentError(c, err)
+		return
+	}
+
+	if attachments == nil {
+		attachments = []db.Attachment{}
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+func (h *Handler) DownloadAttachment(c *gin.Context) {
+	attachmentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de adjunto inválido"})
+		return
+	}
+
+	attachment, data, err := h.svc.DownloadAttachment(c.Request.Context(), currentProfessionalID(c), attachmentID)
+	if err != nil {
+		respondAttachmentError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Data(http.StatusOK, attachment.ContentType, data)
+}
+
+func (h *Handler) DeleteAttachment(c *gin.Context) {
+	attachmentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de adjunto inválido"})
+		return
+	}
+
+	if err := h.svc.DeleteAttachment(c.Request.Context(), currentProfessionalID(c), attachmentID); err != nil {
+		respondAttachmentError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func respondAttachmentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "adjunto, nota o paciente no encontrado"})
+	case errors.Is(err, service.ErrAttachmentTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrUnsupportedFileType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrEmptyAttachment):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrNoteSigned):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrStorageUnavailable), errors.Is(err, service.ErrEncryptionUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luciluz/psiconexo/internal/service"
+)
+
+type auditQueryDTO struct {
+	StartDate    string `form:"start_date"` // YYYY-MM-DD, inclusive
+	EndDate      string `form:"end_date"`   // YYYY-MM-DD, inclusive
+	Action       string `form:"action" binding:"omitempty,oneof=read create update delete"`
+	ResourceType string `form:"resource_type"` // clients, appointments, payments, settings, clinical-notes...
+	ResourceID   *int64 `form:"resource_id"`
+	AssistantID  *int64 `form:"assistant_id"`
+	Cursor       string `form:"cursor"`
+	Limit        int    `form:"limit" binding:"omitempty,min=1,max=200"`
+}
+
+// toFilter parsea las fechas opcionales; el fin se toma hasta el final de ese día.
+func (q auditQueryDTO) toFilter(profID int64) (service.AuditFilter, error) {
+	filter := service.AuditFilter{
+		ProfessionalID: profID,
+		Action:         q.Action,
+		ResourceType:   q.ResourceType,
+		ResourceID:     q.ResourceID,
+		AssistantID:    q.AssistantID,
+	}
+
+	layout := "2006-01-02"
+	if q.StartDate != "" {
+		start, err := time.Parse(layout, q.StartDate)
+		if err != nil {
+			return filter, err
+		}
+		filter.StartAt = &start
+	}
+	if q.EndDate != "" {
+		end, err := time.Parse(layout, q.EndDate)
+		if err != nil {
+			return filter, err
+		}
+		end = end.AddDate(0, 0, 1)
+		filter.EndAt = &end
+	}
+	return filter, nil
+}
+
+// ListAuditLog devuelve quién leyó o cambió qué, más reciente primero.
+func (h *Handler) ListAuditLog(c *gin.Context) {
+	var req auditQueryDTO
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "parametros inválidos: " + err.Error()})
+		return
+	}
+
+	filter, err := req.toFilter(currentProfessionalID(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "formato de fecha inválido (use YYYY-MM-DD)"})
+		return
+	}
+
+	page, err := h.svc.ListAuditLog(c.Request.Context(), service.ListAuditLogRequest{
+		AuditFilter: filter,
+		Cursor:      req.Cursor,
+		Limit:       req.Limit,
+	})
+	if err != nil {
+		respondAuditError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// VerifyAuditLog recorre la cadena de hashes y dice si alguna fila fue alterada o borrada.
+func (h *Handler) VerifyAuditLog(c *gin.Context) {
+	result, err := h.svc.VerifyAuditLog(c.Request.Context(), currentProfessionalID(c))
+	if err != nil {
+		respondAuditError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func respondAuditError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidCursor):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,219 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luciluz/psiconexo/internal/service"
+)
+
+type registerDTO struct {
+	Name     string `json:"name" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
+	Phone    string `json:"phone"`
+	Password string `json:"password" binding:"required"`
+	Slug     string `json:"slug"` // Opcional: si no viene se arma con el nombre
+}
+
+type loginDTO struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type refreshDTO struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type verifyEmailDTO struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type passwordResetDTO struct {
+	Email string `json:"email" binding:"required"`
+}
+
+type confirmPasswordResetDTO struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+func (h *Handler) Register(c *gin.Context) {
+	var req registerDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, err := h.svc.Register(c.Request.Context(), service.RegisterRequest{
+		Name:     req.Name,
+		Email:    req.Email,
+		Phone:    req.Phone,
+		Password: req.Password,
+		Slug:     req.Slug,
+	})
+	if err != nil {
+		respondAuthError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, session)
+}
+
+func (h *Handler) Login(c *gin.Context) {
+	var req loginDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, challenge, err := h.svc.Login(c.Request.Context(), req.Email, req.Password)
+	if err != nil {
+		respondAuthError(c, err)
+		return
+	}
+	if challenge != nil {
+		// Falta el código de la app (o dar de alta la 2FA): todavía no hay sesión
+		c.JSON(http.StatusOK, challenge)
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+// RefreshSession canjea el refresh_token por tokens nuevos; el anterior deja de servir.
+func (h *Handler) RefreshSession(c *gin.Context) {
+	var req refreshDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, err := h.svc.RefreshSession(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		respondAuthError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+func (h *Handler) Logout(c *gin.Context) {
+	var req refreshDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.Logout(c.Request.Context(), req.RefreshToken); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// VerifyEmail recibe el token del enlace que llegó por email.
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	var req verifyEmailDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	prof, err := h.svc.VerifyEmail(c.Request.Context(), req.Token)
+	if err != nil {
+		respondAuthError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, prof)
+}
+
+// ResendEmailVerification vuelve a mandar el enlace de verificación al profesional autenticado.
+func (h *Handler) ResendEmailVerification(c *gin.Context) {
+	if err := h.svc.SendEmailVerification(c.Request.Context(), currentProfessionalID(c)); err != nil {
+		respondAuthError(c, err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// RequestPasswordReset responde siempre 202, exista o no la cuenta.
+func (h *Handler) RequestPasswordReset(c *gin.Context) {
+	var req passwordResetDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.RequestPasswordReset(c.Request.Context(), req.Email); err != nil {
+		respondAuthError(c, err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// ConfirmPasswordReset fija la contraseña nueva; las sesiones abiertas se cierran.
+func (h *Handler) ConfirmPasswordReset(c *gin.Context) {
+	var req confirmPasswordResetDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.ResetPassword(c.Request.Context(), req.Token, req.Password); err != nil {
+		respondAuthError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetMe devuelve el perfil del profesional autenticado.
+func (h *Handler) GetMe(c *gin.Context) {
+	prof, err := h.svc.GetProfessional(c.Request.Context(), currentProfessionalID(c))
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "profesional no encontrado"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, prof)
+}
+
+func respondAuthError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidCredentials), errors.Is(err, service.ErrInvalidRefreshToken),
+		errors.Is(err, service.ErrInvalidTwoFactorChallenge), errors.Is(err, service.ErrInvalidTwoFactorCode),
+		errors.Is(err, service.ErrTwoFactorRequired):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTwoFactorLocked):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTwoFactorAlreadyEnabled), errors.Is(err, service.ErrInvitationUsed),
+		errors.Is(err, service.ErrAssistantActivated):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrEmailTaken), errors.Is(err, service.ErrEmailAlreadyVerified),
+		errors.Is(err, service.ErrSlugTaken), errors.Is(err, service.ErrPhoneTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrWeakPassword), errors.Is(err, service.ErrInvalidVerificationToken),
+		errors.Is(err, service.ErrInvalidResetToken), errors.Is(err, service.ErrInvalidInvitation),
+		errors.Is(err, service.ErrInvalidAssistantRole), errors.Is(err, service.ErrInvalidSlug),
+		errors.Is(err, service.ErrReservedSlug):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "profesional no encontrado"})
+	case errors.Is(err, service.ErrAuthUnavailable), errors.Is(err, service.ErrMailerUnavailable),
+		errors.Is(err, service.ErrEncryptionUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luciluz/psiconexo/internal/service"
+)
+
+type clientFormDTO struct {
+	Title      string                    `json:"title"`       // Vacío = nombre de la plantilla
+	TemplateID *int64                    `json:"template_id"` // Usa las secciones de la plantilla...
+	Sections   []service.TemplateSection `json:"sections"`    // ...o se definen acá
+}
+
+// CreateClientForm le manda un formulario al paciente para que lo complete en el portal.
+func (h *Handler) CreateClientForm(c *gin.Context) {
+	clientID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de paciente inválido"})
+		return
+	}
+
+	var req clientFormDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	form, err := h.svc.CreateClientForm(c.Request.Context(), service.ClientFormRequest{
+		ProfessionalID: currentProfessionalID(c),
+		ClientID:       clientID,
+		Title:          req.Title,
+		TemplateID:     req.TemplateID,
+		Sections:       req.Sections,
+	})
+	if err != nil {
+		respondClientFormError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, form)
+}
+
+// ListClientForms devuelve los formularios del paciente con las respuestas.
+func (h *Handler) ListClientForms(c *gin.Context) {
+	clientID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de paciente inválido"})
+		return
+	}
+
+	forms, err := h.svc.ListClientForms(c.Request.Context(), currentProfessionalID(c), clientID)
+	if err != nil {
+		respondClientFormError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, forms)
+}
+
+func respondClientFormError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "paciente o plantilla no encontrado"})
+	case errors.Is(err, service.ErrInvalidTemplate):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrEncryptionUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -1,21 +1,31 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/luciluz/psiconexo/internal/db"
 	"github.com/luciluz/psiconexo/internal/service"
 )
 
 type createNoteDTO struct {
-	ProfessionalID int64  `json:"professional_id" binding:"required"`
-	ClientID       int64  `json:"client_id" binding:"required"`
-	AppointmentID  *int64 `json:"appointment_id"` // Opcional
-	Content        string `json:"content" binding:"required"`
+	ClientID      int64          `json:"client_id" binding:"required"`
+	AppointmentID *int64         `json:"appointment_id"`                                   // Opcional
+	Type          string         `json:"type" binding:"omitempty,oneof=clinical personal"` // Por defecto clinical
+	Content       string         `json:"content"`                                          // Requerido si no hay plantilla
+	TemplateID    *int64         `json:"template_id"`                                      // Opcional: nota estructurada
+	Sections      map[string]any `json:"sections"`                                         // key de la sección -> valor
 }
 
 type updateNoteDTO struct {
+	Content  string         `json:"content"`
+	Sections map[string]any `json:"sections"` // Omitido = conservar las actuales
+}
+
+type createAddendumDTO struct {
 	Content string `json:"content" binding:"required"`
 }
 
@@ -27,14 +37,17 @@ func (h *Handler) CreateClinicalNote(c *gin.Context) {
 	}
 
 	note, err := h.svc.CreateClinicalNote(c.Request.Context(), service.CreateClinicalNoteRequest{
-		ProfessionalID: req.ProfessionalID,
+		ProfessionalID: currentProfessionalID(c),
 		ClientID:       req.ClientID,
 		AppointmentID:  req.AppointmentID,
+		Type:           req.Type,
 		Content:        req.Content,
+		TemplateID:     req.TemplateID,
+		Sections:       req.Sections,
 	})
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondNoteError(c, err)
 		return
 	}
 
@@ -42,26 +55,23 @@ func (h *Handler) CreateClinicalNote(c *gin.Context) {
 }
 
 func (h *Handler) ListClinicalNotes(c *gin.Context) {
-	// Obtenemos params del Query String (?client_id=1&professional_id=2)
+	// Obtenemos params del Query String (?client_id=1&type=personal)
 	clientIDStr := c.Query("client_id")
-	profIDStr := c.Query("professional_id")
 
-	if clientIDStr == "" || profIDStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "client_id y professional_id son requeridos"})
+	if clientIDStr == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "client_id es requerido"})
 		return
 	}
 
-	clientID, err1 := strconv.ParseInt(clientIDStr, 10, 64)
-	profID, err2 := strconv.ParseInt(profIDStr, 10, 64)
-
-	if err1 != nil || err2 != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "IDs inválidos"})
+	clientID, err := strconv.ParseInt(clientIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de paciente inválido"})
 		return
 	}
 
-	notes, err := h.svc.ListClinicalNotes(c.Request.Context(), clientID, profID)
+	notes, err := h.svc.ListClinicalNotes(c.Request.Context(), clientID, currentProfessionalID(c), c.Query("type"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondNoteError(c, err)
 		return
 	}
 
@@ -84,14 +94,244 @@ func (h *Handler) UpdateClinicalNote(c *gin.Context) {
 	}
 
 	note, err := h.svc.UpdateClinicalNote(c.Request.Context(), service.UpdateClinicalNoteRequest{
-		NoteID:  noteID,
-		Content: req.Content,
+		ProfessionalID: currentProfessionalID(c),
+		NoteID:         noteID,
+		Content:        req.Content,
+		Sections:       req.Sections,
 	})
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondNoteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, note)
+}
+
+// DeleteClinicalNote borra una nota personal. Las clínicas se conservan (409).
+func (h *Handler) DeleteClinicalNote(c *gin.Context) {
+	noteID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de nota inválido"})
+		return
+	}
+
+	if err := h.svc.DeleteClinicalNote(c.Request.Context(), currentProfessionalID(c), noteID); err != nil {
+		respondNoteError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) SignClinicalNote(c *gin.Context) {
+	noteID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de nota inválido"})
+		return
+	}
+
+	note, err := h.svc.SignClinicalNote(c.Request.Context(), currentProfessionalID(c), noteID)
+	if err != nil {
+		respondNoteError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, note)
 }
+
+func (h *Handler) CreateAddendum(c *gin.Context) {
+	noteID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de nota inválido"})
+		return
+	}
+
+	var req createAddendumDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	addendum, err := h.svc.CreateAddendum(c.Request.Context(), service.CreateAddendumRequest{
+		ProfessionalID: currentProfessionalID(c),
+		NoteID:         noteID,
+		Content:        req.Content,
+	})
+	if err != nil {
+		respondNoteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, addendum)
+}
+
+func (h *Handler) ListAddenda(c *gin.Context) {
+	noteID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de nota inválido"})
+		return
+	}
+
+	addenda, err := h.svc.ListAddenda(c.Request.Context(), currentProfessionalID(c), noteID)
+	if err != nil {
+		respondNoteError(c, err)
+		return
+	}
+
+	if addenda == nil {
+		addenda = []db.ClinicalNoteAddenda{}
+	}
+
+	c.JSON(http.StatusOK, addenda)
+}
+
+func (h *Handler) ListNoteRevisions(c *gin.Context) {
+	noteID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de nota inválido"})
+		return
+	}
+
+	revisions, err := h.svc.ListNoteRevisions(c.Request.Context(), currentProfessionalID(c), noteID)
+	if err != nil {
+		respondNoteError(c, err)
+		return
+	}
+
+	if revisions == nil {
+		revisions = []db.ListNoteRevisionsRow{}
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+func (h *Handler) GetNoteRevision(c *gin.Context) {
+	noteID, err1 := strconv.ParseInt(c.Param("id"), 10, 64)
+	number, err2 := strconv.ParseInt(c.Param("rev"), 10, 32)
+	if err1 != nil || err2 != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "IDs inválidos"})
+		return
+	}
+
+	revision, err := h.svc.GetNoteRevision(c.Request.Context(), currentProfessionalID(c), noteID, int32(number))
+	if err != nil {
+		respondNoteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, revision)
+}
+
+// DiffNoteRevisions compara dos revisiones: ?from=1&to=3. Sin `to` (o to=0) compara contra la versión vigente.
+func (h *Handler) DiffNoteRevisions(c *gin.Context) {
+	noteID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de nota inválido"})
+		return
+	}
+
+	var req struct {
+		From int32 `form:"from" binding:"required,gt=0"`
+		To   int32 `form:"to" binding:"gte=0"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "parametros inválidos: " + err.Error()})
+		return
+	}
+
+	diff, err := h.svc.DiffNoteRevisions(c.Request.Context(), currentProfessionalID(c), noteID, req.From, req.To)
+	if err != nil {
+		respondNoteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+// SearchClinicalNotes busca en las notas del profesional: ?q=duelo padre&client_id=3.
+// Devuelve las notas que contienen todas las palabras.
+func (h *Handler) SearchClinicalNotes(c *gin.Context) {
+	var req struct {
+		Query    string `form:"q" binding:"required"`
+		ClientID *int64 `form:"client_id"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q es requerido"})
+		return
+	}
+
+	notes, err := h.svc.SearchClinicalNotes(c.Request.Context(), service.SearchNotesRequest{
+		ProfessionalID: currentProfessionalID(c),
+		ClientID:       req.ClientID,
+		Query:          req.Query,
+	})
+	if err != nil {
+		respondNoteError(c, err)
+		return
+	}
+
+	if notes == nil {
+		notes = []db.ClinicalNote{}
+	}
+
+	c.JSON(http.StatusOK, notes)
+}
+
+// respondNoteError traduce los errores de la historia clínica a códigos HTTP.
+func respondNoteError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "nota no encontrada"})
+	case errors.Is(err, service.ErrNoteSigned), errors.Is(err, service.ErrNoteAlreadySigned), errors.Is(err, service.ErrNoteNotSigned):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrMissingRequiredSections), errors.Is(err, service.ErrRecordRetention):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrEmptyNote), errors.Is(err, service.ErrInvalidSectionValue), errors.Is(err, service.ErrEmptySearch), errors.Is(err, service.ErrInvalidNoteType),
+		errors.Is(err, service.ErrTemplateInactive), errors.Is(err, service.ErrInvalidTemplate):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrDiffTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrEncryptionUnavailable), errors.Is(err, service.ErrSearchUnavailable),
+		errors.Is(err, service.ErrStorageUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// ListPendingDocumentation devuelve los borradores atrasados y las sesiones realizadas sin nota.
+// ?days=3&since=2026-01-01 (ambos opcionales).
+func (h *Handler) ListPendingDocumentation(c *gin.Context) {
+	var req struct {
+		Days  int    `form:"days" binding:"gte=0"`
+		Since string `form:"since"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "parametros inválidos: " + err.Error()})
+		return
+	}
+
+	var since time.Time
+	if req.Since != "" {
+		var err error
+		if since, err = time.Parse("2006-01-02", req.Since); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "since inválido (use YYYY-MM-DD)"})
+			return
+		}
+	}
+
+	pending, err := h.svc.GetPendingDocumentation(c.Request.Context(), service.PendingDocumentationRequest{
+		ProfessionalID: currentProfessionalID(c),
+		DraftAgeDays:   req.Days,
+		Since:          since,
+	})
+	if err != nil {
+		respondNoteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pending)
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luciluz/psiconexo/internal/db"
+	"github.com/luciluz/psiconexo/internal/service"
+)
+
+type exportClinicalRecordDTO struct {
+	Purpose   string `json:"purpose" binding:"omitempty,oneof=patient_request transfer legal other"`
+	Recipient string `json:"recipient"` // A quién se entrega
+}
+
+// ExportClinicalRecord devuelve el PDF de la historia clínica. Es POST porque cada
+// exportación queda registrada; el hash del documento va en X-Document-SHA256.
+func (h *Handler) ExportClinicalRecord(c *gin.Context) {
+	clientID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de paciente inválido"})
+		return
+	}
+
+	var req exportClinicalRecordDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var buf bytes.Buffer
+	export, err := h.svc.ExportClinicalRecord(c.Request.Context(), service.ClinicalRecordExportRequest{
+		ProfessionalID: currentProfessionalID(c),
+		ClientID:       clientID,
+		Purpose:        req.Purpose,
+		Recipient:      req.Recipient,
+	}, &buf)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "paciente no encontrado"})
+		case errors.Is(err, service.ErrEncryptionUnavailable):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	filename := fmt.Sprintf("historia_clinica_%d_%s.pdf", clientID, time.Now().Format("2006-01-02"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("X-Document-SHA256", export.DocumentSha256)
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+// ListClinicalRecordExports devuelve el registro de exportaciones de la historia clínica del paciente.
+func (h *Handler) ListClinicalRecordExports(c *gin.Context) {
+	clientID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de paciente inválido"})
+		return
+	}
+
+	exports, err := h.svc.ListClinicalRecordExports(c.Request.Context(), currentProfessionalID(c), clientID)
+	if errors.Is(err, service.ErrForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if exports == nil {
+		exports = []db.ClinicalRecordExport{}
+	}
+
+	c.JSON(http.StatusOK, exports)
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luciluz/psiconexo/internal/service"
+)
+
+type financesQueryDTO struct {
+	StartDate     string `form:"start_date"`
+	EndDate       string `form:"end_date"`
+	ClientID      *int64 `form:"client_id"`
+	PaymentStatus string `form:"payment_status" binding:"omitempty,oneof=pending paid refunded"`
+	PaymentMethod string `form:"payment_method" binding:"omitempty,oneof=mercadopago transfer cash insurance"`
+	InvoiceStatus string `form:"invoice_status" binding:"omitempty,oneof=pending invoiced error"`
+	Cursor        string `form:"cursor"`
+	Limit         int    `form:"limit" binding:"omitempty,min=1,max=200"`
+}
+
+// toFilter parsea las fechas opcionales del query string.
+func (q financesQueryDTO) toFilter(profID int64) (service.FinancesFilter, error) {
+	filter := service.FinancesFilter{
+		ProfessionalID: profID,
+		ClientID:       q.ClientID,
+		PaymentStatus:  q.PaymentStatus,
+		PaymentMethod:  q.PaymentMethod,
+		InvoiceStatus:  q.InvoiceStatus,
+	}
+
+	layout := "2006-01-02"
+	if q.StartDate != "" {
+		start, err := time.Parse(layout, q.StartDate)
+		if err != nil {
+			return filter, err
+		}
+		filter.StartDate = &start
+	}
+	if q.EndDate != "" {
+		end, err := time.Parse(layout, q.EndDate)
+		if err != nil {
+			return filter, err
+		}
+		filter.EndDate = &end
+	}
+	return filter, nil
+}
+
+func (h *Handler) ListFinances(c *gin.Context) {
+	var req financesQueryDTO
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "parametros inválidos: " + err.Error()})
+		return
+	}
+
+	filter, err := req.toFilter(currentProfessionalID(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "formato de fecha inválido (use YYYY-MM-DD)"})
+		return
+	}
+
+	page, err := h.svc.ListFinances(c.Request.Context(), service.ListFinancesRequest{
+		FinancesFilter: filter,
+		Cursor:         req.Cursor,
+		Limit:          req.Limit,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *Handler) GetFinancialSummary(c *gin.Context) {
+	var req struct {
+		StartDate string `form:"start_date"`
+		EndDate   string `form:"end_date"`
+	}
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "parametros inválidos: " + err.Error()})
+		return
+	}
+
+	// Por defecto: mes en curso
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, -1)
+
+	layout := "2006-01-02"
+	var err error
+	if req.StartDate != "" {
+		if start, err = time.Parse(layout, req.StartDate); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "formato de fecha inválido (use YYYY-MM-DD)"})
+			return
+		}
+	}
+	if req.EndDate != "" {
+		if end, err = time.Parse(layout, req.EndDate); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "formato de fecha inválido (use YYYY-MM-DD)"})
+			return
+		}
+	}
+	if end.Before(start) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date no puede ser anterior a start_date"})
+		return
+	}
+
+	summary, err := h.svc.GetFinancialSummary(c.Request.Context(), currentProfessionalID(c), start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"start_date": start.Format(layout),
+		"end_date":   end.Format(layout),
+		"summary":    summary,
+	})
+}
+
+func (h *Handler) ExportFinances(c *gin.Context) {
+	var req struct {
+		StartDate string `form:"start_date" binding:"required"`
+		EndDate   string `form:"end_date" binding:"required"`
+		Format    string `form:"format" binding:"omitempty,oneof=csv xlsx"`
+		Columns   string `form:"columns"` // ej: date,client,amount
+	}
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "parametros inválidos: " + err.Error()})
+		return
+	}
+
+	layout := "2006-01-02"
+	start, err1 := time.Parse(layout, req.StartDate)
+	end, err2 := time.Parse(layout, req.EndDate)
+	if err1 != nil || err2 != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "formato de fecha inválido (use YYYY-MM-DD)"})
+		return
+	}
+
+	format := req.Format
+	if format == "" {
+		format = service.ExportFormatCSV
+	}
+
+	var columns []string
+	if req.Columns != "" {
+		columns = strings.Split(req.Columns, ",")
+	}
+
+	// Generamos en memoria para poder responder JSON si algo falla antes de escribir
+	var buf bytes.Buffer
+	err := h.svc.ExportFinances(c.Request.Context(), service.ExportFinancesRequest{
+		ProfessionalID: currentProfessionalID(c),
+		StartDate:      start,
+		EndDate:        end,
+		Format:         format,
+		Columns:        columns,
+	}, &buf)
+	if err != nil {
+		if errors.Is(err, service.ErrUnknownExportColumn) || errors.Is(err, service.ErrUnsupportedExportFormat) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if format == service.ExportFormatXLSX {
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	filename := fmt.Sprintf("finanzas_%s_%s.%s", req.StartDate, req.EndDate, format)
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
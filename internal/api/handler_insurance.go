@@ -0,0 +1,250 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luciluz/psiconexo/internal/db"
+	"github.com/luciluz/psiconexo/internal/service"
+)
+
+type insurerDTO struct {
+	Name                  string  `json:"name" binding:"required"`
+	Kind                  string  `json:"kind" binding:"omitempty,oneof=obra_social prepaga"`
+	Code                  string  `json:"code"`
+	SessionFee            float64 `json:"session_fee" binding:"gte=0"`
+	RequiresAuthorization bool    `json:"requires_authorization"`
+	Active                *bool   `json:"active"` // Sólo en PUT; por defecto true
+}
+
+type createCoverageDTO struct {
+	InsurerID    int64   `json:"insurer_id" binding:"required"`
+	Plan         string  `json:"plan"`
+	MemberNumber string  `json:"member_number" binding:"required"`
+	CopayAmount  float64 `json:"copay_amount" binding:"gte=0"`
+}
+
+type applyCoverageDTO struct {
+	CoverageID          int64  `json:"coverage_id" binding:"required"`
+	AuthorizationNumber string `json:"authorization_number"`
+}
+
+func (h *Handler) CreateInsurer(c *gin.Context) {
+	var req insurerDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	insurer, err := h.svc.CreateInsurer(c.Request.Context(), service.InsurerRequest{
+		ProfessionalID:        currentProfessionalID(c),
+		Name:                  req.Name,
+		Kind:                  req.Kind,
+		Code:                  req.Code,
+		SessionFee:            req.SessionFee,
+		RequiresAuthorization: req.RequiresAuthorization,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, insurer)
+}
+
+func (h *Handler) UpdateInsurer(c *gin.Context) {
+	insurerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de obra social inválido"})
+		return
+	}
+
+	var req insurerDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	insurer, err := h.svc.UpdateInsurer(c.Request.Context(), service.InsurerRequest{
+		ProfessionalID:        currentProfessionalID(c),
+		InsurerID:             insurerID,
+		Name:                  req.Name,
+		Kind:                  req.Kind,
+		Code:                  req.Code,
+		SessionFee:            req.SessionFee,
+		RequiresAuthorization: req.RequiresAuthorization,
+		Active:                active,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "obra social no encontrada"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, insurer)
+}
+
+func (h *Handler) ListInsurers(c *gin.Context) {
+	insurers, err := h.svc.ListInsurers(c.Request.Context(), currentProfessionalID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if insurers == nil {
+		insurers = []db.Insurer{}
+	}
+
+	c.JSON(http.StatusOK, insurers)
+}
+
+func (h *Handler) CreateClientCoverage(c *gin.Context) {
+	clientID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de paciente inválido"})
+		return
+	}
+
+	var req createCoverageDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	coverage, err := h.svc.CreateCoverage(c.Request.Context(), service.CreateCoverageRequest{
+		ProfessionalID: currentProfessionalID(c),
+		ClientID:       clientID,
+		InsurerID:      req.InsurerID,
+		Plan:           req.Plan,
+		MemberNumber:   req.MemberNumber,
+		CopayAmount:    req.CopayAmount,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "paciente u obra social no encontrados"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, coverage)
+}
+
+func (h *Handler) ListClientCoverages(c *gin.Context) {
+	clientID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de paciente inválido"})
+		return
+	}
+
+	coverages, err := h.svc.ListClientCoverages(c.Request.Context(), currentProfessionalID(c), clientID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if coverages == nil {
+		coverages = []db.ListClientCoveragesRow{}
+	}
+
+	c.JSON(http.StatusOK, coverages)
+}
+
+func (h *Handler) ApplyAppointmentCoverage(c *gin.Context) {
+	apptID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de turno inválido"})
+		return
+	}
+
+	var req applyCoverageDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	applied, err := h.svc.ApplyCoverage(c.Request.Context(), service.ApplyCoverageRequest{
+		ProfessionalID:      currentProfessionalID(c),
+		AppointmentID:       apptID,
+		CoverageID:          req.CoverageID,
+		AuthorizationNumber: req.AuthorizationNumber,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "turno o cobertura no encontrados"})
+		case errors.Is(err, service.ErrAuthorizationRequired), errors.Is(err, service.ErrCoverageMismatch),
+			errors.Is(err, service.ErrInactiveInsurer):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, applied)
+}
+
+// GetInsurerPresentation devuelve la presentación mensual (?month=YYYY-MM) en JSON o CSV.
+func (h *Handler) GetInsurerPresentation(c *gin.Context) {
+	insurerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de obra social inválido"})
+		return
+	}
+
+	var req struct {
+		Month  string `form:"month" binding:"required"`
+		Format string `form:"format" binding:"omitempty,oneof=json csv"`
+	}
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "month (YYYY-MM) es requerido"})
+		return
+	}
+
+	month, err := time.Parse("2006-01", req.Month)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "month inválido (use YYYY-MM)"})
+		return
+	}
+
+	presentation, err := h.svc.GetInsurerPresentation(c.Request.Context(), currentProfessionalID(c), insurerID, month)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "obra social no encontrada"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Format != "csv" {
+		c.JSON(http.StatusOK, presentation)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := service.WritePresentationCSV(presentation, &buf); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("presentacion_%d_%s.csv", insurerID, req.Month)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
@@ -0,0 +1,221 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luciluz/psiconexo/internal/db"
+	"github.com/luciluz/psiconexo/internal/service"
+)
+
+type registerPaymentDTO struct {
+	ClientID      int64   `json:"client_id" binding:"required"`
+	AppointmentID *int64  `json:"appointment_id"` // Opcional
+	Kind          string  `json:"kind" binding:"omitempty,oneof=payment refund"`
+	Amount        float64 `json:"amount" binding:"required,gt=0"`
+	Method        string  `json:"method" binding:"omitempty,oneof=mercadopago transfer cash insurance"`
+	PaidAt        string  `json:"paid_at"` // YYYY-MM-DD, por defecto hoy
+	Notes         string  `json:"notes"`
+}
+
+type createFeeDTO struct {
+	ClientID      int64   `json:"client_id" binding:"required"`
+	AppointmentID *int64  `json:"appointment_id"`
+	Kind          string  `json:"kind" binding:"omitempty,oneof=late_cancellation other"`
+	Amount        float64 `json:"amount" binding:"required,gt=0"`
+	Date          string  `json:"date"`
+	Description   string  `json:"description"`
+}
+
+func (h *Handler) RegisterPayment(c *gin.Context) {
+	var req registerPaymentDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var paidAt time.Time
+	if req.PaidAt != "" {
+		var err error
+		paidAt, err = time.Parse("2006-01-02", req.PaidAt)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "paid_at inválido (use YYYY-MM-DD)"})
+			return
+		}
+	}
+
+	kind := req.Kind
+	if kind == "" {
+		kind = "payment"
+	}
+
+	payment, err := h.svc.RegisterPayment(c.Request.Context(), service.RegisterPaymentRequest{
+		ProfessionalID: currentProfessionalID(c),
+		ClientID:       req.ClientID,
+		AppointmentID:  req.AppointmentID,
+		Kind:           kind,
+		Amount:         req.Amount,
+		Method:         req.Method,
+		PaidAt:         paidAt,
+		Notes:          req.Notes,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "paciente o turno no encontrado"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, payment)
+}
+
+func (h *Handler) ListPayments(c *gin.Context) {
+	var req struct {
+		ClientID int64 `form:"client_id" binding:"required"`
+	}
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "client_id es requerido"})
+		return
+	}
+
+	payments, err := h.svc.ListPayments(c.Request.Context(), currentProfessionalID(c), req.ClientID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if payments == nil {
+		payments = []db.ClientPayment{}
+	}
+
+	c.JSON(http.StatusOK, payments)
+}
+
+func (h *Handler) CreateFee(c *gin.Context) {
+	var req createFeeDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var date time.Time
+	if req.Date != "" {
+		var err error
+		date, err = time.Parse("2006-01-02", req.Date)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date inválido (use YYYY-MM-DD)"})
+			return
+		}
+	}
+
+	kind := req.Kind
+	if kind == "" {
+		kind = "other"
+	}
+
+	fee, err := h.svc.CreateFee(c.Request.Context(), service.CreateFeeRequest{
+		ProfessionalID: currentProfessionalID(c),
+		ClientID:       req.ClientID,
+		AppointmentID:  req.AppointmentID,
+		Kind:           kind,
+		Amount:         req.Amount,
+		Date:           date,
+		Description:    req.Description,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "paciente o turno no encontrado"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, fee)
+}
+
+func (h *Handler) ListClientBalances(c *gin.Context) {
+	balances, err := h.svc.ListClientBalances(c.Request.Context(), currentProfessionalID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if balances == nil {
+		balances = []db.ListClientBalancesRow{}
+	}
+
+	c.JSON(http.StatusOK, balances)
+}
+
+// GetClientStatement devuelve el estado de cuenta en JSON o, con ?format=pdf, el PDF imprimible.
+func (h *Handler) GetClientStatement(c *gin.Context) {
+	clientID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de paciente inválido"})
+		return
+	}
+
+	var req struct {
+		StartDate string `form:"start_date"`
+		EndDate   string `form:"end_date"`
+		Format    string `form:"format" binding:"omitempty,oneof=json pdf"`
+	}
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "parametros inválidos: " + err.Error()})
+		return
+	}
+
+	// Por defecto: mes en curso
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, -1)
+
+	layout := "2006-01-02"
+	if req.StartDate != "" {
+		if start, err = time.Parse(layout, req.StartDate); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "formato de fecha inválido (use YYYY-MM-DD)"})
+			return
+		}
+	}
+	if req.EndDate != "" {
+		if end, err = time.Parse(layout, req.EndDate); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "formato de fecha inválido (use YYYY-MM-DD)"})
+			return
+		}
+	}
+
+	statement, err := h.svc.GetClientStatement(c.Request.Context(), currentProfessionalID(c), clientID, start, end)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "paciente no encontrado"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Format != "pdf" {
+		c.JSON(http.StatusOK, statement)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := service.RenderStatementPDF(statement, &buf); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error generando PDF: " + err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("estado_de_cuenta_%d_%s.pdf", clientID, end.Format(layout))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
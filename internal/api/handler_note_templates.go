@@ -0,0 +1,105 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luciluz/psiconexo/internal/db"
+	"github.com/luciluz/psiconexo/internal/service"
+)
+
+type noteTemplateDTO struct {
+	Name     string                    `json:"name" binding:"required"`
+	Kind     string                    `json:"kind" binding:"required,oneof=soap intake discharge risk_assessment custom"`
+	Sections []service.TemplateSection `json:"sections"` // Vacío = secciones predefinidas del tipo
+	Active   *bool                     `json:"active"`   // Sólo al actualizar, por defecto true
+}
+
+func (h *Handler) CreateNoteTemplate(c *gin.Context) {
+	var req noteTemplateDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tpl, err := h.svc.CreateNoteTemplate(c.Request.Context(), service.NoteTemplateRequest{
+		ProfessionalID: currentProfessionalID(c),
+		Name:           req.Name,
+		Kind:           req.Kind,
+		Sections:       req.Sections,
+	})
+	if err != nil {
+		respondTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, tpl)
+}
+
+func (h *Handler) UpdateNoteTemplate(c *gin.Context) {
+	templateID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de plantilla inválido"})
+		return
+	}
+
+	var req noteTemplateDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	tpl, err := h.svc.UpdateNoteTemplate(c.Request.Context(), service.NoteTemplateRequest{
+		ProfessionalID: currentProfessionalID(c),
+		TemplateID:     templateID,
+		Name:           req.Name,
+		Kind:           req.Kind,
+		Sections:       req.Sections,
+		Active:         active,
+	})
+	if err != nil {
+		respondTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tpl)
+}
+
+func (h *Handler) ListNoteTemplates(c *gin.Context) {
+	templates, err := h.svc.ListNoteTemplates(c.Request.Context(), currentProfessionalID(c))
+	if err != nil {
+		respondTemplateError(c, err)
+		return
+	}
+
+	if templates == nil {
+		templates = []db.NoteTemplate{}
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// ListNoteTemplatePresets devuelve las secciones de partida de cada tipo (SOAP, admisión, alta, riesgo).
+func (h *Handler) ListNoteTemplatePresets(c *gin.Context) {
+	c.JSON(http.StatusOK, service.NoteTemplatePresets())
+}
+
+func respondTemplateError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "plantilla no encontrada"})
+	case errors.Is(err, service.ErrInvalidTemplate):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,277 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luciluz/psiconexo/internal/service"
+)
+
+type createOrganizationDTO struct {
+	Name             string `json:"name" binding:"required"`
+	RequireTwoFactor bool   `json:"require_two_factor"`
+}
+
+type updateOrganizationDTO struct {
+	Name             string `json:"name" binding:"required"`
+	RequireTwoFactor bool   `json:"require_two_factor"`
+
+	// Identidad: aparece en la página pública de cada profesional del centro
+	LogoURL    string `json:"logo_url"`
+	BrandColor string `json:"brand_color"` // #RRGGBB
+	Address    string `json:"address"`
+	Phone      string `json:"phone"`
+	Website    string `json:"website"`
+
+	// Agenda común: null = cada profesional usa la suya
+	DefaultDurationMinutes  *int  `json:"default_duration_minutes"`
+	BufferMinutes           *int  `json:"buffer_minutes"`
+	TimeIncrementMinutes    *int  `json:"time_increment_minutes"`
+	MinBookingNoticeHours   *int  `json:"min_booking_notice_hours"`
+	BookingRequiresApproval *bool `json:"booking_requires_approval"`
+}
+
+type organizationMemberDTO struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required"` // member o admin
+}
+
+type organizationRoleDTO struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// CreateOrganization crea el centro con el profesional autenticado como administrador.
+func (h *Handler) CreateOrganization(c *gin.Context) {
+	var req createOrganizationDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	org, err := h.svc.CreateOwnOrganization(c.Request.Context(), service.CreateOrganizationRequest{
+		ProfessionalID:   currentProfessionalID(c),
+		Name:             req.Name,
+		RequireTwoFactor: req.RequireTwoFactor,
+	})
+	if err != nil {
+		respondOrganizationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, org)
+}
+
+func (h *Handler) GetOrganization(c *gin.Context) {
+	org, err := h.svc.GetOrganization(c.Request.Context(), currentProfessionalID(c))
+	if err != nil {
+		respondOrganizationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, org)
+}
+
+func (h *Handler) UpdateOrganization(c *gin.Context) {
+	var req updateOrganizationDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	org, err := h.svc.UpdateOrganization(c.Request.Context(), service.UpdateOrganizationRequest{
+		ProfessionalID:          currentProfessionalID(c),
+		Name:                    req.Name,
+		RequireTwoFactor:        req.RequireTwoFactor,
+		LogoURL:                 req.LogoURL,
+		BrandColor:              req.BrandColor,
+		Address:                 req.Address,
+		Phone:                   req.Phone,
+		Website:                 req.Website,
+		DefaultDurationMinutes:  req.DefaultDurationMinutes,
+		BufferMinutes:           req.BufferMinutes,
+		TimeIncrementMinutes:    req.TimeIncrementMinutes,
+		MinBookingNoticeHours:   req.MinBookingNoticeHours,
+		BookingRequiresApproval: req.BookingRequiresApproval,
+	})
+	if err != nil {
+		respondOrganizationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, org)
+}
+
+// InviteOrganizationMember manda la invitación a un profesional que ya tiene cuenta (por email).
+// Entra recién cuando la acepta.
+func (h *Handler) InviteOrganizationMember(c *gin.Context) {
+	var req organizationMemberDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.InviteToOrganization(c.Request.Context(), service.AddOrganizationMemberRequest{
+		ProfessionalID: currentProfessionalID(c),
+		Email:          req.Email,
+		Role:           req.Role,
+	}); err != nil {
+		respondOrganizationError(c, err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// AcceptOrganizationInvitation suma al profesional autenticado a la organización que lo invitó.
+func (h *Handler) AcceptOrganizationInvitation(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	org, err := h.svc.AcceptOrganizationInvitation(c.Request.Context(), currentProfessionalID(c), req.Token)
+	if err != nil {
+		respondOrganizationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, org)
+}
+
+func (h *Handler) UpdateOrganizationMemberRole(c *gin.Context) {
+	memberID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de profesional inválido"})
+		return
+	}
+
+	var req organizationRoleDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.UpdateOrganizationMemberRole(c.Request.Context(), currentProfessionalID(c), memberID, req.Role); err != nil {
+		respondOrganizationError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RemoveOrganizationMember saca a un profesional del centro (o al propio, si se va).
+func (h *Handler) RemoveOrganizationMember(c *gin.Context) {
+	memberID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de profesional inválido"})
+		return
+	}
+
+	if err := h.svc.RemoveOrganizationMember(c.Request.Context(), currentProfessionalID(c), memberID); err != nil {
+		respondOrganizationError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetOrganizationCalendar devuelve la agenda del centro. Por defecto, los próximos 7 días.
+func (h *Handler) GetOrganizationCalendar(c *gin.Context) {
+	var req struct {
+		StartDate      string `form:"start_date"`
+		EndDate        string `form:"end_date"`
+		ProfessionalID *int64 `form:"professional_id"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "parametros inválidos: " + err.Error()})
+		return
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	start, end, ok := parseOrganizationPeriod(c, req.StartDate, req.EndDate, today, today.AddDate(0, 0, 6))
+	if !ok {
+		return
+	}
+
+	entries, err := h.svc.ListOrganizationCalendar(c.Request.Context(), currentProfessionalID(c), start, end, req.ProfessionalID)
+	if err != nil {
+		respondOrganizationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// GetOrganizationFinancialSummary devuelve los KPIs por profesional. Por defecto, el mes en curso.
+func (h *Handler) GetOrganizationFinancialSummary(c *gin.Context) {
+	var req struct {
+		StartDate string `form:"start_date"`
+		EndDate   string `form:"end_date"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "parametros inválidos: " + err.Error()})
+		return
+	}
+
+	now := time.Now()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	start, end, ok := parseOrganizationPeriod(c, req.StartDate, req.EndDate, month, month.AddDate(0, 1, -1))
+	if !ok {
+		return
+	}
+
+	summary, err := h.svc.GetOrganizationFinancialSummary(c.Request.Context(), currentProfessionalID(c), start, end)
+	if err != nil {
+		respondOrganizationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// parseOrganizationPeriod aplica start_date y end_date sobre el período por defecto. Si son
+// inválidos ya respondió 400.
+func parseOrganizationPeriod(c *gin.Context, rawStart, rawEnd string, start, end time.Time) (time.Time, time.Time, bool) {
+	from, err := parseOptionalDate(rawStart)
+	if err == nil && from != nil {
+		start = *from
+	}
+	to, toErr := parseOptionalDate(rawEnd)
+	if toErr == nil && to != nil {
+		end = *to
+	}
+	if err != nil || toErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "formato de fecha inválido (use YYYY-MM-DD)"})
+		return start, end, false
+	}
+	if end.Before(start) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date no puede ser anterior a start_date"})
+		return start, end, false
+	}
+	return start, end, true
+}
+
+func respondOrganizationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrNoOrganization), errors.Is(err, service.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": "sólo los administradores de la organización pueden hacer esto"})
+	case errors.Is(err, service.ErrAlreadyInOrganization), errors.Is(err, service.ErrLastOrganizationAdmin):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidOrganization), errors.Is(err, service.ErrInvalidMemberRole),
+		errors.Is(err, service.ErrInvalidBookingRange), errors.Is(err, service.ErrInvalidInvitation):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrMailerUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luciluz/psiconexo/internal/db"
+	"github.com/luciluz/psiconexo/internal/service"
+)
+
+type createPackageDTO struct {
+	ClientID      int64   `json:"client_id" binding:"required"`
+	Name          string  `json:"name"`
+	TotalSessions int     `json:"total_sessions" binding:"required,gt=0"`
+	Price         float64 `json:"price" binding:"gte=0"`
+	ValidFrom     string  `json:"valid_from" binding:"required"`
+	ValidUntil    string  `json:"valid_until" binding:"required"`
+	Paid          bool    `json:"paid"`
+	PaymentMethod string  `json:"payment_method" binding:"omitempty,oneof=mercadopago transfer cash insurance"`
+}
+
+type updatePackagePaymentDTO struct {
+	PaymentStatus string `json:"payment_status" binding:"required,oneof=pending paid refunded"`
+	PaymentMethod string `json:"payment_method" binding:"omitempty,oneof=mercadopago transfer cash insurance"`
+}
+
+func (h *Handler) CreatePackage(c *gin.Context) {
+	var req createPackageDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	layout := "2006-01-02"
+	validFrom, err1 := time.Parse(layout, req.ValidFrom)
+	validUntil, err2 := time.Parse(layout, req.ValidUntil)
+	if err1 != nil || err2 != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "formato de fecha inválido (use YYYY-MM-DD)"})
+		return
+	}
+
+	pkg, err := h.svc.CreatePackage(c.Request.Context(), service.CreatePackageRequest{
+		ProfessionalID: currentProfessionalID(c),
+		ClientID:       req.ClientID,
+		Name:           req.Name,
+		TotalSessions:  req.TotalSessions,
+		Price:          req.Price,
+		ValidFrom:      validFrom,
+		ValidUntil:     validUntil,
+		Paid:           req.Paid,
+		PaymentMethod:  req.PaymentMethod,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidPackageDates):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "paciente no encontrado"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, pkg)
+}
+
+func (h *Handler) ListPackages(c *gin.Context) {
+	var req struct {
+		ClientID *int64 `form:"client_id"`
+	}
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "client_id inválido"})
+		return
+	}
+
+	pkgs, err := h.svc.ListPackages(c.Request.Context(), currentProfessionalID(c), req.ClientID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if pkgs == nil {
+		pkgs = []db.ListSessionPackagesRow{}
+	}
+
+	c.JSON(http.StatusOK, pkgs)
+}
+
+func (h *Handler) UpdatePackagePayment(c *gin.Context) {
+	packageID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de paquete inválido"})
+		return
+	}
+
+	var req updatePackagePaymentDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pkg, err := h.svc.UpdatePackagePayment(c.Request.Context(), currentProfessionalID(c), packageID, req.PaymentStatus, req.PaymentMethod)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "paquete no encontrado"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, pkg)
+}
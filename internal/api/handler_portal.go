@@ -0,0 +1,262 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luciluz/psiconexo/internal/service"
+)
+
+type portalLinkDTO struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type portalVerifyDTO struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type portalRescheduleDTO struct {
+	Date      string `json:"date" binding:"required"`       // YYYY-MM-DD
+	StartTime string `json:"start_time" binding:"required"` // HH:MM
+}
+
+type portalFormDTO struct {
+	Answers map[string]any `json:"answers" binding:"required"`
+}
+
+// RequestPortalLink responde siempre 202, sea o no paciente de alguien.
+func (h *Handler) RequestPortalLink(c *gin.Context) {
+	var req portalLinkDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.RequestPortalLink(c.Request.Context(), req.Email); err != nil {
+		respondPortalError(c, err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// VerifyPortalLink canjea el token del email por la sesión del portal.
+func (h *Handler) VerifyPortalLink(c *gin.Context) {
+	var req portalVerifyDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, err := h.svc.VerifyPortalLink(c.Request.Context(), req.Token)
+	if err != nil {
+		respondPortalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+func (h *Handler) GetPortalMe(c *gin.Context) {
+	client, err := h.svc.GetPortalClient(c.Request.Context(), currentClientID(c))
+	if err != nil {
+		respondPortalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, client)
+}
+
+func (h *Handler) ListPortalAppointments(c *gin.Context) {
+	appts, err := h.svc.ListPortalAppointments(c.Request.Context(), currentProfessionalID(c), currentClientID(c))
+	if err != nil {
+		respondPortalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, appts)
+}
+
+func (h *Handler) CancelPortalAppointment(c *gin.Context) {
+	apptID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de turno inválido"})
+		return
+	}
+
+	if err := h.svc.CancelPortalAppointment(c.Request.Context(), currentProfessionalID(c), currentClientID(c), apptID); err != nil {
+		respondPortalError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ReschedulePortalAppointment devuelve el turno nuevo; el anterior queda como reprogramado.
+func (h *Handler) ReschedulePortalAppointment(c *gin.Context) {
+	apptID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de turno inválido"})
+		return
+	}
+
+	var req portalRescheduleDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "formato de fecha inválido, use YYYY-MM-DD"})
+		return
+	}
+
+	appt, err := h.svc.ReschedulePortalAppointment(c.Request.Context(), service.PortalRescheduleRequest{
+		ProfessionalID: currentProfessionalID(c),
+		ClientID:       currentClientID(c),
+		AppointmentID:  apptID,
+		Date:           date,
+		StartTime:      req.StartTime,
+	})
+	if err != nil {
+		respondPortalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, appt)
+}
+
+// UploadPaymentProof recibe un multipart/form-data con el comprobante en file.
+func (h *Handler) UploadPaymentProof(c *gin.Context) {
+	apptID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de turno inválido"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, service.MaxPaymentProofBytes+1<<20)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": service.ErrAttachmentTooLarge.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "falta el archivo (campo file)"})
+		return
+	}
+	f, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	data, err := io.ReadAll(io.LimitReader(f, service.MaxPaymentProofBytes+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	proof, err := h.svc.UploadPaymentProof(c.Request.Context(), service.UploadPaymentProofRequest{
+		ProfessionalID: currentProfessionalID(c),
+		ClientID:       currentClientID(c),
+		AppointmentID:  apptID,
+		Filename:       header.Filename,
+		Data:           data,
+	})
+	if err != nil {
+		respondPortalError(c, err)
+		return
+	}
+
+	proof.StorageKey = ""
+	c.JSON(http.StatusCreated, proof)
+}
+
+// GetPortalInvoice redirige a la factura del turno.
+func (h *Handler) GetPortalInvoice(c *gin.Context) {
+	apptID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de turno inválido"})
+		return
+	}
+
+	url, err := h.svc.PortalInvoiceURL(c.Request.Context(), currentProfessionalID(c), currentClientID(c), apptID)
+	if err != nil {
+		respondPortalError(c, err)
+		return
+	}
+
+	c.Redirect(http.StatusFound, url)
+}
+
+func (h *Handler) ListPortalForms(c *gin.Context) {
+	forms, err := h.svc.ListPortalForms(c.Request.Context(), currentProfessionalID(c), currentClientID(c))
+	if err != nil {
+		respondPortalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, forms)
+}
+
+// SubmitPortalForm guarda las respuestas; una vez enviado el formulario no se puede cambiar.
+func (h *Handler) SubmitPortalForm(c *gin.Context) {
+	formID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de formulario inválido"})
+		return
+	}
+
+	var req portalFormDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	form, err := h.svc.SubmitPortalForm(c.Request.Context(), service.SubmitClientFormRequest{
+		ProfessionalID: currentProfessionalID(c),
+		ClientID:       currentClientID(c),
+		FormID:         formID,
+		Answers:        req.Answers,
+	})
+	if err != nil {
+		respondPortalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, form)
+}
+
+func respondPortalError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidLoginLink):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrNotFound), errors.Is(err, service.ErrNoInvoice):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrOutsideCancellationWindow), errors.Is(err, service.ErrAppointmentNotScheduled),
+		errors.Is(err, service.ErrSlotUnavailable), errors.Is(err, service.ErrPaymentAlreadyConfirmed),
+		errors.Is(err, service.ErrFormCompleted):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrAttachmentTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrUnsupportedFileType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrEmptyAttachment), errors.Is(err, service.ErrInvalidSectionValue),
+		errors.Is(err, service.ErrMissingRequiredSections):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrAuthUnavailable), errors.Is(err, service.ErrMailerUnavailable),
+		errors.Is(err, service.ErrStorageUnavailable), errors.Is(err, service.ErrEncryptionUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luciluz/psiconexo/internal/db"
+	"github.com/luciluz/psiconexo/internal/service"
+)
+
+type createPriceDTO struct {
+	Modality      string  `json:"modality" binding:"required,oneof=virtual in_person home"`
+	Duration      int     `json:"duration" binding:"required,gt=0"`
+	Price         float64 `json:"price" binding:"required,gt=0"`
+	EffectiveFrom string  `json:"effective_from"` // YYYY-MM-DD, por defecto hoy
+}
+
+type adjustPricesDTO struct {
+	Series        string  `json:"series" binding:"required"`
+	BasePeriod    string  `json:"base_period"`   // YYYY-MM, opcional
+	TargetPeriod  string  `json:"target_period"` // YYYY-MM, opcional (último cargado)
+	EffectiveFrom string  `json:"effective_from" binding:"required"`
+	RoundTo       float64 `json:"round_to" binding:"gte=0"`
+}
+
+func (h *Handler) CreatePrice(c *gin.Context) {
+	var req createPriceDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	effectiveFrom := time.Now().Truncate(24 * time.Hour)
+	if req.EffectiveFrom != "" {
+		var err error
+		effectiveFrom, err = time.Parse("2006-01-02", req.EffectiveFrom)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "effective_from inválido (use YYYY-MM-DD)"})
+			return
+		}
+	}
+
+	update, err := h.svc.CreatePrice(c.Request.Context(), service.CreatePriceRequest{
+		ProfessionalID: currentProfessionalID(c),
+		Modality:       req.Modality,
+		Duration:       req.Duration,
+		Price:          req.Price,
+		EffectiveFrom:  effectiveFrom,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, update)
+}
+
+// ListPriceList devuelve el historial completo o, con ?date=YYYY-MM-DD, la lista vigente a esa fecha.
+func (h *Handler) ListPriceList(c *gin.Context) {
+	var req struct {
+		Date string `form:"date"`
+	}
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "parametros inválidos: " + err.Error()})
+		return
+	}
+
+	var entries []db.PriceListEntry
+	var err error
+	if req.Date != "" {
+		date, parseErr := time.Parse("2006-01-02", req.Date)
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "formato de fecha inválido (use YYYY-MM-DD)"})
+			return
+		}
+		entries, err = h.svc.GetCurrentPriceList(c.Request.Context(), currentProfessionalID(c), date)
+	} else {
+		entries, err = h.svc.ListPriceList(c.Request.Context(), currentProfessionalID(c))
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if entries == nil {
+		entries = []db.PriceListEntry{}
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+func (h *Handler) AdjustPrices(c *gin.Context) {
+	var req adjustPricesDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	effectiveFrom, err := time.Parse("2006-01-02", req.EffectiveFrom)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "effective_from inválido (use YYYY-MM-DD)"})
+		return
+	}
+
+	var base, target time.Time
+	if req.BasePeriod != "" {
+		if base, err = time.Parse("2006-01", req.BasePeriod); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "base_period inválido (use YYYY-MM)"})
+			return
+		}
+	}
+	if req.TargetPeriod != "" {
+		if target, err = time.Parse("2006-01", req.TargetPeriod); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "target_period inválido (use YYYY-MM)"})
+			return
+		}
+	}
+
+	updates, err := h.svc.AdjustPricesByIndex(c.Request.Context(), service.AdjustPricesRequest{
+		ProfessionalID: currentProfessionalID(c),
+		Series:         req.Series,
+		BasePeriod:     base,
+		TargetPeriod:   target,
+		EffectiveFrom:  effectiveFrom,
+		RoundTo:        req.RoundTo,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPriceIndexNotFound), errors.Is(err, service.ErrEmptyPriceList):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, updates)
+}
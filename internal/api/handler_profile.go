@@ -0,0 +1,164 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luciluz/psiconexo/internal/service"
+)
+
+type updateProfileDTO struct {
+	Name          string `json:"name" binding:"required"`
+	Phone         string `json:"phone"`
+	Slug          string `json:"slug"` // Vacío: sin página pública
+	Title         string `json:"title"`
+	LicenseNumber string `json:"license_number"`
+	Bio           string `json:"bio" binding:"max=2000"`
+}
+
+func (h *Handler) GetProfile(c *gin.Context) {
+	prof, err := h.svc.GetProfile(c.Request.Context(), currentProfessionalID(c))
+	if err != nil {
+		respondProfileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, prof)
+}
+
+// UpdateProfile reemplaza los datos de la página pública. El slug se normaliza ("Lucía Pérez" →
+// "lucia-perez") y vuelve como quedó guardado.
+func (h *Handler) UpdateProfile(c *gin.Context) {
+	var req updateProfileDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	prof, err := h.svc.UpdateProfile(c.Request.Context(), service.UpdateProfileRequest{
+		ProfessionalID: currentProfessionalID(c),
+		Name:           req.Name,
+		Phone:          req.Phone,
+		Slug:           req.Slug,
+		Title:          req.Title,
+		LicenseNumber:  req.LicenseNumber,
+		Bio:            req.Bio,
+	})
+	if err != nil {
+		respondProfileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, prof)
+}
+
+// CheckSlugAvailability responde si la dirección pública está libre (?slug=...), para validar
+// mientras se escribe.
+func (h *Handler) CheckSlugAvailability(c *gin.Context) {
+	slug := c.Query("slug")
+	if slug == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "slug es requerido"})
+		return
+	}
+
+	result, err := h.svc.CheckSlug(c.Request.Context(), currentProfessionalID(c), slug)
+	if err != nil {
+		respondProfileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// UploadProfilePhoto recibe un multipart/form-data con la foto en file.
+func (h *Handler) UploadProfilePhoto(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, service.MaxProfilePhotoBytes+1<<20)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": service.ErrAttachmentTooLarge.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "falta el archivo (campo file)"})
+		return
+	}
+	f, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	data, err := io.ReadAll(io.LimitReader(f, service.MaxProfilePhotoBytes+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	prof, err := h.svc.UploadProfilePhoto(c.Request.Context(), currentProfessionalID(c), data)
+	if err != nil {
+		respondProfileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, prof)
+}
+
+func (h *Handler) DeleteProfilePhoto(c *gin.Context) {
+	if err := h.svc.DeleteProfilePhoto(c.Request.Context(), currentProfessionalID(c)); err != nil {
+		respondProfileError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetProfilePhoto sirve la foto vigente sin sesión: la usa la página pública.
+func (h *Handler) GetProfilePhoto(c *gin.Context) {
+	profID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de profesional inválido"})
+		return
+	}
+
+	data, contentType, err := h.svc.GetProfilePhoto(c.Request.Context(), profID, c.Param("file"))
+	if err != nil {
+		respondProfileError(c, err)
+		return
+	}
+
+	// Cada foto tiene su URL: si cambia, cambia la URL
+	c.Header("Cache-Control", "public, max-age=86400, immutable")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Data(http.StatusOK, contentType, data)
+}
+
+func respondProfileError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrSlugTaken), errors.Is(err, service.ErrPhoneTaken),
+		errors.Is(err, service.ErrEmailTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidSlug), errors.Is(err, service.ErrReservedSlug),
+		errors.Is(err, service.ErrInvalidLicense), errors.Is(err, service.ErrNameRequired),
+		errors.Is(err, service.ErrEmptyAttachment):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrAttachmentTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrUnsupportedFileType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrStorageUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luciluz/psiconexo/internal/service"
+)
+
+type publicSlotsQueryDTO struct {
+	From string `form:"from"` // YYYY-MM-DD, por defecto hoy
+	To   string `form:"to"`   // YYYY-MM-DD, inclusive
+}
+
+type publicBookingDTO struct {
+	Name         string `json:"name" binding:"required,max=200"`
+	Email        string `json:"email" binding:"required,email"`
+	Phone        string `json:"phone" binding:"max=50"`
+	Date         string `json:"date" binding:"required"`       // YYYY-MM-DD
+	StartTime    string `json:"start_time" binding:"required"` // HH:MM, uno de los horarios de /slots
+	CaptchaToken string `json:"captcha_token"`
+	Website      string `json:"website"` // Trampa para bots: el formulario lo deja oculto y vacío
+}
+
+func (h *Handler) GetPublicProfile(c *gin.Context) {
+	profile, err := h.svc.GetPublicProfile(c.Request.Context(), c.Param("slug"))
+	if err != nil {
+		respondPublicError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// ListPublicSlots devuelve los horarios libres: ?from=2025-03-01&to=2025-03-14 (hasta 31 días).
+func (h *Handler) ListPublicSlots(c *gin.Context) {
+	var req publicSlotsQueryDTO
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "parametros inválidos: " + err.Error()})
+		return
+	}
+
+	from, errFrom := parseOptionalDate(req.From)
+	to, errTo := parseOptionalDate(req.To)
+	if errFrom != nil || errTo != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "formato de fecha inválido (use YYYY-MM-DD)"})
+		return
+	}
+
+	slots, err := h.svc.ListAvailableSlots(c.Request.Context(), c.Param("slug"), from, to)
+	if err != nil {
+		respondPublicError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, slots)
+}
+
+// RequestPublicBooking pide un turno sin cuenta. Responde 201 con el estado del turno:
+// requested si el profesional tiene que aprobarlo, scheduled si no.
+func (h *Handler) RequestPublicBooking(c *gin.Context) {
+	var req publicBookingDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Un bot completó el campo oculto: se le responde como si nada, sin crear el turno
+	if req.Website != "" {
+		c.Status(http.StatusAccepted)
+		return
+	}
+
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "formato de fecha inválido, use YYYY-MM-DD"})
+		return
+	}
+
+	result, err := h.svc.RequestPublicBooking(c.Request.Context(), service.PublicBookingRequest{
+		Slug:         c.Param("slug"),
+		Name:         req.Name,
+		Email:        req.Email,
+		Phone:        req.Phone,
+		Date:         date,
+		StartTime:    req.StartTime,
+		CaptchaToken: req.CaptchaToken,
+		IP:           c.ClientIP(),
+	})
+	if err != nil {
+		respondPublicError(c, err)
+		return
+	}
+
+	// La ruta no pasa por AuditMiddleware (no hay sesión): el alta se registra acá, a nombre del paciente
+	c.Set(ctxProfessionalID, result.ProfessionalID)
+	c.Set(ctxClientID, result.ClientID)
+	ev := auditEvent(c, service.AuditCreate, http.StatusCreated)
+	ev.ResourceType = "appointments"
+	ev.ResourceID = result.AppointmentID
+	if err := h.svc.RecordAudit(c.Request.Context(), ev); err != nil {
+		log.Printf("auditoría: no se registró %s (profesional %d): %v", ev.Route, ev.ProfessionalID, err)
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+// parseOptionalDate devuelve nil si la fecha viene vacía.
+func parseOptionalDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	return &date, nil
+}
+
+func respondPublicError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "profesional no encontrado"})
+	case errors.Is(err, service.ErrInvalidBookingRange), errors.Is(err, service.ErrCaptchaFailed):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrSlotUnavailable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTooManyBookingRequests):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -9,8 +9,7 @@ import (
 )
 
 type updateScheduleDTO struct {
-	ProfessionalID int64 `json:"professional_id" binding:"required"`
-	Blocks         []struct {
+	Blocks []struct {
 		DayOfWeek int    `json:"day_of_week" binding:"required,min=1,max=7"`
 		StartTime string `json:"start_time" binding:"required"`
 		EndTime   string `json:"end_time" binding:"required"`
@@ -35,7 +34,7 @@ func (h *Handler) UpdateSchedule(c *gin.Context) {
 	}
 
 	err := h.svc.UpdateSchedule(c.Request.Context(), service.UpdateScheduleRequest{
-		ProfessionalID: req.ProfessionalID,
+		ProfessionalID: currentProfessionalID(c),
 		Blocks:         serviceBlocks,
 	})
 
@@ -48,16 +47,7 @@ func (h *Handler) UpdateSchedule(c *gin.Context) {
 }
 
 func (h *Handler) ListSchedule(c *gin.Context) {
-	var req struct {
-		ProfessionalID int64 `form:"professional_id" binding:"required"`
-	}
-
-	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "professional_id requerido"})
-		return
-	}
-
-	schedule, err := h.svc.ListSchedule(c.Request.Context(), req.ProfessionalID)
+	schedule, err := h.svc.ListSchedule(c.Request.Context(), currentProfessionalID(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
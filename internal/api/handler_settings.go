@@ -8,12 +8,12 @@ import (
 )
 
 type settingsDTO struct {
-	ProfessionalID         int64 `json:"professional_id" binding:"required"`
-	DefaultDurationMinutes int   `json:"default_duration_minutes"`
-	BufferMinutes          int   `json:"buffer_minutes"`
-	TimeIncrementMinutes   int   `json:"time_increment_minutes"`
-	MinBookingNoticeHours  int   `json:"min_booking_notice_hours"`
-	MaxDailyAppointments   *int  `json:"max_daily_appointments"`
+	DefaultDurationMinutes  int   `json:"default_duration_minutes"`
+	BufferMinutes           int   `json:"buffer_minutes"`
+	TimeIncrementMinutes    int   `json:"time_increment_minutes"`
+	MinBookingNoticeHours   int   `json:"min_booking_notice_hours"`
+	MaxDailyAppointments    *int  `json:"max_daily_appointments"`
+	BookingRequiresApproval *bool `json:"booking_requires_approval"` // Por defecto true
 }
 
 func (h *Handler) UpdateSettings(c *gin.Context) {
@@ -24,12 +24,13 @@ func (h *Handler) UpdateSettings(c *gin.Context) {
 	}
 
 	settings, err := h.svc.UpdateSettings(c.Request.Context(), service.UpdateSettingsRequest{
-		ProfessionalID:         req.ProfessionalID,
-		DefaultDurationMinutes: req.DefaultDurationMinutes,
-		BufferMinutes:          req.BufferMinutes,
-		TimeIncrementMinutes:   req.TimeIncrementMinutes,
-		MinBookingNoticeHours:  req.MinBookingNoticeHours,
-		MaxDailyAppointments:   req.MaxDailyAppointments,
+		ProfessionalID:          currentProfessionalID(c),
+		DefaultDurationMinutes:  req.DefaultDurationMinutes,
+		BufferMinutes:           req.BufferMinutes,
+		TimeIncrementMinutes:    req.TimeIncrementMinutes,
+		MinBookingNoticeHours:   req.MinBookingNoticeHours,
+		MaxDailyAppointments:    req.MaxDailyAppointments,
+		BookingRequiresApproval: req.BookingRequiresApproval,
 	})
 
 	if err != nil {
@@ -41,16 +42,9 @@ func (h *Handler) UpdateSettings(c *gin.Context) {
 }
 
 func (h *Handler) GetSettings(c *gin.Context) {
-	var req struct {
-		ProfessionalID int64 `form:"professional_id" binding:"required"`
-	}
-
-	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "professional_id es requerido"})
-		return
-	}
+	profID := currentProfessionalID(c)
 
-	settings, err := h.svc.GetSettings(c.Request.Context(), req.ProfessionalID)
+	settings, err := h.svc.GetSettings(c.Request.Context(), profID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -58,7 +52,7 @@ func (h *Handler) GetSettings(c *gin.Context) {
 
 	if settings == nil {
 		c.JSON(http.StatusOK, gin.H{
-			"professional_id":          req.ProfessionalID,
+			"professional_id":          profID,
 			"default_duration_minutes": 50, // Default hardcodeado por seguridad UX
 			"buffer_minutes":           0,
 			"time_increment_minutes":   30,
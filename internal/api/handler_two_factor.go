@@ -0,0 +1,156 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luciluz/psiconexo/internal/service"
+)
+
+// twoFactorCodeDTO acepta el código de 6 dígitos de la app o un código de recuperación.
+type twoFactorCodeDTO struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type twoFactorChallengeDTO struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+}
+
+type twoFactorLoginDTO struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+// CompleteTwoFactorLogin es el segundo paso del login (two_factor_step = "verify").
+func (h *Handler) CompleteTwoFactorLogin(c *gin.Context) {
+	var req twoFactorLoginDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, err := h.svc.CompleteTwoFactorLogin(c.Request.Context(), req.ChallengeToken, req.Code)
+	if err != nil {
+		respondAuthError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+// BeginRequiredEnrollment da el secreto y el QR cuando el login pidió two_factor_step = "enroll".
+func (h *Handler) BeginRequiredEnrollment(c *gin.Context) {
+	var req twoFactorChallengeDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	enrollment, err := h.svc.BeginRequiredEnrollment(c.Request.Context(), req.ChallengeToken)
+	if err != nil {
+		respondAuthError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// CompleteRequiredEnrollment confirma el alta con el primer código y abre la sesión.
+// Los códigos de recuperación vienen en la respuesta y no se vuelven a mostrar.
+func (h *Handler) CompleteRequiredEnrollment(c *gin.Context) {
+	var req twoFactorLoginDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, err := h.svc.CompleteRequiredEnrollment(c.Request.Context(), req.ChallengeToken, req.Code)
+	if err != nil {
+		respondAuthError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+func (h *Handler) GetTwoFactorStatus(c *gin.Context) {
+	status, err := h.svc.GetTwoFactorStatus(c.Request.Context(), currentProfessionalID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// BeginTwoFactorEnrollment genera el secreto; la 2FA queda activa al confirmar un código.
+func (h *Handler) BeginTwoFactorEnrollment(c *gin.Context) {
+	enrollment, err := h.svc.BeginTwoFactorEnrollment(c.Request.Context(), currentProfessionalID(c))
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+func (h *Handler) ConfirmTwoFactorEnrollment(c *gin.Context) {
+	var req twoFactorCodeDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.svc.ConfirmTwoFactorEnrollment(c.Request.Context(), currentProfessionalID(c), req.Code)
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+func (h *Handler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req twoFactorCodeDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.svc.RegenerateRecoveryCodes(c.Request.Context(), currentProfessionalID(c), req.Code)
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+func (h *Handler) DisableTwoFactor(c *gin.Context) {
+	var req twoFactorCodeDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.DisableTwoFactor(c.Request.Context(), currentProfessionalID(c), req.Code); err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// respondTwoFactorError es para las rutas con sesión: un código mal tipeado es 400, no 401,
+// para que el cliente no lo tome como sesión vencida.
+func respondTwoFactorError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidTwoFactorCode):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTwoFactorNotEnabled), errors.Is(err, service.ErrTwoFactorRequired):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		respondAuthError(c, err)
+	}
+}
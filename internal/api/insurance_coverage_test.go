@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// Prueba de integración (TEST_DB_SOURCE, ver TestCrossTenantAccessReturnsNotFound): con obra
+// social, cobrarle el coseguro al paciente sólo suma el coseguro a los ingresos (el arancel lo
+// debe la obra social), y una obra social dada de baja no se puede imputar.
+func TestCoverageKeepsInsurerFeeOutOfIncome(t *testing.T) {
+	dbSource := os.Getenv("TEST_DB_SOURCE")
+	if dbSource == "" {
+		t.Skip("TEST_DB_SOURCE no está definido; se omite la prueba de coberturas")
+	}
+
+	router := newTestRouter(t, dbSource)
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+	token := registerProfessional(t, router, "coverage-"+suffix+"@example.com")
+
+	clientID := mustCreate(t, router, token, "/api/v1/clients", map[string]any{"name": "Paciente con obra social"})
+	insurerID := mustCreate(t, router, token, "/api/v1/insurers", map[string]any{"name": "OSDE " + suffix, "session_fee": 800})
+	coverageID := mustCreate(t, router, token, fmt.Sprintf("/api/v1/clients/%d/coverages", clientID), map[string]any{
+		"insurer_id": insurerID, "member_number": "123", "copay_amount": 200,
+	})
+	apptID := mustCreate(t, router, token, "/api/v1/appointments", map[string]any{
+		"client_id": clientID, "date": "2030-02-04", "start_time": "10:00", "duration": 50, "price": 1000,
+	})
+
+	path := fmt.Sprintf("/api/v1/appointments/%d/coverage", apptID)
+	if status, body := doRequest(t, router, token, http.MethodPut, path, map[string]any{"coverage_id": coverageID}); status != http.StatusOK {
+		t.Fatalf("PUT %s: %d %s", path, status, body)
+	}
+	mustCreate(t, router, token, "/api/v1/payments", map[string]any{
+		"client_id": clientID, "appointment_id": apptID, "amount": 200, "method": "cash",
+	})
+
+	summaryPath := "/api/v1/finances/summary?start_date=2030-02-01&end_date=2030-02-28"
+	status, body := doRequest(t, router, token, http.MethodGet, summaryPath, nil)
+	if status != http.StatusOK {
+		t.Fatalf("GET %s: %d %s", summaryPath, status, body)
+	}
+	var resp struct {
+		Summary struct {
+			Income string `json:"income"`
+		} `json:"summary"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if income, _ := strconv.ParseFloat(resp.Summary.Income, 64); income != 200 {
+		t.Fatalf("ingresos: se esperaba sólo el coseguro (200) y dio %s", resp.Summary.Income)
+	}
+
+	// Dada de baja: no se le imputan turnos nuevos
+	path = fmt.Sprintf("/api/v1/insurers/%d", insurerID)
+	if status, body := doRequest(t, router, token, http.MethodPut, path, map[string]any{
+		"name": "OSDE " + suffix, "session_fee": 800, "active": false,
+	}); status != http.StatusOK {
+		t.Fatalf("PUT %s: %d %s", path, status, body)
+	}
+	otherID := mustCreate(t, router, token, "/api/v1/appointments", map[string]any{
+		"client_id": clientID, "date": "2030-02-11", "start_time": "10:00", "duration": 50, "price": 1000,
+	})
+	path = fmt.Sprintf("/api/v1/appointments/%d/coverage", otherID)
+	if status, body := doRequest(t, router, token, http.MethodPut, path, map[string]any{"coverage_id": coverageID}); status != http.StatusBadRequest {
+		t.Fatalf("PUT %s con la obra social dada de baja: se esperaba 400 y volvió %d %s", path, status, body)
+	}
+}
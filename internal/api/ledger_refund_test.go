@@ -0,0 +1,95 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Prueba de integración (TEST_DB_SOURCE, ver TestCrossTenantAccessReturnsNotFound): pagar un turno
+// y después reintegrarlo tiene que dejar el saldo en el valor de la sesión, sin contar el
+// reintegro dos veces, tanto si el pago se registró como movimiento como si sólo figuraba en el
+// estado del turno.
+func TestRefundAfterPaymentKeepsBalance(t *testing.T) {
+	dbSource := os.Getenv("TEST_DB_SOURCE")
+	if dbSource == "" {
+		t.Skip("TEST_DB_SOURCE no está definido; se omite la prueba de reintegros")
+	}
+
+	router := newTestRouter(t, dbSource)
+	conn, err := sql.Open("postgres", dbSource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+	token := registerProfessional(t, router, "ledger-"+suffix+"@example.com")
+
+	cases := []struct {
+		name     string
+		date     string
+		markPaid func(t *testing.T, clientID, apptID int64)
+	}{
+		{"pago registrado", "2020-03-02", func(t *testing.T, clientID, apptID int64) {
+			mustCreate(t, router, token, "/api/v1/payments", map[string]any{
+				"client_id": clientID, "appointment_id": apptID, "amount": 1000, "method": "cash", "paid_at": "2020-03-02",
+			})
+		}},
+		{"pago implícito en el turno", "2020-03-03", func(t *testing.T, clientID, apptID int64) {
+			// Turnos cobrados antes de la cuenta corriente: sólo tienen el estado
+			if _, err := conn.Exec(`UPDATE appointments SET payment_status = 'paid', payment_method = 'transfer',
+				payment_confirmed_at = '2020-03-03' WHERE id = $1`, apptID); err != nil {
+				t.Fatal(err)
+			}
+		}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			clientID := mustCreate(t, router, token, "/api/v1/clients", map[string]any{"name": "Paciente " + tc.name})
+			apptID := mustCreate(t, router, token, "/api/v1/appointments", map[string]any{
+				"client_id": clientID, "date": tc.date, "start_time": "10:00", "duration": 50, "price": 1000,
+			})
+			tc.markPaid(t, clientID, apptID)
+
+			if got := statementBalance(t, router, token, clientID); got != 0 {
+				t.Fatalf("saldo después del pago: se esperaba 0 y quedó %.2f", got)
+			}
+
+			// Sin method: se conserva el medio del pago original (no puede quedar vacío por el CHECK)
+			mustCreate(t, router, token, "/api/v1/payments", map[string]any{
+				"client_id": clientID, "appointment_id": apptID, "kind": "refund", "amount": 1000, "paid_at": "2020-03-10",
+			})
+
+			if got := statementBalance(t, router, token, clientID); got != 1000 {
+				t.Fatalf("saldo después del reintegro: se esperaba 1000 y quedó %.2f", got)
+			}
+		})
+	}
+}
+
+func statementBalance(t *testing.T, router *gin.Engine, token string, clientID int64) float64 {
+	t.Helper()
+	path := fmt.Sprintf("/api/v1/clients/%d/statement?start_date=2020-01-01&end_date=%s", clientID, time.Now().Format("2006-01-02"))
+	status, body := doRequest(t, router, token, http.MethodGet, path, nil)
+	if status != http.StatusOK {
+		t.Fatalf("GET %s: %d %s", path, status, body)
+	}
+	var st struct {
+		ClosingBalance float64 `json:"closing_balance"`
+	}
+	if err := json.Unmarshal(body, &st); err != nil {
+		t.Fatal(err)
+	}
+	return st.ClosingBalance
+}
@@ -1,18 +1,40 @@
 package api
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/luciluz/psiconexo/internal/service"
 )
 
+// Claves del contexto donde AuthMiddleware deja al profesional autenticado (o por cuenta de
+// quién actúa el asistente) y, si es un asistente, quién es y qué roles tiene.
+// En el portal, PortalMiddleware deja al paciente y a su profesional.
+const (
+	ctxProfessionalID = "auth.professional_id"
+	ctxAssistantID    = "auth.assistant_id"
+	ctxAssistantRoles = "auth.assistant_roles"
+	ctxClientID       = "auth.client_id"
+)
+
+// headerProfessionalID indica para qué profesional trabaja el asistente en este request.
+// Si trabaja para uno solo se puede omitir.
+const headerProfessionalID = "X-Professional-ID"
+
 func CorsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// FIXME: es inseguro usar * en producción
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, Authorization")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, Authorization, "+headerProfessionalID)
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "OPTIONS, GET, POST, PATCH, DELETE, PUT")
 
 		if c.Request.Method == "OPTIONS" {
@@ -23,3 +45,313 @@ func CorsMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// AuthMiddleware exige "Authorization: Bearer <token de acceso>" y deja el ID del profesional
+// en el contexto. Los handlers lo leen con currentProfessionalID, nunca del request.
+// Con token de asistente, el profesional es el de X-Professional-ID y tiene que haberle dado
+// algún rol; qué rutas puede usar lo decide RequireRole.
+func AuthMiddleware(svc *service.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := bearerToken(c)
+		if !ok {
+			return
+		}
+
+		if profID, err := svc.Authenticate(token); err == nil {
+			c.Set(ctxProfessionalID, profID)
+			c.Next()
+			return
+		}
+
+		assistantID, err := svc.AuthenticateAssistant(token)
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer realm="psiconexo", error="invalid_token"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		profID, roles, ok := assistantEmployer(c, svc, assistantID)
+		if !ok {
+			return
+		}
+
+		c.Set(ctxProfessionalID, profID)
+		c.Set(ctxAssistantID, assistantID)
+		c.Set(ctxAssistantRoles, roles)
+		c.Request = c.Request.WithContext(service.WithAssistant(c.Request.Context(), assistantID))
+		c.Next()
+	}
+}
+
+// AssistantMiddleware es para las rutas propias del asistente (no actúa por nadie todavía).
+func AssistantMiddleware(svc *service.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := bearerToken(c)
+		if !ok {
+			return
+		}
+
+		assistantID, err := svc.AuthenticateAssistant(token)
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer realm="psiconexo", error="invalid_token"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Set(ctxAssistantID, assistantID)
+		c.Next()
+	}
+}
+
+// PortalMiddleware es para las rutas del paciente: exige su token y deja en el contexto la
+// ficha (ctxClientID) y el profesional dueño de esa ficha. Si lo dieron de baja, no entra.
+func PortalMiddleware(svc *service.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := bearerToken(c)
+		if !ok {
+			return
+		}
+
+		clientID, err := svc.AuthenticateClient(token)
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer realm="psiconexo", error="invalid_token"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		client, err := svc.GetPortalClient(c.Request.Context(), clientID)
+		if err != nil {
+			if errors.Is(err, service.ErrNotFound) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": service.ErrUnauthenticated.Error()})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Set(ctxClientID, client.ID)
+		c.Set(ctxProfessionalID, client.ProfessionalID)
+		c.Next()
+	}
+}
+
+// RequireRole deja pasar al profesional y a los asistentes con alguno de los roles.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if currentAssistantID(c) == 0 {
+			c.Next()
+			return
+		}
+		granted := c.GetStringSlice(ctxAssistantRoles)
+		for _, role := range roles {
+			if slices.Contains(granted, role) {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": service.ErrForbidden.Error()})
+	}
+}
+
+// OwnerOnly cierra la ruta a los asistentes, tengan el rol que tengan.
+func OwnerOnly() gin.HandlerFunc {
+	return RequireRole()
+}
+
+// auditedReads son las lecturas que quedan en el registro de auditoría: todo lo que muestra
+// contenido de la historia clínica.
+var auditedReads = []string{
+	"/api/v1/clinical-notes",
+	"/api/v1/attachments/:id/download",
+}
+
+// auditRouteActions corrige la acción de las rutas donde el método no la dice.
+var auditRouteActions = map[string]string{
+	"POST /api/v1/clients/:id/clinical-record": service.AuditRead, // Exportar la historia es leerla
+	"POST /api/v1/clinical-notes/:id/sign":     service.AuditUpdate,
+	"POST /api/v1/assistants/:id/invitation":   service.AuditUpdate,
+	"POST /api/v1/schedule":                    service.AuditUpdate,
+	"POST /api/v1/price-list/adjust":           service.AuditUpdate,
+	"POST /api/v1/me/2fa/enroll":               service.AuditUpdate,
+	"POST /api/v1/me/2fa/confirm":              service.AuditUpdate,
+	"POST /api/v1/me/2fa/recovery-codes":       service.AuditUpdate,
+	"POST /api/v1/me/verification-email":       service.AuditUpdate,
+
+	"POST /api/v1/portal/appointments/:id/cancel":     service.AuditUpdate,
+	"POST /api/v1/portal/appointments/:id/reschedule": service.AuditUpdate,
+}
+
+// AuditMiddleware deja en el registro de auditoría todos los cambios y las lecturas de la
+// historia clínica, con quién los hizo (va después de AuthMiddleware).
+// Las lecturas se registran antes de responder: si no se pueden registrar, no se sirven.
+// Los cambios, cuando salieron bien, con el ID del recurso (el de la ruta o el que se creó).
+func AuditMiddleware(svc *service.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		action := auditAction(c)
+		if action == "" {
+			c.Next()
+			return
+		}
+
+		if action == service.AuditRead {
+			if err := svc.RecordAudit(c.Request.Context(), auditEvent(c, action, 0)); err != nil {
+				log.Printf("auditoría: %v", err)
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "no se pudo registrar el acceso"})
+				return
+			}
+			c.Next()
+			return
+		}
+
+		// Sin ID en la ruta es un alta: el ID sale de la respuesta
+		var capture *bodyCaptureWriter
+		if c.Param("id") == "" {
+			capture = &bodyCaptureWriter{ResponseWriter: c.Writer}
+			c.Writer = capture
+		}
+
+		c.Next()
+
+		status := c.Writer.Status()
+		if status >= http.StatusBadRequest {
+			return
+		}
+		ev := auditEvent(c, action, status)
+		if capture != nil {
+			var created struct {
+				ID int64 `json:"id"`
+			}
+			if json.Unmarshal(capture.body.Bytes(), &created) == nil {
+				ev.ResourceID = created.ID
+			}
+		}
+		// El cambio ya se hizo: sólo queda dejar constancia del error
+		if err := svc.RecordAudit(c.Request.Context(), ev); err != nil {
+			log.Printf("auditoría: no se registró %s (profesional %d): %v", ev.Route, ev.ProfessionalID, err)
+		}
+	}
+}
+
+// auditAction dice qué acción registrar para el request, o "" si no se registra.
+func auditAction(c *gin.Context) string {
+	if action, ok := auditRouteActions[c.Request.Method+" "+c.FullPath()]; ok {
+		return action
+	}
+	switch c.Request.Method {
+	case http.MethodGet:
+		for _, prefix := range auditedReads {
+			if strings.HasPrefix(c.FullPath(), prefix) {
+				return service.AuditRead
+			}
+		}
+		return ""
+	case http.MethodPost:
+		return service.AuditCreate
+	case http.MethodPut, http.MethodPatch:
+		return service.AuditUpdate
+	case http.MethodDelete:
+		return service.AuditDelete
+	default:
+		return ""
+	}
+}
+
+// auditEvent arma el evento con el actor y el recurso de la ruta: el tipo es el primer tramo
+// después de /api/v1 (clients, appointments, clinical-notes...; en el portal, después de
+// /api/v1/portal) y el ID, el :id si lo hay.
+// La ruta se guarda sin query string, que puede traer texto buscado en las notas.
+func auditEvent(c *gin.Context, action string, status int) service.AuditEvent {
+	route := c.FullPath()
+	path := strings.TrimPrefix(route, "/api/v1/")
+	path = strings.TrimPrefix(path, "portal/")
+	resource, _, _ := strings.Cut(path, "/")
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	return service.AuditEvent{
+		ProfessionalID: currentProfessionalID(c),
+		AssistantID:    currentAssistantID(c),
+		ClientID:       currentClientID(c),
+		Action:         action,
+		ResourceType:   resource,
+		ResourceID:     id,
+		Route:          c.Request.Method + " " + route,
+		Status:         status,
+		IP:             c.ClientIP(),
+		UserAgent:      c.Request.UserAgent(),
+	}
+}
+
+// bodyCaptureWriter se queda con una copia de la respuesta para leer el ID de lo que se creó.
+type bodyCaptureWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyCaptureWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyCaptureWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// currentProfessionalID devuelve el profesional autenticado (sólo en rutas con AuthMiddleware).
+func currentProfessionalID(c *gin.Context) int64 {
+	return c.GetInt64(ctxProfessionalID)
+}
+
+// currentAssistantID devuelve el asistente que hace el request, o 0 si es el profesional.
+func currentAssistantID(c *gin.Context) int64 {
+	return c.GetInt64(ctxAssistantID)
+}
+
+// currentClientID devuelve la ficha del paciente en las rutas del portal, o 0 fuera de ellas.
+func currentClientID(c *gin.Context) int64 {
+	return c.GetInt64(ctxClientID)
+}
+
+func bearerToken(c *gin.Context) (string, bool) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		c.Header("WWW-Authenticate", `Bearer realm="psiconexo"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "falta el token de acceso"})
+		return "", false
+	}
+	return token, true
+}
+
+// assistantEmployer resuelve para qué profesional actúa el asistente y con qué roles.
+// Si no puede, responde el error y devuelve ok = false.
+func assistantEmployer(c *gin.Context, svc *service.Service, assistantID int64) (int64, []string, bool) {
+	header := c.GetHeader(headerProfessionalID)
+	if header == "" {
+		employers, err := svc.ListAssistantEmployers(c.Request.Context(), assistantID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return 0, nil, false
+		}
+		if len(employers) != 1 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "indique el profesional en el encabezado " + headerProfessionalID})
+			return 0, nil, false
+		}
+		return employers[0].ID, employers[0].Roles, true
+	}
+
+	profID, err := strconv.ParseInt(header, 10, 64)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": headerProfessionalID + " inválido"})
+		return 0, nil, false
+	}
+	roles, err := svc.AssistantRoles(c.Request.Context(), assistantID, profID)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return 0, nil, false
+	}
+	if len(roles) == 0 {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "no tiene acceso a ese profesional"})
+		return 0, nil, false
+	}
+	return profID, roles, true
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/luciluz/psiconexo/internal/auth"
+)
+
+// Prueba de integración (TEST_DB_SOURCE, ver TestCrossTenantAccessReturnsNotFound): cuando el
+// paciente reprograma desde el portal, el turno nuevo se lleva la cobertura y el pago del
+// original, y el original deja de contar como pagado.
+func TestPortalRescheduleCarriesCoverageAndPayment(t *testing.T) {
+	dbSource := os.Getenv("TEST_DB_SOURCE")
+	if dbSource == "" {
+		t.Skip("TEST_DB_SOURCE no está definido; se omite la prueba de reprogramación desde el portal")
+	}
+
+	router := newTestRouter(t, dbSource)
+	conn, err := sql.Open("postgres", dbSource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+	token := registerProfessional(t, router, "reschedule-"+suffix+"@example.com")
+
+	// Lunes de 9 a 18: el 2030-01-07 y el 2030-01-14 son lunes
+	if status, body := doRequest(t, router, token, http.MethodPost, "/api/v1/schedule", map[string]any{
+		"blocks": []map[string]any{{"day_of_week": 1, "start_time": "09:00", "end_time": "18:00"}},
+	}); status >= http.StatusBadRequest {
+		t.Fatalf("POST /api/v1/schedule: %d %s", status, body)
+	}
+
+	clientID := mustCreate(t, router, token, "/api/v1/clients", map[string]any{"name": "Paciente que reprograma"})
+	insurerID := mustCreate(t, router, token, "/api/v1/insurers", map[string]any{"name": "OSDE " + suffix, "session_fee": 800})
+	coverageID := mustCreate(t, router, token, fmt.Sprintf("/api/v1/clients/%d/coverages", clientID), map[string]any{
+		"insurer_id": insurerID, "member_number": "123", "copay_amount": 200,
+	})
+	apptID := mustCreate(t, router, token, "/api/v1/appointments", map[string]any{
+		"client_id": clientID, "date": "2030-01-07", "start_time": "10:00", "duration": 50, "price": 1000,
+	})
+
+	path := fmt.Sprintf("/api/v1/appointments/%d/coverage", apptID)
+	if status, body := doRequest(t, router, token, http.MethodPut, path, map[string]any{
+		"coverage_id": coverageID, "authorization_number": "A-1",
+	}); status != http.StatusOK {
+		t.Fatalf("PUT %s: %d %s", path, status, body)
+	}
+	paymentID := mustCreate(t, router, token, "/api/v1/payments", map[string]any{
+		"client_id": clientID, "appointment_id": apptID, "amount": 200, "method": "cash",
+	})
+
+	tokens, err := auth.NewTokens(bytes.Repeat([]byte("s"), 32), time.Hour, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	portalToken, _, err := tokens.IssueClientAccess(clientID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path = fmt.Sprintf("/api/v1/portal/appointments/%d/reschedule", apptID)
+	status, body := doRequest(t, router, portalToken, http.MethodPost, path, map[string]any{
+		"date": "2030-01-14", "start_time": "11:00",
+	})
+	if status != http.StatusCreated {
+		t.Fatalf("POST %s: %d %s", path, status, body)
+	}
+	var moved struct {
+		ID            int64  `json:"id"`
+		PaymentStatus string `json:"payment_status"`
+	}
+	if err := json.Unmarshal(body, &moved); err != nil {
+		t.Fatal(err)
+	}
+	if moved.ID == 0 || moved.ID == apptID {
+		t.Fatalf("se esperaba un turno nuevo y volvió %s", body)
+	}
+	if moved.PaymentStatus != "paid" {
+		t.Fatalf("el turno nuevo debería figurar pagado y volvió %q", moved.PaymentStatus)
+	}
+
+	var coverage, authorization string
+	var insurerAmount, copayAmount float64
+	if err := conn.QueryRow(`SELECT coverage_id::TEXT, authorization_number, insurer_amount, copay_amount
+		FROM appointment_coverages WHERE appointment_id = $1`, moved.ID).Scan(&coverage, &authorization, &insurerAmount, &copayAmount); err != nil {
+		t.Fatalf("el turno nuevo no tiene cobertura: %v", err)
+	}
+	if coverage != strconv.FormatInt(coverageID, 10) || authorization != "A-1" || insurerAmount != 800 || copayAmount != 200 {
+		t.Fatalf("cobertura copiada mal: %s %s %.2f %.2f", coverage, authorization, insurerAmount, copayAmount)
+	}
+
+	var oldStatus string
+	if err := conn.QueryRow(`SELECT payment_status FROM appointments WHERE id = $1`, apptID).Scan(&oldStatus); err != nil {
+		t.Fatal(err)
+	}
+	if oldStatus != "pending" {
+		t.Fatalf("el turno reprogramado debería quedar pendiente y quedó %q", oldStatus)
+	}
+
+	var paymentAppt int64
+	if err := conn.QueryRow(`SELECT appointment_id FROM client_payments WHERE id = $1`, paymentID).Scan(&paymentAppt); err != nil {
+		t.Fatal(err)
+	}
+	if paymentAppt != moved.ID {
+		t.Fatalf("el pago debería apuntar al turno %d y apunta al %d", moved.ID, paymentAppt)
+	}
+
+	// Los turnos son futuros: sin cargos todavía, sólo el pago del coseguro, contado una vez
+	if got := statementBalance(t, router, token, clientID); got != -200 {
+		t.Fatalf("saldo después de reprogramar: se esperaba -200 y quedó %.2f", got)
+	}
+}
@@ -1,40 +1,220 @@
 package api
 
-import "github.com/gin-gonic/gin"
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/luciluz/psiconexo/internal/service"
+)
 
 func NewRouter(h *Handler) *gin.Engine {
 	r := gin.Default()
+	// Sin proxies de confianza ClientIP es la IP de la conexión: si no, cualquiera inventa su
+	// IP con X-Forwarded-For (y esquiva el límite de reservas o ensucia la auditoría).
+	// Detrás de un proxy, main configura los suyos con TRUSTED_PROXIES.
+	_ = r.SetTrustedProxies(nil)
 	r.Use(CorsMiddleware())
 
-	v1 := r.Group("/api/v1")
+	// Autenticación (públicas)
+	public := r.Group("/api/v1/auth")
 	{
-		// Usuarios (Profesionales y Clientes)
-		v1.POST("/professionals", h.CreateProfessional)
-		v1.GET("/professionals", h.ListProfessionals)
+		public.POST("/register", h.Register)
+		public.POST("/login", h.Login)                        // Con 2FA devuelve el paso que falta
+		public.POST("/login/2fa", h.CompleteTwoFactorLogin)   // challenge_token + código
+		public.POST("/2fa/enroll", h.BeginRequiredEnrollment) // Alta obligatoria por la organización
+		public.POST("/2fa/enroll/confirm", h.CompleteRequiredEnrollment)
+		public.POST("/refresh", h.RefreshSession) // Rota el refresh token
+		public.POST("/logout", h.Logout)
+		public.POST("/verify-email", h.VerifyEmail)
+		public.POST("/password-reset", h.RequestPasswordReset)         // Manda el enlace por email
+		public.POST("/password-reset/confirm", h.ConfirmPasswordReset) // Enlace de un solo uso
+	}
 
-		v1.POST("/clients", h.CreateClient)
-		v1.GET("/clients", h.ListClients)
+	// Asistentes (secretarias/os): su propia sesión, separada de la de los profesionales
+	assistantAuth := r.Group("/api/v1/auth/assistant")
+	{
+		assistantAuth.POST("/accept-invitation", h.AcceptAssistantInvitation) // Elige contraseña y entra
+		assistantAuth.POST("/login", h.AssistantLogin)
+		assistantAuth.POST("/refresh", h.RefreshAssistantSession)
+		assistantAuth.POST("/logout", h.AssistantLogout)
+	}
 
-		// Agenda (Eventual y Materializada)
-		v1.POST("/appointments", h.CreateAppointment)
-		v1.GET("/appointments", h.ListAppointments)
+	assistant := r.Group("/api/v1/assistant")
+	assistant.Use(AssistantMiddleware(h.svc))
+	{
+		assistant.GET("/me", h.GetAssistantMe) // Profesionales para los que trabaja y con qué roles
+	}
 
-		// Reglas de Recurrencia (Contratos fijos)
-		v1.POST("/recurring-rules", h.CreateRecurringRule)
-		v1.GET("/recurring-rules", h.ListRecurringRules)
+	// Página pública del profesional (sin cuenta): perfil, horarios libres y pedido de turno
+	publicPage := r.Group("/api/v1/public")
+	{
+		publicPage.GET("/:slug", h.GetPublicProfile)
+		publicPage.GET("/:slug/slots", h.ListPublicSlots)          // ?from=&to= (hasta 31 días)
+		publicPage.POST("/:slug/bookings", h.RequestPublicBooking) // Con captcha si está configurado
+	}
 
-		// Bloques de trabajo (Disponibilidad / Configuración)
-		v1.POST("/schedule", h.UpdateSchedule)
-		v1.GET("/schedule", h.ListSchedule)
+	// Fotos de perfil: públicas, cada una con su propia URL
+	r.GET("/api/v1/profile-photos/:id/:file", h.GetProfilePhoto)
+
+	// Portal del paciente: entra con un enlace por email y sólo ve su propia ficha
+	portalAuth := r.Group("/api/v1/portal/auth")
+	{
+		portalAuth.POST("/magic-link", h.RequestPortalLink) // Manda el enlace por email
+		portalAuth.POST("/verify", h.VerifyPortalLink)      // Enlace de un solo uso → sesión
+	}
+
+	portal := r.Group("/api/v1/portal")
+	portal.Use(PortalMiddleware(h.svc), AuditMiddleware(h.svc))
+	{
+		portal.GET("/me", h.GetPortalMe)
+		portal.GET("/appointments", h.ListPortalAppointments) // Próximos y pasados
+		portal.POST("/appointments/:id/cancel", h.CancelPortalAppointment)
+		portal.POST("/appointments/:id/reschedule", h.ReschedulePortalAppointment) // Dentro de la política de cancelación
+		portal.POST("/appointments/:id/payment-proof", h.UploadPaymentProof)       // multipart: file
+		portal.GET("/appointments/:id/invoice", h.GetPortalInvoice)                // Redirige a la factura
+		portal.GET("/forms", h.ListPortalForms)
+		portal.PUT("/forms/:id", h.SubmitPortalForm) // Una sola vez
+	}
+
+	// Todo lo demás exige sesión: el profesional sale del token, nunca del request.
+	// Cuenta, configuración e historia clínica son sólo del profesional.
+	// Los cambios y las lecturas de la historia clínica quedan en el registro de auditoría
+	v1 := r.Group("/api/v1")
+	v1.Use(AuthMiddleware(h.svc), OwnerOnly(), AuditMiddleware(h.svc))
+	{
+		v1.GET("/me", h.GetMe)
+		v1.POST("/me/verification-email", h.ResendEmailVerification)
+
+		// Perfil público (página de reservas)
+		v1.GET("/me/profile", h.GetProfile)
+		v1.PUT("/me/profile", h.UpdateProfile)
+		v1.GET("/me/profile/slug-availability", h.CheckSlugAvailability) // ?slug=...
+		v1.POST("/me/profile/photo", h.UploadProfilePhoto)               // multipart: file
+		v1.DELETE("/me/profile/photo", h.DeleteProfilePhoto)
+
+		// Verificación en dos pasos (TOTP)
+		v1.GET("/me/2fa", h.GetTwoFactorStatus)
+		v1.POST("/me/2fa/enroll", h.BeginTwoFactorEnrollment) // Secreto + URI otpauth:// para el QR
+		v1.POST("/me/2fa/confirm", h.ConfirmTwoFactorEnrollment)
+		v1.POST("/me/2fa/recovery-codes", h.RegenerateRecoveryCodes)
+		v1.DELETE("/me/2fa", h.DisableTwoFactor)
+
+		// Asistentes del consultorio y sus roles
+		v1.POST("/assistants", h.AddAssistant) // Si no tiene cuenta, se lo invita por email
+		v1.GET("/assistants", h.ListAssistants)
+		v1.PUT("/assistants/:id/roles", h.UpdateAssistantRoles)
+		v1.DELETE("/assistants/:id", h.RemoveAssistant)
+		v1.POST("/assistants/:id/invitation", h.ResendAssistantInvitation)
 
 		// Configuración Avanzada (Settings)
 		v1.PUT("/settings", h.UpdateSettings)
 		v1.GET("/settings", h.GetSettings)
 
+		// Historia clínica del paciente
+		v1.POST("/clients/:id/clinical-record", h.ExportClinicalRecord) // PDF de la historia clínica (queda auditado)
+		v1.GET("/clients/:id/clinical-record/exports", h.ListClinicalRecordExports)
+		v1.POST("/clients/:id/forms", h.CreateClientForm) // Se completa desde el portal
+		v1.GET("/clients/:id/forms", h.ListClientForms)
+
 		// Notas Clínicas (Historia Clínica)
 		v1.POST("/clinical-notes", h.CreateClinicalNote)
 		v1.GET("/clinical-notes", h.ListClinicalNotes)
-		v1.PUT("/clinical-notes/:id", h.UpdateClinicalNote) // Nota el :id en la URL
+		v1.GET("/clinical-notes/pending", h.ListPendingDocumentation) // Borradores atrasados y sesiones sin nota
+		v1.GET("/clinical-notes/search", h.SearchClinicalNotes)       // ?q=... sobre el índice ciego
+		v1.PUT("/clinical-notes/:id", h.UpdateClinicalNote)           // Nota el :id en la URL
+		v1.DELETE("/clinical-notes/:id", h.DeleteClinicalNote)        // Sólo personales: las clínicas se conservan
+		v1.POST("/clinical-notes/:id/sign", h.SignClinicalNote)       // Firmada = inmutable
+		v1.POST("/clinical-notes/:id/addenda", h.CreateAddendum)
+		v1.GET("/clinical-notes/:id/addenda", h.ListAddenda)
+		v1.GET("/clinical-notes/:id/revisions", h.ListNoteRevisions)
+		v1.GET("/clinical-notes/:id/revisions/:rev", h.GetNoteRevision)
+		v1.GET("/clinical-notes/:id/diff", h.DiffNoteRevisions) // ?from=1&to=2 (to=0: versión vigente)
+
+		// Adjuntos (de la ficha del paciente o de una nota; cifrados en el almacenamiento)
+		v1.POST("/attachments", h.UploadAttachment) // multipart: file + client_id o note_id
+		v1.GET("/attachments", h.ListAttachments)
+		v1.GET("/attachments/:id/download", h.DownloadAttachment)
+		v1.DELETE("/attachments/:id", h.DeleteAttachment)
+
+		// Plantillas de notas estructuradas (SOAP, admisión, alta, riesgo)
+		v1.POST("/note-templates", h.CreateNoteTemplate)
+		v1.GET("/note-templates", h.ListNoteTemplates)
+		v1.GET("/note-templates/presets", h.ListNoteTemplatePresets)
+		v1.PUT("/note-templates/:id", h.UpdateNoteTemplate)
+
+		// Organización (centro con varios profesionales). Comparte identidad, configuración de
+		// agenda y reportes; la historia clínica sigue siendo de cada profesional
+		v1.POST("/organization", h.CreateOrganization) // Quien la crea queda como administrador
+		v1.GET("/organization", h.GetOrganization)
+		v1.PUT("/organization", h.UpdateOrganization)                               // Sólo administradores
+		v1.POST("/organization/members", h.InviteOrganizationMember)                // Por email: entra cuando acepta
+		v1.POST("/organization/invitations/accept", h.AcceptOrganizationInvitation) // Con la sesión del invitado
+		v1.PUT("/organization/members/:id/role", h.UpdateOrganizationMemberRole)
+		v1.DELETE("/organization/members/:id", h.RemoveOrganizationMember)          // Un administrador, o el propio profesional
+		v1.GET("/organization/calendar", h.GetOrganizationCalendar)                 // Pacientes: sólo los propios, salvo administradores
+		v1.GET("/organization/finances/summary", h.GetOrganizationFinancialSummary) // Sólo administradores
+
+		// Registro de auditoría (quién leyó o cambió qué)
+		v1.GET("/audit-log", h.ListAuditLog)          // Filtros: desde/hasta, acción, recurso, asistente
+		v1.GET("/audit-log/verify", h.VerifyAuditLog) // Recalcula la cadena de hashes
+	}
+
+	// Agenda y finanzas: el profesional y, según sus roles, sus asistentes
+	anyRole := RequireRole(service.RoleAgenda, service.RoleFinances, service.RoleReadOnly)
+	agendaRead := RequireRole(service.RoleAgenda, service.RoleReadOnly)
+	agendaWrite := RequireRole(service.RoleAgenda)
+	financesRead := RequireRole(service.RoleFinances, service.RoleReadOnly)
+	financesWrite := RequireRole(service.RoleFinances)
+
+	shared := r.Group("/api/v1")
+	shared.Use(AuthMiddleware(h.svc), AuditMiddleware(h.svc))
+	{
+		// Pacientes
+		shared.POST("/clients", agendaWrite, h.CreateClient)
+		shared.GET("/clients", anyRole, h.ListClients)
+		shared.GET("/clients/balances", financesRead, h.ListClientBalances)
+		shared.GET("/clients/:id/statement", financesRead, h.GetClientStatement) // ?format=pdf para el imprimible
+		shared.POST("/clients/:id/coverages", financesWrite, h.CreateClientCoverage)
+		shared.GET("/clients/:id/coverages", financesRead, h.ListClientCoverages)
+
+		// Agenda (Eventual y Materializada)
+		shared.POST("/appointments", agendaWrite, h.CreateAppointment)
+		shared.GET("/appointments", agendaRead, h.ListAppointments)
+		shared.PATCH("/appointments/:id/status", agendaWrite, h.UpdateAppointmentStatus) // Completar consume sesión de paquete
+		shared.PUT("/appointments/:id/coverage", financesWrite, h.ApplyAppointmentCoverage)
+		shared.GET("/appointments/:id/payment-proof", financesRead, h.DownloadPaymentProof) // El que subió el paciente
+
+		// Reglas de Recurrencia (Contratos fijos)
+		shared.POST("/recurring-rules", agendaWrite, h.CreateRecurringRule)
+		shared.GET("/recurring-rules", agendaRead, h.ListRecurringRules)
+
+		// Bloques de trabajo (Disponibilidad / Configuración)
+		shared.POST("/schedule", agendaWrite, h.UpdateSchedule)
+		shared.GET("/schedule", agendaRead, h.ListSchedule)
+
+		// Finanzas (Tabla con filtros y KPIs por período)
+		shared.GET("/finances", financesRead, h.ListFinances)
+		shared.GET("/finances/summary", financesRead, h.GetFinancialSummary)
+		shared.GET("/finances/export", financesRead, h.ExportFinances)
+
+		// Cuenta corriente (Pagos, Reintegros y Cargos extra)
+		shared.POST("/payments", financesWrite, h.RegisterPayment)
+		shared.GET("/payments", financesRead, h.ListPayments)
+		shared.POST("/fees", financesWrite, h.CreateFee)
+
+		// Paquetes de sesiones prepagas
+		shared.POST("/packages", financesWrite, h.CreatePackage)
+		shared.GET("/packages", financesRead, h.ListPackages)
+		shared.PATCH("/packages/:id/payment", financesWrite, h.UpdatePackagePayment)
+
+		// Obras sociales / Prepagas
+		shared.POST("/insurers", financesWrite, h.CreateInsurer)
+		shared.GET("/insurers", financesRead, h.ListInsurers)
+		shared.PUT("/insurers/:id", financesWrite, h.UpdateInsurer)
+		shared.GET("/insurers/:id/presentation", financesRead, h.GetInsurerPresentation) // ?month=YYYY-MM
+
+		// Lista de precios (con vigencia y ajuste por índice)
+		shared.POST("/price-list", financesWrite, h.CreatePrice)
+		shared.GET("/price-list", financesRead, h.ListPriceList) // ?date=YYYY-MM-DD para la lista vigente
+		shared.POST("/price-list/adjust", financesWrite, h.AdjustPrices)
 	}
 
 	return r
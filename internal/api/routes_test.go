@@ -0,0 +1,48 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luciluz/psiconexo/internal/service"
+)
+
+// Sin proxies configurados, X-Forwarded-For no cambia la IP del cliente: si no, cualquiera
+// esquivaría el límite de reservas por IP o dejaría otra IP en la auditoría.
+func TestClientIPIgnoresForwardedForByDefault(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		name    string
+		proxies []string
+		want    string
+	}{
+		{"sin proxies de confianza", nil, "10.0.0.5"},
+		{"desde un proxy de confianza", []string{"10.0.0.0/8"}, "203.0.113.7"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewRouter(NewHandler(service.NewService(nil, nil)))
+			if tc.proxies != nil {
+				if err := r.SetTrustedProxies(tc.proxies); err != nil {
+					t.Fatal(err)
+				}
+			}
+			r.GET("/ip", func(c *gin.Context) {
+				c.String(http.StatusOK, c.ClientIP())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+			req.RemoteAddr = "10.0.0.5:41000"
+			req.Header.Set("X-Forwarded-For", "203.0.113.7")
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			if got := rec.Body.String(); got != tc.want {
+				t.Fatalf("se esperaba la IP %s y dio %s", tc.want, got)
+			}
+		})
+	}
+}
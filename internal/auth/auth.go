@@ -0,0 +1,323 @@
+// Package auth implementa las credenciales de los profesionales, sus asistentes y los pacientes
+// del portal: contraseñas con bcrypt, tokens de acceso JWT (HS256) de vida corta, tokens
+// firmados para verificar el email, invitar asistentes y completar el segundo paso del login,
+// tokens opacos (renovación de sesión, recuperación de contraseña, enlaces de ingreso al
+// portal) y códigos TOTP para la verificación en dos pasos.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	DefaultAccessTTL  = 15 * time.Minute
+	DefaultRefreshTTL = 30 * 24 * time.Hour
+
+	EmailVerificationTTL = 48 * time.Hour
+
+	// Entre la contraseña y el código: alcanza para abrir la app autenticadora, o para
+	// escanear el QR si la organización exige dar de alta la 2FA.
+	TwoFactorChallengeTTL  = 5 * time.Minute
+	TwoFactorEnrollmentTTL = 15 * time.Minute
+
+	AssistantInvitationTTL = 7 * 24 * time.Hour
+
+	// El paciente entra con un enlace por email y no tiene token de renovación: cuando vence
+	// la sesión pide otro enlace.
+	PortalSessionTTL = 12 * time.Hour
+
+	minSecretSize = 32
+	bcryptCost    = 12
+)
+
+var (
+	ErrNoSecret      = errors.New("no hay clave de firma de sesiones configurada (JWT_SECRET)")
+	ErrWeakSecret    = errors.New("JWT_SECRET debe tener al menos 32 bytes")
+	ErrInvalidToken  = errors.New("token inválido")
+	ErrExpiredToken  = errors.New("token vencido")
+	ErrWrongPassword = errors.New("contraseña incorrecta")
+)
+
+// HashPassword devuelve el hash bcrypt de la contraseña (incluye sal y costo).
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword compara en tiempo constante; devuelve ErrWrongPassword si no coincide.
+func CheckPassword(hash, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrWrongPassword
+	}
+	return err
+}
+
+// Tipos de token firmado: uno no sirve en lugar del otro.
+const (
+	tokenTypeAccess            = "access"
+	tokenTypeEmailVerification = "email_verification"
+	tokenTypeTwoFactor         = "two_factor"
+	tokenTypeTwoFactorEnroll   = "two_factor_enrollment"
+
+	tokenTypeAssistantAccess     = "assistant_access"
+	tokenTypeAssistantInvitation = "assistant_invitation"
+
+	tokenTypeClientAccess = "client_access"
+)
+
+// assistantTokenTypes son los tokens cuyo sujeto es un asistente y no un profesional.
+var assistantTokenTypes = map[string]bool{
+	tokenTypeAssistantAccess:     true,
+	tokenTypeAssistantInvitation: true,
+}
+
+// clientTokenTypes son los tokens cuyo sujeto es la ficha de un paciente (clients.id).
+var clientTokenTypes = map[string]bool{
+	tokenTypeClientAccess: true,
+}
+
+// Claims son los datos de un token firmado. Según el tipo de token viene ProfessionalID,
+// AssistantID o ClientID; Email sólo viene en los de verificación e invitación.
+type Claims struct {
+	ProfessionalID int64
+	AssistantID    int64
+	ClientID       int64
+	Email          string
+	IssuedAt       time.Time
+	ExpiresAt      time.Time
+}
+
+type jwtClaims struct {
+	Subject   string `json:"sub"`
+	Type      string `json:"typ"`
+	Email     string `json:"email,omitempty"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// El encabezado es siempre el mismo: al verificar se exige igual, así nadie elige el algoritmo.
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Tokens firma y verifica los tokens de sesión.
+type Tokens struct {
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	now        func() time.Time
+}
+
+func NewTokens(secret []byte, accessTTL, refreshTTL time.Duration) (*Tokens, error) {
+	if len(secret) < minSecretSize {
+		return nil, ErrWeakSecret
+	}
+	if accessTTL <= 0 {
+		accessTTL = DefaultAccessTTL
+	}
+	if refreshTTL <= 0 {
+		refreshTTL = DefaultRefreshTTL
+	}
+	return &Tokens{secret: secret, accessTTL: accessTTL, refreshTTL: refreshTTL, now: time.Now}, nil
+}
+
+// FromEnv carga JWT_SECRET y, opcionalmente, ACCESS_TOKEN_TTL y REFRESH_TOKEN_TTL (ej: 15m, 720h).
+func FromEnv() (*Tokens, error) {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		return nil, ErrNoSecret
+	}
+	accessTTL, err := envDuration("ACCESS_TOKEN_TTL")
+	if err != nil {
+		return nil, err
+	}
+	refreshTTL, err := envDuration("REFRESH_TOKEN_TTL")
+	if err != nil {
+		return nil, err
+	}
+	return NewTokens([]byte(secret), accessTTL, refreshTTL)
+}
+
+func envDuration(name string) (time.Duration, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s inválido: %w", name, err)
+	}
+	return d, nil
+}
+
+func (t *Tokens) RefreshTTL() time.Duration {
+	return t.refreshTTL
+}
+
+// IssueAccess genera un token de acceso para el profesional.
+func (t *Tokens) IssueAccess(profID int64) (string, time.Time, error) {
+	return t.issue(jwtClaims{Subject: strconv.FormatInt(profID, 10), Type: tokenTypeAccess}, t.accessTTL)
+}
+
+// VerifyAccess valida firma, tipo y vencimiento del token.
+func (t *Tokens) VerifyAccess(token string) (*Claims, error) {
+	return t.verify(token, tokenTypeAccess)
+}
+
+// IssueEmailVerification firma el enlace de verificación. Lleva el email: si el profesional
+// lo cambia, los enlaces enviados a la dirección anterior dejan de servir.
+func (t *Tokens) IssueEmailVerification(profID int64, email string) (string, time.Time, error) {
+	return t.issue(jwtClaims{
+		Subject: strconv.FormatInt(profID, 10),
+		Type:    tokenTypeEmailVerification,
+		Email:   email,
+	}, EmailVerificationTTL)
+}
+
+func (t *Tokens) VerifyEmailVerification(token string) (*Claims, error) {
+	return t.verify(token, tokenTypeEmailVerification)
+}
+
+// IssueTwoFactorChallenge firma el paso intermedio del login: la contraseña ya se validó y
+// falta el código de la app. No sirve como token de acceso.
+func (t *Tokens) IssueTwoFactorChallenge(profID int64) (string, time.Time, error) {
+	return t.issue(jwtClaims{Subject: strconv.FormatInt(profID, 10), Type: tokenTypeTwoFactor}, TwoFactorChallengeTTL)
+}
+
+func (t *Tokens) VerifyTwoFactorChallenge(token string) (*Claims, error) {
+	return t.verify(token, tokenTypeTwoFactor)
+}
+
+// IssueTwoFactorEnrollment firma el paso intermedio cuando la organización exige 2FA y el
+// profesional todavía no la configuró: sólo sirve para darla de alta.
+func (t *Tokens) IssueTwoFactorEnrollment(profID int64) (string, time.Time, error) {
+	return t.issue(jwtClaims{Subject: strconv.FormatInt(profID, 10), Type: tokenTypeTwoFactorEnroll}, TwoFactorEnrollmentTTL)
+}
+
+func (t *Tokens) VerifyTwoFactorEnrollment(token string) (*Claims, error) {
+	return t.verify(token, tokenTypeTwoFactorEnroll)
+}
+
+// IssueAssistantAccess genera un token de acceso para un asistente. No sirve como token de
+// profesional: el asistente actúa siempre por cuenta de alguno y según sus permisos.
+func (t *Tokens) IssueAssistantAccess(assistantID int64) (string, time.Time, error) {
+	return t.issue(jwtClaims{Subject: strconv.FormatInt(assistantID, 10), Type: tokenTypeAssistantAccess}, t.accessTTL)
+}
+
+func (t *Tokens) VerifyAssistantAccess(token string) (*Claims, error) {
+	return t.verify(token, tokenTypeAssistantAccess)
+}
+
+// IssueAssistantInvitation firma el enlace con el que el asistente elige su contraseña.
+func (t *Tokens) IssueAssistantInvitation(assistantID int64, email string) (string, time.Time, error) {
+	return t.issue(jwtClaims{
+		Subject: strconv.FormatInt(assistantID, 10),
+		Type:    tokenTypeAssistantInvitation,
+		Email:   email,
+	}, AssistantInvitationTTL)
+}
+
+func (t *Tokens) VerifyAssistantInvitation(token string) (*Claims, error) {
+	return t.verify(token, tokenTypeAssistantInvitation)
+}
+
+// IssueClientAccess genera la sesión del paciente en el portal, limitada a su ficha.
+func (t *Tokens) IssueClientAccess(clientID int64) (string, time.Time, error) {
+	return t.issue(jwtClaims{Subject: strconv.FormatInt(clientID, 10), Type: tokenTypeClientAccess}, PortalSessionTTL)
+}
+
+func (t *Tokens) VerifyClientAccess(token string) (*Claims, error) {
+	return t.verify(token, tokenTypeClientAccess)
+}
+
+func (t *Tokens) issue(c jwtClaims, ttl time.Duration) (string, time.Time, error) {
+	now := t.now()
+	expires := now.Add(ttl)
+	c.IssuedAt = now.Unix()
+	c.ExpiresAt = expires.Unix()
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	signingInput := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signingInput + "." + t.sign(signingInput), expires, nil
+}
+
+func (t *Tokens) verify(token, typ string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return nil, ErrInvalidToken
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(t.sign(parts[0]+"."+parts[1]))) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var c jwtClaims
+	if err := json.Unmarshal(payload, &c); err != nil || c.Type != typ {
+		return nil, ErrInvalidToken
+	}
+	subjectID, err := strconv.ParseInt(c.Subject, 10, 64)
+	if err != nil || subjectID <= 0 {
+		return nil, ErrInvalidToken
+	}
+	if !t.now().Before(time.Unix(c.ExpiresAt, 0)) {
+		return nil, ErrExpiredToken
+	}
+
+	claims := &Claims{
+		Email:     c.Email,
+		IssuedAt:  time.Unix(c.IssuedAt, 0),
+		ExpiresAt: time.Unix(c.ExpiresAt, 0),
+	}
+	switch {
+	case assistantTokenTypes[typ]:
+		claims.AssistantID = subjectID
+	case clientTokenTypes[typ]:
+		claims.ClientID = subjectID
+	default:
+		claims.ProfessionalID = subjectID
+	}
+	return claims, nil
+}
+
+func (t *Tokens) sign(signingInput string) string {
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(signingInput))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// NewOpaqueToken genera un token aleatorio (renovación de sesión, recuperación de contraseña).
+// En la base sólo se guarda su hash.
+func NewOpaqueToken() (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, HashOpaqueToken(token), nil
+}
+
+// HashOpaqueToken alcanza con SHA-256: el token ya tiene 256 bits aleatorios.
+func HashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
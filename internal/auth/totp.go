@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Parámetros de TOTP (RFC 6238) que entienden todas las apps autenticadoras.
+const (
+	totpPeriod     = 30 // segundos
+	totpDigits     = 6
+	totpSkew       = 1  // Intervalos de tolerancia hacia cada lado por relojes desfasados
+	totpSecretSize = 20 // 160 bits, lo que recomienda la RFC 4226 para HMAC-SHA1
+
+	recoveryCodeSize = 10 // 80 bits
+)
+
+var base32NoPad = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewTOTPSecret genera el secreto compartido con la app autenticadora, en base32.
+func NewTOTPSecret() (string, error) {
+	b := make([]byte, totpSecretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base32NoPad.EncodeToString(b), nil
+}
+
+// TOTPProvisioningURI arma el enlace otpauth:// que se muestra como QR para dar de alta la cuenta.
+func TOTPProvisioningURI(issuer, account, secret string) string {
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(totpDigits))
+	q.Set("period", fmt.Sprint(totpPeriod))
+	return "otpauth://totp/" + url.PathEscape(issuer+":"+account) + "?" + q.Encode()
+}
+
+// IsTOTPCode indica si el texto tiene forma de código de la app (6 dígitos) y no de código
+// de recuperación.
+func IsTOTPCode(code string) bool {
+	if len(code) != totpDigits {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateTOTP busca el código en el intervalo actual y los vecinos. Sólo acepta intervalos
+// posteriores a lastStep, así un código ya usado no sirve de nuevo; devuelve el intervalo
+// aceptado para guardarlo como el nuevo lastStep.
+func ValidateTOTP(secret, code string, now time.Time, lastStep int64) (int64, bool) {
+	key, err := base32NoPad.DecodeString(strings.ToUpper(secret))
+	if err != nil || !IsTOTPCode(code) {
+		return 0, false
+	}
+	current := now.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= lastStep {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// totpCode es el HOTP (RFC 4226) del número de intervalo.
+func totpCode(key []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1_000_000)
+}
+
+// NewRecoveryCode genera un código de recuperación de un solo uso, en grupos de cuatro
+// caracteres para que se pueda copiar a mano (ej: "k3pq-x2mz-7fha-bc4d").
+func NewRecoveryCode() (code, hash string, err error) {
+	b := make([]byte, recoveryCodeSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	raw := strings.ToLower(base32NoPad.EncodeToString(b))
+	groups := make([]string, 0, len(raw)/4)
+	for i := 0; i < len(raw); i += 4 {
+		groups = append(groups, raw[i:i+4])
+	}
+	code = strings.Join(groups, "-")
+	return code, HashRecoveryCode(code), nil
+}
+
+// HashRecoveryCode ignora mayúsculas, guiones y espacios: el código se tipea a mano.
+func HashRecoveryCode(code string) string {
+	normalized := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(code))
+	return HashOpaqueToken(normalized)
+}
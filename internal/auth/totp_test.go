@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// Secreto de los vectores de la RFC 6238 ("12345678901234567890" en ASCII), en base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCodeMatchesRFC6238(t *testing.T) {
+	key, err := base32NoPad.DecodeString(rfcSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Los vectores de la RFC son de 8 dígitos: con 6 quedan los últimos seis
+	cases := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tc := range cases {
+		if got := totpCode(key, tc.unix/totpPeriod); got != tc.want {
+			t.Fatalf("T=%d: se esperaba %s y dio %s", tc.unix, tc.want, got)
+		}
+	}
+}
+
+func TestValidateTOTPWindow(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	key, err := base32NoPad.DecodeString(rfcSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current := now.Unix() / totpPeriod
+
+	cases := []struct {
+		name   string
+		offset int64 // intervalos respecto de now
+		ok     bool
+	}{
+		{"intervalo actual", 0, true},
+		{"intervalo anterior", -1, true},
+		{"intervalo siguiente", 1, true},
+		{"dos intervalos antes", -2, false},
+		{"dos intervalos después", 2, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			code := totpCode(key, current+tc.offset)
+			step, ok := ValidateTOTP(rfcSecret, code, now, 0)
+			if ok != tc.ok {
+				t.Fatalf("se esperaba ok=%v y dio %v", tc.ok, ok)
+			}
+			if ok && step != current+tc.offset {
+				t.Fatalf("se esperaba el intervalo %d y devolvió %d", current+tc.offset, step)
+			}
+		})
+	}
+}
+
+func TestValidateTOTPRejectsReplay(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code := "005924"
+
+	step, ok := ValidateTOTP(rfcSecret, code, now, 0)
+	if !ok {
+		t.Fatal("el código vigente no fue aceptado")
+	}
+
+	cases := []struct {
+		name     string
+		lastStep int64
+		ok       bool
+	}{
+		{"mismo código otra vez", step, false},
+		{"ya se usó uno posterior", step + 1, false},
+		{"el último fue el anterior", step - 1, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, ok := ValidateTOTP(rfcSecret, code, now, tc.lastStep); ok != tc.ok {
+				t.Fatalf("lastStep=%d: se esperaba ok=%v y dio %v", tc.lastStep, tc.ok, ok)
+			}
+		})
+	}
+}
+
+func TestValidateTOTPRejectsInvalidInput(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+
+	cases := []struct {
+		name   string
+		secret string
+		code   string
+		ok     bool
+	}{
+		{"secreto en minúsculas", strings.ToLower(rfcSecret), "005924", true},
+		{"código equivocado", rfcSecret, "005925", false},
+		{"código corto", rfcSecret, "05924", false},
+		{"código con letras", rfcSecret, "00592a", false},
+		{"código de 8 dígitos", rfcSecret, "89005924", false},
+		{"secreto inválido", "no-es-base32!", "005924", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, ok := ValidateTOTP(tc.secret, tc.code, now, 0); ok != tc.ok {
+				t.Fatalf("se esperaba ok=%v y dio %v", tc.ok, ok)
+			}
+		})
+	}
+}
+
+func TestIsTOTPCode(t *testing.T) {
+	cases := []struct {
+		code string
+		want bool
+	}{
+		{"123456", true},
+		{"000000", true},
+		{"12345", false},
+		{"1234567", false},
+		{"12 456", false},
+		{"k3pq-x2mz-7fha-bc4d", false},
+		{"", false},
+	}
+
+	for _, tc := range cases {
+		if got := IsTOTPCode(tc.code); got != tc.want {
+			t.Fatalf("IsTOTPCode(%q) = %v, se esperaba %v", tc.code, got, tc.want)
+		}
+	}
+}
+
+func TestHashRecoveryCodeNormalizes(t *testing.T) {
+	code, hash, err := NewRecoveryCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	typed := []string{
+		code,
+		strings.ToUpper(code),
+		strings.ReplaceAll(code, "-", ""),
+		strings.ReplaceAll(code, "-", " "),
+	}
+	for _, c := range typed {
+		if got := HashRecoveryCode(c); got != hash {
+			t.Fatalf("HashRecoveryCode(%q) no coincide con el del código %q", c, code)
+		}
+	}
+}
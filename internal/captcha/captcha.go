@@ -0,0 +1,86 @@
+// Package captcha valida el desafío anti-bots de los formularios públicos (reserva de turnos).
+// No depende del proveedor: hCaptcha, reCAPTCHA y Cloudflare Turnstile verifican con el mismo
+// protocolo "siteverify" (POST con secret, response y remoteip; responde {"success": bool}).
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+var ErrFailed = errors.New("no se pudo verificar que no sea un robot; vuelva a intentar")
+
+// Verifier es la dependencia que recibe el servicio.
+type Verifier interface {
+	Verify(ctx context.Context, token, remoteIP string) error
+}
+
+// SiteVerify verifica contra la URL "siteverify" del proveedor.
+type SiteVerify struct {
+	URL    string // ej: https://hcaptcha.com/siteverify
+	Secret string
+	Client *http.Client
+}
+
+// FromEnv arma un SiteVerify con CAPTCHA_VERIFY_URL y CAPTCHA_SECRET.
+// Devuelve nil si CAPTCHA_SECRET no está definido.
+func FromEnv() (Verifier, error) {
+	secret := os.Getenv("CAPTCHA_SECRET")
+	if secret == "" {
+		return nil, nil
+	}
+	verifyURL := os.Getenv("CAPTCHA_VERIFY_URL")
+	if u, err := url.Parse(verifyURL); err != nil || u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("CAPTCHA_VERIFY_URL inválida: %q", verifyURL)
+	}
+	return &SiteVerify{
+		URL:    verifyURL,
+		Secret: secret,
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (v *SiteVerify) Verify(ctx context.Context, token, remoteIP string) error {
+	if token == "" {
+		return ErrFailed
+	}
+
+	form := url.Values{"secret": {v.Secret}, "response": {token}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error consultando captcha: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error consultando captcha: %s", resp.Status)
+	}
+
+	var result struct {
+		Success bool `json:"success"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("respuesta de captcha inválida: %w", err)
+	}
+	if !result.Success {
+		return ErrFailed
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 )
 
@@ -35,6 +36,109 @@ type Appointment struct {
 	UpdatedAt          sql.NullTime   `json:"updated_at"`
 }
 
+type AppointmentCoverage struct {
+	AppointmentID       int64          `json:"appointment_id"`
+	CoverageID          int64          `json:"coverage_id"`
+	InsurerID           int64          `json:"insurer_id"`
+	AuthorizationNumber sql.NullString `json:"authorization_number"`
+	InsurerAmount       string         `json:"insurer_amount"`
+	CopayAmount         string         `json:"copay_amount"`
+	CreatedAt           sql.NullTime   `json:"created_at"`
+}
+
+type AppointmentFinance struct {
+	ID                 int64          `json:"id"`
+	ProfessionalID     int64          `json:"professional_id"`
+	ClientID           int64          `json:"client_id"`
+	Date               time.Time      `json:"date"`
+	StartTime          string         `json:"start_time"`
+	DurationMinutes    int32          `json:"duration_minutes"`
+	Status             sql.NullString `json:"status"`
+	Modality           sql.NullString `json:"modality"`
+	Concept            sql.NullString `json:"concept"`
+	Price              sql.NullString `json:"price"`
+	PaymentStatus      sql.NullString `json:"payment_status"`
+	PaymentMethod      sql.NullString `json:"payment_method"`
+	PaymentProofUrl    sql.NullString `json:"payment_proof_url"`
+	PaymentConfirmedAt sql.NullTime   `json:"payment_confirmed_at"`
+	InvoiceStatus      sql.NullString `json:"invoice_status"`
+	InvoiceUrl         sql.NullString `json:"invoice_url"`
+	InvoiceCae         sql.NullString `json:"invoice_cae"`
+	PackageID          sql.NullInt64  `json:"package_id"`
+}
+
+type Assistant struct {
+	ID        int64          `json:"id"`
+	Name      string         `json:"name"`
+	Email     string         `json:"email"`
+	Phone     sql.NullString `json:"phone"`
+	CreatedAt sql.NullTime   `json:"created_at"`
+}
+
+type AssistantCredential struct {
+	AssistantID  int64        `json:"assistant_id"`
+	PasswordHash string       `json:"password_hash"`
+	UpdatedAt    sql.NullTime `json:"updated_at"`
+}
+
+type AssistantPermission struct {
+	AssistantID    int64        `json:"assistant_id"`
+	ProfessionalID int64        `json:"professional_id"`
+	Role           string       `json:"role"`
+	CreatedAt      sql.NullTime `json:"created_at"`
+}
+
+type AssistantRefreshToken struct {
+	ID          int64        `json:"id"`
+	AssistantID int64        `json:"assistant_id"`
+	TokenHash   string       `json:"token_hash"`
+	ExpiresAt   time.Time    `json:"expires_at"`
+	RevokedAt   sql.NullTime `json:"revoked_at"`
+	CreatedAt   sql.NullTime `json:"created_at"`
+}
+
+type Attachment struct {
+	ID             int64         `json:"id"`
+	ProfessionalID int64         `json:"professional_id"`
+	ClientID       int64         `json:"client_id"`
+	NoteID         sql.NullInt64 `json:"note_id"`
+	Filename       string        `json:"filename"`
+	ContentType    string        `json:"content_type"`
+	SizeBytes      int64         `json:"size_bytes"`
+	Sha256         string        `json:"sha256"`
+	StorageKey     string        `json:"storage_key"`
+	DataKey        string        `json:"data_key"`
+	KeyVersion     sql.NullInt32 `json:"key_version"`
+	CreatedAt      sql.NullTime  `json:"created_at"`
+}
+
+type AuditLog struct {
+	ID             int64          `json:"id"`
+	ProfessionalID int64          `json:"professional_id"`
+	AssistantID    sql.NullInt64  `json:"assistant_id"`
+	Action         string         `json:"action"`
+	ResourceType   string         `json:"resource_type"`
+	ResourceID     sql.NullInt64  `json:"resource_id"`
+	Route          string         `json:"route"`
+	Status         int32          `json:"status"`
+	Ip             sql.NullString `json:"ip"`
+	UserAgent      sql.NullString `json:"user_agent"`
+	CreatedAt      time.Time      `json:"created_at"`
+	PrevHash       string         `json:"prev_hash"`
+	Hash           string         `json:"hash"`
+	ClientID       sql.NullInt64  `json:"client_id"`
+}
+
+type BookingRequest struct {
+	ID             int64     `json:"id"`
+	ProfessionalID int64     `json:"professional_id"`
+	ClientID       int64     `json:"client_id"`
+	AppointmentID  int64     `json:"appointment_id"`
+	Email          string    `json:"email"`
+	Ip             string    `json:"ip"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
 type Client struct {
 	ID                    int64          `json:"id"`
 	ProfessionalID        int64          `json:"professional_id"`
@@ -49,6 +153,75 @@ type Client struct {
 	CreatedAt             sql.NullTime   `json:"created_at"`
 }
 
+type ClientCoverage struct {
+	ID             int64          `json:"id"`
+	ProfessionalID int64          `json:"professional_id"`
+	ClientID       int64          `json:"client_id"`
+	InsurerID      int64          `json:"insurer_id"`
+	Plan           sql.NullString `json:"plan"`
+	MemberNumber   string         `json:"member_number"`
+	CopayAmount    string         `json:"copay_amount"`
+	Active         sql.NullBool   `json:"active"`
+	CreatedAt      sql.NullTime   `json:"created_at"`
+}
+
+type ClientFee struct {
+	ID             int64          `json:"id"`
+	ProfessionalID int64          `json:"professional_id"`
+	ClientID       int64          `json:"client_id"`
+	AppointmentID  sql.NullInt64  `json:"appointment_id"`
+	Kind           string         `json:"kind"`
+	Amount         string         `json:"amount"`
+	Date           time.Time      `json:"date"`
+	Description    sql.NullString `json:"description"`
+	CreatedAt      sql.NullTime   `json:"created_at"`
+}
+
+type ClientForm struct {
+	ID             int64           `json:"id"`
+	ProfessionalID int64           `json:"professional_id"`
+	ClientID       int64           `json:"client_id"`
+	Title          string          `json:"title"`
+	Sections       json.RawMessage `json:"sections"`
+	Answers        sql.NullString  `json:"answers"`
+	KeyVersion     sql.NullInt32   `json:"key_version"`
+	CompletedAt    sql.NullTime    `json:"completed_at"`
+	CreatedAt      sql.NullTime    `json:"created_at"`
+}
+
+type ClientLedger struct {
+	ProfessionalID int64     `json:"professional_id"`
+	ClientID       int64     `json:"client_id"`
+	EntryType      string    `json:"entry_type"`
+	SourceID       int64     `json:"source_id"`
+	EntryDate      time.Time `json:"entry_date"`
+	Description    string    `json:"description"`
+	Debit          string    `json:"debit"`
+	Credit         string    `json:"credit"`
+}
+
+type ClientLoginToken struct {
+	ID        int64        `json:"id"`
+	ClientID  int64        `json:"client_id"`
+	TokenHash string       `json:"token_hash"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt sql.NullTime `json:"created_at"`
+}
+
+type ClientPayment struct {
+	ID             int64          `json:"id"`
+	ProfessionalID int64          `json:"professional_id"`
+	ClientID       int64          `json:"client_id"`
+	AppointmentID  sql.NullInt64  `json:"appointment_id"`
+	Kind           string         `json:"kind"`
+	Amount         string         `json:"amount"`
+	Method         sql.NullString `json:"method"`
+	PaidAt         time.Time      `json:"paid_at"`
+	Notes          sql.NullString `json:"notes"`
+	CreatedAt      sql.NullTime   `json:"created_at"`
+}
+
 type ClinicalNote struct {
 	ID             int64          `json:"id"`
 	ProfessionalID int64          `json:"professional_id"`
@@ -61,6 +234,149 @@ type ClinicalNote struct {
 	SignedAt       sql.NullTime   `json:"signed_at"`
 	CreatedAt      sql.NullTime   `json:"created_at"`
 	UpdatedAt      sql.NullTime   `json:"updated_at"`
+	TemplateID     sql.NullInt64  `json:"template_id"`
+	Sections       sql.NullString `json:"sections"`
+}
+
+type ClinicalNoteAddenda struct {
+	ID             int64         `json:"id"`
+	NoteID         int64         `json:"note_id"`
+	ProfessionalID int64         `json:"professional_id"`
+	Content        string        `json:"content"`
+	KeyVersion     sql.NullInt32 `json:"key_version"`
+	SignedAt       time.Time     `json:"signed_at"`
+	CreatedAt      sql.NullTime  `json:"created_at"`
+}
+
+type ClinicalNoteRevision struct {
+	ID             int64          `json:"id"`
+	NoteID         int64          `json:"note_id"`
+	RevisionNumber int32          `json:"revision_number"`
+	AuthorID       int64          `json:"author_id"`
+	Content        string         `json:"content"`
+	KeyVersion     sql.NullInt32  `json:"key_version"`
+	WrittenAt      sql.NullTime   `json:"written_at"`
+	CreatedAt      sql.NullTime   `json:"created_at"`
+	Sections       sql.NullString `json:"sections"`
+}
+
+type ClinicalNoteSearchToken struct {
+	NoteID int64  `json:"note_id"`
+	Token  string `json:"token"`
+}
+
+type ClinicalRecordExport struct {
+	ID             int64          `json:"id"`
+	ProfessionalID int64          `json:"professional_id"`
+	ClientID       int64          `json:"client_id"`
+	Purpose        sql.NullString `json:"purpose"`
+	Recipient      sql.NullString `json:"recipient"`
+	NoteCount      int32          `json:"note_count"`
+	DocumentSha256 string         `json:"document_sha256"`
+	CreatedAt      sql.NullTime   `json:"created_at"`
+}
+
+type Insurer struct {
+	ID                    int64          `json:"id"`
+	ProfessionalID        int64          `json:"professional_id"`
+	Name                  string         `json:"name"`
+	Kind                  sql.NullString `json:"kind"`
+	Code                  sql.NullString `json:"code"`
+	SessionFee            string         `json:"session_fee"`
+	RequiresAuthorization sql.NullBool   `json:"requires_authorization"`
+	Active                sql.NullBool   `json:"active"`
+	CreatedAt             sql.NullTime   `json:"created_at"`
+}
+
+type NoteTemplate struct {
+	ID             int64           `json:"id"`
+	ProfessionalID int64           `json:"professional_id"`
+	Name           string          `json:"name"`
+	Kind           sql.NullString  `json:"kind"`
+	Sections       json.RawMessage `json:"sections"`
+	Active         sql.NullBool    `json:"active"`
+	CreatedAt      sql.NullTime    `json:"created_at"`
+	UpdatedAt      sql.NullTime    `json:"updated_at"`
+}
+
+type Organization struct {
+	ID                      int64          `json:"id"`
+	Name                    string         `json:"name"`
+	RequireTwoFactor        bool           `json:"require_two_factor"`
+	CreatedAt               sql.NullTime   `json:"created_at"`
+	LogoUrl                 sql.NullString `json:"logo_url"`
+	BrandColor              sql.NullString `json:"brand_color"`
+	Address                 sql.NullString `json:"address"`
+	Phone                   sql.NullString `json:"phone"`
+	Website                 sql.NullString `json:"website"`
+	DefaultDurationMinutes  sql.NullInt32  `json:"default_duration_minutes"`
+	BufferMinutes           sql.NullInt32  `json:"buffer_minutes"`
+	TimeIncrementMinutes    sql.NullInt32  `json:"time_increment_minutes"`
+	MinBookingNoticeHours   sql.NullInt32  `json:"min_booking_notice_hours"`
+	BookingRequiresApproval sql.NullBool   `json:"booking_requires_approval"`
+}
+
+type OrganizationInvitation struct {
+	ID             int64         `json:"id"`
+	OrganizationID int64         `json:"organization_id"`
+	ProfessionalID int64         `json:"professional_id"`
+	InvitedBy      sql.NullInt64 `json:"invited_by"`
+	Role           string        `json:"role"`
+	TokenHash      string        `json:"token_hash"`
+	ExpiresAt      time.Time     `json:"expires_at"`
+	UsedAt         sql.NullTime  `json:"used_at"`
+	CreatedAt      sql.NullTime  `json:"created_at"`
+}
+
+type OrganizationMember struct {
+	ProfessionalID int64        `json:"professional_id"`
+	OrganizationID int64        `json:"organization_id"`
+	Role           string       `json:"role"`
+	CreatedAt      sql.NullTime `json:"created_at"`
+}
+
+type PackageConsumption struct {
+	AppointmentID int64        `json:"appointment_id"`
+	PackageID     int64        `json:"package_id"`
+	ConsumedAt    sql.NullTime `json:"consumed_at"`
+}
+
+type PasswordResetToken struct {
+	ID             int64        `json:"id"`
+	ProfessionalID int64        `json:"professional_id"`
+	TokenHash      string       `json:"token_hash"`
+	ExpiresAt      time.Time    `json:"expires_at"`
+	UsedAt         sql.NullTime `json:"used_at"`
+	CreatedAt      sql.NullTime `json:"created_at"`
+}
+
+type PaymentProof struct {
+	AppointmentID  int64        `json:"appointment_id"`
+	ProfessionalID int64        `json:"professional_id"`
+	ClientID       int64        `json:"client_id"`
+	Filename       string       `json:"filename"`
+	ContentType    string       `json:"content_type"`
+	SizeBytes      int64        `json:"size_bytes"`
+	StorageKey     string       `json:"storage_key"`
+	CreatedAt      sql.NullTime `json:"created_at"`
+}
+
+type PriceIndex struct {
+	Series string    `json:"series"`
+	Period time.Time `json:"period"`
+	Value  string    `json:"value"`
+}
+
+type PriceListEntry struct {
+	ID              int64          `json:"id"`
+	ProfessionalID  int64          `json:"professional_id"`
+	Modality        string         `json:"modality"`
+	DurationMinutes int32          `json:"duration_minutes"`
+	Price           string         `json:"price"`
+	EffectiveFrom   time.Time      `json:"effective_from"`
+	IndexSeries     sql.NullString `json:"index_series"`
+	AppliedAt       sql.NullTime   `json:"applied_at"`
+	CreatedAt       sql.NullTime   `json:"created_at"`
 }
 
 type Professional struct {
@@ -78,27 +394,46 @@ type Professional struct {
 	CreatedAt               sql.NullTime   `json:"created_at"`
 }
 
+type ProfessionalCredential struct {
+	ProfessionalID int64        `json:"professional_id"`
+	PasswordHash   string       `json:"password_hash"`
+	UpdatedAt      sql.NullTime `json:"updated_at"`
+}
+
 type ProfessionalSetting struct {
-	ProfessionalID         int64          `json:"professional_id"`
-	DefaultDurationMinutes sql.NullInt32  `json:"default_duration_minutes"`
-	DefaultPrice           sql.NullString `json:"default_price"`
-	BufferMinutes          sql.NullInt32  `json:"buffer_minutes"`
-	TimeIncrementMinutes   sql.NullInt32  `json:"time_increment_minutes"`
-	BankCbu                sql.NullString `json:"bank_cbu"`
-	BankAlias              sql.NullString `json:"bank_alias"`
-	BankName               sql.NullString `json:"bank_name"`
-	BankHolderName         sql.NullString `json:"bank_holder_name"`
-	SendAliasByEmail       sql.NullBool   `json:"send_alias_by_email"`
-	MpAccessToken          sql.NullString `json:"mp_access_token"`
-	MpUserID               sql.NullString `json:"mp_user_id"`
-	AfipCrtUrl             sql.NullString `json:"afip_crt_url"`
-	AfipKeyUrl             sql.NullString `json:"afip_key_url"`
-	AfipPointOfSale        sql.NullInt32  `json:"afip_point_of_sale"`
-	NotifyByEmail          sql.NullBool   `json:"notify_by_email"`
-	NotifyByWhatsapp       sql.NullBool   `json:"notify_by_whatsapp"`
-	MinBookingNoticeHours  sql.NullInt32  `json:"min_booking_notice_hours"`
-	MaxDailyAppointments   sql.NullInt32  `json:"max_daily_appointments"`
-	UpdatedAt              sql.NullTime   `json:"updated_at"`
+	ProfessionalID          int64          `json:"professional_id"`
+	DefaultDurationMinutes  sql.NullInt32  `json:"default_duration_minutes"`
+	DefaultPrice            sql.NullString `json:"default_price"`
+	BufferMinutes           sql.NullInt32  `json:"buffer_minutes"`
+	TimeIncrementMinutes    sql.NullInt32  `json:"time_increment_minutes"`
+	BankCbu                 sql.NullString `json:"bank_cbu"`
+	BankAlias               sql.NullString `json:"bank_alias"`
+	BankName                sql.NullString `json:"bank_name"`
+	BankHolderName          sql.NullString `json:"bank_holder_name"`
+	SendAliasByEmail        sql.NullBool   `json:"send_alias_by_email"`
+	MpAccessToken           sql.NullString `json:"mp_access_token"`
+	MpUserID                sql.NullString `json:"mp_user_id"`
+	AfipCrtUrl              sql.NullString `json:"afip_crt_url"`
+	AfipKeyUrl              sql.NullString `json:"afip_key_url"`
+	AfipPointOfSale         sql.NullInt32  `json:"afip_point_of_sale"`
+	NotifyByEmail           sql.NullBool   `json:"notify_by_email"`
+	NotifyByWhatsapp        sql.NullBool   `json:"notify_by_whatsapp"`
+	MinBookingNoticeHours   sql.NullInt32  `json:"min_booking_notice_hours"`
+	MaxDailyAppointments    sql.NullInt32  `json:"max_daily_appointments"`
+	UpdatedAt               sql.NullTime   `json:"updated_at"`
+	BookingRequiresApproval sql.NullBool   `json:"booking_requires_approval"`
+}
+
+type ProfessionalTwoFactor struct {
+	ID             int64         `json:"id"`
+	ProfessionalID int64         `json:"professional_id"`
+	Secret         string        `json:"secret"`
+	KeyVersion     sql.NullInt32 `json:"key_version"`
+	ConfirmedAt    sql.NullTime  `json:"confirmed_at"`
+	LastUsedStep   int64         `json:"last_used_step"`
+	FailedAttempts int32         `json:"failed_attempts"`
+	LockedUntil    sql.NullTime  `json:"locked_until"`
+	CreatedAt      sql.NullTime  `json:"created_at"`
 }
 
 type RecurringRule struct {
@@ -115,6 +450,15 @@ type RecurringRule struct {
 	CreatedAt       sql.NullTime   `json:"created_at"`
 }
 
+type RefreshToken struct {
+	ID             int64        `json:"id"`
+	ProfessionalID int64        `json:"professional_id"`
+	TokenHash      string       `json:"token_hash"`
+	ExpiresAt      time.Time    `json:"expires_at"`
+	RevokedAt      sql.NullTime `json:"revoked_at"`
+	CreatedAt      sql.NullTime `json:"created_at"`
+}
+
 type ScheduleConfig struct {
 	ID             int64        `json:"id"`
 	ProfessionalID int64        `json:"professional_id"`
@@ -123,3 +467,27 @@ type ScheduleConfig struct {
 	EndTime        string       `json:"end_time"`
 	CreatedAt      sql.NullTime `json:"created_at"`
 }
+
+type SessionPackage struct {
+	ID             int64          `json:"id"`
+	ProfessionalID int64          `json:"professional_id"`
+	ClientID       int64          `json:"client_id"`
+	Name           string         `json:"name"`
+	TotalSessions  int32          `json:"total_sessions"`
+	UsedSessions   int32          `json:"used_sessions"`
+	Price          string         `json:"price"`
+	ValidFrom      time.Time      `json:"valid_from"`
+	ValidUntil     time.Time      `json:"valid_until"`
+	PaymentStatus  sql.NullString `json:"payment_status"`
+	PaymentMethod  sql.NullString `json:"payment_method"`
+	PaidAt         sql.NullTime   `json:"paid_at"`
+	CreatedAt      sql.NullTime   `json:"created_at"`
+}
+
+type TwoFactorRecoveryCode struct {
+	ID             int64        `json:"id"`
+	ProfessionalID int64        `json:"professional_id"`
+	CodeHash       string       `json:"code_hash"`
+	UsedAt         sql.NullTime `json:"used_at"`
+	CreatedAt      sql.NullTime `json:"created_at"`
+}
@@ -13,10 +13,23 @@ WHERE id = $1 LIMIT 1;
 SELECT * FROM professionals 
 WHERE email = $1 LIMIT 1;
 
+-- name: ListProfessionals :many
+SELECT id, name, email, phone, slug, title, photo_url
+FROM professionals
+ORDER BY name;
+
+-- name: ListProfessionalsToNotify :many
+-- Profesionales que aceptan avisos por email (sin configuración = sí) y con el email verificado
+SELECT p.id, p.name, p.email
+FROM professionals p
+LEFT JOIN professional_settings s ON s.professional_id = p.id
+WHERE COALESCE(s.notify_by_email, TRUE) AND p.email_verified_at IS NOT NULL
+ORDER BY p.id;
+
 -- name: GetProfessionalBySlug :one
 SELECT id, name, slug, photo_url, title, license_number, bio, email, phone
 FROM professionals
-WHERE slug = $1 LIMIT 1;
+WHERE slug = $1 AND email_verified_at IS NOT NULL LIMIT 1;
 
 -- name: UpdateProfessionalProfile :one
 UPDATE professionals
@@ -24,6 +37,328 @@ SET name = $1, phone = $2, slug = $3, photo_url = $4, title = $5, license_number
 WHERE id = $8
 RETURNING *;
 
+-- name: SetProfessionalPhoto :one
+UPDATE professionals
+SET photo_url = $1
+WHERE id = $2
+RETURNING *;
+
+
+-- SECTION: Autenticación
+
+-- name: SetProfessionalPassword :exec
+INSERT INTO professional_credentials (professional_id, password_hash)
+VALUES (@professional_id, @password_hash)
+ON CONFLICT (professional_id) DO UPDATE
+SET password_hash = EXCLUDED.password_hash, updated_at = NOW();
+
+-- name: GetCredentialsByEmail :one
+-- El email se compara sin distinguir mayúsculas
+SELECT p.id AS professional_id, c.password_hash
+FROM professionals p
+JOIN professional_credentials c ON c.professional_id = p.id
+WHERE lower(p.email) = lower(@email)
+LIMIT 1;
+
+-- name: EmailExists :one
+SELECT EXISTS (SELECT 1 FROM professionals WHERE lower(email) = lower(@email));
+
+-- name: CreateRefreshToken :one
+INSERT INTO refresh_tokens (professional_id, token_hash, expires_at)
+VALUES (@professional_id, @token_hash, @expires_at)
+RETURNING *;
+
+-- name: GetRefreshToken :one
+SELECT * FROM refresh_tokens WHERE token_hash = @token_hash LIMIT 1;
+
+-- name: RevokeRefreshToken :execrows
+-- Sólo revoca si seguía vigente: dos renovaciones simultáneas no pueden usar el mismo token
+UPDATE refresh_tokens SET revoked_at = NOW()
+WHERE id = @id AND revoked_at IS NULL;
+
+-- name: RevokeProfessionalRefreshTokens :exec
+UPDATE refresh_tokens SET revoked_at = NOW()
+WHERE professional_id = @professional_id AND revoked_at IS NULL;
+
+-- name: MarkEmailVerified :execrows
+-- Sólo si el email sigue siendo el del enlace. Verificar de nuevo no cambia la fecha
+UPDATE professionals
+SET email_verified_at = COALESCE(email_verified_at, NOW())
+WHERE id = @id AND lower(email) = lower(@email);
+
+-- name: CreatePasswordResetToken :exec
+INSERT INTO password_reset_tokens (professional_id, token_hash, expires_at)
+VALUES (@professional_id, @token_hash, @expires_at);
+
+-- name: InvalidatePasswordResetTokens :exec
+-- Al pedir uno nuevo (o al usarlo) los anteriores dejan de servir
+UPDATE password_reset_tokens SET used_at = NOW()
+WHERE professional_id = @professional_id AND used_at IS NULL;
+
+-- name: ConsumePasswordResetToken :one
+-- Marca el token como usado en la misma sentencia: dos usos simultáneos no pueden ganar ambos
+UPDATE password_reset_tokens SET used_at = NOW()
+WHERE token_hash = @token_hash AND used_at IS NULL AND expires_at > NOW()
+RETURNING professional_id;
+
+
+-- SECTION: Verificación en dos pasos
+
+-- name: StartTwoFactorEnrollment :one
+-- Guarda un secreto nuevo sin confirmar. Si la 2FA ya estaba confirmada no toca nada (no devuelve fila)
+INSERT INTO professional_two_factor (professional_id, secret, key_version)
+VALUES (@professional_id, @secret, @key_version)
+ON CONFLICT (professional_id) DO UPDATE
+SET secret = EXCLUDED.secret, key_version = EXCLUDED.key_version, last_used_step = 0,
+    failed_attempts = 0, locked_until = NULL, created_at = NOW()
+WHERE professional_two_factor.confirmed_at IS NULL
+RETURNING *;
+
+-- name: GetTwoFactor :one
+SELECT * FROM professional_two_factor WHERE professional_id = @professional_id LIMIT 1;
+
+-- name: UseTwoFactorStep :execrows
+-- Acepta el código de un intervalo posterior al último usado (y confirma el alta si estaba pendiente).
+-- Dos usos simultáneos del mismo código no pueden ganar ambos
+UPDATE professional_two_factor
+SET last_used_step = @step, failed_attempts = 0, locked_until = NULL,
+    confirmed_at = COALESCE(confirmed_at, NOW())
+WHERE professional_id = @professional_id AND last_used_step < @step;
+
+-- name: RecordTwoFactorFailure :exec
+-- Al llegar al máximo de intentos se bloquea hasta locked_until y el contador vuelve a cero
+UPDATE professional_two_factor
+SET failed_attempts = CASE WHEN failed_attempts + 1 >= @max_attempts::int THEN 0 ELSE failed_attempts + 1 END,
+    locked_until = CASE WHEN failed_attempts + 1 >= @max_attempts::int THEN @locked_until::timestamptz ELSE locked_until END
+WHERE professional_id = @professional_id;
+
+-- name: ResetTwoFactorFailures :exec
+UPDATE professional_two_factor SET failed_attempts = 0, locked_until = NULL
+WHERE professional_id = @professional_id;
+
+-- name: DeleteTwoFactor :exec
+DELETE FROM professional_two_factor WHERE professional_id = @professional_id;
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO two_factor_recovery_codes (professional_id, code_hash)
+VALUES (@professional_id, @code_hash);
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM two_factor_recovery_codes WHERE professional_id = @professional_id;
+
+-- name: UseRecoveryCode :execrows
+UPDATE two_factor_recovery_codes SET used_at = NOW()
+WHERE professional_id = @professional_id AND code_hash = @code_hash AND used_at IS NULL;
+
+-- name: CountRecoveryCodes :one
+SELECT COUNT(*) FROM two_factor_recovery_codes
+WHERE professional_id = @professional_id AND used_at IS NULL;
+
+-- name: ListTwoFactorForRotation :many
+SELECT * FROM professional_two_factor
+WHERE id > @after_id
+  AND key_version IS DISTINCT FROM @key_version::int
+ORDER BY id
+LIMIT @batch_size;
+
+-- name: RewrapTwoFactorSecret :execrows
+UPDATE professional_two_factor
+SET secret = @secret, key_version = @new_key_version
+WHERE id = @id AND key_version IS NOT DISTINCT FROM @old_key_version AND secret = @old_secret;
+
+
+-- SECTION: Organizaciones
+
+-- name: CreateOrganization :one
+INSERT INTO organizations (name, require_two_factor)
+VALUES (@name, @require_two_factor)
+RETURNING *;
+
+-- name: SetOrganizationTwoFactor :execrows
+UPDATE organizations SET require_two_factor = @require_two_factor
+WHERE id = @id;
+
+-- name: GetOrganization :one
+SELECT * FROM organizations WHERE id = @id;
+
+-- name: UpdateOrganization :one
+UPDATE organizations
+SET name = @name, require_two_factor = @require_two_factor,
+    logo_url = @logo_url, brand_color = @brand_color, address = @address, phone = @phone, website = @website,
+    default_duration_minutes = @default_duration_minutes, buffer_minutes = @buffer_minutes,
+    time_increment_minutes = @time_increment_minutes, min_booking_notice_hours = @min_booking_notice_hours,
+    booking_requires_approval = @booking_requires_approval
+WHERE id = @id
+RETURNING *;
+
+-- name: AddOrganizationMember :exec
+-- Si ya pertenecía a otra organización, pasa a ésta
+INSERT INTO organization_members (organization_id, professional_id, role)
+VALUES (@organization_id, @professional_id, @role)
+ON CONFLICT (professional_id) DO UPDATE
+SET organization_id = EXCLUDED.organization_id, role = EXCLUDED.role, created_at = NOW();
+
+-- name: JoinOrganization :execrows
+-- Como AddOrganizationMember, pero si ya pertenece a una organización no la toca: 0 filas
+INSERT INTO organization_members (organization_id, professional_id, role)
+VALUES (@organization_id, @professional_id, @role)
+ON CONFLICT (professional_id) DO NOTHING;
+
+-- name: CreateOrganizationInvitation :exec
+INSERT INTO organization_invitations (organization_id, professional_id, invited_by, role, token_hash, expires_at)
+VALUES (@organization_id, @professional_id, @invited_by, @role, @token_hash, @expires_at);
+
+-- name: InvalidateOrganizationInvitations :exec
+-- Al reenviarla, la invitación anterior deja de servir
+UPDATE organization_invitations SET used_at = NOW()
+WHERE organization_id = @organization_id AND professional_id = @professional_id AND used_at IS NULL;
+
+-- name: ConsumeOrganizationInvitation :one
+-- Sólo la acepta el invitado, con su sesión, y una sola vez
+UPDATE organization_invitations SET used_at = NOW()
+WHERE token_hash = @token_hash AND professional_id = @professional_id
+  AND used_at IS NULL AND expires_at > NOW()
+RETURNING *;
+
+-- name: GetOrganizationMembership :one
+SELECT * FROM organization_members WHERE professional_id = @professional_id;
+
+-- name: ListOrganizationMembers :many
+SELECT m.professional_id, p.name, p.email, p.slug, p.title, m.role, m.created_at
+FROM organization_members m
+JOIN professionals p ON p.id = m.professional_id
+WHERE m.organization_id = @organization_id
+ORDER BY p.name;
+
+-- name: UpdateOrganizationMemberRole :execrows
+UPDATE organization_members SET role = @role
+WHERE organization_id = @organization_id AND professional_id = @professional_id;
+
+-- name: RemoveOrganizationMember :execrows
+DELETE FROM organization_members
+WHERE organization_id = @organization_id AND professional_id = @professional_id;
+
+-- name: CountOrganizationAdmins :one
+SELECT COUNT(*) FROM organization_members
+WHERE organization_id = @organization_id AND role = 'admin';
+
+-- name: ListOrganizationAppointments :many
+-- Agenda del centro: los turnos de todos sus profesionales (sin los cancelados ni reprogramados)
+SELECT a.id, a.professional_id, p.name as professional_name, a.client_id, c.name as client_name,
+       a.date, a.start_time, a.duration_minutes, a.modality, a.status
+FROM appointments a
+JOIN organization_members m ON m.professional_id = a.professional_id
+JOIN professionals p ON p.id = a.professional_id
+JOIN clients c ON c.id = a.client_id
+WHERE m.organization_id = @organization_id
+  AND a.date >= @start_date::date
+  AND a.date <= @end_date::date
+  AND a.status NOT IN ('cancelled', 'rescheduled')
+  AND (sqlc.narg('professional_id')::bigint IS NULL OR a.professional_id = sqlc.narg('professional_id')::bigint)
+ORDER BY a.date, a.start_time, p.name;
+
+-- name: GetOrganizationFinancialSummary :many
+-- KPIs de Finanzas por profesional del centro, con los mismos criterios que GetFinancialSummary
+SELECT p.id as professional_id, p.name as professional_name,
+    COUNT(a.id)::BIGINT as sessions_count,
+    COALESCE(SUM(CASE WHEN a.payment_status = 'paid' THEN a.price ELSE 0 END), 0)::DECIMAL as income,
+    COALESCE(SUM(CASE WHEN a.payment_status = 'pending' THEN a.price ELSE 0 END), 0)::DECIMAL as pending_collection,
+    COALESCE(SUM(CASE WHEN a.payment_status = 'paid' AND a.invoice_status = 'pending' THEN a.price ELSE 0 END), 0)::DECIMAL as pending_invoicing
+FROM organization_members m
+JOIN professionals p ON p.id = m.professional_id
+LEFT JOIN appointment_finances a ON a.professional_id = m.professional_id
+  AND a.status != 'cancelled'
+  AND a.date >= @start_date::date
+  AND a.date <= @end_date::date
+WHERE m.organization_id = @organization_id
+GROUP BY p.id, p.name
+ORDER BY p.name;
+
+-- name: OrganizationRequiresTwoFactor :one
+SELECT EXISTS (
+    SELECT 1 FROM organization_members m
+    JOIN organizations o ON o.id = m.organization_id
+    WHERE m.professional_id = @professional_id AND o.require_two_factor
+);
+
+
+-- SECTION: Asistentes
+
+-- name: CreateAssistant :one
+INSERT INTO assistants (name, email, phone)
+VALUES (@name, @email, @phone)
+RETURNING *;
+
+-- name: GetAssistant :one
+SELECT * FROM assistants WHERE id = @id LIMIT 1;
+
+-- name: GetAssistantByEmail :one
+SELECT * FROM assistants WHERE lower(email) = lower(@email) LIMIT 1;
+
+-- name: SetInitialAssistantPassword :execrows
+-- Sólo la primera vez (al aceptar la invitación): reusar el enlace no cambia la contraseña
+INSERT INTO assistant_credentials (assistant_id, password_hash)
+VALUES (@assistant_id, @password_hash)
+ON CONFLICT (assistant_id) DO NOTHING;
+
+-- name: GetAssistantCredentialsByEmail :one
+SELECT a.id AS assistant_id, c.password_hash
+FROM assistants a
+JOIN assistant_credentials c ON c.assistant_id = a.id
+WHERE lower(a.email) = lower(@email)
+LIMIT 1;
+
+-- name: AssistantHasCredentials :one
+SELECT EXISTS (SELECT 1 FROM assistant_credentials WHERE assistant_id = @assistant_id);
+
+-- name: GrantAssistantRole :exec
+INSERT INTO assistant_permissions (assistant_id, professional_id, role)
+VALUES (@assistant_id, @professional_id, @role)
+ON CONFLICT DO NOTHING;
+
+-- name: RevokeAssistantRoles :execrows
+DELETE FROM assistant_permissions
+WHERE assistant_id = @assistant_id AND professional_id = @professional_id;
+
+-- name: ListAssistantRoles :many
+SELECT role FROM assistant_permissions
+WHERE assistant_id = @assistant_id AND professional_id = @professional_id
+ORDER BY role;
+
+-- name: ListAssistantsForProfessional :many
+SELECT a.id, a.name, a.email, a.phone,
+       string_agg(p.role, ',' ORDER BY p.role)::text AS roles,
+       EXISTS (SELECT 1 FROM assistant_credentials c WHERE c.assistant_id = a.id) AS activated
+FROM assistants a
+JOIN assistant_permissions p ON p.assistant_id = a.id
+WHERE p.professional_id = @professional_id
+GROUP BY a.id
+ORDER BY a.name;
+
+-- name: ListProfessionalsForAssistant :many
+SELECT pr.id, pr.name, string_agg(p.role, ',' ORDER BY p.role)::text AS roles
+FROM assistant_permissions p
+JOIN professionals pr ON pr.id = p.professional_id
+WHERE p.assistant_id = @assistant_id
+GROUP BY pr.id
+ORDER BY pr.name;
+
+-- name: CreateAssistantRefreshToken :one
+INSERT INTO assistant_refresh_tokens (assistant_id, token_hash, expires_at)
+VALUES (@assistant_id, @token_hash, @expires_at)
+RETURNING *;
+
+-- name: GetAssistantRefreshToken :one
+SELECT * FROM assistant_refresh_tokens WHERE token_hash = @token_hash LIMIT 1;
+
+-- name: RevokeAssistantRefreshToken :execrows
+UPDATE assistant_refresh_tokens SET revoked_at = NOW()
+WHERE id = @id AND revoked_at IS NULL;
+
+-- name: RevokeAssistantRefreshTokens :exec
+UPDATE assistant_refresh_tokens SET revoked_at = NOW()
+WHERE assistant_id = @assistant_id AND revoked_at IS NULL;
+
 
 -- SECTION: Professional Settings
 
@@ -36,9 +371,9 @@ INSERT INTO professional_settings (
     bank_cbu, bank_alias, bank_name, bank_holder_name, send_alias_by_email,
     mp_access_token, mp_user_id,
     afip_crt_url, afip_key_url, afip_point_of_sale, 
-    notify_by_email, notify_by_whatsapp
+    notify_by_email, notify_by_whatsapp, booking_requires_approval
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20
 )
 ON CONFLICT (professional_id) DO UPDATE SET
     default_duration_minutes = EXCLUDED.default_duration_minutes,
@@ -59,6 +394,7 @@ ON CONFLICT (professional_id) DO UPDATE SET
     afip_point_of_sale = EXCLUDED.afip_point_of_sale,
     notify_by_email = EXCLUDED.notify_by_email,
     notify_by_whatsapp = EXCLUDED.notify_by_whatsapp,
+    booking_requires_approval = EXCLUDED.booking_requires_approval,
     updated_at = NOW()
 RETURNING *;
 
@@ -83,7 +419,7 @@ UPDATE clients
 SET name = $1, email = $2, phone = $3, 
     birth_date = $4, medications = $5, 
     emergency_contact_name = $6, emergency_contact_phone = $7
-WHERE id = $8
+WHERE id = $8 AND professional_id = $9
 RETURNING *;
 
 -- name: ListClients :many
@@ -92,7 +428,484 @@ WHERE professional_id = $1 AND active = TRUE
 ORDER BY name;
 
 -- name: GetClient :one
-SELECT * FROM clients WHERE id = $1 LIMIT 1;
+SELECT * FROM clients WHERE id = $1 AND professional_id = $2 LIMIT 1;
+
+
+-- SECTION: Portal del Paciente
+
+-- name: ListPortalClientsByEmail :many
+-- Fichas activas con ese email (una por profesional con el que se atiende)
+SELECT c.id, c.name, p.name AS professional_name
+FROM clients c
+JOIN professionals p ON p.id = c.professional_id
+WHERE lower(c.email) = lower(@email) AND c.active = TRUE
+ORDER BY c.id;
+
+-- name: GetPortalClient :one
+-- Lo que el portal necesita de la ficha y de su profesional (sin datos clínicos)
+SELECT c.id, c.professional_id, c.name, c.email, c.active,
+       p.name AS professional_name, p.title AS professional_title,
+       COALESCE(p.cancellation_window_hours, 24)::int AS cancellation_window_hours
+FROM clients c
+JOIN professionals p ON p.id = c.professional_id
+WHERE c.id = @id
+LIMIT 1;
+
+-- name: CreateClientLoginToken :exec
+INSERT INTO client_login_tokens (client_id, token_hash, expires_at)
+VALUES (@client_id, @token_hash, @expires_at);
+
+-- name: InvalidateClientLoginTokens :exec
+-- Al pedir uno nuevo los anteriores dejan de servir
+UPDATE client_login_tokens SET used_at = NOW()
+WHERE client_id = @client_id AND used_at IS NULL;
+
+-- name: ConsumeClientLoginToken :one
+-- Marca el enlace como usado en la misma sentencia: dos usos simultáneos no pueden ganar ambos
+UPDATE client_login_tokens SET used_at = NOW()
+WHERE token_hash = @token_hash AND used_at IS NULL AND expires_at > NOW()
+RETURNING client_id;
+
+-- name: ListPortalAppointments :many
+-- Turnos del paciente sin el comentario interno del profesional (notes)
+SELECT a.id, a.date, a.start_time, a.duration_minutes, a.status, a.modality, a.meeting_url,
+       a.price, a.payment_status, a.payment_method, a.invoice_status, a.invoice_url,
+       (pp.appointment_id IS NOT NULL)::bool AS has_payment_proof
+FROM appointments a
+LEFT JOIN payment_proofs pp ON pp.appointment_id = a.id
+WHERE a.client_id = @client_id AND a.professional_id = @professional_id
+ORDER BY a.date DESC, a.start_time DESC;
+
+-- name: GetClientAppointment :one
+SELECT * FROM appointments
+WHERE id = @id AND client_id = @client_id AND professional_id = @professional_id
+LIMIT 1;
+
+-- name: SetClientAppointmentStatus :execrows
+-- Sólo sobre turnos todavía agendados: cancelar o reprogramar dos veces no hace nada
+UPDATE appointments SET status = @status, updated_at = NOW()
+WHERE id = @id AND client_id = @client_id AND professional_id = @professional_id
+  AND status = 'scheduled';
+
+-- name: CopyAppointmentCoverage :exec
+-- Al reprogramar, el turno nuevo conserva la cobertura y los montos del original
+INSERT INTO appointment_coverages (
+    appointment_id, coverage_id, insurer_id, authorization_number, insurer_amount, copay_amount
+)
+SELECT @new_appointment_id, coverage_id, insurer_id, authorization_number, insurer_amount, copay_amount
+FROM appointment_coverages
+WHERE appointment_id = @old_appointment_id;
+
+-- name: MoveAppointmentPayment :exec
+-- Al reprogramar, el pago pasa al turno nuevo (el original se deja pendiente aparte, para no
+-- contarlo dos veces en la cuenta corriente)
+UPDATE appointments n
+SET payment_status = o.payment_status, payment_method = o.payment_method,
+    payment_proof_url = o.payment_proof_url, payment_confirmed_at = o.payment_confirmed_at,
+    updated_at = NOW()
+FROM appointments o
+WHERE n.id = @new_appointment_id AND o.id = @old_appointment_id
+  AND n.professional_id = @professional_id AND o.professional_id = @professional_id;
+
+-- name: MoveAppointmentClientPayments :exec
+UPDATE client_payments SET appointment_id = @new_appointment_id
+WHERE appointment_id = @old_appointment_id AND professional_id = @professional_id;
+
+-- name: MovePaymentProof :exec
+UPDATE payment_proofs SET appointment_id = @new_appointment_id
+WHERE appointment_id = @old_appointment_id AND professional_id = @professional_id;
+
+-- name: GetPaymentProof :one
+SELECT * FROM payment_proofs
+WHERE appointment_id = @appointment_id AND professional_id = @professional_id
+LIMIT 1;
+
+-- name: UpsertPaymentProof :exec
+-- Si el paciente vuelve a subirlo, reemplaza al anterior
+INSERT INTO payment_proofs (appointment_id, professional_id, client_id, filename, content_type, size_bytes, storage_key)
+VALUES (@appointment_id, @professional_id, @client_id, @filename, @content_type, @size_bytes, @storage_key)
+ON CONFLICT (appointment_id) DO UPDATE
+SET filename = EXCLUDED.filename, content_type = EXCLUDED.content_type,
+    size_bytes = EXCLUDED.size_bytes, storage_key = EXCLUDED.storage_key, created_at = NOW();
+
+-- name: SetAppointmentPaymentProof :exec
+UPDATE appointments SET payment_proof_url = @payment_proof_url, updated_at = NOW()
+WHERE id = @id AND professional_id = @professional_id;
+
+-- name: CreateClientForm :one
+INSERT INTO client_forms (professional_id, client_id, title, sections)
+VALUES (@professional_id, @client_id, @title, @sections)
+RETURNING *;
+
+-- name: ListClientForms :many
+SELECT * FROM client_forms
+WHERE professional_id = @professional_id AND client_id = @client_id
+ORDER BY created_at DESC, id DESC;
+
+-- name: GetClientForm :one
+SELECT * FROM client_forms
+WHERE id = @id AND professional_id = @professional_id AND client_id = @client_id
+LIMIT 1;
+
+-- name: CompleteClientForm :execrows
+-- Una sola vez: las respuestas ya enviadas no se pisan
+UPDATE client_forms
+SET answers = @answers, key_version = @key_version, completed_at = NOW()
+WHERE id = @id AND professional_id = @professional_id AND client_id = @client_id
+  AND completed_at IS NULL;
+
+-- name: ListClientFormsForRotation :many
+SELECT * FROM client_forms
+WHERE id > @after_id
+  AND answers IS NOT NULL
+  AND key_version IS DISTINCT FROM @key_version::int
+ORDER BY id
+LIMIT @batch_size;
+
+-- name: RewrapClientFormAnswers :execrows
+UPDATE client_forms
+SET answers = @answers, key_version = @new_key_version
+WHERE id = @id AND key_version IS NOT DISTINCT FROM @old_key_version AND answers = @old_answers;
+
+
+-- SECTION: Página Pública (reserva de turnos sin cuenta)
+
+-- name: ListBusySlots :many
+-- Lo que ocupa la agenda en el rango (incluye los pedidos sin aprobar)
+SELECT id, date, start_time, duration_minutes
+FROM appointments
+WHERE professional_id = @professional_id
+  AND date >= @start_date::date AND date <= @end_date::date
+  AND status NOT IN ('cancelled', 'rescheduled')
+ORDER BY date, start_time;
+
+-- name: FindClientByEmail :one
+-- La ficha activa del profesional con ese email, si ya es paciente
+SELECT * FROM clients
+WHERE professional_id = @professional_id AND lower(email) = lower(@email) AND active = TRUE
+ORDER BY id
+LIMIT 1;
+
+-- name: LockBookingRequester :exec
+-- Serializa los pedidos de una misma IP o email hasta el fin de la transacción, así el conteo
+-- de abajo y el alta no se pisan. Usa la variante de dos claves para no chocar con LockAuditChain
+SELECT pg_advisory_xact_lock(hashtext('booking_requests'), hashtext(@requester::text));
+
+-- name: CountBookingRequestsByIP :one
+SELECT COUNT(*) FROM booking_requests
+WHERE ip = @ip AND created_at > @since;
+
+-- name: CountBookingRequestsByEmail :one
+SELECT COUNT(*) FROM booking_requests
+WHERE professional_id = @professional_id AND email = @email AND created_at > @since;
+
+-- name: CountRequestedAppointments :one
+-- Pedidos del paciente que el profesional todavía no respondió
+SELECT COUNT(*) FROM appointments
+WHERE professional_id = @professional_id AND client_id = @client_id
+  AND status = 'requested' AND date >= CURRENT_DATE;
+
+-- name: CreateBookingAppointment :one
+INSERT INTO appointments (
+    professional_id, client_id, date, start_time, duration_minutes,
+    price, concept, status, payment_status
+)
+VALUES (
+    @professional_id, @client_id, @date, @start_time, @duration_minutes,
+    @price, 'Sesión de Terapia', @status, 'pending'
+)
+RETURNING *;
+
+-- name: CreateBookingRequest :exec
+INSERT INTO booking_requests (professional_id, client_id, appointment_id, email, ip)
+VALUES (@professional_id, @client_id, @appointment_id, @email, @ip);
+
+
+-- SECTION: Cuenta Corriente (Pagos, Cargos y Saldos)
+
+-- name: CreateClientPayment :one
+INSERT INTO client_payments (
+    professional_id, client_id, appointment_id, kind, amount, method, paid_at, notes
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: ListClientPayments :many
+SELECT * FROM client_payments
+WHERE professional_id = $1 AND client_id = $2
+ORDER BY paid_at DESC, id DESC;
+
+-- name: GetImplicitAppointmentPayment :one
+-- El pago que la vista client_ledger deduce de payment_status = 'paid' cuando el turno
+-- todavía no tiene movimientos en client_payments (mismo importe y fecha que la vista)
+SELECT COALESCE(ac.copay_amount, a.price, 0)::DECIMAL as amount,
+       COALESCE(a.payment_confirmed_at::DATE, a.date)::DATE as paid_at
+FROM appointments a
+LEFT JOIN appointment_coverages ac ON ac.appointment_id = a.id
+WHERE a.id = @id AND a.professional_id = @professional_id
+  AND a.payment_status = 'paid'
+  AND NOT EXISTS (SELECT 1 FROM client_payments p WHERE p.appointment_id = a.id)
+  AND NOT EXISTS (SELECT 1 FROM package_consumptions pc WHERE pc.appointment_id = a.id);
+
+-- name: CreateClientFee :one
+INSERT INTO client_fees (
+    professional_id, client_id, appointment_id, kind, amount, date, description
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: ListClientLedger :many
+-- Movimientos de la cuenta corriente hasta end_date (el saldo inicial del período se calcula en backend)
+SELECT * FROM client_ledger
+WHERE professional_id = @professional_id
+  AND client_id = @client_id
+  AND entry_date <= @end_date::date
+ORDER BY entry_date, entry_type, source_id;
+
+-- name: ListClientBalances :many
+-- Saldos pendientes por paciente (positivo = el paciente adeuda)
+SELECT c.id as client_id, c.name as client_name,
+       SUM(l.debit - l.credit)::DECIMAL as balance
+FROM clients c
+JOIN client_ledger l ON l.client_id = c.id AND l.professional_id = c.professional_id
+WHERE c.professional_id = $1
+GROUP BY c.id, c.name
+HAVING SUM(l.debit - l.credit) <> 0
+ORDER BY balance DESC;
+
+
+-- SECTION: Paquetes de Sesiones
+
+-- name: CreateSessionPackage :one
+INSERT INTO session_packages (
+    professional_id, client_id, name, total_sessions, price,
+    valid_from, valid_until, payment_status, payment_method, paid_at
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING *;
+
+-- name: ListSessionPackages :many
+SELECT sp.*, c.name as client_name,
+       (sp.total_sessions - sp.used_sessions)::INTEGER as remaining_sessions
+FROM session_packages sp
+JOIN clients c ON sp.client_id = c.id
+WHERE sp.professional_id = @professional_id
+  AND (sqlc.narg('client_id')::bigint IS NULL OR sp.client_id = sqlc.narg('client_id')::bigint)
+ORDER BY sp.valid_until DESC, sp.id DESC;
+
+-- name: GetSessionPackage :one
+SELECT * FROM session_packages WHERE id = $1 AND professional_id = $2 LIMIT 1;
+
+-- name: FindPackageForAppointment :one
+-- Paquete vigente con sesiones disponibles para la fecha del turno (se consume el que vence antes)
+SELECT * FROM session_packages
+WHERE professional_id = @professional_id
+  AND client_id = @client_id
+  AND valid_from <= @date::date
+  AND valid_until >= @date::date
+  AND used_sessions < total_sessions
+ORDER BY valid_until, id
+LIMIT 1
+FOR UPDATE;
+
+-- name: CreatePackageConsumption :exec
+INSERT INTO package_consumptions (appointment_id, package_id)
+VALUES ($1, $2);
+
+-- name: GetPackageConsumption :one
+SELECT * FROM package_consumptions WHERE appointment_id = $1 LIMIT 1;
+
+-- name: DeletePackageConsumption :exec
+DELETE FROM package_consumptions WHERE appointment_id = $1;
+
+-- name: AddPackageUsage :exec
+-- delta = +1 al consumir una sesión, -1 al liberarla
+UPDATE session_packages
+SET used_sessions = used_sessions + @delta::INTEGER
+WHERE id = @id AND professional_id = @professional_id;
+
+-- name: UpdateSessionPackagePayment :one
+-- Volver a marcarlo pagado conserva la fecha del pago original
+UPDATE session_packages
+SET payment_status = $1, payment_method = $2,
+    paid_at = CASE WHEN $1 = 'paid' THEN COALESCE(paid_at, NOW()) ELSE NULL END
+WHERE id = $3 AND professional_id = $4
+RETURNING *;
+
+
+-- SECTION: Obras Sociales / Prepagas
+
+-- name: CreateInsurer :one
+INSERT INTO insurers (professional_id, name, kind, code, session_fee, requires_authorization, active)
+VALUES ($1, $2, $3, $4, $5, $6, TRUE)
+RETURNING *;
+
+-- name: UpdateInsurer :one
+UPDATE insurers
+SET name = $1, kind = $2, code = $3, session_fee = $4, requires_authorization = $5, active = $6
+WHERE id = $7 AND professional_id = $8
+RETURNING *;
+
+-- name: ListInsurers :many
+SELECT * FROM insurers
+WHERE professional_id = $1
+ORDER BY name;
+
+-- name: GetInsurer :one
+SELECT * FROM insurers WHERE id = $1 AND professional_id = $2 LIMIT 1;
+
+-- name: CreateClientCoverage :one
+INSERT INTO client_coverages (professional_id, client_id, insurer_id, plan, member_number, copay_amount, active)
+VALUES ($1, $2, $3, $4, $5, $6, TRUE)
+RETURNING *;
+
+-- name: ListClientCoverages :many
+SELECT cc.*, i.name as insurer_name
+FROM client_coverages cc
+JOIN insurers i ON cc.insurer_id = i.id
+WHERE cc.professional_id = $1 AND cc.client_id = $2
+ORDER BY cc.active DESC, cc.created_at DESC;
+
+-- name: GetClientCoverage :one
+SELECT * FROM client_coverages WHERE id = $1 AND professional_id = $2 LIMIT 1;
+
+-- name: UpsertAppointmentCoverage :one
+INSERT INTO appointment_coverages (
+    appointment_id, coverage_id, insurer_id, authorization_number, insurer_amount, copay_amount
+)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (appointment_id) DO UPDATE SET
+    coverage_id = EXCLUDED.coverage_id,
+    insurer_id = EXCLUDED.insurer_id,
+    authorization_number = EXCLUDED.authorization_number,
+    insurer_amount = EXCLUDED.insurer_amount,
+    copay_amount = EXCLUDED.copay_amount
+RETURNING *;
+
+-- name: SetAppointmentInsuranceBilling :one
+-- El precio del turno pasa a ser arancel de la obra social + coseguro
+UPDATE appointments
+SET price = $1, payment_method = 'insurance', updated_at = NOW()
+WHERE id = $2 AND professional_id = $3
+RETURNING *;
+
+-- name: GetInsurerPresentation :many
+-- Presentación mensual: sesiones realizadas con cobertura de la obra social en el período
+SELECT a.id as appointment_id, a.date, a.start_time, a.concept,
+       c.name as client_name, cc.plan, cc.member_number,
+       ac.authorization_number, ac.insurer_amount, ac.copay_amount
+FROM appointment_coverages ac
+JOIN appointments a ON ac.appointment_id = a.id
+JOIN clients c ON a.client_id = c.id
+JOIN client_coverages cc ON ac.coverage_id = cc.id
+WHERE a.professional_id = @professional_id
+  AND ac.insurer_id = @insurer_id
+  AND a.status = 'completed'
+  AND a.date >= @start_date::date
+  AND a.date <= @end_date::date
+ORDER BY c.name, a.date, a.start_time;
+
+
+-- SECTION: Lista de Precios e Índices
+
+-- name: CreatePriceListEntry :one
+INSERT INTO price_list_entries (professional_id, modality, duration_minutes, price, effective_from, index_series)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (professional_id, modality, duration_minutes, effective_from) DO UPDATE SET
+    price = EXCLUDED.price,
+    index_series = EXCLUDED.index_series,
+    applied_at = NULL
+RETURNING *;
+
+-- name: ListPriceListEntries :many
+SELECT * FROM price_list_entries
+WHERE professional_id = $1
+ORDER BY modality, duration_minutes, effective_from DESC;
+
+-- name: GetPriceListEntry :one
+SELECT * FROM price_list_entries WHERE id = $1 AND professional_id = $2 LIMIT 1;
+
+-- name: GetCurrentPriceList :many
+-- Precio vigente a una fecha para cada combinación modalidad/duración
+SELECT DISTINCT ON (modality, duration_minutes) *
+FROM price_list_entries
+WHERE professional_id = @professional_id
+  AND effective_from <= @date::date
+ORDER BY modality, duration_minutes, effective_from DESC;
+
+-- name: GetPriceFor :one
+SELECT * FROM price_list_entries
+WHERE professional_id = @professional_id
+  AND modality = @modality
+  AND duration_minutes = @duration_minutes
+  AND effective_from <= @date::date
+ORDER BY effective_from DESC
+LIMIT 1;
+
+-- name: ListPendingPriceUpdates :many
+-- Precios que ya entraron en vigencia y todavía no se propagaron
+SELECT * FROM price_list_entries
+WHERE applied_at IS NULL AND effective_from <= CURRENT_DATE
+ORDER BY effective_from, id;
+
+-- name: MarkPriceListEntryApplied :exec
+UPDATE price_list_entries SET applied_at = NOW() WHERE id = $1;
+
+-- name: UpdateFutureAppointmentPrices :execrows
+-- Turnos futuros impagos de esa modalidad/duración (no toca paquetes ni obras sociales), desde
+-- hoy o desde que rige el precio, y sólo hasta que entra en vigencia el siguiente: un precio
+-- cargado con fecha pasada no pisa al que lo reemplazó
+UPDATE appointments a
+SET price = @price, updated_at = NOW()
+WHERE a.professional_id = @professional_id
+  AND COALESCE(a.modality, 'virtual') = @modality::text
+  AND a.duration_minutes = @duration_minutes
+  AND a.date >= GREATEST(@effective_from::date, CURRENT_DATE)
+  AND a.status = 'scheduled'
+  AND a.payment_status = 'pending'
+  AND NOT EXISTS (SELECT 1 FROM package_consumptions pc WHERE pc.appointment_id = a.id)
+  AND NOT EXISTS (SELECT 1 FROM appointment_coverages ac WHERE ac.appointment_id = a.id)
+  AND NOT EXISTS (
+      SELECT 1 FROM price_list_entries n
+      WHERE n.professional_id = a.professional_id
+        AND n.modality = @modality::text
+        AND n.duration_minutes = a.duration_minutes
+        AND n.effective_from > @effective_from::date
+        AND n.effective_from <= a.date
+  );
+
+-- name: UpdateActiveRecurringRulePrices :execrows
+-- Sólo si es el precio que rige hoy: si ya entró en vigencia uno más nuevo, las reglas quedan con ése
+UPDATE recurring_rules r
+SET price = @price
+WHERE r.professional_id = @professional_id
+  AND COALESCE(r.modality, 'virtual') = @modality::text
+  AND r.duration_minutes = @duration_minutes
+  AND r.active = TRUE
+  AND NOT EXISTS (
+      SELECT 1 FROM price_list_entries n
+      WHERE n.professional_id = r.professional_id
+        AND n.modality = @modality::text
+        AND n.duration_minutes = r.duration_minutes
+        AND n.effective_from > @effective_from::date
+        AND n.effective_from <= CURRENT_DATE
+  );
+
+-- name: UpsertPriceIndex :exec
+INSERT INTO price_indexes (series, period, value)
+VALUES ($1, $2, $3)
+ON CONFLICT (series, period) DO UPDATE SET value = EXCLUDED.value;
+
+-- name: GetPriceIndex :one
+SELECT * FROM price_indexes
+WHERE series = $1 AND period = $2
+LIMIT 1;
+
+-- name: GetLatestPriceIndex :one
+SELECT * FROM price_indexes
+WHERE series = $1
+ORDER BY period DESC
+LIMIT 1;
 
 
 -- SECTION: Clinical Notes (NUEVO - Privacidad)
@@ -100,40 +913,309 @@ SELECT * FROM clients WHERE id = $1 LIMIT 1;
 -- name: CreateClinicalNote :one
 INSERT INTO clinical_notes (
     professional_id, client_id, appointment_id, 
-    type, content, key_version, status, signed_at
+    type, content, key_version, status, signed_at,
+    template_id, sections
 )
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 RETURNING *;
 
 -- name: ListClinicalNotes :many
+-- type opcional: 'clinical' o 'personal' (NULL = ambas)
 SELECT * FROM clinical_notes
-WHERE client_id = $1 AND professional_id = $2
+WHERE client_id = @client_id AND professional_id = @professional_id
+  AND (sqlc.narg('type')::text IS NULL OR type = sqlc.narg('type')::text)
 ORDER BY created_at DESC;
 
 -- name: GetNoteById :one
-SELECT * FROM clinical_notes WHERE id = $1 LIMIT 1;
+SELECT * FROM clinical_notes WHERE id = $1 AND professional_id = $2 LIMIT 1;
 
 -- name: UpdateClinicalNote :one
 -- Solo permite editar si NO está firmada (controlar esto en backend también)
 UPDATE clinical_notes
-SET content = $1, updated_at = NOW()
-WHERE id = $2 AND status = 'draft'
+SET content = $1, sections = $2, key_version = $3, updated_at = NOW()
+WHERE id = $4 AND professional_id = $5 AND status = 'draft'
 RETURNING *;
 
 -- name: SignClinicalNote :one
 -- "Firma" la nota: cambia estado a signed y pone fecha
 UPDATE clinical_notes
 SET status = 'signed', signed_at = NOW(), updated_at = NOW()
-WHERE id = $1
+WHERE id = $1 AND professional_id = $2 AND status = 'draft'
 RETURNING *;
 
+-- name: DeleteClinicalNote :execrows
+-- Sólo las notas personales: las clínicas integran la historia clínica y se conservan
+DELETE FROM clinical_notes
+WHERE id = @id AND professional_id = @professional_id AND type = 'personal';
+
+-- name: DeleteNoteAddenda :exec
+DELETE FROM clinical_note_addenda WHERE note_id = @note_id;
+
+-- name: DeleteNoteRevisions :exec
+DELETE FROM clinical_note_revisions WHERE note_id = @note_id;
+
+-- name: DeleteNoteAttachments :exec
+DELETE FROM attachments WHERE note_id = @note_id;
+
 -- name: GetDraftNotes :many
--- Para el dashboard de "Notas Pendientes"
-SELECT n.*, c.name as client_name 
+-- Para el dashboard de "Notas Pendientes": borradores clínicos sin firmar creados antes del corte
+SELECT n.id, n.client_id, n.appointment_id, n.template_id, n.created_at, n.updated_at,
+       c.name AS client_name
 FROM clinical_notes n
 JOIN clients c ON n.client_id = c.id
-WHERE n.professional_id = $1 AND n.status = 'draft'
-ORDER BY n.created_at DESC;
+WHERE n.professional_id = @professional_id AND n.status = 'draft' AND n.type = 'clinical'
+  AND n.created_at < @created_before::timestamptz
+ORDER BY n.created_at;
+
+-- name: ListAppointmentsWithoutNote :many
+-- Sesiones realizadas que todavía no tienen ninguna nota clínica asociada
+SELECT a.id, a.client_id, a.date, a.start_time, a.duration_minutes, a.modality,
+       c.name AS client_name
+FROM appointments a
+JOIN clients c ON a.client_id = c.id
+WHERE a.professional_id = @professional_id AND a.status = 'completed'
+  AND a.date BETWEEN @from_date::date AND @to_date::date
+  AND NOT EXISTS (SELECT 1 FROM clinical_notes n WHERE n.appointment_id = a.id AND n.type = 'clinical')
+ORDER BY a.date, a.start_time;
+
+-- name: ListClinicalNotesForRotation :many
+-- Notas con clave maestra vieja o todavía en claro (previas al cifrado), por lotes
+SELECT * FROM clinical_notes
+WHERE id > @after_id
+  AND (key_version IS DISTINCT FROM @key_version::int OR content NOT LIKE 'enc:%')
+ORDER BY id
+LIMIT @batch_size;
+
+-- name: RewrapClinicalNote :execrows
+-- No toca updated_at: rotar claves no es editar la nota. Sólo si el contenido sigue siendo el
+-- leído: una nota en claro editada mientras tanto conserva la versión y no se pisa
+UPDATE clinical_notes
+SET content = @content, sections = @sections, key_version = @new_key_version
+WHERE id = @id AND key_version IS NOT DISTINCT FROM @old_key_version
+  AND content = @old_content AND sections IS NOT DISTINCT FROM @old_sections;
+
+-- name: GetNoteForUpdate :one
+SELECT * FROM clinical_notes WHERE id = $1 AND professional_id = $2 LIMIT 1 FOR UPDATE;
+
+-- name: CreateNoteRevision :one
+INSERT INTO clinical_note_revisions (note_id, revision_number, author_id, content, sections, key_version, written_at)
+VALUES (
+    @note_id,
+    (SELECT COALESCE(MAX(r.revision_number), 0) + 1 FROM clinical_note_revisions r WHERE r.note_id = @note_id),
+    @author_id, @content, @sections, @key_version, @written_at
+)
+RETURNING *;
+
+-- name: ListNoteRevisions :many
+-- Sólo metadatos: el contenido se pide revisión por revisión
+SELECT id, note_id, revision_number, author_id, key_version, written_at, created_at
+FROM clinical_note_revisions
+WHERE note_id = $1
+ORDER BY revision_number;
+
+-- name: GetNoteRevision :one
+SELECT * FROM clinical_note_revisions
+WHERE note_id = $1 AND revision_number = $2
+LIMIT 1;
+
+-- name: ListNoteRevisionsForRotation :many
+SELECT * FROM clinical_note_revisions
+WHERE id > @after_id
+  AND (key_version IS DISTINCT FROM @key_version::int OR content NOT LIKE 'enc:%')
+ORDER BY id
+LIMIT @batch_size;
+
+-- name: RewrapNoteRevision :execrows
+UPDATE clinical_note_revisions
+SET content = @content, sections = @sections, key_version = @new_key_version
+WHERE id = @id AND key_version IS NOT DISTINCT FROM @old_key_version
+  AND content = @old_content AND sections IS NOT DISTINCT FROM @old_sections;
+
+-- name: CreateNoteAddendum :one
+INSERT INTO clinical_note_addenda (note_id, professional_id, content, key_version)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: ListNoteAddenda :many
+SELECT * FROM clinical_note_addenda
+WHERE note_id = $1
+ORDER BY signed_at, id;
+
+-- name: ListNoteAddendaForRotation :many
+SELECT * FROM clinical_note_addenda
+WHERE id > @after_id
+  AND (key_version IS DISTINCT FROM @key_version::int OR content NOT LIKE 'enc:%')
+ORDER BY id
+LIMIT @batch_size;
+
+-- name: RewrapNoteAddendum :execrows
+UPDATE clinical_note_addenda
+SET content = @content, key_version = @new_key_version
+WHERE id = @id AND key_version IS NOT DISTINCT FROM @old_key_version AND content = @old_content;
+
+
+-- SECTION: Búsqueda en Notas (índice ciego)
+
+-- name: AddNoteSearchTokens :exec
+INSERT INTO clinical_note_search_tokens (note_id, token)
+SELECT @note_id, unnest(@tokens::text[])
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteNoteSearchTokens :exec
+DELETE FROM clinical_note_search_tokens WHERE note_id = $1;
+
+-- name: SearchClinicalNotes :many
+-- Notas del profesional que contienen todos los tokens buscados
+SELECT n.* FROM clinical_notes n
+WHERE n.professional_id = @professional_id
+  AND (sqlc.narg('client_id')::bigint IS NULL OR n.client_id = sqlc.narg('client_id')::bigint)
+  AND n.id IN (
+      SELECT t.note_id FROM clinical_note_search_tokens t
+      WHERE t.token = ANY(@tokens::text[])
+      GROUP BY t.note_id
+      HAVING COUNT(DISTINCT t.token) = @token_count::int
+  )
+ORDER BY n.created_at DESC
+LIMIT @max_results;
+
+-- name: ListNotesForIndexing :many
+SELECT * FROM clinical_notes
+WHERE id > @after_id
+ORDER BY id
+LIMIT @batch_size;
+
+
+-- SECTION: Adjuntos
+
+-- name: CreateAttachment :one
+INSERT INTO attachments (
+    professional_id, client_id, note_id, filename, content_type,
+    size_bytes, sha256, storage_key, data_key, key_version
+) VALUES (
+    @professional_id, @client_id, @note_id, @filename, @content_type,
+    @size_bytes, @sha256, @storage_key, @data_key, @key_version
+)
+RETURNING *;
+
+-- name: GetAttachment :one
+SELECT * FROM attachments WHERE id = $1 AND professional_id = $2 LIMIT 1;
+
+-- name: ListAttachments :many
+-- Adjuntos de un paciente (todos, incluidos los de sus notas) o de una nota puntual
+SELECT * FROM attachments
+WHERE professional_id = @professional_id
+  AND (sqlc.narg('client_id')::bigint IS NULL OR client_id = sqlc.narg('client_id')::bigint)
+  AND (sqlc.narg('note_id')::bigint IS NULL OR note_id = sqlc.narg('note_id')::bigint)
+ORDER BY created_at DESC;
+
+-- name: DeleteAttachment :exec
+DELETE FROM attachments WHERE id = $1 AND professional_id = $2;
+
+-- name: ListAttachmentsForRotation :many
+SELECT * FROM attachments
+WHERE id > @after_id
+  AND key_version IS DISTINCT FROM @key_version::int
+ORDER BY id
+LIMIT @batch_size;
+
+-- name: RewrapAttachmentKey :execrows
+UPDATE attachments
+SET data_key = @data_key, key_version = @new_key_version
+WHERE id = @id AND key_version IS NOT DISTINCT FROM @old_key_version AND data_key = @old_data_key;
+
+
+-- SECTION: Clinical Record Export
+
+-- name: ListSignedNotesForRecord :many
+-- Notas que integran la historia clínica: sólo firmadas y de tipo clínico
+SELECT * FROM clinical_notes
+WHERE professional_id = @professional_id AND client_id = @client_id
+  AND status = 'signed' AND type = 'clinical'
+ORDER BY signed_at, id;
+
+-- name: ListClientSessions :many
+SELECT id, date, start_time, duration_minutes, modality, status
+FROM appointments
+WHERE professional_id = @professional_id AND client_id = @client_id AND status = 'completed'
+ORDER BY date, start_time;
+
+-- name: CreateClinicalRecordExport :one
+INSERT INTO clinical_record_exports (
+    professional_id, client_id, purpose, recipient, note_count, document_sha256
+) VALUES (
+    @professional_id, @client_id, @purpose, @recipient, @note_count, @document_sha256
+)
+RETURNING *;
+
+-- name: ListClinicalRecordExports :many
+SELECT * FROM clinical_record_exports
+WHERE professional_id = @professional_id AND client_id = @client_id
+ORDER BY created_at DESC;
+
+
+-- SECTION: Auditoría
+
+-- name: LockAuditChain :exec
+-- Serializa las altas de un mismo profesional para que dos requests no encadenen sobre el mismo hash
+SELECT pg_advisory_xact_lock(@professional_id::bigint);
+
+-- name: GetLastAuditHash :one
+SELECT hash FROM audit_log
+WHERE professional_id = @professional_id
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: CreateAuditEntry :one
+INSERT INTO audit_log (
+    professional_id, assistant_id, client_id, action, resource_type, resource_id,
+    route, status, ip, user_agent, created_at, prev_hash, hash
+) VALUES (
+    @professional_id, @assistant_id, @client_id, @action, @resource_type, @resource_id,
+    @route, @status, @ip, @user_agent, @created_at, @prev_hash, @hash
+)
+RETURNING *;
+
+-- name: ListAuditEntries :many
+-- Más recientes primero. Filtros opcionales (NULL = sin filtro) y paginación por cursor sobre id
+SELECT * FROM audit_log
+WHERE professional_id = @professional_id
+  AND (sqlc.narg('start_at')::timestamptz IS NULL OR created_at >= sqlc.narg('start_at')::timestamptz)
+  AND (sqlc.narg('end_at')::timestamptz IS NULL OR created_at < sqlc.narg('end_at')::timestamptz)
+  AND (sqlc.narg('action')::text IS NULL OR action = sqlc.narg('action')::text)
+  AND (sqlc.narg('resource_type')::text IS NULL OR resource_type = sqlc.narg('resource_type')::text)
+  AND (sqlc.narg('resource_id')::bigint IS NULL OR resource_id = sqlc.narg('resource_id')::bigint)
+  AND (sqlc.narg('assistant_id')::bigint IS NULL OR assistant_id = sqlc.narg('assistant_id')::bigint)
+  AND (sqlc.narg('cursor_id')::bigint IS NULL OR id < sqlc.narg('cursor_id')::bigint)
+ORDER BY id DESC
+LIMIT @page_size;
+
+-- name: ListAuditChain :many
+-- Recorre la cadena en orden para verificarla, de a tandas
+SELECT * FROM audit_log
+WHERE professional_id = @professional_id AND id > @after_id
+ORDER BY id
+LIMIT @batch_size;
+
+-- SECTION: Note Templates
+
+-- name: CreateNoteTemplate :one
+INSERT INTO note_templates (professional_id, name, kind, sections)
+VALUES (@professional_id, @name, @kind, @sections::text::jsonb)
+RETURNING *;
+
+-- name: UpdateNoteTemplate :one
+UPDATE note_templates
+SET name = @name, kind = @kind, sections = @sections::text::jsonb, active = @active, updated_at = NOW()
+WHERE id = @id AND professional_id = @professional_id
+RETURNING *;
+
+-- name: ListNoteTemplates :many
+SELECT * FROM note_templates
+WHERE professional_id = $1
+ORDER BY active DESC, name;
+
+-- name: GetNoteTemplate :one
+SELECT * FROM note_templates WHERE id = $1 AND professional_id = $2 LIMIT 1;
 
 
 -- SECTION: Schedule Configuration
@@ -179,7 +1261,7 @@ WHERE r.professional_id = $1
 -- name: ToggleRecurringRule :one
 UPDATE recurring_rules
 SET active = $1
-WHERE id = $2
+WHERE id = $2 AND professional_id = $3
 RETURNING *;
 
 
@@ -215,18 +1297,23 @@ SELECT id, start_time, duration_minutes, status
 FROM appointments
 WHERE professional_id = $1 
   AND date = $2::date 
-  AND status != 'cancelled';
+  AND status NOT IN ('cancelled', 'rescheduled');
 
 -- name: GetAppointment :one
 SELECT a.*, c.name as client_name, c.email as client_email
 FROM appointments a
 JOIN clients c ON a.client_id = c.id
-WHERE a.id = $1 LIMIT 1;
+WHERE a.id = $1 AND a.professional_id = $2 LIMIT 1;
+
+-- name: GetAppointmentForUpdate :one
+-- Bloquea el turno hasta el fin de la transacción: dos cambios de estado simultáneos no pueden
+-- consumir (o devolver) dos veces la sesión del paquete
+SELECT * FROM appointments WHERE id = $1 AND professional_id = $2 LIMIT 1 FOR UPDATE;
 
 -- name: UpdateAppointmentStatus :one
 UPDATE appointments
 SET status = $1, updated_at = NOW()
-WHERE id = $2
+WHERE id = $2 AND professional_id = $3
 RETURNING *;
 
 -- name: UpdateAppointmentPayment :one
@@ -235,38 +1322,82 @@ UPDATE appointments
 SET payment_status = $1, payment_method = $2, payment_proof_url = $3, 
     payment_confirmed_at = CASE WHEN $1 = 'paid' THEN NOW() ELSE NULL END,
     updated_at = NOW()
-WHERE id = $4
+WHERE id = $4 AND professional_id = $5
 RETURNING *;
 
 -- name: UpdateAppointmentInvoice :one
 -- Cuando se genera la factura en AFIP
 UPDATE appointments
 SET invoice_status = $1, invoice_url = $2, invoice_cae = $3, updated_at = NOW()
-WHERE id = $4
+WHERE id = $4 AND professional_id = $5
 RETURNING *;
 
 -- name: GetFinancesDashboard :many
 -- Query para la pantalla de "Finanzas" (Tabla principal)
--- Trae todos los turnos que no estén cancelados, ordenados por fecha desc
-SELECT a.id, a.date, a.concept, a.price, 
-       a.payment_status, a.payment_method, a.payment_proof_url,
-       a.invoice_status, a.invoice_url,
+-- Filtros opcionales (NULL = sin filtro) y paginación por cursor sobre (date, id) desc
+SELECT a.id, a.date, a.start_time, a.client_id, a.concept, a.price,
+       a.payment_status, a.payment_method, a.payment_proof_url, a.payment_confirmed_at,
+       a.invoice_status, a.invoice_url, a.invoice_cae, a.package_id,
        c.name as client_name
-FROM appointments a
+FROM appointment_finances a
 JOIN clients c ON a.client_id = c.id
-WHERE a.professional_id = $1 
+WHERE a.professional_id = @professional_id
   AND a.status != 'cancelled'
-ORDER BY a.date DESC
-LIMIT $2 OFFSET $3;
+  AND (sqlc.narg('start_date')::date IS NULL OR a.date >= sqlc.narg('start_date')::date)
+  AND (sqlc.narg('end_date')::date IS NULL OR a.date <= sqlc.narg('end_date')::date)
+  AND (sqlc.narg('client_id')::bigint IS NULL OR a.client_id = sqlc.narg('client_id')::bigint)
+  AND (sqlc.narg('payment_status')::text IS NULL OR a.payment_status = sqlc.narg('payment_status')::text)
+  AND (sqlc.narg('payment_method')::text IS NULL OR a.payment_method = sqlc.narg('payment_method')::text)
+  AND (sqlc.narg('invoice_status')::text IS NULL OR a.invoice_status = sqlc.narg('invoice_status')::text)
+  AND (sqlc.narg('cursor_date')::date IS NULL OR (a.date, a.id) < (sqlc.narg('cursor_date')::date, sqlc.narg('cursor_id')::bigint))
+ORDER BY a.date DESC, a.id DESC
+LIMIT @page_size;
+
+-- name: GetFinancesTotals :one
+-- Totales del listado de Finanzas para los mismos filtros (sin paginar)
+SELECT
+    COUNT(*)::BIGINT as sessions_count,
+    COALESCE(SUM(a.price), 0)::DECIMAL as total_amount,
+    COALESCE(SUM(CASE WHEN a.payment_status = 'paid' THEN a.price ELSE 0 END), 0)::DECIMAL as paid_amount,
+    COALESCE(SUM(CASE WHEN a.payment_status = 'pending' THEN a.price ELSE 0 END), 0)::DECIMAL as pending_amount,
+    COALESCE(SUM(CASE WHEN a.payment_status = 'refunded' THEN a.price ELSE 0 END), 0)::DECIMAL as refunded_amount
+FROM appointment_finances a
+WHERE a.professional_id = @professional_id
+  AND a.status != 'cancelled'
+  AND (sqlc.narg('start_date')::date IS NULL OR a.date >= sqlc.narg('start_date')::date)
+  AND (sqlc.narg('end_date')::date IS NULL OR a.date <= sqlc.narg('end_date')::date)
+  AND (sqlc.narg('client_id')::bigint IS NULL OR a.client_id = sqlc.narg('client_id')::bigint)
+  AND (sqlc.narg('payment_status')::text IS NULL OR a.payment_status = sqlc.narg('payment_status')::text)
+  AND (sqlc.narg('payment_method')::text IS NULL OR a.payment_method = sqlc.narg('payment_method')::text)
+  AND (sqlc.narg('invoice_status')::text IS NULL OR a.invoice_status = sqlc.narg('invoice_status')::text);
 
 -- name: GetFinancialSummary :one
--- KPIs de Finanzas: Sumas rápidas para las tarjetas de arriba
+-- KPIs de Finanzas: Sumas rápidas para las tarjetas de arriba, para un período arbitrario
 SELECT 
-    COALESCE(SUM(CASE WHEN date >= DATE_TRUNC('month', CURRENT_DATE) AND payment_status = 'paid' THEN price ELSE 0 END), 0)::DECIMAL as current_month_income,
+    COUNT(*)::BIGINT as sessions_count,
+    COALESCE(SUM(CASE WHEN payment_status = 'paid' THEN price ELSE 0 END), 0)::DECIMAL as income,
     COALESCE(SUM(CASE WHEN payment_status = 'pending' THEN price ELSE 0 END), 0)::DECIMAL as pending_collection,
-    COALESCE(SUM(CASE WHEN payment_status = 'paid' AND invoice_status = 'pending' THEN price ELSE 0 END), 0)::DECIMAL as pending_invoicing
-FROM appointments
-WHERE professional_id = $1 AND status != 'cancelled';
+    COALESCE(SUM(CASE WHEN payment_status = 'paid' AND invoice_status = 'pending' THEN price ELSE 0 END), 0)::DECIMAL as pending_invoicing,
+    COALESCE(AVG(price), 0)::DECIMAL as average_price
+FROM appointment_finances
+WHERE professional_id = @professional_id AND status != 'cancelled'
+  AND date >= @start_date::date
+  AND date <= @end_date::date;
+
+-- name: ListFinancesForExport :many
+-- Exportación para el contador: todos los turnos no cancelados del rango, en orden cronológico
+-- (los turnos cubiertos por un paquete figuran con el precio proporcional del paquete)
+SELECT a.id, a.date, a.start_time, a.duration_minutes, a.modality, a.concept, a.price,
+       a.payment_status, a.payment_method, a.payment_confirmed_at,
+       a.invoice_status, a.invoice_cae, a.invoice_url,
+       a.package_id, c.name as client_name, c.email as client_email
+FROM appointment_finances a
+JOIN clients c ON a.client_id = c.id
+WHERE a.professional_id = @professional_id
+  AND a.status != 'cancelled'
+  AND a.date >= @start_date::date
+  AND a.date <= @end_date::date
+ORDER BY a.date, a.start_time;
 
 -- name: CheckAppointmentExistsForRule :one
 SELECT EXISTS(
@@ -287,7 +1418,7 @@ SELECT NOT EXISTS(
 -- Actualiza el comentario simple del turno (ej: "paciente llegó tarde")
 UPDATE appointments
 SET notes = $1, updated_at = NOW()
-WHERE id = $2
+WHERE id = $2 AND professional_id = $3
 RETURNING *;
 
 -- name: UpdateRecurringRule :one
@@ -300,5 +1431,5 @@ SET
     modality = $4,
     price = $5,
     active = $6
-WHERE id = $7
+WHERE id = $7 AND professional_id = $8
 RETURNING *;
\ No newline at end of file
@@ -9,8 +9,75 @@ import (
 	"context"
 	"database/sql"
 	"time"
+
+	"github.com/lib/pq"
 )
 
+const addNoteSearchTokens = `-- name: AddNoteSearchTokens :exec
+INSERT INTO clinical_note_search_tokens (note_id, token)
+SELECT $1, unnest($2::text[])
+ON CONFLICT DO NOTHING
+`
+
+type AddNoteSearchTokensParams struct {
+	NoteID int64    `json:"note_id"`
+	Tokens []string `json:"tokens"`
+}
+
+// SECTION: Búsqueda en Notas (índice ciego)
+func (q *Queries) AddNoteSearchTokens(ctx context.Context, arg AddNoteSearchTokensParams) error {
+	_, err := q.db.ExecContext(ctx, addNoteSearchTokens, arg.NoteID, pq.Array(arg.Tokens))
+	return err
+}
+
+const addOrganizationMember = `-- name: AddOrganizationMember :exec
+INSERT INTO organization_members (organization_id, professional_id, role)
+VALUES ($1, $2, $3)
+ON CONFLICT (professional_id) DO UPDATE
+SET organization_id = EXCLUDED.organization_id, role = EXCLUDED.role, created_at = NOW()
+`
+
+type AddOrganizationMemberParams struct {
+	OrganizationID int64  `json:"organization_id"`
+	ProfessionalID int64  `json:"professional_id"`
+	Role           string `json:"role"`
+}
+
+// Si ya pertenecía a otra organización, pasa a ésta
+func (q *Queries) AddOrganizationMember(ctx context.Context, arg AddOrganizationMemberParams) error {
+	_, err := q.db.ExecContext(ctx, addOrganizationMember, arg.OrganizationID, arg.ProfessionalID, arg.Role)
+	return err
+}
+
+const addPackageUsage = `-- name: AddPackageUsage :exec
+UPDATE session_packages
+SET used_sessions = used_sessions + $1::INTEGER
+WHERE id = $2 AND professional_id = $3
+`
+
+type AddPackageUsageParams struct {
+	Delta          int32 `json:"delta"`
+	ID             int64 `json:"id"`
+	ProfessionalID int64 `json:"professional_id"`
+}
+
+// delta = +1 al consumir una sesión, -1 al liberarla
+func (q *Queries) AddPackageUsage(ctx context.Context, arg AddPackageUsageParams) error {
+	_, err := q.db.ExecContext(ctx, addPackageUsage, arg.Delta, arg.ID, arg.ProfessionalID)
+	return err
+}
+
+const assistantHasCredentials = `-- name: AssistantHasCredentials :one
+SELECT EXISTS (SELECT 1 FROM assistant_credentials WHERE assistant_id = $1)
+`
+
+func (q *Queries) AssistantHasCredentials(ctx context.Context, assistantID int64) (bool, error) {
+	row := q.db.QueryRowContext(ctx, assistantHasCredentials, assistantID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
 const checkAppointmentExistsForRule = `-- name: CheckAppointmentExistsForRule :one
 SELECT EXISTS(
     SELECT 1 FROM appointments 
@@ -52,6 +119,192 @@ func (q *Queries) CheckSlugAvailability(ctx context.Context, arg CheckSlugAvaila
 	return available, err
 }
 
+const completeClientForm = `-- name: CompleteClientForm :execrows
+UPDATE client_forms
+SET answers = $1, key_version = $2, completed_at = NOW()
+WHERE id = $3 AND professional_id = $4 AND client_id = $5
+  AND completed_at IS NULL
+`
+
+type CompleteClientFormParams struct {
+	Answers        sql.NullString `json:"answers"`
+	KeyVersion     sql.NullInt32  `json:"key_version"`
+	ID             int64          `json:"id"`
+	ProfessionalID int64          `json:"professional_id"`
+	ClientID       int64          `json:"client_id"`
+}
+
+// Una sola vez: las respuestas ya enviadas no se pisan
+func (q *Queries) CompleteClientForm(ctx context.Context, arg CompleteClientFormParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, completeClientForm,
+		arg.Answers,
+		arg.KeyVersion,
+		arg.ID,
+		arg.ProfessionalID,
+		arg.ClientID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const consumeClientLoginToken = `-- name: ConsumeClientLoginToken :one
+UPDATE client_login_tokens SET used_at = NOW()
+WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+RETURNING client_id
+`
+
+// Marca el enlace como usado en la misma sentencia: dos usos simultáneos no pueden ganar ambos
+func (q *Queries) ConsumeClientLoginToken(ctx context.Context, tokenHash string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, consumeClientLoginToken, tokenHash)
+	var client_id int64
+	err := row.Scan(&client_id)
+	return client_id, err
+}
+
+const consumeOrganizationInvitation = `-- name: ConsumeOrganizationInvitation :one
+UPDATE organization_invitations SET used_at = NOW()
+WHERE token_hash = $1 AND professional_id = $2
+  AND used_at IS NULL AND expires_at > NOW()
+RETURNING id, organization_id, professional_id, invited_by, role, token_hash, expires_at, used_at, created_at
+`
+
+type ConsumeOrganizationInvitationParams struct {
+	TokenHash      string `json:"token_hash"`
+	ProfessionalID int64  `json:"professional_id"`
+}
+
+// Sólo la acepta el invitado, con su sesión, y una sola vez
+func (q *Queries) ConsumeOrganizationInvitation(ctx context.Context, arg ConsumeOrganizationInvitationParams) (OrganizationInvitation, error) {
+	row := q.db.QueryRowContext(ctx, consumeOrganizationInvitation, arg.TokenHash, arg.ProfessionalID)
+	var i OrganizationInvitation
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.ProfessionalID,
+		&i.InvitedBy,
+		&i.Role,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const consumePasswordResetToken = `-- name: ConsumePasswordResetToken :one
+UPDATE password_reset_tokens SET used_at = NOW()
+WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+RETURNING professional_id
+`
+
+// Marca el token como usado en la misma sentencia: dos usos simultáneos no pueden ganar ambos
+func (q *Queries) ConsumePasswordResetToken(ctx context.Context, tokenHash string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, consumePasswordResetToken, tokenHash)
+	var professional_id int64
+	err := row.Scan(&professional_id)
+	return professional_id, err
+}
+
+const copyAppointmentCoverage = `-- name: CopyAppointmentCoverage :exec
+INSERT INTO appointment_coverages (
+    appointment_id, coverage_id, insurer_id, authorization_number, insurer_amount, copay_amount
+)
+SELECT $1, coverage_id, insurer_id, authorization_number, insurer_amount, copay_amount
+FROM appointment_coverages
+WHERE appointment_id = $2
+`
+
+type CopyAppointmentCoverageParams struct {
+	NewAppointmentID int64 `json:"new_appointment_id"`
+	OldAppointmentID int64 `json:"old_appointment_id"`
+}
+
+// Al reprogramar, el turno nuevo conserva la cobertura y los montos del original
+func (q *Queries) CopyAppointmentCoverage(ctx context.Context, arg CopyAppointmentCoverageParams) error {
+	_, err := q.db.ExecContext(ctx, copyAppointmentCoverage, arg.NewAppointmentID, arg.OldAppointmentID)
+	return err
+}
+
+const countBookingRequestsByEmail = `-- name: CountBookingRequestsByEmail :one
+SELECT COUNT(*) FROM booking_requests
+WHERE professional_id = $1 AND email = $2 AND created_at > $3
+`
+
+type CountBookingRequestsByEmailParams struct {
+	ProfessionalID int64     `json:"professional_id"`
+	Email          string    `json:"email"`
+	Since          time.Time `json:"since"`
+}
+
+func (q *Queries) CountBookingRequestsByEmail(ctx context.Context, arg CountBookingRequestsByEmailParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countBookingRequestsByEmail, arg.ProfessionalID, arg.Email, arg.Since)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countBookingRequestsByIP = `-- name: CountBookingRequestsByIP :one
+SELECT COUNT(*) FROM booking_requests
+WHERE ip = $1 AND created_at > $2
+`
+
+type CountBookingRequestsByIPParams struct {
+	Ip    string    `json:"ip"`
+	Since time.Time `json:"since"`
+}
+
+func (q *Queries) CountBookingRequestsByIP(ctx context.Context, arg CountBookingRequestsByIPParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countBookingRequestsByIP, arg.Ip, arg.Since)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countOrganizationAdmins = `-- name: CountOrganizationAdmins :one
+SELECT COUNT(*) FROM organization_members
+WHERE organization_id = $1 AND role = 'admin'
+`
+
+func (q *Queries) CountOrganizationAdmins(ctx context.Context, organizationID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countOrganizationAdmins, organizationID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countRecoveryCodes = `-- name: CountRecoveryCodes :one
+SELECT COUNT(*) FROM two_factor_recovery_codes
+WHERE professional_id = $1 AND used_at IS NULL
+`
+
+func (q *Queries) CountRecoveryCodes(ctx context.Context, professionalID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countRecoveryCodes, professionalID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countRequestedAppointments = `-- name: CountRequestedAppointments :one
+SELECT COUNT(*) FROM appointments
+WHERE professional_id = $1 AND client_id = $2
+  AND status = 'requested' AND date >= CURRENT_DATE
+`
+
+type CountRequestedAppointmentsParams struct {
+	ProfessionalID int64 `json:"professional_id"`
+	ClientID       int64 `json:"client_id"`
+}
+
+// Pedidos del paciente que el profesional todavía no respondió
+func (q *Queries) CountRequestedAppointments(ctx context.Context, arg CountRequestedAppointmentsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countRequestedAppointments, arg.ProfessionalID, arg.ClientID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createAppointment = `-- name: CreateAppointment :one
 
 INSERT INTO appointments (
@@ -132,436 +385,506 @@ func (q *Queries) CreateAppointment(ctx context.Context, arg CreateAppointmentPa
 	return i, err
 }
 
-const createClient = `-- name: CreateClient :one
-
-INSERT INTO clients (
-    name, email, phone, professional_id, 
-    birth_date, medications, emergency_contact_name, emergency_contact_phone, 
-    active
-)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-RETURNING id, professional_id, name, email, phone, birth_date, medications, emergency_contact_name, emergency_contact_phone, active, created_at
+const createAssistant = `-- name: CreateAssistant :one
+INSERT INTO assistants (name, email, phone)
+VALUES ($1, $2, $3)
+RETURNING id, name, email, phone, created_at
 `
 
-type CreateClientParams struct {
-	Name                  string         `json:"name"`
-	Email                 sql.NullString `json:"email"`
-	Phone                 sql.NullString `json:"phone"`
-	ProfessionalID        int64          `json:"professional_id"`
-	BirthDate             sql.NullTime   `json:"birth_date"`
-	Medications           sql.NullString `json:"medications"`
-	EmergencyContactName  sql.NullString `json:"emergency_contact_name"`
-	EmergencyContactPhone sql.NullString `json:"emergency_contact_phone"`
-	Active                sql.NullBool   `json:"active"`
+type CreateAssistantParams struct {
+	Name  string         `json:"name"`
+	Email string         `json:"email"`
+	Phone sql.NullString `json:"phone"`
 }
 
-// SECTION: Clients
-func (q *Queries) CreateClient(ctx context.Context, arg CreateClientParams) (Client, error) {
-	row := q.db.QueryRowContext(ctx, createClient,
-		arg.Name,
-		arg.Email,
-		arg.Phone,
-		arg.ProfessionalID,
-		arg.BirthDate,
-		arg.Medications,
-		arg.EmergencyContactName,
-		arg.EmergencyContactPhone,
-		arg.Active,
-	)
-	var i Client
+// SECTION: Asistentes
+func (q *Queries) CreateAssistant(ctx context.Context, arg CreateAssistantParams) (Assistant, error) {
+	row := q.db.QueryRowContext(ctx, createAssistant, arg.Name, arg.Email, arg.Phone)
+	var i Assistant
 	err := row.Scan(
 		&i.ID,
-		&i.ProfessionalID,
 		&i.Name,
 		&i.Email,
 		&i.Phone,
-		&i.BirthDate,
-		&i.Medications,
-		&i.EmergencyContactName,
-		&i.EmergencyContactPhone,
-		&i.Active,
 		&i.CreatedAt,
 	)
 	return i, err
 }
 
-const createClinicalNote = `-- name: CreateClinicalNote :one
+const createAssistantRefreshToken = `-- name: CreateAssistantRefreshToken :one
+INSERT INTO assistant_refresh_tokens (assistant_id, token_hash, expires_at)
+VALUES ($1, $2, $3)
+RETURNING id, assistant_id, token_hash, expires_at, revoked_at, created_at
+`
 
-INSERT INTO clinical_notes (
-    professional_id, client_id, appointment_id, 
-    type, content, key_version, status, signed_at
+type CreateAssistantRefreshTokenParams struct {
+	AssistantID int64     `json:"assistant_id"`
+	TokenHash   string    `json:"token_hash"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreateAssistantRefreshToken(ctx context.Context, arg CreateAssistantRefreshTokenParams) (AssistantRefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, createAssistantRefreshToken, arg.AssistantID, arg.TokenHash, arg.ExpiresAt)
+	var i AssistantRefreshToken
+	err := row.Scan(
+		&i.ID,
+		&i.AssistantID,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createAttachment = `-- name: CreateAttachment :one
+INSERT INTO attachments (
+    professional_id, client_id, note_id, filename, content_type,
+    size_bytes, sha256, storage_key, data_key, key_version
+) VALUES (
+    $1, $2, $3, $4, $5,
+    $6, $7, $8, $9, $10
 )
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING id, professional_id, client_id, appointment_id, type, content, key_version, status, signed_at, created_at, updated_at
+RETURNING id, professional_id, client_id, note_id, filename, content_type, size_bytes, sha256, storage_key, data_key, key_version, created_at
 `
 
-type CreateClinicalNoteParams struct {
-	ProfessionalID int64          `json:"professional_id"`
-	ClientID       int64          `json:"client_id"`
-	AppointmentID  sql.NullInt64  `json:"appointment_id"`
-	Type           sql.NullString `json:"type"`
-	Content        string         `json:"content"`
-	KeyVersion     sql.NullInt32  `json:"key_version"`
-	Status         sql.NullString `json:"status"`
-	SignedAt       sql.NullTime   `json:"signed_at"`
+type CreateAttachmentParams struct {
+	ProfessionalID int64         `json:"professional_id"`
+	ClientID       int64         `json:"client_id"`
+	NoteID         sql.NullInt64 `json:"note_id"`
+	Filename       string        `json:"filename"`
+	ContentType    string        `json:"content_type"`
+	SizeBytes      int64         `json:"size_bytes"`
+	Sha256         string        `json:"sha256"`
+	StorageKey     string        `json:"storage_key"`
+	DataKey        string        `json:"data_key"`
+	KeyVersion     sql.NullInt32 `json:"key_version"`
 }
 
-// SECTION: Clinical Notes (NUEVO - Privacidad)
-func (q *Queries) CreateClinicalNote(ctx context.Context, arg CreateClinicalNoteParams) (ClinicalNote, error) {
-	row := q.db.QueryRowContext(ctx, createClinicalNote,
+// SECTION: Adjuntos
+func (q *Queries) CreateAttachment(ctx context.Context, arg CreateAttachmentParams) (Attachment, error) {
+	row := q.db.QueryRowContext(ctx, createAttachment,
 		arg.ProfessionalID,
 		arg.ClientID,
-		arg.AppointmentID,
-		arg.Type,
-		arg.Content,
+		arg.NoteID,
+		arg.Filename,
+		arg.ContentType,
+		arg.SizeBytes,
+		arg.Sha256,
+		arg.StorageKey,
+		arg.DataKey,
 		arg.KeyVersion,
-		arg.Status,
-		arg.SignedAt,
 	)
-	var i ClinicalNote
+	var i Attachment
 	err := row.Scan(
 		&i.ID,
 		&i.ProfessionalID,
 		&i.ClientID,
-		&i.AppointmentID,
-		&i.Type,
-		&i.Content,
+		&i.NoteID,
+		&i.Filename,
+		&i.ContentType,
+		&i.SizeBytes,
+		&i.Sha256,
+		&i.StorageKey,
+		&i.DataKey,
 		&i.KeyVersion,
-		&i.Status,
-		&i.SignedAt,
 		&i.CreatedAt,
-		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const createProfessional = `-- name: CreateProfessional :one
-
-INSERT INTO professionals (name, email, phone, slug, cancellation_window_hours)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, name, email, phone, slug, photo_url, title, license_number, bio, cancellation_window_hours, email_verified_at, created_at
+const createAuditEntry = `-- name: CreateAuditEntry :one
+INSERT INTO audit_log (
+    professional_id, assistant_id, client_id, action, resource_type, resource_id,
+    route, status, ip, user_agent, created_at, prev_hash, hash
+) VALUES (
+    $1, $2, $3, $4, $5, $6,
+    $7, $8, $9, $10, $11, $12, $13
+)
+RETURNING id, professional_id, assistant_id, action, resource_type, resource_id, route, status, ip, user_agent, created_at, prev_hash, hash, client_id
 `
 
-type CreateProfessionalParams struct {
-	Name                    string         `json:"name"`
-	Email                   string         `json:"email"`
-	Phone                   sql.NullString `json:"phone"`
-	Slug                    sql.NullString `json:"slug"`
-	CancellationWindowHours sql.NullInt32  `json:"cancellation_window_hours"`
+type CreateAuditEntryParams struct {
+	ProfessionalID int64          `json:"professional_id"`
+	AssistantID    sql.NullInt64  `json:"assistant_id"`
+	ClientID       sql.NullInt64  `json:"client_id"`
+	Action         string         `json:"action"`
+	ResourceType   string         `json:"resource_type"`
+	ResourceID     sql.NullInt64  `json:"resource_id"`
+	Route          string         `json:"route"`
+	Status         int32          `json:"status"`
+	Ip             sql.NullString `json:"ip"`
+	UserAgent      sql.NullString `json:"user_agent"`
+	CreatedAt      time.Time      `json:"created_at"`
+	PrevHash       string         `json:"prev_hash"`
+	Hash           string         `json:"hash"`
 }
 
-// SECTION: Professionals
-func (q *Queries) CreateProfessional(ctx context.Context, arg CreateProfessionalParams) (Professional, error) {
-	row := q.db.QueryRowContext(ctx, createProfessional,
-		arg.Name,
-		arg.Email,
-		arg.Phone,
-		arg.Slug,
-		arg.CancellationWindowHours,
+func (q *Queries) CreateAuditEntry(ctx context.Context, arg CreateAuditEntryParams) (AuditLog, error) {
+	row := q.db.QueryRowContext(ctx, createAuditEntry,
+		arg.ProfessionalID,
+		arg.AssistantID,
+		arg.ClientID,
+		arg.Action,
+		arg.ResourceType,
+		arg.ResourceID,
+		arg.Route,
+		arg.Status,
+		arg.Ip,
+		arg.UserAgent,
+		arg.CreatedAt,
+		arg.PrevHash,
+		arg.Hash,
 	)
-	var i Professional
+	var i AuditLog
 	err := row.Scan(
 		&i.ID,
-		&i.Name,
-		&i.Email,
-		&i.Phone,
-		&i.Slug,
-		&i.PhotoUrl,
-		&i.Title,
-		&i.LicenseNumber,
-		&i.Bio,
-		&i.CancellationWindowHours,
-		&i.EmailVerifiedAt,
+		&i.ProfessionalID,
+		&i.AssistantID,
+		&i.Action,
+		&i.ResourceType,
+		&i.ResourceID,
+		&i.Route,
+		&i.Status,
+		&i.Ip,
+		&i.UserAgent,
 		&i.CreatedAt,
+		&i.PrevHash,
+		&i.Hash,
+		&i.ClientID,
 	)
 	return i, err
 }
 
-const createRecurringRule = `-- name: CreateRecurringRule :one
-
-INSERT INTO recurring_rules (
-    professional_id, client_id, day_of_week, start_time, duration_minutes, 
-    modality, price, start_date, active
+const createBookingAppointment = `-- name: CreateBookingAppointment :one
+INSERT INTO appointments (
+    professional_id, client_id, date, start_time, duration_minutes,
+    price, concept, status, payment_status
 )
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, TRUE)
-RETURNING id, professional_id, client_id, day_of_week, start_time, duration_minutes, modality, price, active, start_date, created_at
+VALUES (
+    $1, $2, $3, $4, $5,
+    $6, 'Sesión de Terapia', $7, 'pending'
+)
+RETURNING id, professional_id, client_id, date, start_time, duration_minutes, status, modality, meeting_url, price, concept, payment_status, payment_method, payment_proof_url, payment_confirmed_at, invoice_status, invoice_url, invoice_cae, notes, rescheduled_from_id, recurring_rule_id, created_at, updated_at
 `
 
-type CreateRecurringRuleParams struct {
+type CreateBookingAppointmentParams struct {
 	ProfessionalID  int64          `json:"professional_id"`
 	ClientID        int64          `json:"client_id"`
-	DayOfWeek       int32          `json:"day_of_week"`
+	Date            time.Time      `json:"date"`
 	StartTime       string         `json:"start_time"`
 	DurationMinutes int32          `json:"duration_minutes"`
-	Modality        sql.NullString `json:"modality"`
 	Price           sql.NullString `json:"price"`
-	StartDate       sql.NullTime   `json:"start_date"`
+	Status          sql.NullString `json:"status"`
 }
 
-// SECTION: Recurring Rules
-func (q *Queries) CreateRecurringRule(ctx context.Context, arg CreateRecurringRuleParams) (RecurringRule, error) {
-	row := q.db.QueryRowContext(ctx, createRecurringRule,
+func (q *Queries) CreateBookingAppointment(ctx context.Context, arg CreateBookingAppointmentParams) (Appointment, error) {
+	row := q.db.QueryRowContext(ctx, createBookingAppointment,
 		arg.ProfessionalID,
 		arg.ClientID,
-		arg.DayOfWeek,
+		arg.Date,
 		arg.StartTime,
 		arg.DurationMinutes,
-		arg.Modality,
 		arg.Price,
-		arg.StartDate,
+		arg.Status,
 	)
-	var i RecurringRule
+	var i Appointment
 	err := row.Scan(
 		&i.ID,
 		&i.ProfessionalID,
 		&i.ClientID,
-		&i.DayOfWeek,
+		&i.Date,
 		&i.StartTime,
 		&i.DurationMinutes,
+		&i.Status,
 		&i.Modality,
+		&i.MeetingUrl,
 		&i.Price,
-		&i.Active,
-		&i.StartDate,
+		&i.Concept,
+		&i.PaymentStatus,
+		&i.PaymentMethod,
+		&i.PaymentProofUrl,
+		&i.PaymentConfirmedAt,
+		&i.InvoiceStatus,
+		&i.InvoiceUrl,
+		&i.InvoiceCae,
+		&i.Notes,
+		&i.RescheduledFromID,
+		&i.RecurringRuleID,
 		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const createScheduleConfig = `-- name: CreateScheduleConfig :one
-
-INSERT INTO schedule_configs (professional_id, day_of_week, start_time, end_time)
-VALUES ($1, $2, $3, $4)
-RETURNING id, professional_id, day_of_week, start_time, end_time, created_at
+const createBookingRequest = `-- name: CreateBookingRequest :exec
+INSERT INTO booking_requests (professional_id, client_id, appointment_id, email, ip)
+VALUES ($1, $2, $3, $4, $5)
 `
 
-type CreateScheduleConfigParams struct {
+type CreateBookingRequestParams struct {
 	ProfessionalID int64  `json:"professional_id"`
-	DayOfWeek      int32  `json:"day_of_week"`
-	StartTime      string `json:"start_time"`
-	EndTime        string `json:"end_time"`
+	ClientID       int64  `json:"client_id"`
+	AppointmentID  int64  `json:"appointment_id"`
+	Email          string `json:"email"`
+	Ip             string `json:"ip"`
 }
 
-// SECTION: Schedule Configuration
-func (q *Queries) CreateScheduleConfig(ctx context.Context, arg CreateScheduleConfigParams) (ScheduleConfig, error) {
-	row := q.db.QueryRowContext(ctx, createScheduleConfig,
+func (q *Queries) CreateBookingRequest(ctx context.Context, arg CreateBookingRequestParams) error {
+	_, err := q.db.ExecContext(ctx, createBookingRequest,
 		arg.ProfessionalID,
-		arg.DayOfWeek,
-		arg.StartTime,
-		arg.EndTime,
+		arg.ClientID,
+		arg.AppointmentID,
+		arg.Email,
+		arg.Ip,
 	)
-	var i ScheduleConfig
+	return err
+}
+
+const createClient = `-- name: CreateClient :one
+
+INSERT INTO clients (
+    name, email, phone, professional_id, 
+    birth_date, medications, emergency_contact_name, emergency_contact_phone, 
+    active
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, professional_id, name, email, phone, birth_date, medications, emergency_contact_name, emergency_contact_phone, active, created_at
+`
+
+type CreateClientParams struct {
+	Name                  string         `json:"name"`
+	Email                 sql.NullString `json:"email"`
+	Phone                 sql.NullString `json:"phone"`
+	ProfessionalID        int64          `json:"professional_id"`
+	BirthDate             sql.NullTime   `json:"birth_date"`
+	Medications           sql.NullString `json:"medications"`
+	EmergencyContactName  sql.NullString `json:"emergency_contact_name"`
+	EmergencyContactPhone sql.NullString `json:"emergency_contact_phone"`
+	Active                sql.NullBool   `json:"active"`
+}
+
+// SECTION: Clients
+func (q *Queries) CreateClient(ctx context.Context, arg CreateClientParams) (Client, error) {
+	row := q.db.QueryRowContext(ctx, createClient,
+		arg.Name,
+		arg.Email,
+		arg.Phone,
+		arg.ProfessionalID,
+		arg.BirthDate,
+		arg.Medications,
+		arg.EmergencyContactName,
+		arg.EmergencyContactPhone,
+		arg.Active,
+	)
+	var i Client
 	err := row.Scan(
 		&i.ID,
 		&i.ProfessionalID,
-		&i.DayOfWeek,
-		&i.StartTime,
-		&i.EndTime,
+		&i.Name,
+		&i.Email,
+		&i.Phone,
+		&i.BirthDate,
+		&i.Medications,
+		&i.EmergencyContactName,
+		&i.EmergencyContactPhone,
+		&i.Active,
 		&i.CreatedAt,
 	)
 	return i, err
 }
 
-const deleteScheduleConfigs = `-- name: DeleteScheduleConfigs :exec
-DELETE FROM schedule_configs WHERE professional_id = $1
+const createClientCoverage = `-- name: CreateClientCoverage :one
+INSERT INTO client_coverages (professional_id, client_id, insurer_id, plan, member_number, copay_amount, active)
+VALUES ($1, $2, $3, $4, $5, $6, TRUE)
+RETURNING id, professional_id, client_id, insurer_id, plan, member_number, copay_amount, active, created_at
 `
 
-func (q *Queries) DeleteScheduleConfigs(ctx context.Context, professionalID int64) error {
-	_, err := q.db.ExecContext(ctx, deleteScheduleConfigs, professionalID)
-	return err
+type CreateClientCoverageParams struct {
+	ProfessionalID int64          `json:"professional_id"`
+	ClientID       int64          `json:"client_id"`
+	InsurerID      int64          `json:"insurer_id"`
+	Plan           sql.NullString `json:"plan"`
+	MemberNumber   string         `json:"member_number"`
+	CopayAmount    string         `json:"copay_amount"`
 }
 
-const getActiveRecurringRules = `-- name: GetActiveRecurringRules :many
-SELECT r.id, r.professional_id, r.client_id, r.day_of_week, r.start_time, r.duration_minutes, r.modality, r.price, r.active, r.start_date, r.created_at FROM recurring_rules r
-JOIN clients c ON r.client_id = c.id
-WHERE r.professional_id = $1 
-  AND r.active = TRUE 
-  AND c.active = TRUE
-`
-
-func (q *Queries) GetActiveRecurringRules(ctx context.Context, professionalID int64) ([]RecurringRule, error) {
-	rows, err := q.db.QueryContext(ctx, getActiveRecurringRules, professionalID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []RecurringRule
-	for rows.Next() {
-		var i RecurringRule
-		if err := rows.Scan(
-			&i.ID,
-			&i.ProfessionalID,
-			&i.ClientID,
-			&i.DayOfWeek,
-			&i.StartTime,
-			&i.DurationMinutes,
-			&i.Modality,
-			&i.Price,
-			&i.Active,
-			&i.StartDate,
-			&i.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+func (q *Queries) CreateClientCoverage(ctx context.Context, arg CreateClientCoverageParams) (ClientCoverage, error) {
+	row := q.db.QueryRowContext(ctx, createClientCoverage,
+		arg.ProfessionalID,
+		arg.ClientID,
+		arg.InsurerID,
+		arg.Plan,
+		arg.MemberNumber,
+		arg.CopayAmount,
+	)
+	var i ClientCoverage
+	err := row.Scan(
+		&i.ID,
+		&i.ProfessionalID,
+		&i.ClientID,
+		&i.InsurerID,
+		&i.Plan,
+		&i.MemberNumber,
+		&i.CopayAmount,
+		&i.Active,
+		&i.CreatedAt,
+	)
+	return i, err
 }
 
-const getAppointment = `-- name: GetAppointment :one
-SELECT a.id, a.professional_id, a.client_id, a.date, a.start_time, a.duration_minutes, a.status, a.modality, a.meeting_url, a.price, a.concept, a.payment_status, a.payment_method, a.payment_proof_url, a.payment_confirmed_at, a.invoice_status, a.invoice_url, a.invoice_cae, a.notes, a.rescheduled_from_id, a.recurring_rule_id, a.created_at, a.updated_at, c.name as client_name, c.email as client_email
-FROM appointments a
-JOIN clients c ON a.client_id = c.id
-WHERE a.id = $1 LIMIT 1
+const createClientFee = `-- name: CreateClientFee :one
+INSERT INTO client_fees (
+    professional_id, client_id, appointment_id, kind, amount, date, description
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, professional_id, client_id, appointment_id, kind, amount, date, description, created_at
 `
 
-type GetAppointmentRow struct {
-	ID                 int64          `json:"id"`
-	ProfessionalID     int64          `json:"professional_id"`
-	ClientID           int64          `json:"client_id"`
-	Date               time.Time      `json:"date"`
-	StartTime          string         `json:"start_time"`
-	DurationMinutes    int32          `json:"duration_minutes"`
-	Status             sql.NullString `json:"status"`
-	Modality           sql.NullString `json:"modality"`
-	MeetingUrl         sql.NullString `json:"meeting_url"`
-	Price              sql.NullString `json:"price"`
-	Concept            sql.NullString `json:"concept"`
-	PaymentStatus      sql.NullString `json:"payment_status"`
-	PaymentMethod      sql.NullString `json:"payment_method"`
-	PaymentProofUrl    sql.NullString `json:"payment_proof_url"`
-	PaymentConfirmedAt sql.NullTime   `json:"payment_confirmed_at"`
-	InvoiceStatus      sql.NullString `json:"invoice_status"`
-	InvoiceUrl         sql.NullString `json:"invoice_url"`
-	InvoiceCae         sql.NullString `json:"invoice_cae"`
-	Notes              sql.NullString `json:"notes"`
-	RescheduledFromID  sql.NullInt64  `json:"rescheduled_from_id"`
-	RecurringRuleID    sql.NullInt64  `json:"recurring_rule_id"`
-	CreatedAt          sql.NullTime   `json:"created_at"`
-	UpdatedAt          sql.NullTime   `json:"updated_at"`
-	ClientName         string         `json:"client_name"`
-	ClientEmail        sql.NullString `json:"client_email"`
+type CreateClientFeeParams struct {
+	ProfessionalID int64          `json:"professional_id"`
+	ClientID       int64          `json:"client_id"`
+	AppointmentID  sql.NullInt64  `json:"appointment_id"`
+	Kind           string         `json:"kind"`
+	Amount         string         `json:"amount"`
+	Date           time.Time      `json:"date"`
+	Description    sql.NullString `json:"description"`
 }
 
-func (q *Queries) GetAppointment(ctx context.Context, id int64) (GetAppointmentRow, error) {
-	row := q.db.QueryRowContext(ctx, getAppointment, id)
-	var i GetAppointmentRow
+func (q *Queries) CreateClientFee(ctx context.Context, arg CreateClientFeeParams) (ClientFee, error) {
+	row := q.db.QueryRowContext(ctx, createClientFee,
+		arg.ProfessionalID,
+		arg.ClientID,
+		arg.AppointmentID,
+		arg.Kind,
+		arg.Amount,
+		arg.Date,
+		arg.Description,
+	)
+	var i ClientFee
 	err := row.Scan(
 		&i.ID,
 		&i.ProfessionalID,
 		&i.ClientID,
+		&i.AppointmentID,
+		&i.Kind,
+		&i.Amount,
 		&i.Date,
-		&i.StartTime,
-		&i.DurationMinutes,
-		&i.Status,
-		&i.Modality,
-		&i.MeetingUrl,
-		&i.Price,
-		&i.Concept,
-		&i.PaymentStatus,
-		&i.PaymentMethod,
-		&i.PaymentProofUrl,
-		&i.PaymentConfirmedAt,
-		&i.InvoiceStatus,
-		&i.InvoiceUrl,
-		&i.InvoiceCae,
-		&i.Notes,
-		&i.RescheduledFromID,
-		&i.RecurringRuleID,
+		&i.Description,
 		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.ClientName,
-		&i.ClientEmail,
 	)
 	return i, err
 }
 
-const getClient = `-- name: GetClient :one
-SELECT id, professional_id, name, email, phone, birth_date, medications, emergency_contact_name, emergency_contact_phone, active, created_at FROM clients WHERE id = $1 LIMIT 1
+const createClientForm = `-- name: CreateClientForm :one
+INSERT INTO client_forms (professional_id, client_id, title, sections)
+VALUES ($1, $2, $3, $4)
+RETURNING id, professional_id, client_id, title, sections, answers, key_version, completed_at, created_at
 `
 
-func (q *Queries) GetClient(ctx context.Context, id int64) (Client, error) {
-	row := q.db.QueryRowContext(ctx, getClient, id)
-	var i Client
+type CreateClientFormParams struct {
+	ProfessionalID int64  `json:"professional_id"`
+	ClientID       int64  `json:"client_id"`
+	Title          string `json:"title"`
+	Sections       string `json:"sections"`
+}
+
+func (q *Queries) CreateClientForm(ctx context.Context, arg CreateClientFormParams) (ClientForm, error) {
+	row := q.db.QueryRowContext(ctx, createClientForm,
+		arg.ProfessionalID,
+		arg.ClientID,
+		arg.Title,
+		arg.Sections,
+	)
+	var i ClientForm
 	err := row.Scan(
 		&i.ID,
 		&i.ProfessionalID,
-		&i.Name,
-		&i.Email,
-		&i.Phone,
-		&i.BirthDate,
-		&i.Medications,
-		&i.EmergencyContactName,
-		&i.EmergencyContactPhone,
-		&i.Active,
+		&i.ClientID,
+		&i.Title,
+		&i.Sections,
+		&i.Answers,
+		&i.KeyVersion,
+		&i.CompletedAt,
 		&i.CreatedAt,
 	)
 	return i, err
 }
 
-const getDayAppointments = `-- name: GetDayAppointments :many
-SELECT id, start_time, duration_minutes, status
-FROM appointments
-WHERE professional_id = $1 
-  AND date = $2::date 
-  AND status != 'cancelled'
+const createClientLoginToken = `-- name: CreateClientLoginToken :exec
+INSERT INTO client_login_tokens (client_id, token_hash, expires_at)
+VALUES ($1, $2, $3)
 `
 
-type GetDayAppointmentsParams struct {
-	ProfessionalID int64     `json:"professional_id"`
-	Column2        time.Time `json:"column_2"`
+type CreateClientLoginTokenParams struct {
+	ClientID  int64     `json:"client_id"`
+	TokenHash string    `json:"token_hash"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
 
-type GetDayAppointmentsRow struct {
-	ID              int64          `json:"id"`
-	StartTime       string         `json:"start_time"`
-	DurationMinutes int32          `json:"duration_minutes"`
-	Status          sql.NullString `json:"status"`
+func (q *Queries) CreateClientLoginToken(ctx context.Context, arg CreateClientLoginTokenParams) error {
+	_, err := q.db.ExecContext(ctx, createClientLoginToken, arg.ClientID, arg.TokenHash, arg.ExpiresAt)
+	return err
 }
 
-func (q *Queries) GetDayAppointments(ctx context.Context, arg GetDayAppointmentsParams) ([]GetDayAppointmentsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getDayAppointments, arg.ProfessionalID, arg.Column2)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetDayAppointmentsRow
-	for rows.Next() {
-		var i GetDayAppointmentsRow
-		if err := rows.Scan(
-			&i.ID,
-			&i.StartTime,
-			&i.DurationMinutes,
-			&i.Status,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+const createClientPayment = `-- name: CreateClientPayment :one
+INSERT INTO client_payments (
+    professional_id, client_id, appointment_id, kind, amount, method, paid_at, notes
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, professional_id, client_id, appointment_id, kind, amount, method, paid_at, notes, created_at
+`
+
+type CreateClientPaymentParams struct {
+	ProfessionalID int64          `json:"professional_id"`
+	ClientID       int64          `json:"client_id"`
+	AppointmentID  sql.NullInt64  `json:"appointment_id"`
+	Kind           string         `json:"kind"`
+	Amount         string         `json:"amount"`
+	Method         sql.NullString `json:"method"`
+	PaidAt         time.Time      `json:"paid_at"`
+	Notes          sql.NullString `json:"notes"`
 }
 
-const getDraftNotes = `-- name: GetDraftNotes :many
-SELECT n.id, n.professional_id, n.client_id, n.appointment_id, n.type, n.content, n.key_version, n.status, n.signed_at, n.created_at, n.updated_at, c.name as client_name 
-FROM clinical_notes n
-JOIN clients c ON n.client_id = c.id
-WHERE n.professional_id = $1 AND n.status = 'draft'
-ORDER BY n.created_at DESC
+// SECTION: Cuenta Corriente (Pagos, Cargos y Saldos)
+func (q *Queries) CreateClientPayment(ctx context.Context, arg CreateClientPaymentParams) (ClientPayment, error) {
+	row := q.db.QueryRowContext(ctx, createClientPayment,
+		arg.ProfessionalID,
+		arg.ClientID,
+		arg.AppointmentID,
+		arg.Kind,
+		arg.Amount,
+		arg.Method,
+		arg.PaidAt,
+		arg.Notes,
+	)
+	var i ClientPayment
+	err := row.Scan(
+		&i.ID,
+		&i.ProfessionalID,
+		&i.ClientID,
+		&i.AppointmentID,
+		&i.Kind,
+		&i.Amount,
+		&i.Method,
+		&i.PaidAt,
+		&i.Notes,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createClinicalNote = `-- name: CreateClinicalNote :one
+INSERT INTO clinical_notes (
+    professional_id, client_id, appointment_id, 
+    type, content, key_version, status, signed_at,
+    template_id, sections
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING id, professional_id, client_id, appointment_id, type, content, key_version, status, signed_at, created_at, updated_at, template_id, sections
 `
 
-type GetDraftNotesRow struct {
-	ID             int64          `json:"id"`
+type CreateClinicalNoteParams struct {
 	ProfessionalID int64          `json:"professional_id"`
 	ClientID       int64          `json:"client_id"`
 	AppointmentID  sql.NullInt64  `json:"appointment_id"`
@@ -570,425 +893,4906 @@ type GetDraftNotesRow struct {
 	KeyVersion     sql.NullInt32  `json:"key_version"`
 	Status         sql.NullString `json:"status"`
 	SignedAt       sql.NullTime   `json:"signed_at"`
-	CreatedAt      sql.NullTime   `json:"created_at"`
-	UpdatedAt      sql.NullTime   `json:"updated_at"`
-	ClientName     string         `json:"client_name"`
+	TemplateID     sql.NullInt64  `json:"template_id"`
+	Sections       sql.NullString `json:"sections"`
 }
 
-// Para el dashboard de "Notas Pendientes"
-func (q *Queries) GetDraftNotes(ctx context.Context, professionalID int64) ([]GetDraftNotesRow, error) {
-	rows, err := q.db.QueryContext(ctx, getDraftNotes, professionalID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetDraftNotesRow
-	for rows.Next() {
-		var i GetDraftNotesRow
-		if err := rows.Scan(
-			&i.ID,
-			&i.ProfessionalID,
-			&i.ClientID,
-			&i.AppointmentID,
-			&i.Type,
-			&i.Content,
-			&i.KeyVersion,
-			&i.Status,
-			&i.SignedAt,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.ClientName,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+// SECTION: Clinical Notes (NUEVO - Privacidad)
+func (q *Queries) CreateClinicalNote(ctx context.Context, arg CreateClinicalNoteParams) (ClinicalNote, error) {
+	row := q.db.QueryRowContext(ctx, createClinicalNote,
+		arg.ProfessionalID,
+		arg.ClientID,
+		arg.AppointmentID,
+		arg.Type,
+		arg.Content,
+		arg.KeyVersion,
+		arg.Status,
+		arg.SignedAt,
+		arg.TemplateID,
+		arg.Sections,
+	)
+	var i ClinicalNote
+	err := row.Scan(
+		&i.ID,
+		&i.ProfessionalID,
+		&i.ClientID,
+		&i.AppointmentID,
+		&i.Type,
+		&i.Content,
+		&i.KeyVersion,
+		&i.Status,
+		&i.SignedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TemplateID,
+		&i.Sections,
+	)
+	return i, err
 }
 
-const getFinancesDashboard = `-- name: GetFinancesDashboard :many
-SELECT a.id, a.date, a.concept, a.price, 
-       a.payment_status, a.payment_method, a.payment_proof_url,
-       a.invoice_status, a.invoice_url,
-       c.name as client_name
-FROM appointments a
-JOIN clients c ON a.client_id = c.id
-WHERE a.professional_id = $1 
-  AND a.status != 'cancelled'
-ORDER BY a.date DESC
-LIMIT $2 OFFSET $3
+const createClinicalRecordExport = `-- name: CreateClinicalRecordExport :one
+INSERT INTO clinical_record_exports (
+    professional_id, client_id, purpose, recipient, note_count, document_sha256
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+RETURNING id, professional_id, client_id, purpose, recipient, note_count, document_sha256, created_at
 `
 
-type GetFinancesDashboardParams struct {
-	ProfessionalID int64 `json:"professional_id"`
-	Limit          int32 `json:"limit"`
-	Offset         int32 `json:"offset"`
-}
-
-type GetFinancesDashboardRow struct {
-	ID              int64          `json:"id"`
-	Date            time.Time      `json:"date"`
-	Concept         sql.NullString `json:"concept"`
-	Price           sql.NullString `json:"price"`
-	PaymentStatus   sql.NullString `json:"payment_status"`
-	PaymentMethod   sql.NullString `json:"payment_method"`
-	PaymentProofUrl sql.NullString `json:"payment_proof_url"`
-	InvoiceStatus   sql.NullString `json:"invoice_status"`
-	InvoiceUrl      sql.NullString `json:"invoice_url"`
-	ClientName      string         `json:"client_name"`
+type CreateClinicalRecordExportParams struct {
+	ProfessionalID int64          `json:"professional_id"`
+	ClientID       int64          `json:"client_id"`
+	Purpose        sql.NullString `json:"purpose"`
+	Recipient      sql.NullString `json:"recipient"`
+	NoteCount      int32          `json:"note_count"`
+	DocumentSha256 string         `json:"document_sha256"`
 }
 
-// Query para la pantalla de "Finanzas" (Tabla principal)
-// Trae todos los turnos que no estén cancelados, ordenados por fecha desc
-func (q *Queries) GetFinancesDashboard(ctx context.Context, arg GetFinancesDashboardParams) ([]GetFinancesDashboardRow, error) {
-	rows, err := q.db.QueryContext(ctx, getFinancesDashboard, arg.ProfessionalID, arg.Limit, arg.Offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetFinancesDashboardRow
-	for rows.Next() {
-		var i GetFinancesDashboardRow
-		if err := rows.Scan(
-			&i.ID,
-			&i.Date,
-			&i.Concept,
-			&i.Price,
-			&i.PaymentStatus,
-			&i.PaymentMethod,
-			&i.PaymentProofUrl,
-			&i.InvoiceStatus,
-			&i.InvoiceUrl,
-			&i.ClientName,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+func (q *Queries) CreateClinicalRecordExport(ctx context.Context, arg CreateClinicalRecordExportParams) (ClinicalRecordExport, error) {
+	row := q.db.QueryRowContext(ctx, createClinicalRecordExport,
+		arg.ProfessionalID,
+		arg.ClientID,
+		arg.Purpose,
+		arg.Recipient,
+		arg.NoteCount,
+		arg.DocumentSha256,
+	)
+	var i ClinicalRecordExport
+	err := row.Scan(
+		&i.ID,
+		&i.ProfessionalID,
+		&i.ClientID,
+		&i.Purpose,
+		&i.Recipient,
+		&i.NoteCount,
+		&i.DocumentSha256,
+		&i.CreatedAt,
+	)
+	return i, err
 }
 
-const getFinancialSummary = `-- name: GetFinancialSummary :one
-SELECT 
-    COALESCE(SUM(CASE WHEN date >= DATE_TRUNC('month', CURRENT_DATE) AND payment_status = 'paid' THEN price ELSE 0 END), 0)::DECIMAL as current_month_income,
-    COALESCE(SUM(CASE WHEN payment_status = 'pending' THEN price ELSE 0 END), 0)::DECIMAL as pending_collection,
-    COALESCE(SUM(CASE WHEN payment_status = 'paid' AND invoice_status = 'pending' THEN price ELSE 0 END), 0)::DECIMAL as pending_invoicing
-FROM appointments
-WHERE professional_id = $1 AND status != 'cancelled'
+const createInsurer = `-- name: CreateInsurer :one
+INSERT INTO insurers (professional_id, name, kind, code, session_fee, requires_authorization, active)
+VALUES ($1, $2, $3, $4, $5, $6, TRUE)
+RETURNING id, professional_id, name, kind, code, session_fee, requires_authorization, active, created_at
 `
 
-type GetFinancialSummaryRow struct {
-	CurrentMonthIncome string `json:"current_month_income"`
-	PendingCollection  string `json:"pending_collection"`
-	PendingInvoicing   string `json:"pending_invoicing"`
+type CreateInsurerParams struct {
+	ProfessionalID        int64          `json:"professional_id"`
+	Name                  string         `json:"name"`
+	Kind                  sql.NullString `json:"kind"`
+	Code                  sql.NullString `json:"code"`
+	SessionFee            string         `json:"session_fee"`
+	RequiresAuthorization sql.NullBool   `json:"requires_authorization"`
 }
 
-// KPIs de Finanzas: Sumas rápidas para las tarjetas de arriba
-func (q *Queries) GetFinancialSummary(ctx context.Context, professionalID int64) (GetFinancialSummaryRow, error) {
-	row := q.db.QueryRowContext(ctx, getFinancialSummary, professionalID)
-	var i GetFinancialSummaryRow
-	err := row.Scan(&i.CurrentMonthIncome, &i.PendingCollection, &i.PendingInvoicing)
+// SECTION: Obras Sociales / Prepagas
+func (q *Queries) CreateInsurer(ctx context.Context, arg CreateInsurerParams) (Insurer, error) {
+	row := q.db.QueryRowContext(ctx, createInsurer,
+		arg.ProfessionalID,
+		arg.Name,
+		arg.Kind,
+		arg.Code,
+		arg.SessionFee,
+		arg.RequiresAuthorization,
+	)
+	var i Insurer
+	err := row.Scan(
+		&i.ID,
+		&i.ProfessionalID,
+		&i.Name,
+		&i.Kind,
+		&i.Code,
+		&i.SessionFee,
+		&i.RequiresAuthorization,
+		&i.Active,
+		&i.CreatedAt,
+	)
 	return i, err
 }
 
-const getNoteById = `-- name: GetNoteById :one
-SELECT id, professional_id, client_id, appointment_id, type, content, key_version, status, signed_at, created_at, updated_at FROM clinical_notes WHERE id = $1 LIMIT 1
+const createNoteAddendum = `-- name: CreateNoteAddendum :one
+INSERT INTO clinical_note_addenda (note_id, professional_id, content, key_version)
+VALUES ($1, $2, $3, $4)
+RETURNING id, note_id, professional_id, content, key_version, signed_at, created_at
 `
 
-func (q *Queries) GetNoteById(ctx context.Context, id int64) (ClinicalNote, error) {
-	row := q.db.QueryRowContext(ctx, getNoteById, id)
-	var i ClinicalNote
+type CreateNoteAddendumParams struct {
+	NoteID         int64         `json:"note_id"`
+	ProfessionalID int64         `json:"professional_id"`
+	Content        string        `json:"content"`
+	KeyVersion     sql.NullInt32 `json:"key_version"`
+}
+
+func (q *Queries) CreateNoteAddendum(ctx context.Context, arg CreateNoteAddendumParams) (ClinicalNoteAddenda, error) {
+	row := q.db.QueryRowContext(ctx, createNoteAddendum,
+		arg.NoteID,
+		arg.ProfessionalID,
+		arg.Content,
+		arg.KeyVersion,
+	)
+	var i ClinicalNoteAddenda
 	err := row.Scan(
 		&i.ID,
+		&i.NoteID,
 		&i.ProfessionalID,
-		&i.ClientID,
-		&i.AppointmentID,
-		&i.Type,
 		&i.Content,
 		&i.KeyVersion,
-		&i.Status,
 		&i.SignedAt,
 		&i.CreatedAt,
-		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const getProfessional = `-- name: GetProfessional :one
-SELECT id, name, email, phone, slug, photo_url, title, license_number, bio, cancellation_window_hours, email_verified_at, created_at FROM professionals 
-WHERE id = $1 LIMIT 1
+const createNoteRevision = `-- name: CreateNoteRevision :one
+INSERT INTO clinical_note_revisions (note_id, revision_number, author_id, content, sections, key_version, written_at)
+VALUES (
+    $1,
+    (SELECT COALESCE(MAX(r.revision_number), 0) + 1 FROM clinical_note_revisions r WHERE r.note_id = $1),
+    $2, $3, $4, $5, $6
+)
+RETURNING id, note_id, revision_number, author_id, content, key_version, written_at, created_at, sections
 `
 
-func (q *Queries) GetProfessional(ctx context.Context, id int64) (Professional, error) {
-	row := q.db.QueryRowContext(ctx, getProfessional, id)
-	var i Professional
+type CreateNoteRevisionParams struct {
+	NoteID     int64          `json:"note_id"`
+	AuthorID   int64          `json:"author_id"`
+	Content    string         `json:"content"`
+	Sections   sql.NullString `json:"sections"`
+	KeyVersion sql.NullInt32  `json:"key_version"`
+	WrittenAt  sql.NullTime   `json:"written_at"`
+}
+
+func (q *Queries) CreateNoteRevision(ctx context.Context, arg CreateNoteRevisionParams) (ClinicalNoteRevision, error) {
+	row := q.db.QueryRowContext(ctx, createNoteRevision,
+		arg.NoteID,
+		arg.AuthorID,
+		arg.Content,
+		arg.Sections,
+		arg.KeyVersion,
+		arg.WrittenAt,
+	)
+	var i ClinicalNoteRevision
 	err := row.Scan(
 		&i.ID,
-		&i.Name,
-		&i.Email,
-		&i.Phone,
-		&i.Slug,
-		&i.PhotoUrl,
-		&i.Title,
-		&i.LicenseNumber,
-		&i.Bio,
-		&i.CancellationWindowHours,
-		&i.EmailVerifiedAt,
+		&i.NoteID,
+		&i.RevisionNumber,
+		&i.AuthorID,
+		&i.Content,
+		&i.KeyVersion,
+		&i.WrittenAt,
 		&i.CreatedAt,
+		&i.Sections,
 	)
 	return i, err
 }
 
-const getProfessionalByEmail = `-- name: GetProfessionalByEmail :one
-SELECT id, name, email, phone, slug, photo_url, title, license_number, bio, cancellation_window_hours, email_verified_at, created_at FROM professionals 
-WHERE email = $1 LIMIT 1
+const createNoteTemplate = `-- name: CreateNoteTemplate :one
+INSERT INTO note_templates (professional_id, name, kind, sections)
+VALUES ($1, $2, $3, $4::text::jsonb)
+RETURNING id, professional_id, name, kind, sections, active, created_at, updated_at
 `
 
-func (q *Queries) GetProfessionalByEmail(ctx context.Context, email string) (Professional, error) {
-	row := q.db.QueryRowContext(ctx, getProfessionalByEmail, email)
-	var i Professional
+type CreateNoteTemplateParams struct {
+	ProfessionalID int64          `json:"professional_id"`
+	Name           string         `json:"name"`
+	Kind           sql.NullString `json:"kind"`
+	Sections       string         `json:"sections"`
+}
+
+// SECTION: Note Templates
+func (q *Queries) CreateNoteTemplate(ctx context.Context, arg CreateNoteTemplateParams) (NoteTemplate, error) {
+	row := q.db.QueryRowContext(ctx, createNoteTemplate,
+		arg.ProfessionalID,
+		arg.Name,
+		arg.Kind,
+		arg.Sections,
+	)
+	var i NoteTemplate
 	err := row.Scan(
 		&i.ID,
+		&i.ProfessionalID,
 		&i.Name,
-		&i.Email,
-		&i.Phone,
-		&i.Slug,
-		&i.PhotoUrl,
-		&i.Title,
-		&i.LicenseNumber,
-		&i.Bio,
-		&i.CancellationWindowHours,
-		&i.EmailVerifiedAt,
+		&i.Kind,
+		&i.Sections,
+		&i.Active,
 		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const getProfessionalBySlug = `-- name: GetProfessionalBySlug :one
-SELECT id, name, slug, photo_url, title, license_number, bio, email, phone
-FROM professionals
-WHERE slug = $1 LIMIT 1
+const createOrganization = `-- name: CreateOrganization :one
+INSERT INTO organizations (name, require_two_factor)
+VALUES ($1, $2)
+RETURNING id, name, require_two_factor, created_at, logo_url, brand_color, address, phone, website, default_duration_minutes, buffer_minutes, time_increment_minutes, min_booking_notice_hours, booking_requires_approval
 `
 
-type GetProfessionalBySlugRow struct {
-	ID            int64          `json:"id"`
-	Name          string         `json:"name"`
-	Slug          sql.NullString `json:"slug"`
-	PhotoUrl      sql.NullString `json:"photo_url"`
-	Title         sql.NullString `json:"title"`
-	LicenseNumber sql.NullString `json:"license_number"`
-	Bio           sql.NullString `json:"bio"`
-	Email         string         `json:"email"`
-	Phone         sql.NullString `json:"phone"`
+type CreateOrganizationParams struct {
+	Name             string `json:"name"`
+	RequireTwoFactor bool   `json:"require_two_factor"`
 }
 
-func (q *Queries) GetProfessionalBySlug(ctx context.Context, slug sql.NullString) (GetProfessionalBySlugRow, error) {
-	row := q.db.QueryRowContext(ctx, getProfessionalBySlug, slug)
-	var i GetProfessionalBySlugRow
+// SECTION: Organizaciones
+func (q *Queries) CreateOrganization(ctx context.Context, arg CreateOrganizationParams) (Organization, error) {
+	row := q.db.QueryRowContext(ctx, createOrganization, arg.Name, arg.RequireTwoFactor)
+	var i Organization
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.RequireTwoFactor,
+		&i.CreatedAt,
+		&i.LogoUrl,
+		&i.BrandColor,
+		&i.Address,
+		&i.Phone,
+		&i.Website,
+		&i.DefaultDurationMinutes,
+		&i.BufferMinutes,
+		&i.TimeIncrementMinutes,
+		&i.MinBookingNoticeHours,
+		&i.BookingRequiresApproval,
+	)
+	return i, err
+}
+
+const createOrganizationInvitation = `-- name: CreateOrganizationInvitation :exec
+INSERT INTO organization_invitations (organization_id, professional_id, invited_by, role, token_hash, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+type CreateOrganizationInvitationParams struct {
+	OrganizationID int64         `json:"organization_id"`
+	ProfessionalID int64         `json:"professional_id"`
+	InvitedBy      sql.NullInt64 `json:"invited_by"`
+	Role           string        `json:"role"`
+	TokenHash      string        `json:"token_hash"`
+	ExpiresAt      time.Time     `json:"expires_at"`
+}
+
+func (q *Queries) CreateOrganizationInvitation(ctx context.Context, arg CreateOrganizationInvitationParams) error {
+	_, err := q.db.ExecContext(ctx, createOrganizationInvitation,
+		arg.OrganizationID,
+		arg.ProfessionalID,
+		arg.InvitedBy,
+		arg.Role,
+		arg.TokenHash,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const createPackageConsumption = `-- name: CreatePackageConsumption :exec
+INSERT INTO package_consumptions (appointment_id, package_id)
+VALUES ($1, $2)
+`
+
+type CreatePackageConsumptionParams struct {
+	AppointmentID int64 `json:"appointment_id"`
+	PackageID     int64 `json:"package_id"`
+}
+
+func (q *Queries) CreatePackageConsumption(ctx context.Context, arg CreatePackageConsumptionParams) error {
+	_, err := q.db.ExecContext(ctx, createPackageConsumption, arg.AppointmentID, arg.PackageID)
+	return err
+}
+
+const createPasswordResetToken = `-- name: CreatePasswordResetToken :exec
+INSERT INTO password_reset_tokens (professional_id, token_hash, expires_at)
+VALUES ($1, $2, $3)
+`
+
+type CreatePasswordResetTokenParams struct {
+	ProfessionalID int64     `json:"professional_id"`
+	TokenHash      string    `json:"token_hash"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) error {
+	_, err := q.db.ExecContext(ctx, createPasswordResetToken, arg.ProfessionalID, arg.TokenHash, arg.ExpiresAt)
+	return err
+}
+
+const createPriceListEntry = `-- name: CreatePriceListEntry :one
+INSERT INTO price_list_entries (professional_id, modality, duration_minutes, price, effective_from, index_series)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (professional_id, modality, duration_minutes, effective_from) DO UPDATE SET
+    price = EXCLUDED.price,
+    index_series = EXCLUDED.index_series,
+    applied_at = NULL
+RETURNING id, professional_id, modality, duration_minutes, price, effective_from, index_series, applied_at, created_at
+`
+
+type CreatePriceListEntryParams struct {
+	ProfessionalID  int64          `json:"professional_id"`
+	Modality        string         `json:"modality"`
+	DurationMinutes int32          `json:"duration_minutes"`
+	Price           string         `json:"price"`
+	EffectiveFrom   time.Time      `json:"effective_from"`
+	IndexSeries     sql.NullString `json:"index_series"`
+}
+
+// SECTION: Lista de Precios e Índices
+func (q *Queries) CreatePriceListEntry(ctx context.Context, arg CreatePriceListEntryParams) (PriceListEntry, error) {
+	row := q.db.QueryRowContext(ctx, createPriceListEntry,
+		arg.ProfessionalID,
+		arg.Modality,
+		arg.DurationMinutes,
+		arg.Price,
+		arg.EffectiveFrom,
+		arg.IndexSeries,
+	)
+	var i PriceListEntry
+	err := row.Scan(
+		&i.ID,
+		&i.ProfessionalID,
+		&i.Modality,
+		&i.DurationMinutes,
+		&i.Price,
+		&i.EffectiveFrom,
+		&i.IndexSeries,
+		&i.AppliedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createProfessional = `-- name: CreateProfessional :one
+
+INSERT INTO professionals (name, email, phone, slug, cancellation_window_hours)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, name, email, phone, slug, photo_url, title, license_number, bio, cancellation_window_hours, email_verified_at, created_at
+`
+
+type CreateProfessionalParams struct {
+	Name                    string         `json:"name"`
+	Email                   string         `json:"email"`
+	Phone                   sql.NullString `json:"phone"`
+	Slug                    sql.NullString `json:"slug"`
+	CancellationWindowHours sql.NullInt32  `json:"cancellation_window_hours"`
+}
+
+// SECTION: Professionals
+func (q *Queries) CreateProfessional(ctx context.Context, arg CreateProfessionalParams) (Professional, error) {
+	row := q.db.QueryRowContext(ctx, createProfessional,
+		arg.Name,
+		arg.Email,
+		arg.Phone,
+		arg.Slug,
+		arg.CancellationWindowHours,
+	)
+	var i Professional
 	err := row.Scan(
 		&i.ID,
 		&i.Name,
+		&i.Email,
+		&i.Phone,
 		&i.Slug,
 		&i.PhotoUrl,
 		&i.Title,
 		&i.LicenseNumber,
 		&i.Bio,
-		&i.Email,
-		&i.Phone,
+		&i.CancellationWindowHours,
+		&i.EmailVerifiedAt,
+		&i.CreatedAt,
 	)
 	return i, err
 }
 
-const getProfessionalSettings = `-- name: GetProfessionalSettings :one
-SELECT professional_id, default_duration_minutes, default_price, buffer_minutes, time_increment_minutes, bank_cbu, bank_alias, bank_name, bank_holder_name, send_alias_by_email, mp_access_token, mp_user_id, afip_crt_url, afip_key_url, afip_point_of_sale, notify_by_email, notify_by_whatsapp, min_booking_notice_hours, max_daily_appointments, updated_at FROM professional_settings
-WHERE professional_id = $1
+const createRecoveryCode = `-- name: CreateRecoveryCode :exec
+INSERT INTO two_factor_recovery_codes (professional_id, code_hash)
+VALUES ($1, $2)
 `
 
-func (q *Queries) GetProfessionalSettings(ctx context.Context, professionalID int64) (ProfessionalSetting, error) {
-	row := q.db.QueryRowContext(ctx, getProfessionalSettings, professionalID)
-	var i ProfessionalSetting
+type CreateRecoveryCodeParams struct {
+	ProfessionalID int64  `json:"professional_id"`
+	CodeHash       string `json:"code_hash"`
+}
+
+func (q *Queries) CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) error {
+	_, err := q.db.ExecContext(ctx, createRecoveryCode, arg.ProfessionalID, arg.CodeHash)
+	return err
+}
+
+const createRecurringRule = `-- name: CreateRecurringRule :one
+
+INSERT INTO recurring_rules (
+    professional_id, client_id, day_of_week, start_time, duration_minutes, 
+    modality, price, start_date, active
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, TRUE)
+RETURNING id, professional_id, client_id, day_of_week, start_time, duration_minutes, modality, price, active, start_date, created_at
+`
+
+type CreateRecurringRuleParams struct {
+	ProfessionalID  int64          `json:"professional_id"`
+	ClientID        int64          `json:"client_id"`
+	DayOfWeek       int32          `json:"day_of_week"`
+	StartTime       string         `json:"start_time"`
+	DurationMinutes int32          `json:"duration_minutes"`
+	Modality        sql.NullString `json:"modality"`
+	Price           sql.NullString `json:"price"`
+	StartDate       sql.NullTime   `json:"start_date"`
+}
+
+// SECTION: Recurring Rules
+func (q *Queries) CreateRecurringRule(ctx context.Context, arg CreateRecurringRuleParams) (RecurringRule, error) {
+	row := q.db.QueryRowContext(ctx, createRecurringRule,
+		arg.ProfessionalID,
+		arg.ClientID,
+		arg.DayOfWeek,
+		arg.StartTime,
+		arg.DurationMinutes,
+		arg.Modality,
+		arg.Price,
+		arg.StartDate,
+	)
+	var i RecurringRule
 	err := row.Scan(
+		&i.ID,
 		&i.ProfessionalID,
-		&i.DefaultDurationMinutes,
-		&i.DefaultPrice,
-		&i.BufferMinutes,
-		&i.TimeIncrementMinutes,
-		&i.BankCbu,
-		&i.BankAlias,
-		&i.BankName,
-		&i.BankHolderName,
-		&i.SendAliasByEmail,
-		&i.MpAccessToken,
-		&i.MpUserID,
-		&i.AfipCrtUrl,
-		&i.AfipKeyUrl,
-		&i.AfipPointOfSale,
-		&i.NotifyByEmail,
-		&i.NotifyByWhatsapp,
-		&i.MinBookingNoticeHours,
-		&i.MaxDailyAppointments,
-		&i.UpdatedAt,
+		&i.ClientID,
+		&i.DayOfWeek,
+		&i.StartTime,
+		&i.DurationMinutes,
+		&i.Modality,
+		&i.Price,
+		&i.Active,
+		&i.StartDate,
+		&i.CreatedAt,
 	)
 	return i, err
 }
 
-const listAppointmentsInDateRange = `-- name: ListAppointmentsInDateRange :many
-SELECT a.id, a.professional_id, a.client_id, a.date, a.start_time, a.duration_minutes, a.status, a.modality, a.meeting_url, a.price, a.concept, a.payment_status, a.payment_method, a.payment_proof_url, a.payment_confirmed_at, a.invoice_status, a.invoice_url, a.invoice_cae, a.notes, a.rescheduled_from_id, a.recurring_rule_id, a.created_at, a.updated_at, c.name as client_name
-FROM appointments a
-JOIN clients c ON a.client_id = c.id
-WHERE a.professional_id = $1 
-  AND a.date >= $2::date 
-  AND a.date <= $3::date
-ORDER BY a.date, a.start_time
+const createRefreshToken = `-- name: CreateRefreshToken :one
+INSERT INTO refresh_tokens (professional_id, token_hash, expires_at)
+VALUES ($1, $2, $3)
+RETURNING id, professional_id, token_hash, expires_at, revoked_at, created_at
 `
 
-type ListAppointmentsInDateRangeParams struct {
+type CreateRefreshTokenParams struct {
 	ProfessionalID int64     `json:"professional_id"`
-	Column2        time.Time `json:"column_2"`
-	Column3        time.Time `json:"column_3"`
+	TokenHash      string    `json:"token_hash"`
+	ExpiresAt      time.Time `json:"expires_at"`
 }
 
-type ListAppointmentsInDateRangeRow struct {
-	ID                 int64          `json:"id"`
-	ProfessionalID     int64          `json:"professional_id"`
-	ClientID           int64          `json:"client_id"`
-	Date               time.Time      `json:"date"`
-	StartTime          string         `json:"start_time"`
-	DurationMinutes    int32          `json:"duration_minutes"`
-	Status             sql.NullString `json:"status"`
-	Modality           sql.NullString `json:"modality"`
-	MeetingUrl         sql.NullString `json:"meeting_url"`
-	Price              sql.NullString `json:"price"`
-	Concept            sql.NullString `json:"concept"`
-	PaymentStatus      sql.NullString `json:"payment_status"`
-	PaymentMethod      sql.NullString `json:"payment_method"`
-	PaymentProofUrl    sql.NullString `json:"payment_proof_url"`
-	PaymentConfirmedAt sql.NullTime   `json:"payment_confirmed_at"`
-	InvoiceStatus      sql.NullString `json:"invoice_status"`
-	InvoiceUrl         sql.NullString `json:"invoice_url"`
-	InvoiceCae         sql.NullString `json:"invoice_cae"`
-	Notes              sql.NullString `json:"notes"`
-	RescheduledFromID  sql.NullInt64  `json:"rescheduled_from_id"`
-	RecurringRuleID    sql.NullInt64  `json:"recurring_rule_id"`
-	CreatedAt          sql.NullTime   `json:"created_at"`
-	UpdatedAt          sql.NullTime   `json:"updated_at"`
-	ClientName         string         `json:"client_name"`
+func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, createRefreshToken, arg.ProfessionalID, arg.TokenHash, arg.ExpiresAt)
+	var i RefreshToken
+	err := row.Scan(
+		&i.ID,
+		&i.ProfessionalID,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
 }
 
-func (q *Queries) ListAppointmentsInDateRange(ctx context.Context, arg ListAppointmentsInDateRangeParams) ([]ListAppointmentsInDateRangeRow, error) {
-	rows, err := q.db.QueryContext(ctx, listAppointmentsInDateRange, arg.ProfessionalID, arg.Column2, arg.Column3)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []ListAppointmentsInDateRangeRow
-	for rows.Next() {
-		var i ListAppointmentsInDateRangeRow
-		if err := rows.Scan(
-			&i.ID,
-			&i.ProfessionalID,
-			&i.ClientID,
-			&i.Date,
-			&i.StartTime,
-			&i.DurationMinutes,
-			&i.Status,
-			&i.Modality,
-			&i.MeetingUrl,
-			&i.Price,
-			&i.Concept,
-			&i.PaymentStatus,
-			&i.PaymentMethod,
-			&i.PaymentProofUrl,
-			&i.PaymentConfirmedAt,
-			&i.InvoiceStatus,
-			&i.InvoiceUrl,
-			&i.InvoiceCae,
-			&i.Notes,
-			&i.RescheduledFromID,
-			&i.RecurringRuleID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.ClientName,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
+const createScheduleConfig = `-- name: CreateScheduleConfig :one
+
+INSERT INTO schedule_configs (professional_id, day_of_week, start_time, end_time)
+VALUES ($1, $2, $3, $4)
+RETURNING id, professional_id, day_of_week, start_time, end_time, created_at
+`
+
+type CreateScheduleConfigParams struct {
+	ProfessionalID int64  `json:"professional_id"`
+	DayOfWeek      int32  `json:"day_of_week"`
+	StartTime      string `json:"start_time"`
+	EndTime        string `json:"end_time"`
+}
+
+// SECTION: Schedule Configuration
+func (q *Queries) CreateScheduleConfig(ctx context.Context, arg CreateScheduleConfigParams) (ScheduleConfig, error) {
+	row := q.db.QueryRowContext(ctx, createScheduleConfig,
+		arg.ProfessionalID,
+		arg.DayOfWeek,
+		arg.StartTime,
+		arg.EndTime,
+	)
+	var i ScheduleConfig
+	err := row.Scan(
+		&i.ID,
+		&i.ProfessionalID,
+		&i.DayOfWeek,
+		&i.StartTime,
+		&i.EndTime,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createSessionPackage = `-- name: CreateSessionPackage :one
+INSERT INTO session_packages (
+    professional_id, client_id, name, total_sessions, price,
+    valid_from, valid_until, payment_status, payment_method, paid_at
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING id, professional_id, client_id, name, total_sessions, used_sessions, price, valid_from, valid_until, payment_status, payment_method, paid_at, created_at
+`
+
+type CreateSessionPackageParams struct {
+	ProfessionalID int64          `json:"professional_id"`
+	ClientID       int64          `json:"client_id"`
+	Name           string         `json:"name"`
+	TotalSessions  int32          `json:"total_sessions"`
+	Price          string         `json:"price"`
+	ValidFrom      time.Time      `json:"valid_from"`
+	ValidUntil     time.Time      `json:"valid_until"`
+	PaymentStatus  sql.NullString `json:"payment_status"`
+	PaymentMethod  sql.NullString `json:"payment_method"`
+	PaidAt         sql.NullTime   `json:"paid_at"`
+}
+
+// SECTION: Paquetes de Sesiones
+func (q *Queries) CreateSessionPackage(ctx context.Context, arg CreateSessionPackageParams) (SessionPackage, error) {
+	row := q.db.QueryRowContext(ctx, createSessionPackage,
+		arg.ProfessionalID,
+		arg.ClientID,
+		arg.Name,
+		arg.TotalSessions,
+		arg.Price,
+		arg.ValidFrom,
+		arg.ValidUntil,
+		arg.PaymentStatus,
+		arg.PaymentMethod,
+		arg.PaidAt,
+	)
+	var i SessionPackage
+	err := row.Scan(
+		&i.ID,
+		&i.ProfessionalID,
+		&i.ClientID,
+		&i.Name,
+		&i.TotalSessions,
+		&i.UsedSessions,
+		&i.Price,
+		&i.ValidFrom,
+		&i.ValidUntil,
+		&i.PaymentStatus,
+		&i.PaymentMethod,
+		&i.PaidAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteAttachment = `-- name: DeleteAttachment :exec
+DELETE FROM attachments WHERE id = $1 AND professional_id = $2
+`
+
+type DeleteAttachmentParams struct {
+	ID             int64 `json:"id"`
+	ProfessionalID int64 `json:"professional_id"`
+}
+
+func (q *Queries) DeleteAttachment(ctx context.Context, arg DeleteAttachmentParams) error {
+	_, err := q.db.ExecContext(ctx, deleteAttachment, arg.ID, arg.ProfessionalID)
+	return err
+}
+
+const deleteClinicalNote = `-- name: DeleteClinicalNote :execrows
+DELETE FROM clinical_notes
+WHERE id = $1 AND professional_id = $2 AND type = 'personal'
+`
+
+type DeleteClinicalNoteParams struct {
+	ID             int64 `json:"id"`
+	ProfessionalID int64 `json:"professional_id"`
+}
+
+// Sólo las notas personales: las clínicas integran la historia clínica y se conservan
+func (q *Queries) DeleteClinicalNote(ctx context.Context, arg DeleteClinicalNoteParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteClinicalNote, arg.ID, arg.ProfessionalID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteNoteAddenda = `-- name: DeleteNoteAddenda :exec
+DELETE FROM clinical_note_addenda WHERE note_id = $1
+`
+
+func (q *Queries) DeleteNoteAddenda(ctx context.Context, noteID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteNoteAddenda, noteID)
+	return err
+}
+
+const deleteNoteAttachments = `-- name: DeleteNoteAttachments :exec
+DELETE FROM attachments WHERE note_id = $1
+`
+
+func (q *Queries) DeleteNoteAttachments(ctx context.Context, noteID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteNoteAttachments, noteID)
+	return err
+}
+
+const deleteNoteRevisions = `-- name: DeleteNoteRevisions :exec
+DELETE FROM clinical_note_revisions WHERE note_id = $1
+`
+
+func (q *Queries) DeleteNoteRevisions(ctx context.Context, noteID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteNoteRevisions, noteID)
+	return err
+}
+
+const deleteNoteSearchTokens = `-- name: DeleteNoteSearchTokens :exec
+DELETE FROM clinical_note_search_tokens WHERE note_id = $1
+`
+
+func (q *Queries) DeleteNoteSearchTokens(ctx context.Context, noteID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteNoteSearchTokens, noteID)
+	return err
+}
+
+const deletePackageConsumption = `-- name: DeletePackageConsumption :exec
+DELETE FROM package_consumptions WHERE appointment_id = $1
+`
+
+func (q *Queries) DeletePackageConsumption(ctx context.Context, appointmentID int64) error {
+	_, err := q.db.ExecContext(ctx, deletePackageConsumption, appointmentID)
+	return err
+}
+
+const deleteRecoveryCodes = `-- name: DeleteRecoveryCodes :exec
+DELETE FROM two_factor_recovery_codes WHERE professional_id = $1
+`
+
+func (q *Queries) DeleteRecoveryCodes(ctx context.Context, professionalID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteRecoveryCodes, professionalID)
+	return err
+}
+
+const deleteScheduleConfigs = `-- name: DeleteScheduleConfigs :exec
+DELETE FROM schedule_configs WHERE professional_id = $1
+`
+
+func (q *Queries) DeleteScheduleConfigs(ctx context.Context, professionalID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteScheduleConfigs, professionalID)
+	return err
+}
+
+const deleteTwoFactor = `-- name: DeleteTwoFactor :exec
+DELETE FROM professional_two_factor WHERE professional_id = $1
+`
+
+func (q *Queries) DeleteTwoFactor(ctx context.Context, professionalID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteTwoFactor, professionalID)
+	return err
+}
+
+const emailExists = `-- name: EmailExists :one
+SELECT EXISTS (SELECT 1 FROM professionals WHERE lower(email) = lower($1))
+`
+
+func (q *Queries) EmailExists(ctx context.Context, email string) (bool, error) {
+	row := q.db.QueryRowContext(ctx, emailExists, email)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const findClientByEmail = `-- name: FindClientByEmail :one
+SELECT id, professional_id, name, email, phone, birth_date, medications, emergency_contact_name, emergency_contact_phone, active, created_at FROM clients
+WHERE professional_id = $1 AND lower(email) = lower($2) AND active = TRUE
+ORDER BY id
+LIMIT 1
+`
+
+type FindClientByEmailParams struct {
+	ProfessionalID int64  `json:"professional_id"`
+	Email          string `json:"email"`
+}
+
+// La ficha activa del profesional con ese email, si ya es paciente
+func (q *Queries) FindClientByEmail(ctx context.Context, arg FindClientByEmailParams) (Client, error) {
+	row := q.db.QueryRowContext(ctx, findClientByEmail, arg.ProfessionalID, arg.Email)
+	var i Client
+	err := row.Scan(
+		&i.ID,
+		&i.ProfessionalID,
+		&i.Name,
+		&i.Email,
+		&i.Phone,
+		&i.BirthDate,
+		&i.Medications,
+		&i.EmergencyContactName,
+		&i.EmergencyContactPhone,
+		&i.Active,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const findPackageForAppointment = `-- name: FindPackageForAppointment :one
+SELECT id, professional_id, client_id, name, total_sessions, used_sessions, price, valid_from, valid_until, payment_status, payment_method, paid_at, created_at FROM session_packages
+WHERE professional_id = $1
+  AND client_id = $2
+  AND valid_from <= $3::date
+  AND valid_until >= $3::date
+  AND used_sessions < total_sessions
+ORDER BY valid_until, id
+LIMIT 1
+FOR UPDATE
+`
+
+type FindPackageForAppointmentParams struct {
+	ProfessionalID int64     `json:"professional_id"`
+	ClientID       int64     `json:"client_id"`
+	Date           time.Time `json:"date"`
+}
+
+// Paquete vigente con sesiones disponibles para la fecha del turno (se consume el que vence antes)
+func (q *Queries) FindPackageForAppointment(ctx context.Context, arg FindPackageForAppointmentParams) (SessionPackage, error) {
+	row := q.db.QueryRowContext(ctx, findPackageForAppointment, arg.ProfessionalID, arg.ClientID, arg.Date)
+	var i SessionPackage
+	err := row.Scan(
+		&i.ID,
+		&i.ProfessionalID,
+		&i.ClientID,
+		&i.Name,
+		&i.TotalSessions,
+		&i.UsedSessions,
+		&i.Price,
+		&i.ValidFrom,
+		&i.ValidUntil,
+		&i.PaymentStatus,
+		&i.PaymentMethod,
+		&i.PaidAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getActiveRecurringRules = `-- name: GetActiveRecurringRules :many
+SELECT r.id, r.professional_id, r.client_id, r.day_of_week, r.start_time, r.duration_minutes, r.modality, r.price, r.active, r.start_date, r.created_at FROM recurring_rules r
+JOIN clients c ON r.client_id = c.id
+WHERE r.professional_id = $1 
+  AND r.active = TRUE 
+  AND c.active = TRUE
+`
+
+func (q *Queries) GetActiveRecurringRules(ctx context.Context, professionalID int64) ([]RecurringRule, error) {
+	rows, err := q.db.QueryContext(ctx, getActiveRecurringRules, professionalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RecurringRule
+	for rows.Next() {
+		var i RecurringRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProfessionalID,
+			&i.ClientID,
+			&i.DayOfWeek,
+			&i.StartTime,
+			&i.DurationMinutes,
+			&i.Modality,
+			&i.Price,
+			&i.Active,
+			&i.StartDate,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAppointment = `-- name: GetAppointment :one
+SELECT a.id, a.professional_id, a.client_id, a.date, a.start_time, a.duration_minutes, a.status, a.modality, a.meeting_url, a.price, a.concept, a.payment_status, a.payment_method, a.payment_proof_url, a.payment_confirmed_at, a.invoice_status, a.invoice_url, a.invoice_cae, a.notes, a.rescheduled_from_id, a.recurring_rule_id, a.created_at, a.updated_at, c.name as client_name, c.email as client_email
+FROM appointments a
+JOIN clients c ON a.client_id = c.id
+WHERE a.id = $1 AND a.professional_id = $2 LIMIT 1
+`
+
+type GetAppointmentParams struct {
+	ID             int64 `json:"id"`
+	ProfessionalID int64 `json:"professional_id"`
+}
+
+type GetAppointmentRow struct {
+	ID                 int64          `json:"id"`
+	ProfessionalID     int64          `json:"professional_id"`
+	ClientID           int64          `json:"client_id"`
+	Date               time.Time      `json:"date"`
+	StartTime          string         `json:"start_time"`
+	DurationMinutes    int32          `json:"duration_minutes"`
+	Status             sql.NullString `json:"status"`
+	Modality           sql.NullString `json:"modality"`
+	MeetingUrl         sql.NullString `json:"meeting_url"`
+	Price              sql.NullString `json:"price"`
+	Concept            sql.NullString `json:"concept"`
+	PaymentStatus      sql.NullString `json:"payment_status"`
+	PaymentMethod      sql.NullString `json:"payment_method"`
+	PaymentProofUrl    sql.NullString `json:"payment_proof_url"`
+	PaymentConfirmedAt sql.NullTime   `json:"payment_confirmed_at"`
+	InvoiceStatus      sql.NullString `json:"invoice_status"`
+	InvoiceUrl         sql.NullString `json:"invoice_url"`
+	InvoiceCae         sql.NullString `json:"invoice_cae"`
+	Notes              sql.NullString `json:"notes"`
+	RescheduledFromID  sql.NullInt64  `json:"rescheduled_from_id"`
+	RecurringRuleID    sql.NullInt64  `json:"recurring_rule_id"`
+	CreatedAt          sql.NullTime   `json:"created_at"`
+	UpdatedAt          sql.NullTime   `json:"updated_at"`
+	ClientName         string         `json:"client_name"`
+	ClientEmail        sql.NullString `json:"client_email"`
+}
+
+func (q *Queries) GetAppointment(ctx context.Context, arg GetAppointmentParams) (GetAppointmentRow, error) {
+	row := q.db.QueryRowContext(ctx, getAppointment, arg.ID, arg.ProfessionalID)
+	var i GetAppointmentRow
+	err := row.Scan(
+		&i.ID,
+		&i.ProfessionalID,
+		&i.ClientID,
+		&i.Date,
+		&i.StartTime,
+		&i.DurationMinutes,
+		&i.Status,
+		&i.Modality,
+		&i.MeetingUrl,
+		&i.Price,
+		&i.Concept,
+		&i.PaymentStatus,
+		&i.PaymentMethod,
+		&i.PaymentProofUrl,
+		&i.PaymentConfirmedAt,
+		&i.InvoiceStatus,
+		&i.InvoiceUrl,
+		&i.InvoiceCae,
+		&i.Notes,
+		&i.RescheduledFromID,
+		&i.RecurringRuleID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ClientName,
+		&i.ClientEmail,
+	)
+	return i, err
+}
+
+const getAppointmentForUpdate = `-- name: GetAppointmentForUpdate :one
+SELECT id, professional_id, client_id, date, start_time, duration_minutes, status, modality, meeting_url, price, concept, payment_status, payment_method, payment_proof_url, payment_confirmed_at, invoice_status, invoice_url, invoice_cae, notes, rescheduled_from_id, recurring_rule_id, created_at, updated_at FROM appointments WHERE id = $1 AND professional_id = $2 LIMIT 1 FOR UPDATE
+`
+
+type GetAppointmentForUpdateParams struct {
+	ID             int64 `json:"id"`
+	ProfessionalID int64 `json:"professional_id"`
+}
+
+// Bloquea el turno hasta el fin de la transacción: dos cambios de estado simultáneos no pueden
+// consumir (o devolver) dos veces la sesión del paquete
+func (q *Queries) GetAppointmentForUpdate(ctx context.Context, arg GetAppointmentForUpdateParams) (Appointment, error) {
+	row := q.db.QueryRowContext(ctx, getAppointmentForUpdate, arg.ID, arg.ProfessionalID)
+	var i Appointment
+	err := row.Scan(
+		&i.ID,
+		&i.ProfessionalID,
+		&i.ClientID,
+		&i.Date,
+		&i.StartTime,
+		&i.DurationMinutes,
+		&i.Status,
+		&i.Modality,
+		&i.MeetingUrl,
+		&i.Price,
+		&i.Concept,
+		&i.PaymentStatus,
+		&i.PaymentMethod,
+		&i.PaymentProofUrl,
+		&i.PaymentConfirmedAt,
+		&i.InvoiceStatus,
+		&i.InvoiceUrl,
+		&i.InvoiceCae,
+		&i.Notes,
+		&i.RescheduledFromID,
+		&i.RecurringRuleID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getAssistant = `-- name: GetAssistant :one
+SELECT id, name, email, phone, created_at FROM assistants WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetAssistant(ctx context.Context, id int64) (Assistant, error) {
+	row := q.db.QueryRowContext(ctx, getAssistant, id)
+	var i Assistant
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.Phone,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getAssistantByEmail = `-- name: GetAssistantByEmail :one
+SELECT id, name, email, phone, created_at FROM assistants WHERE lower(email) = lower($1) LIMIT 1
+`
+
+func (q *Queries) GetAssistantByEmail(ctx context.Context, email string) (Assistant, error) {
+	row := q.db.QueryRowContext(ctx, getAssistantByEmail, email)
+	var i Assistant
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.Phone,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getAssistantCredentialsByEmail = `-- name: GetAssistantCredentialsByEmail :one
+SELECT a.id AS assistant_id, c.password_hash
+FROM assistants a
+JOIN assistant_credentials c ON c.assistant_id = a.id
+WHERE lower(a.email) = lower($1)
+LIMIT 1
+`
+
+type GetAssistantCredentialsByEmailRow struct {
+	AssistantID  int64  `json:"assistant_id"`
+	PasswordHash string `json:"password_hash"`
+}
+
+func (q *Queries) GetAssistantCredentialsByEmail(ctx context.Context, email string) (GetAssistantCredentialsByEmailRow, error) {
+	row := q.db.QueryRowContext(ctx, getAssistantCredentialsByEmail, email)
+	var i GetAssistantCredentialsByEmailRow
+	err := row.Scan(&i.AssistantID, &i.PasswordHash)
+	return i, err
+}
+
+const getAssistantRefreshToken = `-- name: GetAssistantRefreshToken :one
+SELECT id, assistant_id, token_hash, expires_at, revoked_at, created_at FROM assistant_refresh_tokens WHERE token_hash = $1 LIMIT 1
+`
+
+func (q *Queries) GetAssistantRefreshToken(ctx context.Context, tokenHash string) (AssistantRefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, getAssistantRefreshToken, tokenHash)
+	var i AssistantRefreshToken
+	err := row.Scan(
+		&i.ID,
+		&i.AssistantID,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getAttachment = `-- name: GetAttachment :one
+SELECT id, professional_id, client_id, note_id, filename, content_type, size_bytes, sha256, storage_key, data_key, key_version, created_at FROM attachments WHERE id = $1 AND professional_id = $2 LIMIT 1
+`
+
+type GetAttachmentParams struct {
+	ID             int64 `json:"id"`
+	ProfessionalID int64 `json:"professional_id"`
+}
+
+func (q *Queries) GetAttachment(ctx context.Context, arg GetAttachmentParams) (Attachment, error) {
+	row := q.db.QueryRowContext(ctx, getAttachment, arg.ID, arg.ProfessionalID)
+	var i Attachment
+	err := row.Scan(
+		&i.ID,
+		&i.ProfessionalID,
+		&i.ClientID,
+		&i.NoteID,
+		&i.Filename,
+		&i.ContentType,
+		&i.SizeBytes,
+		&i.Sha256,
+		&i.StorageKey,
+		&i.DataKey,
+		&i.KeyVersion,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getClient = `-- name: GetClient :one
+SELECT id, professional_id, name, email, phone, birth_date, medications, emergency_contact_name, emergency_contact_phone, active, created_at FROM clients WHERE id = $1 AND professional_id = $2 LIMIT 1
+`
+
+type GetClientParams struct {
+	ID             int64 `json:"id"`
+	ProfessionalID int64 `json:"professional_id"`
+}
+
+func (q *Queries) GetClient(ctx context.Context, arg GetClientParams) (Client, error) {
+	row := q.db.QueryRowContext(ctx, getClient, arg.ID, arg.ProfessionalID)
+	var i Client
+	err := row.Scan(
+		&i.ID,
+		&i.ProfessionalID,
+		&i.Name,
+		&i.Email,
+		&i.Phone,
+		&i.BirthDate,
+		&i.Medications,
+		&i.EmergencyContactName,
+		&i.EmergencyContactPhone,
+		&i.Active,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getClientAppointment = `-- name: GetClientAppointment :one
+SELECT id, professional_id, client_id, date, start_time, duration_minutes, status, modality, meeting_url, price, concept, payment_status, payment_method, payment_proof_url, payment_confirmed_at, invoice_status, invoice_url, invoice_cae, notes, rescheduled_from_id, recurring_rule_id, created_at, updated_at FROM appointments
+WHERE id = $1 AND client_id = $2 AND professional_id = $3
+LIMIT 1
+`
+
+type GetClientAppointmentParams struct {
+	ID             int64 `json:"id"`
+	ClientID       int64 `json:"client_id"`
+	ProfessionalID int64 `json:"professional_id"`
+}
+
+func (q *Queries) GetClientAppointment(ctx context.Context, arg GetClientAppointmentParams) (Appointment, error) {
+	row := q.db.QueryRowContext(ctx, getClientAppointment, arg.ID, arg.ClientID, arg.ProfessionalID)
+	var i Appointment
+	err := row.Scan(
+		&i.ID,
+		&i.ProfessionalID,
+		&i.ClientID,
+		&i.Date,
+		&i.StartTime,
+		&i.DurationMinutes,
+		&i.Status,
+		&i.Modality,
+		&i.MeetingUrl,
+		&i.Price,
+		&i.Concept,
+		&i.PaymentStatus,
+		&i.PaymentMethod,
+		&i.PaymentProofUrl,
+		&i.PaymentConfirmedAt,
+		&i.InvoiceStatus,
+		&i.InvoiceUrl,
+		&i.InvoiceCae,
+		&i.Notes,
+		&i.RescheduledFromID,
+		&i.RecurringRuleID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getClientCoverage = `-- name: GetClientCoverage :one
+SELECT id, professional_id, client_id, insurer_id, plan, member_number, copay_amount, active, created_at FROM client_coverages WHERE id = $1 AND professional_id = $2 LIMIT 1
+`
+
+type GetClientCoverageParams struct {
+	ID             int64 `json:"id"`
+	ProfessionalID int64 `json:"professional_id"`
+}
+
+func (q *Queries) GetClientCoverage(ctx context.Context, arg GetClientCoverageParams) (ClientCoverage, error) {
+	row := q.db.QueryRowContext(ctx, getClientCoverage, arg.ID, arg.ProfessionalID)
+	var i ClientCoverage
+	err := row.Scan(
+		&i.ID,
+		&i.ProfessionalID,
+		&i.ClientID,
+		&i.InsurerID,
+		&i.Plan,
+		&i.MemberNumber,
+		&i.CopayAmount,
+		&i.Active,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getClientForm = `-- name: GetClientForm :one
+SELECT id, professional_id, client_id, title, sections, answers, key_version, completed_at, created_at FROM client_forms
+WHERE id = $1 AND professional_id = $2 AND client_id = $3
+LIMIT 1
+`
+
+type GetClientFormParams struct {
+	ID             int64 `json:"id"`
+	ProfessionalID int64 `json:"professional_id"`
+	ClientID       int64 `json:"client_id"`
+}
+
+func (q *Queries) GetClientForm(ctx context.Context, arg GetClientFormParams) (ClientForm, error) {
+	row := q.db.QueryRowContext(ctx, getClientForm, arg.ID, arg.ProfessionalID, arg.ClientID)
+	var i ClientForm
+	err := row.Scan(
+		&i.ID,
+		&i.ProfessionalID,
+		&i.ClientID,
+		&i.Title,
+		&i.Sections,
+		&i.Answers,
+		&i.KeyVersion,
+		&i.CompletedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getCredentialsByEmail = `-- name: GetCredentialsByEmail :one
+SELECT p.id AS professional_id, c.password_hash
+FROM professionals p
+JOIN professional_credentials c ON c.professional_id = p.id
+WHERE lower(p.email) = lower($1)
+LIMIT 1
+`
+
+type GetCredentialsByEmailRow struct {
+	ProfessionalID int64  `json:"professional_id"`
+	PasswordHash   string `json:"password_hash"`
+}
+
+// El email se compara sin distinguir mayúsculas
+func (q *Queries) GetCredentialsByEmail(ctx context.Context, email string) (GetCredentialsByEmailRow, error) {
+	row := q.db.QueryRowContext(ctx, getCredentialsByEmail, email)
+	var i GetCredentialsByEmailRow
+	err := row.Scan(&i.ProfessionalID, &i.PasswordHash)
+	return i, err
+}
+
+const getCurrentPriceList = `-- name: GetCurrentPriceList :many
+SELECT DISTINCT ON (modality, duration_minutes) *
+FROM price_list_entries
+WHERE professional_id = $1
+  AND effective_from <= $2::date
+ORDER BY modality, duration_minutes, effective_from DESC
+`
+
+type GetCurrentPriceListParams struct {
+	ProfessionalID int64     `json:"professional_id"`
+	Date           time.Time `json:"date"`
+}
+
+// Precio vigente a una fecha para cada combinación modalidad/duración
+func (q *Queries) GetCurrentPriceList(ctx context.Context, arg GetCurrentPriceListParams) ([]PriceListEntry, error) {
+	rows, err := q.db.QueryContext(ctx, getCurrentPriceList, arg.ProfessionalID, arg.Date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PriceListEntry
+	for rows.Next() {
+		var i PriceListEntry
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProfessionalID,
+			&i.Modality,
+			&i.DurationMinutes,
+			&i.Price,
+			&i.EffectiveFrom,
+			&i.IndexSeries,
+			&i.AppliedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDayAppointments = `-- name: GetDayAppointments :many
+SELECT id, start_time, duration_minutes, status
+FROM appointments
+WHERE professional_id = $1 
+  AND date = $2::date 
+  AND status NOT IN ('cancelled', 'rescheduled')
+`
+
+type GetDayAppointmentsParams struct {
+	ProfessionalID int64     `json:"professional_id"`
+	Column2        time.Time `json:"column_2"`
+}
+
+type GetDayAppointmentsRow struct {
+	ID              int64          `json:"id"`
+	StartTime       string         `json:"start_time"`
+	DurationMinutes int32          `json:"duration_minutes"`
+	Status          sql.NullString `json:"status"`
+}
+
+func (q *Queries) GetDayAppointments(ctx context.Context, arg GetDayAppointmentsParams) ([]GetDayAppointmentsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getDayAppointments, arg.ProfessionalID, arg.Column2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDayAppointmentsRow
+	for rows.Next() {
+		var i GetDayAppointmentsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.StartTime,
+			&i.DurationMinutes,
+			&i.Status,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDraftNotes = `-- name: GetDraftNotes :many
+SELECT n.id, n.client_id, n.appointment_id, n.template_id, n.created_at, n.updated_at,
+       c.name AS client_name
+FROM clinical_notes n
+JOIN clients c ON n.client_id = c.id
+WHERE n.professional_id = $1 AND n.status = 'draft' AND n.type = 'clinical'
+  AND n.created_at < $2::timestamptz
+ORDER BY n.created_at
+`
+
+type GetDraftNotesParams struct {
+	ProfessionalID int64     `json:"professional_id"`
+	CreatedBefore  time.Time `json:"created_before"`
+}
+
+type GetDraftNotesRow struct {
+	ID            int64         `json:"id"`
+	ClientID      int64         `json:"client_id"`
+	AppointmentID sql.NullInt64 `json:"appointment_id"`
+	TemplateID    sql.NullInt64 `json:"template_id"`
+	CreatedAt     sql.NullTime  `json:"created_at"`
+	UpdatedAt     sql.NullTime  `json:"updated_at"`
+	ClientName    string        `json:"client_name"`
+}
+
+// Para el dashboard de "Notas Pendientes": borradores clínicos sin firmar creados antes del corte
+func (q *Queries) GetDraftNotes(ctx context.Context, arg GetDraftNotesParams) ([]GetDraftNotesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getDraftNotes, arg.ProfessionalID, arg.CreatedBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDraftNotesRow
+	for rows.Next() {
+		var i GetDraftNotesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ClientID,
+			&i.AppointmentID,
+			&i.TemplateID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ClientName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFinancesDashboard = `-- name: GetFinancesDashboard :many
+SELECT a.id, a.date, a.start_time, a.client_id, a.concept, a.price,
+       a.payment_status, a.payment_method, a.payment_proof_url, a.payment_confirmed_at,
+       a.invoice_status, a.invoice_url, a.invoice_cae, a.package_id,
+       c.name as client_name
+FROM appointment_finances a
+JOIN clients c ON a.client_id = c.id
+WHERE a.professional_id = $1
+  AND a.status != 'cancelled'
+  AND ($2::date IS NULL OR a.date >= $2::date)
+  AND ($3::date IS NULL OR a.date <= $3::date)
+  AND ($4::bigint IS NULL OR a.client_id = $4::bigint)
+  AND ($5::text IS NULL OR a.payment_status = $5::text)
+  AND ($6::text IS NULL OR a.payment_method = $6::text)
+  AND ($7::text IS NULL OR a.invoice_status = $7::text)
+  AND ($8::date IS NULL OR (a.date, a.id) < ($8::date, $9::bigint))
+ORDER BY a.date DESC, a.id DESC
+LIMIT $10
+`
+
+type GetFinancesDashboardParams struct {
+	ProfessionalID int64          `json:"professional_id"`
+	StartDate      sql.NullTime   `json:"start_date"`
+	EndDate        sql.NullTime   `json:"end_date"`
+	ClientID       sql.NullInt64  `json:"client_id"`
+	PaymentStatus  sql.NullString `json:"payment_status"`
+	PaymentMethod  sql.NullString `json:"payment_method"`
+	InvoiceStatus  sql.NullString `json:"invoice_status"`
+	CursorDate     sql.NullTime   `json:"cursor_date"`
+	CursorID       sql.NullInt64  `json:"cursor_id"`
+	PageSize       int32          `json:"page_size"`
+}
+
+type GetFinancesDashboardRow struct {
+	ID                 int64          `json:"id"`
+	Date               time.Time      `json:"date"`
+	StartTime          string         `json:"start_time"`
+	ClientID           int64          `json:"client_id"`
+	Concept            sql.NullString `json:"concept"`
+	Price              sql.NullString `json:"price"`
+	PaymentStatus      sql.NullString `json:"payment_status"`
+	PaymentMethod      sql.NullString `json:"payment_method"`
+	PaymentProofUrl    sql.NullString `json:"payment_proof_url"`
+	PaymentConfirmedAt sql.NullTime   `json:"payment_confirmed_at"`
+	InvoiceStatus      sql.NullString `json:"invoice_status"`
+	InvoiceUrl         sql.NullString `json:"invoice_url"`
+	InvoiceCae         sql.NullString `json:"invoice_cae"`
+	PackageID          sql.NullInt64  `json:"package_id"`
+	ClientName         string         `json:"client_name"`
+}
+
+// Query para la pantalla de "Finanzas" (Tabla principal)
+// Filtros opcionales (NULL = sin filtro) y paginación por cursor sobre (date, id) desc
+func (q *Queries) GetFinancesDashboard(ctx context.Context, arg GetFinancesDashboardParams) ([]GetFinancesDashboardRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFinancesDashboard,
+		arg.ProfessionalID,
+		arg.StartDate,
+		arg.EndDate,
+		arg.ClientID,
+		arg.PaymentStatus,
+		arg.PaymentMethod,
+		arg.InvoiceStatus,
+		arg.CursorDate,
+		arg.CursorID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFinancesDashboardRow
+	for rows.Next() {
+		var i GetFinancesDashboardRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.StartTime,
+			&i.ClientID,
+			&i.Concept,
+			&i.Price,
+			&i.PaymentStatus,
+			&i.PaymentMethod,
+			&i.PaymentProofUrl,
+			&i.PaymentConfirmedAt,
+			&i.InvoiceStatus,
+			&i.InvoiceUrl,
+			&i.InvoiceCae,
+			&i.PackageID,
+			&i.ClientName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFinancesTotals = `-- name: GetFinancesTotals :one
+SELECT
+    COUNT(*)::BIGINT as sessions_count,
+    COALESCE(SUM(a.price), 0)::DECIMAL as total_amount,
+    COALESCE(SUM(CASE WHEN a.payment_status = 'paid' THEN a.price ELSE 0 END), 0)::DECIMAL as paid_amount,
+    COALESCE(SUM(CASE WHEN a.payment_status = 'pending' THEN a.price ELSE 0 END), 0)::DECIMAL as pending_amount,
+    COALESCE(SUM(CASE WHEN a.payment_status = 'refunded' THEN a.price ELSE 0 END), 0)::DECIMAL as refunded_amount
+FROM appointment_finances a
+WHERE a.professional_id = $1
+  AND a.status != 'cancelled'
+  AND ($2::date IS NULL OR a.date >= $2::date)
+  AND ($3::date IS NULL OR a.date <= $3::date)
+  AND ($4::bigint IS NULL OR a.client_id = $4::bigint)
+  AND ($5::text IS NULL OR a.payment_status = $5::text)
+  AND ($6::text IS NULL OR a.payment_method = $6::text)
+  AND ($7::text IS NULL OR a.invoice_status = $7::text)
+`
+
+type GetFinancesTotalsParams struct {
+	ProfessionalID int64          `json:"professional_id"`
+	StartDate      sql.NullTime   `json:"start_date"`
+	EndDate        sql.NullTime   `json:"end_date"`
+	ClientID       sql.NullInt64  `json:"client_id"`
+	PaymentStatus  sql.NullString `json:"payment_status"`
+	PaymentMethod  sql.NullString `json:"payment_method"`
+	InvoiceStatus  sql.NullString `json:"invoice_status"`
+}
+
+type GetFinancesTotalsRow struct {
+	SessionsCount  int64  `json:"sessions_count"`
+	TotalAmount    string `json:"total_amount"`
+	PaidAmount     string `json:"paid_amount"`
+	PendingAmount  string `json:"pending_amount"`
+	RefundedAmount string `json:"refunded_amount"`
+}
+
+// Totales del listado de Finanzas para los mismos filtros (sin paginar)
+func (q *Queries) GetFinancesTotals(ctx context.Context, arg GetFinancesTotalsParams) (GetFinancesTotalsRow, error) {
+	row := q.db.QueryRowContext(ctx, getFinancesTotals,
+		arg.ProfessionalID,
+		arg.StartDate,
+		arg.EndDate,
+		arg.ClientID,
+		arg.PaymentStatus,
+		arg.PaymentMethod,
+		arg.InvoiceStatus,
+	)
+	var i GetFinancesTotalsRow
+	err := row.Scan(
+		&i.SessionsCount,
+		&i.TotalAmount,
+		&i.PaidAmount,
+		&i.PendingAmount,
+		&i.RefundedAmount,
+	)
+	return i, err
+}
+
+const getFinancialSummary = `-- name: GetFinancialSummary :one
+SELECT 
+    COUNT(*)::BIGINT as sessions_count,
+    COALESCE(SUM(CASE WHEN payment_status = 'paid' THEN price ELSE 0 END), 0)::DECIMAL as income,
+    COALESCE(SUM(CASE WHEN payment_status = 'pending' THEN price ELSE 0 END), 0)::DECIMAL as pending_collection,
+    COALESCE(SUM(CASE WHEN payment_status = 'paid' AND invoice_status = 'pending' THEN price ELSE 0 END), 0)::DECIMAL as pending_invoicing,
+    COALESCE(AVG(price), 0)::DECIMAL as average_price
+FROM appointment_finances
+WHERE professional_id = $1 AND status != 'cancelled'
+  AND date >= $2::date
+  AND date <= $3::date
+`
+
+type GetFinancialSummaryParams struct {
+	ProfessionalID int64     `json:"professional_id"`
+	StartDate      time.Time `json:"start_date"`
+	EndDate        time.Time `json:"end_date"`
+}
+
+type GetFinancialSummaryRow struct {
+	SessionsCount     int64  `json:"sessions_count"`
+	Income            string `json:"income"`
+	PendingCollection string `json:"pending_collection"`
+	PendingInvoicing  string `json:"pending_invoicing"`
+	AveragePrice      string `json:"average_price"`
+}
+
+// KPIs de Finanzas: Sumas rápidas para las tarjetas de arriba, para un período arbitrario
+func (q *Queries) GetFinancialSummary(ctx context.Context, arg GetFinancialSummaryParams) (GetFinancialSummaryRow, error) {
+	row := q.db.QueryRowContext(ctx, getFinancialSummary, arg.ProfessionalID, arg.StartDate, arg.EndDate)
+	var i GetFinancialSummaryRow
+	err := row.Scan(
+		&i.SessionsCount,
+		&i.Income,
+		&i.PendingCollection,
+		&i.PendingInvoicing,
+		&i.AveragePrice,
+	)
+	return i, err
+}
+
+const getImplicitAppointmentPayment = `-- name: GetImplicitAppointmentPayment :one
+SELECT COALESCE(ac.copay_amount, a.price, 0)::DECIMAL as amount,
+       COALESCE(a.payment_confirmed_at::DATE, a.date)::DATE as paid_at
+FROM appointments a
+LEFT JOIN appointment_coverages ac ON ac.appointment_id = a.id
+WHERE a.id = $1 AND a.professional_id = $2
+  AND a.payment_status = 'paid'
+  AND NOT EXISTS (SELECT 1 FROM client_payments p WHERE p.appointment_id = a.id)
+  AND NOT EXISTS (SELECT 1 FROM package_consumptions pc WHERE pc.appointment_id = a.id)
+`
+
+type GetImplicitAppointmentPaymentParams struct {
+	ID             int64 `json:"id"`
+	ProfessionalID int64 `json:"professional_id"`
+}
+
+type GetImplicitAppointmentPaymentRow struct {
+	Amount string    `json:"amount"`
+	PaidAt time.Time `json:"paid_at"`
+}
+
+// El pago que la vista client_ledger deduce de payment_status = 'paid' cuando el turno
+// todavía no tiene movimientos en client_payments (mismo importe y fecha que la vista)
+func (q *Queries) GetImplicitAppointmentPayment(ctx context.Context, arg GetImplicitAppointmentPaymentParams) (GetImplicitAppointmentPaymentRow, error) {
+	row := q.db.QueryRowContext(ctx, getImplicitAppointmentPayment, arg.ID, arg.ProfessionalID)
+	var i GetImplicitAppointmentPaymentRow
+	err := row.Scan(&i.Amount, &i.PaidAt)
+	return i, err
+}
+
+const getInsurer = `-- name: GetInsurer :one
+SELECT id, professional_id, name, kind, code, session_fee, requires_authorization, active, created_at FROM insurers WHERE id = $1 AND professional_id = $2 LIMIT 1
+`
+
+type GetInsurerParams struct {
+	ID             int64 `json:"id"`
+	ProfessionalID int64 `json:"professional_id"`
+}
+
+func (q *Queries) GetInsurer(ctx context.Context, arg GetInsurerParams) (Insurer, error) {
+	row := q.db.QueryRowContext(ctx, getInsurer, arg.ID, arg.ProfessionalID)
+	var i Insurer
+	err := row.Scan(
+		&i.ID,
+		&i.ProfessionalID,
+		&i.Name,
+		&i.Kind,
+		&i.Code,
+		&i.SessionFee,
+		&i.RequiresAuthorization,
+		&i.Active,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getInsurerPresentation = `-- name: GetInsurerPresentation :many
+SELECT a.id as appointment_id, a.date, a.start_time, a.concept,
+       c.name as client_name, cc.plan, cc.member_number,
+       ac.authorization_number, ac.insurer_amount, ac.copay_amount
+FROM appointment_coverages ac
+JOIN appointments a ON ac.appointment_id = a.id
+JOIN clients c ON a.client_id = c.id
+JOIN client_coverages cc ON ac.coverage_id = cc.id
+WHERE a.professional_id = $1
+  AND ac.insurer_id = $2
+  AND a.status = 'completed'
+  AND a.date >= $3::date
+  AND a.date <= $4::date
+ORDER BY c.name, a.date, a.start_time
+`
+
+type GetInsurerPresentationParams struct {
+	ProfessionalID int64     `json:"professional_id"`
+	InsurerID      int64     `json:"insurer_id"`
+	StartDate      time.Time `json:"start_date"`
+	EndDate        time.Time `json:"end_date"`
+}
+
+type GetInsurerPresentationRow struct {
+	AppointmentID       int64          `json:"appointment_id"`
+	Date                time.Time      `json:"date"`
+	StartTime           string         `json:"start_time"`
+	Concept             sql.NullString `json:"concept"`
+	ClientName          string         `json:"client_name"`
+	Plan                sql.NullString `json:"plan"`
+	MemberNumber        string         `json:"member_number"`
+	AuthorizationNumber sql.NullString `json:"authorization_number"`
+	InsurerAmount       string         `json:"insurer_amount"`
+	CopayAmount         string         `json:"copay_amount"`
+}
+
+// Presentación mensual: sesiones realizadas con cobertura de la obra social en el período
+func (q *Queries) GetInsurerPresentation(ctx context.Context, arg GetInsurerPresentationParams) ([]GetInsurerPresentationRow, error) {
+	rows, err := q.db.QueryContext(ctx, getInsurerPresentation,
+		arg.ProfessionalID,
+		arg.InsurerID,
+		arg.StartDate,
+		arg.EndDate,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetInsurerPresentationRow
+	for rows.Next() {
+		var i GetInsurerPresentationRow
+		if err := rows.Scan(
+			&i.AppointmentID,
+			&i.Date,
+			&i.StartTime,
+			&i.Concept,
+			&i.ClientName,
+			&i.Plan,
+			&i.MemberNumber,
+			&i.AuthorizationNumber,
+			&i.InsurerAmount,
+			&i.CopayAmount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLastAuditHash = `-- name: GetLastAuditHash :one
+SELECT hash FROM audit_log
+WHERE professional_id = $1
+ORDER BY id DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLastAuditHash(ctx context.Context, professionalID int64) (string, error) {
+	row := q.db.QueryRowContext(ctx, getLastAuditHash, professionalID)
+	var hash string
+	err := row.Scan(&hash)
+	return hash, err
+}
+
+const getLatestPriceIndex = `-- name: GetLatestPriceIndex :one
+SELECT series, period, value FROM price_indexes
+WHERE series = $1
+ORDER BY period DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLatestPriceIndex(ctx context.Context, series string) (PriceIndex, error) {
+	row := q.db.QueryRowContext(ctx, getLatestPriceIndex, series)
+	var i PriceIndex
+	err := row.Scan(&i.Series, &i.Period, &i.Value)
+	return i, err
+}
+
+const getNoteById = `-- name: GetNoteById :one
+SELECT id, professional_id, client_id, appointment_id, type, content, key_version, status, signed_at, created_at, updated_at, template_id, sections FROM clinical_notes WHERE id = $1 AND professional_id = $2 LIMIT 1
+`
+
+type GetNoteByIdParams struct {
+	ID             int64 `json:"id"`
+	ProfessionalID int64 `json:"professional_id"`
+}
+
+func (q *Queries) GetNoteById(ctx context.Context, arg GetNoteByIdParams) (ClinicalNote, error) {
+	row := q.db.QueryRowContext(ctx, getNoteById, arg.ID, arg.ProfessionalID)
+	var i ClinicalNote
+	err := row.Scan(
+		&i.ID,
+		&i.ProfessionalID,
+		&i.ClientID,
+		&i.AppointmentID,
+		&i.Type,
+		&i.Content,
+		&i.KeyVersion,
+		&i.Status,
+		&i.SignedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TemplateID,
+		&i.Sections,
+	)
+	return i, err
+}
+
+const getNoteForUpdate = `-- name: GetNoteForUpdate :one
+SELECT id, professional_id, client_id, appointment_id, type, content, key_version, status, signed_at, created_at, updated_at, template_id, sections FROM clinical_notes WHERE id = $1 AND professional_id = $2 LIMIT 1 FOR UPDATE
+`
+
+type GetNoteForUpdateParams struct {
+	ID             int64 `json:"id"`
+	ProfessionalID int64 `json:"professional_id"`
+}
+
+func (q *Queries) GetNoteForUpdate(ctx context.Context, arg GetNoteForUpdateParams) (ClinicalNote, error) {
+	row := q.db.QueryRowContext(ctx, getNoteForUpdate, arg.ID, arg.ProfessionalID)
+	var i ClinicalNote
+	err := row.Scan(
+		&i.ID,
+		&i.ProfessionalID,
+		&i.ClientID,
+		&i.AppointmentID,
+		&i.Type,
+		&i.Content,
+		&i.KeyVersion,
+		&i.Status,
+		&i.SignedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TemplateID,
+		&i.Sections,
+	)
+	return i, err
+}
+
+const getNoteRevision = `-- name: GetNoteRevision :one
+SELECT id, note_id, revision_number, author_id, content, key_version, written_at, created_at, sections FROM clinical_note_revisions
+WHERE note_id = $1 AND revision_number = $2
+LIMIT 1
+`
+
+type GetNoteRevisionParams struct {
+	NoteID         int64 `json:"note_id"`
+	RevisionNumber int32 `json:"revision_number"`
+}
+
+func (q *Queries) GetNoteRevision(ctx context.Context, arg GetNoteRevisionParams) (ClinicalNoteRevision, error) {
+	row := q.db.QueryRowContext(ctx, getNoteRevision, arg.NoteID, arg.RevisionNumber)
+	var i ClinicalNoteRevision
+	err := row.Scan(
+		&i.ID,
+		&i.NoteID,
+		&i.RevisionNumber,
+		&i.AuthorID,
+		&i.Content,
+		&i.KeyVersion,
+		&i.WrittenAt,
+		&i.CreatedAt,
+		&i.Sections,
+	)
+	return i, err
+}
+
+const getNoteTemplate = `-- name: GetNoteTemplate :one
+SELECT id, professional_id, name, kind, sections, active, created_at, updated_at FROM note_templates WHERE id = $1 AND professional_id = $2 LIMIT 1
+`
+
+type GetNoteTemplateParams struct {
+	ID             int64 `json:"id"`
+	ProfessionalID int64 `json:"professional_id"`
+}
+
+func (q *Queries) GetNoteTemplate(ctx context.Context, arg GetNoteTemplateParams) (NoteTemplate, error) {
+	row := q.db.QueryRowContext(ctx, getNoteTemplate, arg.ID, arg.ProfessionalID)
+	var i NoteTemplate
+	err := row.Scan(
+		&i.ID,
+		&i.ProfessionalID,
+		&i.Name,
+		&i.Kind,
+		&i.Sections,
+		&i.Active,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getOrganization = `-- name: GetOrganization :one
+SELECT id, name, require_two_factor, created_at, logo_url, brand_color, address, phone, website, default_duration_minutes, buffer_minutes, time_increment_minutes, min_booking_notice_hours, booking_requires_approval FROM organizations WHERE id = $1
+`
+
+func (q *Queries) GetOrganization(ctx context.Context, id int64) (Organization, error) {
+	row := q.db.QueryRowContext(ctx, getOrganization, id)
+	var i Organization
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.RequireTwoFactor,
+		&i.CreatedAt,
+		&i.LogoUrl,
+		&i.BrandColor,
+		&i.Address,
+		&i.Phone,
+		&i.Website,
+		&i.DefaultDurationMinutes,
+		&i.BufferMinutes,
+		&i.TimeIncrementMinutes,
+		&i.MinBookingNoticeHours,
+		&i.BookingRequiresApproval,
+	)
+	return i, err
+}
+
+const getOrganizationFinancialSummary = `-- name: GetOrganizationFinancialSummary :many
+SELECT p.id as professional_id, p.name as professional_name,
+    COUNT(a.id)::BIGINT as sessions_count,
+    COALESCE(SUM(CASE WHEN a.payment_status = 'paid' THEN a.price ELSE 0 END), 0)::DECIMAL as income,
+    COALESCE(SUM(CASE WHEN a.payment_status = 'pending' THEN a.price ELSE 0 END), 0)::DECIMAL as pending_collection,
+    COALESCE(SUM(CASE WHEN a.payment_status = 'paid' AND a.invoice_status = 'pending' THEN a.price ELSE 0 END), 0)::DECIMAL as pending_invoicing
+FROM organization_members m
+JOIN professionals p ON p.id = m.professional_id
+LEFT JOIN appointment_finances a ON a.professional_id = m.professional_id
+  AND a.status != 'cancelled'
+  AND a.date >= $1::date
+  AND a.date <= $2::date
+WHERE m.organization_id = $3
+GROUP BY p.id, p.name
+ORDER BY p.name
+`
+
+type GetOrganizationFinancialSummaryParams struct {
+	StartDate      time.Time `json:"start_date"`
+	EndDate        time.Time `json:"end_date"`
+	OrganizationID int64     `json:"organization_id"`
+}
+
+type GetOrganizationFinancialSummaryRow struct {
+	ProfessionalID    int64  `json:"professional_id"`
+	ProfessionalName  string `json:"professional_name"`
+	SessionsCount     int64  `json:"sessions_count"`
+	Income            string `json:"income"`
+	PendingCollection string `json:"pending_collection"`
+	PendingInvoicing  string `json:"pending_invoicing"`
+}
+
+// KPIs de Finanzas por profesional del centro, con los mismos criterios que GetFinancialSummary
+func (q *Queries) GetOrganizationFinancialSummary(ctx context.Context, arg GetOrganizationFinancialSummaryParams) ([]GetOrganizationFinancialSummaryRow, error) {
+	rows, err := q.db.QueryContext(ctx, getOrganizationFinancialSummary, arg.StartDate, arg.EndDate, arg.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetOrganizationFinancialSummaryRow
+	for rows.Next() {
+		var i GetOrganizationFinancialSummaryRow
+		if err := rows.Scan(
+			&i.ProfessionalID,
+			&i.ProfessionalName,
+			&i.SessionsCount,
+			&i.Income,
+			&i.PendingCollection,
+			&i.PendingInvoicing,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOrganizationMembership = `-- name: GetOrganizationMembership :one
+SELECT professional_id, organization_id, role, created_at FROM organization_members WHERE professional_id = $1
+`
+
+func (q *Queries) GetOrganizationMembership(ctx context.Context, professionalID int64) (OrganizationMember, error) {
+	row := q.db.QueryRowContext(ctx, getOrganizationMembership, professionalID)
+	var i OrganizationMember
+	err := row.Scan(
+		&i.ProfessionalID,
+		&i.OrganizationID,
+		&i.Role,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getPackageConsumption = `-- name: GetPackageConsumption :one
+SELECT appointment_id, package_id, consumed_at FROM package_consumptions WHERE appointment_id = $1 LIMIT 1
+`
+
+func (q *Queries) GetPackageConsumption(ctx context.Context, appointmentID int64) (PackageConsumption, error) {
+	row := q.db.QueryRowContext(ctx, getPackageConsumption, appointmentID)
+	var i PackageConsumption
+	err := row.Scan(&i.AppointmentID, &i.PackageID, &i.ConsumedAt)
+	return i, err
+}
+
+const getPaymentProof = `-- name: GetPaymentProof :one
+SELECT appointment_id, professional_id, client_id, filename, content_type, size_bytes, storage_key, created_at FROM payment_proofs
+WHERE appointment_id = $1 AND professional_id = $2
+LIMIT 1
+`
+
+type GetPaymentProofParams struct {
+	AppointmentID  int64 `json:"appointment_id"`
+	ProfessionalID int64 `json:"professional_id"`
+}
+
+func (q *Queries) GetPaymentProof(ctx context.Context, arg GetPaymentProofParams) (PaymentProof, error) {
+	row := q.db.QueryRowContext(ctx, getPaymentProof, arg.AppointmentID, arg.ProfessionalID)
+	var i PaymentProof
+	err := row.Scan(
+		&i.AppointmentID,
+		&i.ProfessionalID,
+		&i.ClientID,
+		&i.Filename,
+		&i.ContentType,
+		&i.SizeBytes,
+		&i.StorageKey,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getPortalClient = `-- name: GetPortalClient :one
+SELECT c.id, c.professional_id, c.name, c.email, c.active,
+       p.name AS professional_name, p.title AS professional_title,
+       COALESCE(p.cancellation_window_hours, 24)::int AS cancellation_window_hours
+FROM clients c
+JOIN professionals p ON p.id = c.professional_id
+WHERE c.id = $1
+LIMIT 1
+`
+
+type GetPortalClientRow struct {
+	ID                      int64          `json:"id"`
+	ProfessionalID          int64          `json:"professional_id"`
+	Name                    string         `json:"name"`
+	Email                   sql.NullString `json:"email"`
+	Active                  sql.NullBool   `json:"active"`
+	ProfessionalName        string         `json:"professional_name"`
+	ProfessionalTitle       sql.NullString `json:"professional_title"`
+	CancellationWindowHours int32          `json:"cancellation_window_hours"`
+}
+
+// Lo que el portal necesita de la ficha y de su profesional (sin datos clínicos)
+func (q *Queries) GetPortalClient(ctx context.Context, id int64) (GetPortalClientRow, error) {
+	row := q.db.QueryRowContext(ctx, getPortalClient, id)
+	var i GetPortalClientRow
+	err := row.Scan(
+		&i.ID,
+		&i.ProfessionalID,
+		&i.Name,
+		&i.Email,
+		&i.Active,
+		&i.ProfessionalName,
+		&i.ProfessionalTitle,
+		&i.CancellationWindowHours,
+	)
+	return i, err
+}
+
+const getPriceFor = `-- name: GetPriceFor :one
+SELECT id, professional_id, modality, duration_minutes, price, effective_from, index_series, applied_at, created_at FROM price_list_entries
+WHERE professional_id = $1
+  AND modality = $2
+  AND duration_minutes = $3
+  AND effective_from <= $4::date
+ORDER BY effective_from DESC
+LIMIT 1
+`
+
+type GetPriceForParams struct {
+	ProfessionalID  int64     `json:"professional_id"`
+	Modality        string    `json:"modality"`
+	DurationMinutes int32     `json:"duration_minutes"`
+	Date            time.Time `json:"date"`
+}
+
+func (q *Queries) GetPriceFor(ctx context.Context, arg GetPriceForParams) (PriceListEntry, error) {
+	row := q.db.QueryRowContext(ctx, getPriceFor,
+		arg.ProfessionalID,
+		arg.Modality,
+		arg.DurationMinutes,
+		arg.Date,
+	)
+	var i PriceListEntry
+	err := row.Scan(
+		&i.ID,
+		&i.ProfessionalID,
+		&i.Modality,
+		&i.DurationMinutes,
+		&i.Price,
+		&i.EffectiveFrom,
+		&i.IndexSeries,
+		&i.AppliedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getPriceIndex = `-- name: GetPriceIndex :one
+SELECT series, period, value FROM price_indexes
+WHERE series = $1 AND period = $2
+LIMIT 1
+`
+
+type GetPriceIndexParams struct {
+	Series string    `json:"series"`
+	Period time.Time `json:"period"`
+}
+
+func (q *Queries) GetPriceIndex(ctx context.Context, arg GetPriceIndexParams) (PriceIndex, error) {
+	row := q.db.QueryRowContext(ctx, getPriceIndex, arg.Series, arg.Period)
+	var i PriceIndex
+	err := row.Scan(&i.Series, &i.Period, &i.Value)
+	return i, err
+}
+
+const getPriceListEntry = `-- name: GetPriceListEntry :one
+SELECT id, professional_id, modality, duration_minutes, price, effective_from, index_series, applied_at, created_at FROM price_list_entries WHERE id = $1 AND professional_id = $2 LIMIT 1
+`
+
+type GetPriceListEntryParams struct {
+	ID             int64 `json:"id"`
+	ProfessionalID int64 `json:"professional_id"`
+}
+
+func (q *Queries) GetPriceListEntry(ctx context.Context, arg GetPriceListEntryParams) (PriceListEntry, error) {
+	row := q.db.QueryRowContext(ctx, getPriceListEntry, arg.ID, arg.ProfessionalID)
+	var i PriceListEntry
+	err := row.Scan(
+		&i.ID,
+		&i.ProfessionalID,
+		&i.Modality,
+		&i.DurationMinutes,
+		&i.Price,
+		&i.EffectiveFrom,
+		&i.IndexSeries,
+		&i.AppliedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getProfessional = `-- name: GetProfessional :one
+SELECT id, name, email, phone, slug, photo_url, title, license_number, bio, cancellation_window_hours, email_verified_at, created_at FROM professionals 
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetProfessional(ctx context.Context, id int64) (Professional, error) {
+	row := q.db.QueryRowContext(ctx, getProfessional, id)
+	var i Professional
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.Phone,
+		&i.Slug,
+		&i.PhotoUrl,
+		&i.Title,
+		&i.LicenseNumber,
+		&i.Bio,
+		&i.CancellationWindowHours,
+		&i.EmailVerifiedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getProfessionalByEmail = `-- name: GetProfessionalByEmail :one
+SELECT id, name, email, phone, slug, photo_url, title, license_number, bio, cancellation_window_hours, email_verified_at, created_at FROM professionals 
+WHERE email = $1 LIMIT 1
+`
+
+func (q *Queries) GetProfessionalByEmail(ctx context.Context, email string) (Professional, error) {
+	row := q.db.QueryRowContext(ctx, getProfessionalByEmail, email)
+	var i Professional
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.Phone,
+		&i.Slug,
+		&i.PhotoUrl,
+		&i.Title,
+		&i.LicenseNumber,
+		&i.Bio,
+		&i.CancellationWindowHours,
+		&i.EmailVerifiedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getProfessionalBySlug = `-- name: GetProfessionalBySlug :one
+SELECT id, name, slug, photo_url, title, license_number, bio, email, phone
+FROM professionals
+WHERE slug = $1 AND email_verified_at IS NOT NULL LIMIT 1
+`
+
+type GetProfessionalBySlugRow struct {
+	ID            int64          `json:"id"`
+	Name          string         `json:"name"`
+	Slug          sql.NullString `json:"slug"`
+	PhotoUrl      sql.NullString `json:"photo_url"`
+	Title         sql.NullString `json:"title"`
+	LicenseNumber sql.NullString `json:"license_number"`
+	Bio           sql.NullString `json:"bio"`
+	Email         string         `json:"email"`
+	Phone         sql.NullString `json:"phone"`
+}
+
+func (q *Queries) GetProfessionalBySlug(ctx context.Context, slug sql.NullString) (GetProfessionalBySlugRow, error) {
+	row := q.db.QueryRowContext(ctx, getProfessionalBySlug, slug)
+	var i GetProfessionalBySlugRow
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Slug,
+		&i.PhotoUrl,
+		&i.Title,
+		&i.LicenseNumber,
+		&i.Bio,
+		&i.Email,
+		&i.Phone,
+	)
+	return i, err
+}
+
+const getProfessionalSettings = `-- name: GetProfessionalSettings :one
+SELECT professional_id, default_duration_minutes, default_price, buffer_minutes, time_increment_minutes, bank_cbu, bank_alias, bank_name, bank_holder_name, send_alias_by_email, mp_access_token, mp_user_id, afip_crt_url, afip_key_url, afip_point_of_sale, notify_by_email, notify_by_whatsapp, min_booking_notice_hours, max_daily_appointments, updated_at, booking_requires_approval FROM professional_settings
+WHERE professional_id = $1
+`
+
+func (q *Queries) GetProfessionalSettings(ctx context.Context, professionalID int64) (ProfessionalSetting, error) {
+	row := q.db.QueryRowContext(ctx, getProfessionalSettings, professionalID)
+	var i ProfessionalSetting
+	err := row.Scan(
+		&i.ProfessionalID,
+		&i.DefaultDurationMinutes,
+		&i.DefaultPrice,
+		&i.BufferMinutes,
+		&i.TimeIncrementMinutes,
+		&i.BankCbu,
+		&i.BankAlias,
+		&i.BankName,
+		&i.BankHolderName,
+		&i.SendAliasByEmail,
+		&i.MpAccessToken,
+		&i.MpUserID,
+		&i.AfipCrtUrl,
+		&i.AfipKeyUrl,
+		&i.AfipPointOfSale,
+		&i.NotifyByEmail,
+		&i.NotifyByWhatsapp,
+		&i.MinBookingNoticeHours,
+		&i.MaxDailyAppointments,
+		&i.UpdatedAt,
+		&i.BookingRequiresApproval,
+	)
+	return i, err
+}
+
+const getRefreshToken = `-- name: GetRefreshToken :one
+SELECT id, professional_id, token_hash, expires_at, revoked_at, created_at FROM refresh_tokens WHERE token_hash = $1 LIMIT 1
+`
+
+func (q *Queries) GetRefreshToken(ctx context.Context, tokenHash string) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, getRefreshToken, tokenHash)
+	var i RefreshToken
+	err := row.Scan(
+		&i.ID,
+		&i.ProfessionalID,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getSessionPackage = `-- name: GetSessionPackage :one
+SELECT id, professional_id, client_id, name, total_sessions, used_sessions, price, valid_from, valid_until, payment_status, payment_method, paid_at, created_at FROM session_packages WHERE id = $1 AND professional_id = $2 LIMIT 1
+`
+
+type GetSessionPackageParams struct {
+	ID             int64 `json:"id"`
+	ProfessionalID int64 `json:"professional_id"`
+}
+
+func (q *Queries) GetSessionPackage(ctx context.Context, arg GetSessionPackageParams) (SessionPackage, error) {
+	row := q.db.QueryRowContext(ctx, getSessionPackage, arg.ID, arg.ProfessionalID)
+	var i SessionPackage
+	err := row.Scan(
+		&i.ID,
+		&i.ProfessionalID,
+		&i.ClientID,
+		&i.Name,
+		&i.TotalSessions,
+		&i.UsedSessions,
+		&i.Price,
+		&i.ValidFrom,
+		&i.ValidUntil,
+		&i.PaymentStatus,
+		&i.PaymentMethod,
+		&i.PaidAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getTwoFactor = `-- name: GetTwoFactor :one
+SELECT id, professional_id, secret, key_version, confirmed_at, last_used_step, failed_attempts, locked_until, created_at FROM professional_two_factor WHERE professional_id = $1 LIMIT 1
+`
+
+func (q *Queries) GetTwoFactor(ctx context.Context, professionalID int64) (ProfessionalTwoFactor, error) {
+	row := q.db.QueryRowContext(ctx, getTwoFactor, professionalID)
+	var i ProfessionalTwoFactor
+	err := row.Scan(
+		&i.ID,
+		&i.ProfessionalID,
+		&i.Secret,
+		&i.KeyVersion,
+		&i.ConfirmedAt,
+		&i.LastUsedStep,
+		&i.FailedAttempts,
+		&i.LockedUntil,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const grantAssistantRole = `-- name: GrantAssistantRole :exec
+INSERT INTO assistant_permissions (assistant_id, professional_id, role)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING
+`
+
+type GrantAssistantRoleParams struct {
+	AssistantID    int64  `json:"assistant_id"`
+	ProfessionalID int64  `json:"professional_id"`
+	Role           string `json:"role"`
+}
+
+func (q *Queries) GrantAssistantRole(ctx context.Context, arg GrantAssistantRoleParams) error {
+	_, err := q.db.ExecContext(ctx, grantAssistantRole, arg.AssistantID, arg.ProfessionalID, arg.Role)
+	return err
+}
+
+const invalidateClientLoginTokens = `-- name: InvalidateClientLoginTokens :exec
+UPDATE client_login_tokens SET used_at = NOW()
+WHERE client_id = $1 AND used_at IS NULL
+`
+
+// Al pedir uno nuevo los anteriores dejan de servir
+func (q *Queries) InvalidateClientLoginTokens(ctx context.Context, clientID int64) error {
+	_, err := q.db.ExecContext(ctx, invalidateClientLoginTokens, clientID)
+	return err
+}
+
+const invalidateOrganizationInvitations = `-- name: InvalidateOrganizationInvitations :exec
+UPDATE organization_invitations SET used_at = NOW()
+WHERE organization_id = $1 AND professional_id = $2 AND used_at IS NULL
+`
+
+type InvalidateOrganizationInvitationsParams struct {
+	OrganizationID int64 `json:"organization_id"`
+	ProfessionalID int64 `json:"professional_id"`
+}
+
+// Al reenviarla, la invitación anterior deja de servir
+func (q *Queries) InvalidateOrganizationInvitations(ctx context.Context, arg InvalidateOrganizationInvitationsParams) error {
+	_, err := q.db.ExecContext(ctx, invalidateOrganizationInvitations, arg.OrganizationID, arg.ProfessionalID)
+	return err
+}
+
+const invalidatePasswordResetTokens = `-- name: InvalidatePasswordResetTokens :exec
+UPDATE password_reset_tokens SET used_at = NOW()
+WHERE professional_id = $1 AND used_at IS NULL
+`
+
+// Al pedir uno nuevo (o al usarlo) los anteriores dejan de servir
+func (q *Queries) InvalidatePasswordResetTokens(ctx context.Context, professionalID int64) error {
+	_, err := q.db.ExecContext(ctx, invalidatePasswordResetTokens, professionalID)
+	return err
+}
+
+const joinOrganization = `-- name: JoinOrganization :execrows
+INSERT INTO organization_members (organization_id, professional_id, role)
+VALUES ($1, $2, $3)
+ON CONFLICT (professional_id) DO NOTHING
+`
+
+type JoinOrganizationParams struct {
+	OrganizationID int64  `json:"organization_id"`
+	ProfessionalID int64  `json:"professional_id"`
+	Role           string `json:"role"`
+}
+
+// Como AddOrganizationMember, pero si ya pertenece a una organización no la toca: 0 filas
+func (q *Queries) JoinOrganization(ctx context.Context, arg JoinOrganizationParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, joinOrganization, arg.OrganizationID, arg.ProfessionalID, arg.Role)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const listAppointmentsInDateRange = `-- name: ListAppointmentsInDateRange :many
+SELECT a.id, a.professional_id, a.client_id, a.date, a.start_time, a.duration_minutes, a.status, a.modality, a.meeting_url, a.price, a.concept, a.payment_status, a.payment_method, a.payment_proof_url, a.payment_confirmed_at, a.invoice_status, a.invoice_url, a.invoice_cae, a.notes, a.rescheduled_from_id, a.recurring_rule_id, a.created_at, a.updated_at, c.name as client_name
+FROM appointments a
+JOIN clients c ON a.client_id = c.id
+WHERE a.professional_id = $1 
+  AND a.date >= $2::date 
+  AND a.date <= $3::date
+ORDER BY a.date, a.start_time
+`
+
+type ListAppointmentsInDateRangeParams struct {
+	ProfessionalID int64     `json:"professional_id"`
+	Column2        time.Time `json:"column_2"`
+	Column3        time.Time `json:"column_3"`
+}
+
+type ListAppointmentsInDateRangeRow struct {
+	ID                 int64          `json:"id"`
+	ProfessionalID     int64          `json:"professional_id"`
+	ClientID           int64          `json:"client_id"`
+	Date               time.Time      `json:"date"`
+	StartTime          string         `json:"start_time"`
+	DurationMinutes    int32          `json:"duration_minutes"`
+	Status             sql.NullString `json:"status"`
+	Modality           sql.NullString `json:"modality"`
+	MeetingUrl         sql.NullString `json:"meeting_url"`
+	Price              sql.NullString `json:"price"`
+	Concept            sql.NullString `json:"concept"`
+	PaymentStatus      sql.NullString `json:"payment_status"`
+	PaymentMethod      sql.NullString `json:"payment_method"`
+	PaymentProofUrl    sql.NullString `json:"payment_proof_url"`
+	PaymentConfirmedAt sql.NullTime   `json:"payment_confirmed_at"`
+	InvoiceStatus      sql.NullString `json:"invoice_status"`
+	InvoiceUrl         sql.NullString `json:"invoice_url"`
+	InvoiceCae         sql.NullString `json:"invoice_cae"`
+	Notes              sql.NullString `json:"notes"`
+	RescheduledFromID  sql.NullInt64  `json:"rescheduled_from_id"`
+	RecurringRuleID    sql.NullInt64  `json:"recurring_rule_id"`
+	CreatedAt          sql.NullTime   `json:"created_at"`
+	UpdatedAt          sql.NullTime   `json:"updated_at"`
+	ClientName         string         `json:"client_name"`
+}
+
+func (q *Queries) ListAppointmentsInDateRange(ctx context.Context, arg ListAppointmentsInDateRangeParams) ([]ListAppointmentsInDateRangeRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAppointmentsInDateRange, arg.ProfessionalID, arg.Column2, arg.Column3)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAppointmentsInDateRangeRow
+	for rows.Next() {
+		var i ListAppointmentsInDateRangeRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProfessionalID,
+			&i.ClientID,
+			&i.Date,
+			&i.StartTime,
+			&i.DurationMinutes,
+			&i.Status,
+			&i.Modality,
+			&i.MeetingUrl,
+			&i.Price,
+			&i.Concept,
+			&i.PaymentStatus,
+			&i.PaymentMethod,
+			&i.PaymentProofUrl,
+			&i.PaymentConfirmedAt,
+			&i.InvoiceStatus,
+			&i.InvoiceUrl,
+			&i.InvoiceCae,
+			&i.Notes,
+			&i.RescheduledFromID,
+			&i.RecurringRuleID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ClientName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAppointmentsWithoutNote = `-- name: ListAppointmentsWithoutNote :many
+SELECT a.id, a.client_id, a.date, a.start_time, a.duration_minutes, a.modality,
+       c.name AS client_name
+FROM appointments a
+JOIN clients c ON a.client_id = c.id
+WHERE a.professional_id = $1 AND a.status = 'completed'
+  AND a.date BETWEEN $2::date AND $3::date
+  AND NOT EXISTS (SELECT 1 FROM clinical_notes n WHERE n.appointment_id = a.id AND n.type = 'clinical')
+ORDER BY a.date, a.start_time
+`
+
+type ListAppointmentsWithoutNoteParams struct {
+	ProfessionalID int64     `json:"professional_id"`
+	FromDate       time.Time `json:"from_date"`
+	ToDate         time.Time `json:"to_date"`
+}
+
+type ListAppointmentsWithoutNoteRow struct {
+	ID              int64          `json:"id"`
+	ClientID        int64          `json:"client_id"`
+	Date            time.Time      `json:"date"`
+	StartTime       string         `json:"start_time"`
+	DurationMinutes int32          `json:"duration_minutes"`
+	Modality        sql.NullString `json:"modality"`
+	ClientName      string         `json:"client_name"`
+}
+
+// Sesiones realizadas que todavía no tienen ninguna nota clínica asociada
+func (q *Queries) ListAppointmentsWithoutNote(ctx context.Context, arg ListAppointmentsWithoutNoteParams) ([]ListAppointmentsWithoutNoteRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAppointmentsWithoutNote, arg.ProfessionalID, arg.FromDate, arg.ToDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAppointmentsWithoutNoteRow
+	for rows.Next() {
+		var i ListAppointmentsWithoutNoteRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ClientID,
+			&i.Date,
+			&i.StartTime,
+			&i.DurationMinutes,
+			&i.Modality,
+			&i.ClientName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAssistantRoles = `-- name: ListAssistantRoles :many
+SELECT role FROM assistant_permissions
+WHERE assistant_id = $1 AND professional_id = $2
+ORDER BY role
+`
+
+type ListAssistantRolesParams struct {
+	AssistantID    int64 `json:"assistant_id"`
+	ProfessionalID int64 `json:"professional_id"`
+}
+
+func (q *Queries) ListAssistantRoles(ctx context.Context, arg ListAssistantRolesParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listAssistantRoles, arg.AssistantID, arg.ProfessionalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		items = append(items, role)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAssistantsForProfessional = `-- name: ListAssistantsForProfessional :many
+SELECT a.id, a.name, a.email, a.phone,
+       string_agg(p.role, ',' ORDER BY p.role)::text AS roles,
+       EXISTS (SELECT 1 FROM assistant_credentials c WHERE c.assistant_id = a.id) AS activated
+FROM assistants a
+JOIN assistant_permissions p ON p.assistant_id = a.id
+WHERE p.professional_id = $1
+GROUP BY a.id
+ORDER BY a.name
+`
+
+type ListAssistantsForProfessionalRow struct {
+	ID        int64          `json:"id"`
+	Name      string         `json:"name"`
+	Email     string         `json:"email"`
+	Phone     sql.NullString `json:"phone"`
+	Roles     string         `json:"roles"`
+	Activated bool           `json:"activated"`
+}
+
+func (q *Queries) ListAssistantsForProfessional(ctx context.Context, professionalID int64) ([]ListAssistantsForProfessionalRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAssistantsForProfessional, professionalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAssistantsForProfessionalRow
+	for rows.Next() {
+		var i ListAssistantsForProfessionalRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Email,
+			&i.Phone,
+			&i.Roles,
+			&i.Activated,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAttachments = `-- name: ListAttachments :many
+SELECT id, professional_id, client_id, note_id, filename, content_type, size_bytes, sha256, storage_key, data_key, key_version, created_at FROM attachments
+WHERE professional_id = $1
+  AND ($2::bigint IS NULL OR client_id = $2::bigint)
+  AND ($3::bigint IS NULL OR note_id = $3::bigint)
+ORDER BY created_at DESC
+`
+
+type ListAttachmentsParams struct {
+	ProfessionalID int64         `json:"professional_id"`
+	ClientID       sql.NullInt64 `json:"client_id"`
+	NoteID         sql.NullInt64 `json:"note_id"`
+}
+
+// Adjuntos de un paciente (todos, incluidos los de sus notas) o de una nota puntual
+func (q *Queries) ListAttachments(ctx context.Context, arg ListAttachmentsParams) ([]Attachment, error) {
+	rows, err := q.db.QueryContext(ctx, listAttachments, arg.ProfessionalID, arg.ClientID, arg.NoteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Attachment
+	for rows.Next() {
+		var i Attachment
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProfessionalID,
+			&i.ClientID,
+			&i.NoteID,
+			&i.Filename,
+			&i.ContentType,
+			&i.SizeBytes,
+			&i.Sha256,
+			&i.StorageKey,
+			&i.DataKey,
+			&i.KeyVersion,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAttachmentsForRotation = `-- name: ListAttachmentsForRotation :many
+SELECT id, professional_id, client_id, note_id, filename, content_type, size_bytes, sha256, storage_key, data_key, key_version, created_at FROM attachments
+WHERE id > $1
+  AND key_version IS DISTINCT FROM $2::int
+ORDER BY id
+LIMIT $3
+`
+
+type ListAttachmentsForRotationParams struct {
+	AfterID    int64 `json:"after_id"`
+	KeyVersion int32 `json:"key_version"`
+	BatchSize  int32 `json:"batch_size"`
+}
+
+func (q *Queries) ListAttachmentsForRotation(ctx context.Context, arg ListAttachmentsForRotationParams) ([]Attachment, error) {
+	rows, err := q.db.QueryContext(ctx, listAttachmentsForRotation, arg.AfterID, arg.KeyVersion, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Attachment
+	for rows.Next() {
+		var i Attachment
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProfessionalID,
+			&i.ClientID,
+			&i.NoteID,
+			&i.Filename,
+			&i.ContentType,
+			&i.SizeBytes,
+			&i.Sha256,
+			&i.StorageKey,
+			&i.DataKey,
+			&i.KeyVersion,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAuditChain = `-- name: ListAuditChain :many
+SELECT id, professional_id, assistant_id, action, resource_type, resource_id, route, status, ip, user_agent, created_at, prev_hash, hash, client_id FROM audit_log
+WHERE professional_id = $1 AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListAuditChainParams struct {
+	ProfessionalID int64 `json:"professional_id"`
+	AfterID        int64 `json:"after_id"`
+	BatchSize      int32 `json:"batch_size"`
+}
+
+// Recorre la cadena en orden para verificarla, de a tandas
+func (q *Queries) ListAuditChain(ctx context.Context, arg ListAuditChainParams) ([]AuditLog, error) {
+	rows, err := q.db.QueryContext(ctx, listAuditChain, arg.ProfessionalID, arg.AfterID, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditLog
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProfessionalID,
+			&i.AssistantID,
+			&i.Action,
+			&i.ResourceType,
+			&i.ResourceID,
+			&i.Route,
+			&i.Status,
+			&i.Ip,
+			&i.UserAgent,
+			&i.CreatedAt,
+			&i.PrevHash,
+			&i.Hash,
+			&i.ClientID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAuditEntries = `-- name: ListAuditEntries :many
+SELECT id, professional_id, assistant_id, action, resource_type, resource_id, route, status, ip, user_agent, created_at, prev_hash, hash, client_id FROM audit_log
+WHERE professional_id = $1
+  AND ($2::timestamptz IS NULL OR created_at >= $2::timestamptz)
+  AND ($3::timestamptz IS NULL OR created_at < $3::timestamptz)
+  AND ($4::text IS NULL OR action = $4::text)
+  AND ($5::text IS NULL OR resource_type = $5::text)
+  AND ($6::bigint IS NULL OR resource_id = $6::bigint)
+  AND ($7::bigint IS NULL OR assistant_id = $7::bigint)
+  AND ($8::bigint IS NULL OR id < $8::bigint)
+ORDER BY id DESC
+LIMIT $9
+`
+
+type ListAuditEntriesParams struct {
+	ProfessionalID int64          `json:"professional_id"`
+	StartAt        sql.NullTime   `json:"start_at"`
+	EndAt          sql.NullTime   `json:"end_at"`
+	Action         sql.NullString `json:"action"`
+	ResourceType   sql.NullString `json:"resource_type"`
+	ResourceID     sql.NullInt64  `json:"resource_id"`
+	AssistantID    sql.NullInt64  `json:"assistant_id"`
+	CursorID       sql.NullInt64  `json:"cursor_id"`
+	PageSize       int32          `json:"page_size"`
+}
+
+// Más recientes primero. Filtros opcionales (NULL = sin filtro) y paginación por cursor sobre id
+func (q *Queries) ListAuditEntries(ctx context.Context, arg ListAuditEntriesParams) ([]AuditLog, error) {
+	rows, err := q.db.QueryContext(ctx, listAuditEntries,
+		arg.ProfessionalID,
+		arg.StartAt,
+		arg.EndAt,
+		arg.Action,
+		arg.ResourceType,
+		arg.ResourceID,
+		arg.AssistantID,
+		arg.CursorID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditLog
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProfessionalID,
+			&i.AssistantID,
+			&i.Action,
+			&i.ResourceType,
+			&i.ResourceID,
+			&i.Route,
+			&i.Status,
+			&i.Ip,
+			&i.UserAgent,
+			&i.CreatedAt,
+			&i.PrevHash,
+			&i.Hash,
+			&i.ClientID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBusySlots = `-- name: ListBusySlots :many
+SELECT id, date, start_time, duration_minutes
+FROM appointments
+WHERE professional_id = $1
+  AND date >= $2::date AND date <= $3::date
+  AND status NOT IN ('cancelled', 'rescheduled')
+ORDER BY date, start_time
+`
+
+type ListBusySlotsParams struct {
+	ProfessionalID int64     `json:"professional_id"`
+	StartDate      time.Time `json:"start_date"`
+	EndDate        time.Time `json:"end_date"`
+}
+
+type ListBusySlotsRow struct {
+	ID              int64     `json:"id"`
+	Date            time.Time `json:"date"`
+	StartTime       string    `json:"start_time"`
+	DurationMinutes int32     `json:"duration_minutes"`
+}
+
+// SECTION: Página Pública (reserva de turnos sin cuenta)
+// Lo que ocupa la agenda en el rango (incluye los pedidos sin aprobar)
+func (q *Queries) ListBusySlots(ctx context.Context, arg ListBusySlotsParams) ([]ListBusySlotsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listBusySlots, arg.ProfessionalID, arg.StartDate, arg.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListBusySlotsRow
+	for rows.Next() {
+		var i ListBusySlotsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.StartTime,
+			&i.DurationMinutes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listClientBalances = `-- name: ListClientBalances :many
+SELECT c.id as client_id, c.name as client_name,
+       SUM(l.debit - l.credit)::DECIMAL as balance
+FROM clients c
+JOIN client_ledger l ON l.client_id = c.id AND l.professional_id = c.professional_id
+WHERE c.professional_id = $1
+GROUP BY c.id, c.name
+HAVING SUM(l.debit - l.credit) <> 0
+ORDER BY balance DESC
+`
+
+type ListClientBalancesRow struct {
+	ClientID   int64  `json:"client_id"`
+	ClientName string `json:"client_name"`
+	Balance    string `json:"balance"`
+}
+
+// Saldos pendientes por paciente (positivo = el paciente adeuda)
+func (q *Queries) ListClientBalances(ctx context.Context, professionalID int64) ([]ListClientBalancesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listClientBalances, professionalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListClientBalancesRow
+	for rows.Next() {
+		var i ListClientBalancesRow
+		if err := rows.Scan(&i.ClientID, &i.ClientName, &i.Balance); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listClientCoverages = `-- name: ListClientCoverages :many
+SELECT cc.id, cc.professional_id, cc.client_id, cc.insurer_id, cc.plan, cc.member_number, cc.copay_amount, cc.active, cc.created_at, i.name as insurer_name
+FROM client_coverages cc
+JOIN insurers i ON cc.insurer_id = i.id
+WHERE cc.professional_id = $1 AND cc.client_id = $2
+ORDER BY cc.active DESC, cc.created_at DESC
+`
+
+type ListClientCoveragesParams struct {
+	ProfessionalID int64 `json:"professional_id"`
+	ClientID       int64 `json:"client_id"`
+}
+
+type ListClientCoveragesRow struct {
+	ID             int64          `json:"id"`
+	ProfessionalID int64          `json:"professional_id"`
+	ClientID       int64          `json:"client_id"`
+	InsurerID      int64          `json:"insurer_id"`
+	Plan           sql.NullString `json:"plan"`
+	MemberNumber   string         `json:"member_number"`
+	CopayAmount    string         `json:"copay_amount"`
+	Active         sql.NullBool   `json:"active"`
+	CreatedAt      sql.NullTime   `json:"created_at"`
+	InsurerName    string         `json:"insurer_name"`
+}
+
+func (q *Queries) ListClientCoverages(ctx context.Context, arg ListClientCoveragesParams) ([]ListClientCoveragesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listClientCoverages, arg.ProfessionalID, arg.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListClientCoveragesRow
+	for rows.Next() {
+		var i ListClientCoveragesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProfessionalID,
+			&i.ClientID,
+			&i.InsurerID,
+			&i.Plan,
+			&i.MemberNumber,
+			&i.CopayAmount,
+			&i.Active,
+			&i.CreatedAt,
+			&i.InsurerName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listClientForms = `-- name: ListClientForms :many
+SELECT id, professional_id, client_id, title, sections, answers, key_version, completed_at, created_at FROM client_forms
+WHERE professional_id = $1 AND client_id = $2
+ORDER BY created_at DESC, id DESC
+`
+
+type ListClientFormsParams struct {
+	ProfessionalID int64 `json:"professional_id"`
+	ClientID       int64 `json:"client_id"`
+}
+
+func (q *Queries) ListClientForms(ctx context.Context, arg ListClientFormsParams) ([]ClientForm, error) {
+	rows, err := q.db.QueryContext(ctx, listClientForms, arg.ProfessionalID, arg.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClientForm
+	for rows.Next() {
+		var i ClientForm
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProfessionalID,
+			&i.ClientID,
+			&i.Title,
+			&i.Sections,
+			&i.Answers,
+			&i.KeyVersion,
+			&i.CompletedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listClientFormsForRotation = `-- name: ListClientFormsForRotation :many
+SELECT id, professional_id, client_id, title, sections, answers, key_version, completed_at, created_at FROM client_forms
+WHERE id > $1
+  AND answers IS NOT NULL
+  AND key_version IS DISTINCT FROM $2::int
+ORDER BY id
+LIMIT $3
+`
+
+type ListClientFormsForRotationParams struct {
+	AfterID    int64 `json:"after_id"`
+	KeyVersion int32 `json:"key_version"`
+	BatchSize  int32 `json:"batch_size"`
+}
+
+func (q *Queries) ListClientFormsForRotation(ctx context.Context, arg ListClientFormsForRotationParams) ([]ClientForm, error) {
+	rows, err := q.db.QueryContext(ctx, listClientFormsForRotation, arg.AfterID, arg.KeyVersion, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClientForm
+	for rows.Next() {
+		var i ClientForm
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProfessionalID,
+			&i.ClientID,
+			&i.Title,
+			&i.Sections,
+			&i.Answers,
+			&i.KeyVersion,
+			&i.CompletedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listClientLedger = `-- name: ListClientLedger :many
+SELECT professional_id, client_id, entry_type, source_id, entry_date, description, debit, credit FROM client_ledger
+WHERE professional_id = $1
+  AND client_id = $2
+  AND entry_date <= $3::date
+ORDER BY entry_date, entry_type, source_id
+`
+
+type ListClientLedgerParams struct {
+	ProfessionalID int64     `json:"professional_id"`
+	ClientID       int64     `json:"client_id"`
+	EndDate        time.Time `json:"end_date"`
+}
+
+// Movimientos de la cuenta corriente hasta end_date (el saldo inicial del período se calcula en backend)
+func (q *Queries) ListClientLedger(ctx context.Context, arg ListClientLedgerParams) ([]ClientLedger, error) {
+	rows, err := q.db.QueryContext(ctx, listClientLedger, arg.ProfessionalID, arg.ClientID, arg.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClientLedger
+	for rows.Next() {
+		var i ClientLedger
+		if err := rows.Scan(
+			&i.ProfessionalID,
+			&i.ClientID,
+			&i.EntryType,
+			&i.SourceID,
+			&i.EntryDate,
+			&i.Description,
+			&i.Debit,
+			&i.Credit,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listClientPayments = `-- name: ListClientPayments :many
+SELECT id, professional_id, client_id, appointment_id, kind, amount, method, paid_at, notes, created_at FROM client_payments
+WHERE professional_id = $1 AND client_id = $2
+ORDER BY paid_at DESC, id DESC
+`
+
+type ListClientPaymentsParams struct {
+	ProfessionalID int64 `json:"professional_id"`
+	ClientID       int64 `json:"client_id"`
+}
+
+func (q *Queries) ListClientPayments(ctx context.Context, arg ListClientPaymentsParams) ([]ClientPayment, error) {
+	rows, err := q.db.QueryContext(ctx, listClientPayments, arg.ProfessionalID, arg.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClientPayment
+	for rows.Next() {
+		var i ClientPayment
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProfessionalID,
+			&i.ClientID,
+			&i.AppointmentID,
+			&i.Kind,
+			&i.Amount,
+			&i.Method,
+			&i.PaidAt,
+			&i.Notes,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listClientSessions = `-- name: ListClientSessions :many
+SELECT id, date, start_time, duration_minutes, modality, status
+FROM appointments
+WHERE professional_id = $1 AND client_id = $2 AND status = 'completed'
+ORDER BY date, start_time
+`
+
+type ListClientSessionsParams struct {
+	ProfessionalID int64 `json:"professional_id"`
+	ClientID       int64 `json:"client_id"`
+}
+
+type ListClientSessionsRow struct {
+	ID              int64          `json:"id"`
+	Date            time.Time      `json:"date"`
+	StartTime       string         `json:"start_time"`
+	DurationMinutes int32          `json:"duration_minutes"`
+	Modality        sql.NullString `json:"modality"`
+	Status          sql.NullString `json:"status"`
+}
+
+func (q *Queries) ListClientSessions(ctx context.Context, arg ListClientSessionsParams) ([]ListClientSessionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listClientSessions, arg.ProfessionalID, arg.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListClientSessionsRow
+	for rows.Next() {
+		var i ListClientSessionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.StartTime,
+			&i.DurationMinutes,
+			&i.Modality,
+			&i.Status,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listClients = `-- name: ListClients :many
+SELECT id, professional_id, name, email, phone, birth_date, medications, emergency_contact_name, emergency_contact_phone, active, created_at FROM clients
+WHERE professional_id = $1 AND active = TRUE
+ORDER BY name
+`
+
+func (q *Queries) ListClients(ctx context.Context, professionalID int64) ([]Client, error) {
+	rows, err := q.db.QueryContext(ctx, listClients, professionalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Client
+	for rows.Next() {
+		var i Client
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProfessionalID,
+			&i.Name,
+			&i.Email,
+			&i.Phone,
+			&i.BirthDate,
+			&i.Medications,
+			&i.EmergencyContactName,
+			&i.EmergencyContactPhone,
+			&i.Active,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listClinicalNotes = `-- name: ListClinicalNotes :many
+SELECT id, professional_id, client_id, appointment_id, type, content, key_version, status, signed_at, created_at, updated_at, template_id, sections FROM clinical_notes
+WHERE client_id = $1 AND professional_id = $2
+  AND ($3::text IS NULL OR type = $3::text)
+ORDER BY created_at DESC
+`
+
+type ListClinicalNotesParams struct {
+	ClientID       int64          `json:"client_id"`
+	ProfessionalID int64          `json:"professional_id"`
+	Type           sql.NullString `json:"type"`
+}
+
+// type opcional: 'clinical' o 'personal' (NULL = ambas)
+func (q *Queries) ListClinicalNotes(ctx context.Context, arg ListClinicalNotesParams) ([]ClinicalNote, error) {
+	rows, err := q.db.QueryContext(ctx, listClinicalNotes, arg.ClientID, arg.ProfessionalID, arg.Type)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClinicalNote
+	for rows.Next() {
+		var i ClinicalNote
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProfessionalID,
+			&i.ClientID,
+			&i.AppointmentID,
+			&i.Type,
+			&i.Content,
+			&i.KeyVersion,
+			&i.Status,
+			&i.SignedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.TemplateID,
+			&i.Sections,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listClinicalNotesForRotation = `-- name: ListClinicalNotesForRotation :many
+SELECT id, professional_id, client_id, appointment_id, type, content, key_version, status, signed_at, created_at, updated_at, template_id, sections FROM clinical_notes
+WHERE id > $1
+  AND (key_version IS DISTINCT FROM $2::int OR content NOT LIKE 'enc:%')
+ORDER BY id
+LIMIT $3
+`
+
+type ListClinicalNotesForRotationParams struct {
+	AfterID    int64 `json:"after_id"`
+	KeyVersion int32 `json:"key_version"`
+	BatchSize  int32 `json:"batch_size"`
+}
+
+// Notas con clave maestra vieja o todavía en claro (previas al cifrado), por lotes
+func (q *Queries) ListClinicalNotesForRotation(ctx context.Context, arg ListClinicalNotesForRotationParams) ([]ClinicalNote, error) {
+	rows, err := q.db.QueryContext(ctx, listClinicalNotesForRotation, arg.AfterID, arg.KeyVersion, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClinicalNote
+	for rows.Next() {
+		var i ClinicalNote
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProfessionalID,
+			&i.ClientID,
+			&i.AppointmentID,
+			&i.Type,
+			&i.Content,
+			&i.KeyVersion,
+			&i.Status,
+			&i.SignedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.TemplateID,
+			&i.Sections,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listClinicalRecordExports = `-- name: ListClinicalRecordExports :many
+SELECT id, professional_id, client_id, purpose, recipient, note_count, document_sha256, created_at FROM clinical_record_exports
+WHERE professional_id = $1 AND client_id = $2
+ORDER BY created_at DESC
+`
+
+type ListClinicalRecordExportsParams struct {
+	ProfessionalID int64 `json:"professional_id"`
+	ClientID       int64 `json:"client_id"`
+}
+
+func (q *Queries) ListClinicalRecordExports(ctx context.Context, arg ListClinicalRecordExportsParams) ([]ClinicalRecordExport, error) {
+	rows, err := q.db.QueryContext(ctx, listClinicalRecordExports, arg.ProfessionalID, arg.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClinicalRecordExport
+	for rows.Next() {
+		var i ClinicalRecordExport
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProfessionalID,
+			&i.ClientID,
+			&i.Purpose,
+			&i.Recipient,
+			&i.NoteCount,
+			&i.DocumentSha256,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFinancesForExport = `-- name: ListFinancesForExport :many
+SELECT a.id, a.date, a.start_time, a.duration_minutes, a.modality, a.concept, a.price,
+       a.payment_status, a.payment_method, a.payment_confirmed_at,
+       a.invoice_status, a.invoice_cae, a.invoice_url,
+       a.package_id, c.name as client_name, c.email as client_email
+FROM appointment_finances a
+JOIN clients c ON a.client_id = c.id
+WHERE a.professional_id = $1
+  AND a.status != 'cancelled'
+  AND a.date >= $2::date
+  AND a.date <= $3::date
+ORDER BY a.date, a.start_time
+`
+
+type ListFinancesForExportParams struct {
+	ProfessionalID int64     `json:"professional_id"`
+	StartDate      time.Time `json:"start_date"`
+	EndDate        time.Time `json:"end_date"`
+}
+
+type ListFinancesForExportRow struct {
+	ID                 int64          `json:"id"`
+	Date               time.Time      `json:"date"`
+	StartTime          string         `json:"start_time"`
+	DurationMinutes    int32          `json:"duration_minutes"`
+	Modality           sql.NullString `json:"modality"`
+	Concept            sql.NullString `json:"concept"`
+	Price              sql.NullString `json:"price"`
+	PaymentStatus      sql.NullString `json:"payment_status"`
+	PaymentMethod      sql.NullString `json:"payment_method"`
+	PaymentConfirmedAt sql.NullTime   `json:"payment_confirmed_at"`
+	InvoiceStatus      sql.NullString `json:"invoice_status"`
+	InvoiceCae         sql.NullString `json:"invoice_cae"`
+	InvoiceUrl         sql.NullString `json:"invoice_url"`
+	PackageID          sql.NullInt64  `json:"package_id"`
+	ClientName         string         `json:"client_name"`
+	ClientEmail        sql.NullString `json:"client_email"`
+}
+
+// Exportación para el contador: todos los turnos no cancelados del rango, en orden cronológico
+// (los turnos cubiertos por un paquete figuran con el precio proporcional del paquete)
+func (q *Queries) ListFinancesForExport(ctx context.Context, arg ListFinancesForExportParams) ([]ListFinancesForExportRow, error) {
+	rows, err := q.db.QueryContext(ctx, listFinancesForExport, arg.ProfessionalID, arg.StartDate, arg.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFinancesForExportRow
+	for rows.Next() {
+		var i ListFinancesForExportRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.StartTime,
+			&i.DurationMinutes,
+			&i.Modality,
+			&i.Concept,
+			&i.Price,
+			&i.PaymentStatus,
+			&i.PaymentMethod,
+			&i.PaymentConfirmedAt,
+			&i.InvoiceStatus,
+			&i.InvoiceCae,
+			&i.InvoiceUrl,
+			&i.PackageID,
+			&i.ClientName,
+			&i.ClientEmail,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listInsurers = `-- name: ListInsurers :many
+SELECT id, professional_id, name, kind, code, session_fee, requires_authorization, active, created_at FROM insurers
+WHERE professional_id = $1
+ORDER BY name
+`
+
+func (q *Queries) ListInsurers(ctx context.Context, professionalID int64) ([]Insurer, error) {
+	rows, err := q.db.QueryContext(ctx, listInsurers, professionalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Insurer
+	for rows.Next() {
+		var i Insurer
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProfessionalID,
+			&i.Name,
+			&i.Kind,
+			&i.Code,
+			&i.SessionFee,
+			&i.RequiresAuthorization,
+			&i.Active,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNoteAddenda = `-- name: ListNoteAddenda :many
+SELECT id, note_id, professional_id, content, key_version, signed_at, created_at FROM clinical_note_addenda
+WHERE note_id = $1
+ORDER BY signed_at, id
+`
+
+func (q *Queries) ListNoteAddenda(ctx context.Context, noteID int64) ([]ClinicalNoteAddenda, error) {
+	rows, err := q.db.QueryContext(ctx, listNoteAddenda, noteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClinicalNoteAddenda
+	for rows.Next() {
+		var i ClinicalNoteAddenda
+		if err := rows.Scan(
+			&i.ID,
+			&i.NoteID,
+			&i.ProfessionalID,
+			&i.Content,
+			&i.KeyVersion,
+			&i.SignedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNoteAddendaForRotation = `-- name: ListNoteAddendaForRotation :many
+SELECT id, note_id, professional_id, content, key_version, signed_at, created_at FROM clinical_note_addenda
+WHERE id > $1
+  AND (key_version IS DISTINCT FROM $2::int OR content NOT LIKE 'enc:%')
+ORDER BY id
+LIMIT $3
+`
+
+type ListNoteAddendaForRotationParams struct {
+	AfterID    int64 `json:"after_id"`
+	KeyVersion int32 `json:"key_version"`
+	BatchSize  int32 `json:"batch_size"`
+}
+
+func (q *Queries) ListNoteAddendaForRotation(ctx context.Context, arg ListNoteAddendaForRotationParams) ([]ClinicalNoteAddenda, error) {
+	rows, err := q.db.QueryContext(ctx, listNoteAddendaForRotation, arg.AfterID, arg.KeyVersion, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClinicalNoteAddenda
+	for rows.Next() {
+		var i ClinicalNoteAddenda
+		if err := rows.Scan(
+			&i.ID,
+			&i.NoteID,
+			&i.ProfessionalID,
+			&i.Content,
+			&i.KeyVersion,
+			&i.SignedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNoteRevisions = `-- name: ListNoteRevisions :many
+SELECT id, note_id, revision_number, author_id, key_version, written_at, created_at
+FROM clinical_note_revisions
+WHERE note_id = $1
+ORDER BY revision_number
+`
+
+type ListNoteRevisionsRow struct {
+	ID             int64         `json:"id"`
+	NoteID         int64         `json:"note_id"`
+	RevisionNumber int32         `json:"revision_number"`
+	AuthorID       int64         `json:"author_id"`
+	KeyVersion     sql.NullInt32 `json:"key_version"`
+	WrittenAt      sql.NullTime  `json:"written_at"`
+	CreatedAt      sql.NullTime  `json:"created_at"`
+}
+
+// Sólo metadatos: el contenido se pide revisión por revisión
+func (q *Queries) ListNoteRevisions(ctx context.Context, noteID int64) ([]ListNoteRevisionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listNoteRevisions, noteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListNoteRevisionsRow
+	for rows.Next() {
+		var i ListNoteRevisionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.NoteID,
+			&i.RevisionNumber,
+			&i.AuthorID,
+			&i.KeyVersion,
+			&i.WrittenAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNoteRevisionsForRotation = `-- name: ListNoteRevisionsForRotation :many
+SELECT id, note_id, revision_number, author_id, content, key_version, written_at, created_at, sections FROM clinical_note_revisions
+WHERE id > $1
+  AND (key_version IS DISTINCT FROM $2::int OR content NOT LIKE 'enc:%')
+ORDER BY id
+LIMIT $3
+`
+
+type ListNoteRevisionsForRotationParams struct {
+	AfterID    int64 `json:"after_id"`
+	KeyVersion int32 `json:"key_version"`
+	BatchSize  int32 `json:"batch_size"`
+}
+
+func (q *Queries) ListNoteRevisionsForRotation(ctx context.Context, arg ListNoteRevisionsForRotationParams) ([]ClinicalNoteRevision, error) {
+	rows, err := q.db.QueryContext(ctx, listNoteRevisionsForRotation, arg.AfterID, arg.KeyVersion, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClinicalNoteRevision
+	for rows.Next() {
+		var i ClinicalNoteRevision
+		if err := rows.Scan(
+			&i.ID,
+			&i.NoteID,
+			&i.RevisionNumber,
+			&i.AuthorID,
+			&i.Content,
+			&i.KeyVersion,
+			&i.WrittenAt,
+			&i.CreatedAt,
+			&i.Sections,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNoteTemplates = `-- name: ListNoteTemplates :many
+SELECT id, professional_id, name, kind, sections, active, created_at, updated_at FROM note_templates
+WHERE professional_id = $1
+ORDER BY active DESC, name
+`
+
+func (q *Queries) ListNoteTemplates(ctx context.Context, professionalID int64) ([]NoteTemplate, error) {
+	rows, err := q.db.QueryContext(ctx, listNoteTemplates, professionalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NoteTemplate
+	for rows.Next() {
+		var i NoteTemplate
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProfessionalID,
+			&i.Name,
+			&i.Kind,
+			&i.Sections,
+			&i.Active,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNotesForIndexing = `-- name: ListNotesForIndexing :many
+SELECT id, professional_id, client_id, appointment_id, type, content, key_version, status, signed_at, created_at, updated_at, template_id, sections FROM clinical_notes
+WHERE id > $1
+ORDER BY id
+LIMIT $2
+`
+
+type ListNotesForIndexingParams struct {
+	AfterID   int64 `json:"after_id"`
+	BatchSize int32 `json:"batch_size"`
+}
+
+func (q *Queries) ListNotesForIndexing(ctx context.Context, arg ListNotesForIndexingParams) ([]ClinicalNote, error) {
+	rows, err := q.db.QueryContext(ctx, listNotesForIndexing, arg.AfterID, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClinicalNote
+	for rows.Next() {
+		var i ClinicalNote
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProfessionalID,
+			&i.ClientID,
+			&i.AppointmentID,
+			&i.Type,
+			&i.Content,
+			&i.KeyVersion,
+			&i.Status,
+			&i.SignedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.TemplateID,
+			&i.Sections,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrganizationAppointments = `-- name: ListOrganizationAppointments :many
+SELECT a.id, a.professional_id, p.name as professional_name, a.client_id, c.name as client_name,
+       a.date, a.start_time, a.duration_minutes, a.modality, a.status
+FROM appointments a
+JOIN organization_members m ON m.professional_id = a.professional_id
+JOIN professionals p ON p.id = a.professional_id
+JOIN clients c ON c.id = a.client_id
+WHERE m.organization_id = $1
+  AND a.date >= $2::date
+  AND a.date <= $3::date
+  AND a.status NOT IN ('cancelled', 'rescheduled')
+  AND ($4::bigint IS NULL OR a.professional_id = $4::bigint)
+ORDER BY a.date, a.start_time, p.name
+`
+
+type ListOrganizationAppointmentsParams struct {
+	OrganizationID int64         `json:"organization_id"`
+	StartDate      time.Time     `json:"start_date"`
+	EndDate        time.Time     `json:"end_date"`
+	ProfessionalID sql.NullInt64 `json:"professional_id"`
+}
+
+type ListOrganizationAppointmentsRow struct {
+	ID               int64          `json:"id"`
+	ProfessionalID   int64          `json:"professional_id"`
+	ProfessionalName string         `json:"professional_name"`
+	ClientID         int64          `json:"client_id"`
+	ClientName       string         `json:"client_name"`
+	Date             time.Time      `json:"date"`
+	StartTime        string         `json:"start_time"`
+	DurationMinutes  int32          `json:"duration_minutes"`
+	Modality         sql.NullString `json:"modality"`
+	Status           sql.NullString `json:"status"`
+}
+
+// Agenda del centro: los turnos de todos sus profesionales (sin los cancelados ni reprogramados)
+func (q *Queries) ListOrganizationAppointments(ctx context.Context, arg ListOrganizationAppointmentsParams) ([]ListOrganizationAppointmentsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listOrganizationAppointments,
+		arg.OrganizationID,
+		arg.StartDate,
+		arg.EndDate,
+		arg.ProfessionalID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOrganizationAppointmentsRow
+	for rows.Next() {
+		var i ListOrganizationAppointmentsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProfessionalID,
+			&i.ProfessionalName,
+			&i.ClientID,
+			&i.ClientName,
+			&i.Date,
+			&i.StartTime,
+			&i.DurationMinutes,
+			&i.Modality,
+			&i.Status,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrganizationMembers = `-- name: ListOrganizationMembers :many
+SELECT m.professional_id, p.name, p.email, p.slug, p.title, m.role, m.created_at
+FROM organization_members m
+JOIN professionals p ON p.id = m.professional_id
+WHERE m.organization_id = $1
+ORDER BY p.name
+`
+
+type ListOrganizationMembersRow struct {
+	ProfessionalID int64          `json:"professional_id"`
+	Name           string         `json:"name"`
+	Email          string         `json:"email"`
+	Slug           sql.NullString `json:"slug"`
+	Title          sql.NullString `json:"title"`
+	Role           string         `json:"role"`
+	CreatedAt      sql.NullTime   `json:"created_at"`
+}
+
+func (q *Queries) ListOrganizationMembers(ctx context.Context, organizationID int64) ([]ListOrganizationMembersRow, error) {
+	rows, err := q.db.QueryContext(ctx, listOrganizationMembers, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOrganizationMembersRow
+	for rows.Next() {
+		var i ListOrganizationMembersRow
+		if err := rows.Scan(
+			&i.ProfessionalID,
+			&i.Name,
+			&i.Email,
+			&i.Slug,
+			&i.Title,
+			&i.Role,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPendingPriceUpdates = `-- name: ListPendingPriceUpdates :many
+SELECT id, professional_id, modality, duration_minutes, price, effective_from, index_series, applied_at, created_at FROM price_list_entries
+WHERE applied_at IS NULL AND effective_from <= CURRENT_DATE
+ORDER BY effective_from, id
+`
+
+// Precios que ya entraron en vigencia y todavía no se propagaron
+func (q *Queries) ListPendingPriceUpdates(ctx context.Context) ([]PriceListEntry, error) {
+	rows, err := q.db.QueryContext(ctx, listPendingPriceUpdates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PriceListEntry
+	for rows.Next() {
+		var i PriceListEntry
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProfessionalID,
+			&i.Modality,
+			&i.DurationMinutes,
+			&i.Price,
+			&i.EffectiveFrom,
+			&i.IndexSeries,
+			&i.AppliedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPortalAppointments = `-- name: ListPortalAppointments :many
+SELECT a.id, a.date, a.start_time, a.duration_minutes, a.status, a.modality, a.meeting_url,
+       a.price, a.payment_status, a.payment_method, a.invoice_status, a.invoice_url,
+       (pp.appointment_id IS NOT NULL)::bool AS has_payment_proof
+FROM appointments a
+LEFT JOIN payment_proofs pp ON pp.appointment_id = a.id
+WHERE a.client_id = $1 AND a.professional_id = $2
+ORDER BY a.date DESC, a.start_time DESC
+`
+
+type ListPortalAppointmentsParams struct {
+	ClientID       int64 `json:"client_id"`
+	ProfessionalID int64 `json:"professional_id"`
+}
+
+type ListPortalAppointmentsRow struct {
+	ID              int64          `json:"id"`
+	Date            time.Time      `json:"date"`
+	StartTime       string         `json:"start_time"`
+	DurationMinutes int32          `json:"duration_minutes"`
+	Status          sql.NullString `json:"status"`
+	Modality        sql.NullString `json:"modality"`
+	MeetingUrl      sql.NullString `json:"meeting_url"`
+	Price           sql.NullString `json:"price"`
+	PaymentStatus   sql.NullString `json:"payment_status"`
+	PaymentMethod   sql.NullString `json:"payment_method"`
+	InvoiceStatus   sql.NullString `json:"invoice_status"`
+	InvoiceUrl      sql.NullString `json:"invoice_url"`
+	HasPaymentProof bool           `json:"has_payment_proof"`
+}
+
+// Turnos del paciente sin el comentario interno del profesional (notes)
+func (q *Queries) ListPortalAppointments(ctx context.Context, arg ListPortalAppointmentsParams) ([]ListPortalAppointmentsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPortalAppointments, arg.ClientID, arg.ProfessionalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPortalAppointmentsRow
+	for rows.Next() {
+		var i ListPortalAppointmentsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.StartTime,
+			&i.DurationMinutes,
+			&i.Status,
+			&i.Modality,
+			&i.MeetingUrl,
+			&i.Price,
+			&i.PaymentStatus,
+			&i.PaymentMethod,
+			&i.InvoiceStatus,
+			&i.InvoiceUrl,
+			&i.HasPaymentProof,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPortalClientsByEmail = `-- name: ListPortalClientsByEmail :many
+SELECT c.id, c.name, p.name AS professional_name
+FROM clients c
+JOIN professionals p ON p.id = c.professional_id
+WHERE lower(c.email) = lower($1) AND c.active = TRUE
+ORDER BY c.id
+`
+
+type ListPortalClientsByEmailRow struct {
+	ID               int64  `json:"id"`
+	Name             string `json:"name"`
+	ProfessionalName string `json:"professional_name"`
+}
+
+// SECTION: Portal del Paciente
+// Fichas activas con ese email (una por profesional con el que se atiende)
+func (q *Queries) ListPortalClientsByEmail(ctx context.Context, email string) ([]ListPortalClientsByEmailRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPortalClientsByEmail, email)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPortalClientsByEmailRow
+	for rows.Next() {
+		var i ListPortalClientsByEmailRow
+		if err := rows.Scan(&i.ID, &i.Name, &i.ProfessionalName); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPriceListEntries = `-- name: ListPriceListEntries :many
+SELECT id, professional_id, modality, duration_minutes, price, effective_from, index_series, applied_at, created_at FROM price_list_entries
+WHERE professional_id = $1
+ORDER BY modality, duration_minutes, effective_from DESC
+`
+
+func (q *Queries) ListPriceListEntries(ctx context.Context, professionalID int64) ([]PriceListEntry, error) {
+	rows, err := q.db.QueryContext(ctx, listPriceListEntries, professionalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PriceListEntry
+	for rows.Next() {
+		var i PriceListEntry
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProfessionalID,
+			&i.Modality,
+			&i.DurationMinutes,
+			&i.Price,
+			&i.EffectiveFrom,
+			&i.IndexSeries,
+			&i.AppliedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProfessionals = `-- name: ListProfessionals :many
+SELECT id, name, email, phone, slug, title, photo_url
+FROM professionals
+ORDER BY name
+`
+
+type ListProfessionalsRow struct {
+	ID       int64          `json:"id"`
+	Name     string         `json:"name"`
+	Email    string         `json:"email"`
+	Phone    sql.NullString `json:"phone"`
+	Slug     sql.NullString `json:"slug"`
+	Title    sql.NullString `json:"title"`
+	PhotoUrl sql.NullString `json:"photo_url"`
+}
+
+func (q *Queries) ListProfessionals(ctx context.Context) ([]ListProfessionalsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listProfessionals)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListProfessionalsRow
+	for rows.Next() {
+		var i ListProfessionalsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Email,
+			&i.Phone,
+			&i.Slug,
+			&i.Title,
+			&i.PhotoUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProfessionalsForAssistant = `-- name: ListProfessionalsForAssistant :many
+SELECT pr.id, pr.name, string_agg(p.role, ',' ORDER BY p.role)::text AS roles
+FROM assistant_permissions p
+JOIN professionals pr ON pr.id = p.professional_id
+WHERE p.assistant_id = $1
+GROUP BY pr.id
+ORDER BY pr.name
+`
+
+type ListProfessionalsForAssistantRow struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Roles string `json:"roles"`
+}
+
+func (q *Queries) ListProfessionalsForAssistant(ctx context.Context, assistantID int64) ([]ListProfessionalsForAssistantRow, error) {
+	rows, err := q.db.QueryContext(ctx, listProfessionalsForAssistant, assistantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListProfessionalsForAssistantRow
+	for rows.Next() {
+		var i ListProfessionalsForAssistantRow
+		if err := rows.Scan(&i.ID, &i.Name, &i.Roles); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProfessionalsToNotify = `-- name: ListProfessionalsToNotify :many
+SELECT p.id, p.name, p.email
+FROM professionals p
+LEFT JOIN professional_settings s ON s.professional_id = p.id
+WHERE COALESCE(s.notify_by_email, TRUE) AND p.email_verified_at IS NOT NULL
+ORDER BY p.id
+`
+
+type ListProfessionalsToNotifyRow struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// Profesionales que aceptan avisos por email (sin configuración = sí) y con el email verificado
+func (q *Queries) ListProfessionalsToNotify(ctx context.Context) ([]ListProfessionalsToNotifyRow, error) {
+	rows, err := q.db.QueryContext(ctx, listProfessionalsToNotify)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListProfessionalsToNotifyRow
+	for rows.Next() {
+		var i ListProfessionalsToNotifyRow
+		if err := rows.Scan(&i.ID, &i.Name, &i.Email); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRecurringRules = `-- name: ListRecurringRules :many
+SELECT r.id, r.professional_id, r.client_id, r.day_of_week, r.start_time, r.duration_minutes, r.modality, r.price, r.active, r.start_date, r.created_at, c.name as client_name
+FROM recurring_rules r
+JOIN clients c ON r.client_id = c.id
+WHERE r.professional_id = $1
+ORDER BY r.day_of_week, r.start_time
+`
+
+type ListRecurringRulesRow struct {
+	ID              int64          `json:"id"`
+	ProfessionalID  int64          `json:"professional_id"`
+	ClientID        int64          `json:"client_id"`
+	DayOfWeek       int32          `json:"day_of_week"`
+	StartTime       string         `json:"start_time"`
+	DurationMinutes int32          `json:"duration_minutes"`
+	Modality        sql.NullString `json:"modality"`
+	Price           sql.NullString `json:"price"`
+	Active          sql.NullBool   `json:"active"`
+	StartDate       sql.NullTime   `json:"start_date"`
+	CreatedAt       sql.NullTime   `json:"created_at"`
+	ClientName      string         `json:"client_name"`
+}
+
+func (q *Queries) ListRecurringRules(ctx context.Context, professionalID int64) ([]ListRecurringRulesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listRecurringRules, professionalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListRecurringRulesRow
+	for rows.Next() {
+		var i ListRecurringRulesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProfessionalID,
+			&i.ClientID,
+			&i.DayOfWeek,
+			&i.StartTime,
+			&i.DurationMinutes,
+			&i.Modality,
+			&i.Price,
+			&i.Active,
+			&i.StartDate,
+			&i.CreatedAt,
+			&i.ClientName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listScheduleConfigs = `-- name: ListScheduleConfigs :many
+SELECT id, professional_id, day_of_week, start_time, end_time, created_at FROM schedule_configs
+WHERE professional_id = $1
+ORDER BY day_of_week, start_time
+`
+
+func (q *Queries) ListScheduleConfigs(ctx context.Context, professionalID int64) ([]ScheduleConfig, error) {
+	rows, err := q.db.QueryContext(ctx, listScheduleConfigs, professionalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ScheduleConfig
+	for rows.Next() {
+		var i ScheduleConfig
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProfessionalID,
+			&i.DayOfWeek,
+			&i.StartTime,
+			&i.EndTime,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSessionPackages = `-- name: ListSessionPackages :many
+SELECT sp.id, sp.professional_id, sp.client_id, sp.name, sp.total_sessions, sp.used_sessions, sp.price, sp.valid_from, sp.valid_until, sp.payment_status, sp.payment_method, sp.paid_at, sp.created_at, c.name as client_name,
+       (sp.total_sessions - sp.used_sessions)::INTEGER as remaining_sessions
+FROM session_packages sp
+JOIN clients c ON sp.client_id = c.id
+WHERE sp.professional_id = $1
+  AND ($2::bigint IS NULL OR sp.client_id = $2::bigint)
+ORDER BY sp.valid_until DESC, sp.id DESC
+`
+
+type ListSessionPackagesParams struct {
+	ProfessionalID int64         `json:"professional_id"`
+	ClientID       sql.NullInt64 `json:"client_id"`
+}
+
+type ListSessionPackagesRow struct {
+	ID                int64          `json:"id"`
+	ProfessionalID    int64          `json:"professional_id"`
+	ClientID          int64          `json:"client_id"`
+	Name              string         `json:"name"`
+	TotalSessions     int32          `json:"total_sessions"`
+	UsedSessions      int32          `json:"used_sessions"`
+	Price             string         `json:"price"`
+	ValidFrom         time.Time      `json:"valid_from"`
+	ValidUntil        time.Time      `json:"valid_until"`
+	PaymentStatus     sql.NullString `json:"payment_status"`
+	PaymentMethod     sql.NullString `json:"payment_method"`
+	PaidAt            sql.NullTime   `json:"paid_at"`
+	CreatedAt         sql.NullTime   `json:"created_at"`
+	ClientName        string         `json:"client_name"`
+	RemainingSessions int32          `json:"remaining_sessions"`
+}
+
+func (q *Queries) ListSessionPackages(ctx context.Context, arg ListSessionPackagesParams) ([]ListSessionPackagesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSessionPackages, arg.ProfessionalID, arg.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSessionPackagesRow
+	for rows.Next() {
+		var i ListSessionPackagesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProfessionalID,
+			&i.ClientID,
+			&i.Name,
+			&i.TotalSessions,
+			&i.UsedSessions,
+			&i.Price,
+			&i.ValidFrom,
+			&i.ValidUntil,
+			&i.PaymentStatus,
+			&i.PaymentMethod,
+			&i.PaidAt,
+			&i.CreatedAt,
+			&i.ClientName,
+			&i.RemainingSessions,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSignedNotesForRecord = `-- name: ListSignedNotesForRecord :many
+SELECT id, professional_id, client_id, appointment_id, type, content, key_version, status, signed_at, created_at, updated_at, template_id, sections FROM clinical_notes
+WHERE professional_id = $1 AND client_id = $2
+  AND status = 'signed' AND type = 'clinical'
+ORDER BY signed_at, id
+`
+
+type ListSignedNotesForRecordParams struct {
+	ProfessionalID int64 `json:"professional_id"`
+	ClientID       int64 `json:"client_id"`
+}
+
+// SECTION: Clinical Record Export
+// Notas que integran la historia clínica: sólo firmadas y de tipo clínico
+func (q *Queries) ListSignedNotesForRecord(ctx context.Context, arg ListSignedNotesForRecordParams) ([]ClinicalNote, error) {
+	rows, err := q.db.QueryContext(ctx, listSignedNotesForRecord, arg.ProfessionalID, arg.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClinicalNote
+	for rows.Next() {
+		var i ClinicalNote
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProfessionalID,
+			&i.ClientID,
+			&i.AppointmentID,
+			&i.Type,
+			&i.Content,
+			&i.KeyVersion,
+			&i.Status,
+			&i.SignedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.TemplateID,
+			&i.Sections,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTwoFactorForRotation = `-- name: ListTwoFactorForRotation :many
+SELECT id, professional_id, secret, key_version, confirmed_at, last_used_step, failed_attempts, locked_until, created_at FROM professional_two_factor
+WHERE id > $1
+  AND key_version IS DISTINCT FROM $2::int
+ORDER BY id
+LIMIT $3
+`
+
+type ListTwoFactorForRotationParams struct {
+	AfterID    int64 `json:"after_id"`
+	KeyVersion int32 `json:"key_version"`
+	BatchSize  int32 `json:"batch_size"`
+}
+
+func (q *Queries) ListTwoFactorForRotation(ctx context.Context, arg ListTwoFactorForRotationParams) ([]ProfessionalTwoFactor, error) {
+	rows, err := q.db.QueryContext(ctx, listTwoFactorForRotation, arg.AfterID, arg.KeyVersion, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ProfessionalTwoFactor
+	for rows.Next() {
+		var i ProfessionalTwoFactor
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProfessionalID,
+			&i.Secret,
+			&i.KeyVersion,
+			&i.ConfirmedAt,
+			&i.LastUsedStep,
+			&i.FailedAttempts,
+			&i.LockedUntil,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockAuditChain = `-- name: LockAuditChain :exec
+SELECT pg_advisory_xact_lock($1::bigint)
+`
+
+// SECTION: Auditoría
+// Serializa las altas de un mismo profesional para que dos requests no encadenen sobre el mismo hash
+func (q *Queries) LockAuditChain(ctx context.Context, professionalID int64) error {
+	_, err := q.db.ExecContext(ctx, lockAuditChain, professionalID)
+	return err
+}
+
+const lockBookingRequester = `-- name: LockBookingRequester :exec
+SELECT pg_advisory_xact_lock(hashtext('booking_requests'), hashtext($1::text))
+`
+
+// Serializa los pedidos de una misma IP o email hasta el fin de la transacción, así el conteo
+// de abajo y el alta no se pisan. Usa la variante de dos claves para no chocar con LockAuditChain
+func (q *Queries) LockBookingRequester(ctx context.Context, requester string) error {
+	_, err := q.db.ExecContext(ctx, lockBookingRequester, requester)
+	return err
+}
+
+const markEmailVerified = `-- name: MarkEmailVerified :execrows
+UPDATE professionals
+SET email_verified_at = COALESCE(email_verified_at, NOW())
+WHERE id = $1 AND lower(email) = lower($2)
+`
+
+type MarkEmailVerifiedParams struct {
+	ID    int64  `json:"id"`
+	Email string `json:"email"`
+}
+
+// Sólo si el email sigue siendo el del enlace. Verificar de nuevo no cambia la fecha
+func (q *Queries) MarkEmailVerified(ctx context.Context, arg MarkEmailVerifiedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markEmailVerified, arg.ID, arg.Email)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const markPriceListEntryApplied = `-- name: MarkPriceListEntryApplied :exec
+UPDATE price_list_entries SET applied_at = NOW() WHERE id = $1
+`
+
+func (q *Queries) MarkPriceListEntryApplied(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, markPriceListEntryApplied, id)
+	return err
+}
+
+const moveAppointmentClientPayments = `-- name: MoveAppointmentClientPayments :exec
+UPDATE client_payments SET appointment_id = $1
+WHERE appointment_id = $2 AND professional_id = $3
+`
+
+type MoveAppointmentClientPaymentsParams struct {
+	NewAppointmentID int64 `json:"new_appointment_id"`
+	OldAppointmentID int64 `json:"old_appointment_id"`
+	ProfessionalID   int64 `json:"professional_id"`
+}
+
+func (q *Queries) MoveAppointmentClientPayments(ctx context.Context, arg MoveAppointmentClientPaymentsParams) error {
+	_, err := q.db.ExecContext(ctx, moveAppointmentClientPayments, arg.NewAppointmentID, arg.OldAppointmentID, arg.ProfessionalID)
+	return err
+}
+
+const moveAppointmentPayment = `-- name: MoveAppointmentPayment :exec
+UPDATE appointments n
+SET payment_status = o.payment_status, payment_method = o.payment_method,
+    payment_proof_url = o.payment_proof_url, payment_confirmed_at = o.payment_confirmed_at,
+    updated_at = NOW()
+FROM appointments o
+WHERE n.id = $1 AND o.id = $2
+  AND n.professional_id = $3 AND o.professional_id = $3
+`
+
+type MoveAppointmentPaymentParams struct {
+	NewAppointmentID int64 `json:"new_appointment_id"`
+	OldAppointmentID int64 `json:"old_appointment_id"`
+	ProfessionalID   int64 `json:"professional_id"`
+}
+
+// Al reprogramar, el pago pasa al turno nuevo (el original se deja pendiente aparte, para no
+// contarlo dos veces en la cuenta corriente)
+func (q *Queries) MoveAppointmentPayment(ctx context.Context, arg MoveAppointmentPaymentParams) error {
+	_, err := q.db.ExecContext(ctx, moveAppointmentPayment, arg.NewAppointmentID, arg.OldAppointmentID, arg.ProfessionalID)
+	return err
+}
+
+const movePaymentProof = `-- name: MovePaymentProof :exec
+UPDATE payment_proofs SET appointment_id = $1
+WHERE appointment_id = $2 AND professional_id = $3
+`
+
+type MovePaymentProofParams struct {
+	NewAppointmentID int64 `json:"new_appointment_id"`
+	OldAppointmentID int64 `json:"old_appointment_id"`
+	ProfessionalID   int64 `json:"professional_id"`
+}
+
+func (q *Queries) MovePaymentProof(ctx context.Context, arg MovePaymentProofParams) error {
+	_, err := q.db.ExecContext(ctx, movePaymentProof, arg.NewAppointmentID, arg.OldAppointmentID, arg.ProfessionalID)
+	return err
+}
+
+const organizationRequiresTwoFactor = `-- name: OrganizationRequiresTwoFactor :one
+SELECT EXISTS (
+    SELECT 1 FROM organization_members m
+    JOIN organizations o ON o.id = m.organization_id
+    WHERE m.professional_id = $1 AND o.require_two_factor
+)
+`
+
+func (q *Queries) OrganizationRequiresTwoFactor(ctx context.Context, professionalID int64) (bool, error) {
+	row := q.db.QueryRowContext(ctx, organizationRequiresTwoFactor, professionalID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const recordTwoFactorFailure = `-- name: RecordTwoFactorFailure :exec
+UPDATE professional_two_factor
+SET failed_attempts = CASE WHEN failed_attempts + 1 >= $1::int THEN 0 ELSE failed_attempts + 1 END,
+    locked_until = CASE WHEN failed_attempts + 1 >= $1::int THEN $2::timestamptz ELSE locked_until END
+WHERE professional_id = $3
+`
+
+type RecordTwoFactorFailureParams struct {
+	MaxAttempts    int32     `json:"max_attempts"`
+	LockedUntil    time.Time `json:"locked_until"`
+	ProfessionalID int64     `json:"professional_id"`
+}
+
+// Al llegar al máximo de intentos se bloquea hasta locked_until y el contador vuelve a cero
+func (q *Queries) RecordTwoFactorFailure(ctx context.Context, arg RecordTwoFactorFailureParams) error {
+	_, err := q.db.ExecContext(ctx, recordTwoFactorFailure, arg.MaxAttempts, arg.LockedUntil, arg.ProfessionalID)
+	return err
+}
+
+const removeOrganizationMember = `-- name: RemoveOrganizationMember :execrows
+DELETE FROM organization_members
+WHERE organization_id = $1 AND professional_id = $2
+`
+
+type RemoveOrganizationMemberParams struct {
+	OrganizationID int64 `json:"organization_id"`
+	ProfessionalID int64 `json:"professional_id"`
+}
+
+func (q *Queries) RemoveOrganizationMember(ctx context.Context, arg RemoveOrganizationMemberParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, removeOrganizationMember, arg.OrganizationID, arg.ProfessionalID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const resetTwoFactorFailures = `-- name: ResetTwoFactorFailures :exec
+UPDATE professional_two_factor SET failed_attempts = 0, locked_until = NULL
+WHERE professional_id = $1
+`
+
+func (q *Queries) ResetTwoFactorFailures(ctx context.Context, professionalID int64) error {
+	_, err := q.db.ExecContext(ctx, resetTwoFactorFailures, professionalID)
+	return err
+}
+
+const revokeAssistantRefreshToken = `-- name: RevokeAssistantRefreshToken :execrows
+UPDATE assistant_refresh_tokens SET revoked_at = NOW()
+WHERE id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeAssistantRefreshToken(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeAssistantRefreshToken, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const revokeAssistantRefreshTokens = `-- name: RevokeAssistantRefreshTokens :exec
+UPDATE assistant_refresh_tokens SET revoked_at = NOW()
+WHERE assistant_id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeAssistantRefreshTokens(ctx context.Context, assistantID int64) error {
+	_, err := q.db.ExecContext(ctx, revokeAssistantRefreshTokens, assistantID)
+	return err
+}
+
+const revokeAssistantRoles = `-- name: RevokeAssistantRoles :execrows
+DELETE FROM assistant_permissions
+WHERE assistant_id = $1 AND professional_id = $2
+`
+
+type RevokeAssistantRolesParams struct {
+	AssistantID    int64 `json:"assistant_id"`
+	ProfessionalID int64 `json:"professional_id"`
+}
+
+func (q *Queries) RevokeAssistantRoles(ctx context.Context, arg RevokeAssistantRolesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeAssistantRoles, arg.AssistantID, arg.ProfessionalID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const revokeProfessionalRefreshTokens = `-- name: RevokeProfessionalRefreshTokens :exec
+UPDATE refresh_tokens SET revoked_at = NOW()
+WHERE professional_id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeProfessionalRefreshTokens(ctx context.Context, professionalID int64) error {
+	_, err := q.db.ExecContext(ctx, revokeProfessionalRefreshTokens, professionalID)
+	return err
+}
+
+const revokeRefreshToken = `-- name: RevokeRefreshToken :execrows
+UPDATE refresh_tokens SET revoked_at = NOW()
+WHERE id = $1 AND revoked_at IS NULL
+`
+
+// Sólo revoca si seguía vigente: dos renovaciones simultáneas no pueden usar el mismo token
+func (q *Queries) RevokeRefreshToken(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeRefreshToken, id)
+	if err != nil {
+		return 0, err
 	}
-	if err := rows.Close(); err != nil {
-		return nil, err
+	return result.RowsAffected()
+}
+
+const rewrapAttachmentKey = `-- name: RewrapAttachmentKey :execrows
+UPDATE attachments
+SET data_key = $1, key_version = $2
+WHERE id = $3 AND key_version IS NOT DISTINCT FROM $4 AND data_key = $5
+`
+
+type RewrapAttachmentKeyParams struct {
+	DataKey       string        `json:"data_key"`
+	NewKeyVersion sql.NullInt32 `json:"new_key_version"`
+	ID            int64         `json:"id"`
+	OldKeyVersion sql.NullInt32 `json:"old_key_version"`
+	OldDataKey    string        `json:"old_data_key"`
+}
+
+func (q *Queries) RewrapAttachmentKey(ctx context.Context, arg RewrapAttachmentKeyParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, rewrapAttachmentKey,
+		arg.DataKey,
+		arg.NewKeyVersion,
+		arg.ID,
+		arg.OldKeyVersion,
+		arg.OldDataKey,
+	)
+	if err != nil {
+		return 0, err
 	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+	return result.RowsAffected()
+}
+
+const rewrapClientFormAnswers = `-- name: RewrapClientFormAnswers :execrows
+UPDATE client_forms
+SET answers = $1, key_version = $2
+WHERE id = $3 AND key_version IS NOT DISTINCT FROM $4 AND answers = $5
+`
+
+type RewrapClientFormAnswersParams struct {
+	Answers       string        `json:"answers"`
+	NewKeyVersion sql.NullInt32 `json:"new_key_version"`
+	ID            int64         `json:"id"`
+	OldKeyVersion sql.NullInt32 `json:"old_key_version"`
+	OldAnswers    string        `json:"old_answers"`
+}
+
+func (q *Queries) RewrapClientFormAnswers(ctx context.Context, arg RewrapClientFormAnswersParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, rewrapClientFormAnswers,
+		arg.Answers,
+		arg.NewKeyVersion,
+		arg.ID,
+		arg.OldKeyVersion,
+		arg.OldAnswers,
+	)
+	if err != nil {
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected()
 }
 
-const listClients = `-- name: ListClients :many
-SELECT id, professional_id, name, email, phone, birth_date, medications, emergency_contact_name, emergency_contact_phone, active, created_at FROM clients
-WHERE professional_id = $1 AND active = TRUE
-ORDER BY name
+const rewrapClinicalNote = `-- name: RewrapClinicalNote :execrows
+UPDATE clinical_notes
+SET content = $1, sections = $2, key_version = $3
+WHERE id = $4 AND key_version IS NOT DISTINCT FROM $5
+  AND content = $6 AND sections IS NOT DISTINCT FROM $7
 `
 
-func (q *Queries) ListClients(ctx context.Context, professionalID int64) ([]Client, error) {
-	rows, err := q.db.QueryContext(ctx, listClients, professionalID)
+type RewrapClinicalNoteParams struct {
+	Content       string         `json:"content"`
+	Sections      sql.NullString `json:"sections"`
+	NewKeyVersion sql.NullInt32  `json:"new_key_version"`
+	ID            int64          `json:"id"`
+	OldKeyVersion sql.NullInt32  `json:"old_key_version"`
+	OldContent    string         `json:"old_content"`
+	OldSections   sql.NullString `json:"old_sections"`
+}
+
+// No toca updated_at: rotar claves no es editar la nota. Sólo si el contenido sigue siendo el
+// leído: una nota en claro editada mientras tanto conserva la versión y no se pisa
+func (q *Queries) RewrapClinicalNote(ctx context.Context, arg RewrapClinicalNoteParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, rewrapClinicalNote,
+		arg.Content,
+		arg.Sections,
+		arg.NewKeyVersion,
+		arg.ID,
+		arg.OldKeyVersion,
+		arg.OldContent,
+		arg.OldSections,
+	)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	defer rows.Close()
-	var items []Client
-	for rows.Next() {
-		var i Client
-		if err := rows.Scan(
-			&i.ID,
-			&i.ProfessionalID,
-			&i.Name,
-			&i.Email,
-			&i.Phone,
-			&i.BirthDate,
-			&i.Medications,
-			&i.EmergencyContactName,
-			&i.EmergencyContactPhone,
-			&i.Active,
-			&i.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
+	return result.RowsAffected()
+}
+
+const rewrapNoteAddendum = `-- name: RewrapNoteAddendum :execrows
+UPDATE clinical_note_addenda
+SET content = $1, key_version = $2
+WHERE id = $3 AND key_version IS NOT DISTINCT FROM $4 AND content = $5
+`
+
+type RewrapNoteAddendumParams struct {
+	Content       string        `json:"content"`
+	NewKeyVersion sql.NullInt32 `json:"new_key_version"`
+	ID            int64         `json:"id"`
+	OldKeyVersion sql.NullInt32 `json:"old_key_version"`
+	OldContent    string        `json:"old_content"`
+}
+
+func (q *Queries) RewrapNoteAddendum(ctx context.Context, arg RewrapNoteAddendumParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, rewrapNoteAddendum,
+		arg.Content,
+		arg.NewKeyVersion,
+		arg.ID,
+		arg.OldKeyVersion,
+		arg.OldContent,
+	)
+	if err != nil {
+		return 0, err
 	}
-	if err := rows.Close(); err != nil {
-		return nil, err
+	return result.RowsAffected()
+}
+
+const rewrapNoteRevision = `-- name: RewrapNoteRevision :execrows
+UPDATE clinical_note_revisions
+SET content = $1, sections = $2, key_version = $3
+WHERE id = $4 AND key_version IS NOT DISTINCT FROM $5
+  AND content = $6 AND sections IS NOT DISTINCT FROM $7
+`
+
+type RewrapNoteRevisionParams struct {
+	Content       string         `json:"content"`
+	Sections      sql.NullString `json:"sections"`
+	NewKeyVersion sql.NullInt32  `json:"new_key_version"`
+	ID            int64          `json:"id"`
+	OldKeyVersion sql.NullInt32  `json:"old_key_version"`
+	OldContent    string         `json:"old_content"`
+	OldSections   sql.NullString `json:"old_sections"`
+}
+
+func (q *Queries) RewrapNoteRevision(ctx context.Context, arg RewrapNoteRevisionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, rewrapNoteRevision,
+		arg.Content,
+		arg.Sections,
+		arg.NewKeyVersion,
+		arg.ID,
+		arg.OldKeyVersion,
+		arg.OldContent,
+		arg.OldSections,
+	)
+	if err != nil {
+		return 0, err
 	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+	return result.RowsAffected()
+}
+
+const rewrapTwoFactorSecret = `-- name: RewrapTwoFactorSecret :execrows
+UPDATE professional_two_factor
+SET secret = $1, key_version = $2
+WHERE id = $3 AND key_version IS NOT DISTINCT FROM $4 AND secret = $5
+`
+
+type RewrapTwoFactorSecretParams struct {
+	Secret        string        `json:"secret"`
+	NewKeyVersion sql.NullInt32 `json:"new_key_version"`
+	ID            int64         `json:"id"`
+	OldKeyVersion sql.NullInt32 `json:"old_key_version"`
+	OldSecret     string        `json:"old_secret"`
+}
+
+func (q *Queries) RewrapTwoFactorSecret(ctx context.Context, arg RewrapTwoFactorSecretParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, rewrapTwoFactorSecret,
+		arg.Secret,
+		arg.NewKeyVersion,
+		arg.ID,
+		arg.OldKeyVersion,
+		arg.OldSecret,
+	)
+	if err != nil {
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected()
 }
 
-const listClinicalNotes = `-- name: ListClinicalNotes :many
-SELECT id, professional_id, client_id, appointment_id, type, content, key_version, status, signed_at, created_at, updated_at FROM clinical_notes
-WHERE client_id = $1 AND professional_id = $2
-ORDER BY created_at DESC
+const searchClinicalNotes = `-- name: SearchClinicalNotes :many
+SELECT n.id, n.professional_id, n.client_id, n.appointment_id, n.type, n.content, n.key_version, n.status, n.signed_at, n.created_at, n.updated_at, n.template_id, n.sections FROM clinical_notes n
+WHERE n.professional_id = $1
+  AND ($2::bigint IS NULL OR n.client_id = $2::bigint)
+  AND n.id IN (
+      SELECT t.note_id FROM clinical_note_search_tokens t
+      WHERE t.token = ANY($3::text[])
+      GROUP BY t.note_id
+      HAVING COUNT(DISTINCT t.token) = $4::int
+  )
+ORDER BY n.created_at DESC
+LIMIT $5
 `
 
-type ListClinicalNotesParams struct {
-	ClientID       int64 `json:"client_id"`
-	ProfessionalID int64 `json:"professional_id"`
+type SearchClinicalNotesParams struct {
+	ProfessionalID int64         `json:"professional_id"`
+	ClientID       sql.NullInt64 `json:"client_id"`
+	Tokens         []string      `json:"tokens"`
+	TokenCount     int32         `json:"token_count"`
+	MaxResults     int32         `json:"max_results"`
 }
 
-func (q *Queries) ListClinicalNotes(ctx context.Context, arg ListClinicalNotesParams) ([]ClinicalNote, error) {
-	rows, err := q.db.QueryContext(ctx, listClinicalNotes, arg.ClientID, arg.ProfessionalID)
+// Notas del profesional que contienen todos los tokens buscados
+func (q *Queries) SearchClinicalNotes(ctx context.Context, arg SearchClinicalNotesParams) ([]ClinicalNote, error) {
+	rows, err := q.db.QueryContext(ctx, searchClinicalNotes,
+		arg.ProfessionalID,
+		arg.ClientID,
+		pq.Array(arg.Tokens),
+		arg.TokenCount,
+		arg.MaxResults,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -1008,6 +5812,8 @@ func (q *Queries) ListClinicalNotes(ctx context.Context, arg ListClinicalNotesPa
 			&i.SignedAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.TemplateID,
+			&i.Sections,
 		); err != nil {
 			return nil, err
 		}
@@ -1022,111 +5828,197 @@ func (q *Queries) ListClinicalNotes(ctx context.Context, arg ListClinicalNotesPa
 	return items, nil
 }
 
-const listRecurringRules = `-- name: ListRecurringRules :many
-SELECT r.id, r.professional_id, r.client_id, r.day_of_week, r.start_time, r.duration_minutes, r.modality, r.price, r.active, r.start_date, r.created_at, c.name as client_name
-FROM recurring_rules r
-JOIN clients c ON r.client_id = c.id
-WHERE r.professional_id = $1
-ORDER BY r.day_of_week, r.start_time
+const setAppointmentInsuranceBilling = `-- name: SetAppointmentInsuranceBilling :one
+UPDATE appointments
+SET price = $1, payment_method = 'insurance', updated_at = NOW()
+WHERE id = $2 AND professional_id = $3
+RETURNING id, professional_id, client_id, date, start_time, duration_minutes, status, modality, meeting_url, price, concept, payment_status, payment_method, payment_proof_url, payment_confirmed_at, invoice_status, invoice_url, invoice_cae, notes, rescheduled_from_id, recurring_rule_id, created_at, updated_at
 `
 
-type ListRecurringRulesRow struct {
+type SetAppointmentInsuranceBillingParams struct {
+	Price          sql.NullString `json:"price"`
+	ID             int64          `json:"id"`
+	ProfessionalID int64          `json:"professional_id"`
+}
+
+// El precio del turno pasa a ser arancel de la obra social + coseguro
+func (q *Queries) SetAppointmentInsuranceBilling(ctx context.Context, arg SetAppointmentInsuranceBillingParams) (Appointment, error) {
+	row := q.db.QueryRowContext(ctx, setAppointmentInsuranceBilling, arg.Price, arg.ID, arg.ProfessionalID)
+	var i Appointment
+	err := row.Scan(
+		&i.ID,
+		&i.ProfessionalID,
+		&i.ClientID,
+		&i.Date,
+		&i.StartTime,
+		&i.DurationMinutes,
+		&i.Status,
+		&i.Modality,
+		&i.MeetingUrl,
+		&i.Price,
+		&i.Concept,
+		&i.PaymentStatus,
+		&i.PaymentMethod,
+		&i.PaymentProofUrl,
+		&i.PaymentConfirmedAt,
+		&i.InvoiceStatus,
+		&i.InvoiceUrl,
+		&i.InvoiceCae,
+		&i.Notes,
+		&i.RescheduledFromID,
+		&i.RecurringRuleID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const setAppointmentPaymentProof = `-- name: SetAppointmentPaymentProof :exec
+UPDATE appointments SET payment_proof_url = $1, updated_at = NOW()
+WHERE id = $2 AND professional_id = $3
+`
+
+type SetAppointmentPaymentProofParams struct {
+	PaymentProofUrl sql.NullString `json:"payment_proof_url"`
 	ID              int64          `json:"id"`
 	ProfessionalID  int64          `json:"professional_id"`
-	ClientID        int64          `json:"client_id"`
-	DayOfWeek       int32          `json:"day_of_week"`
-	StartTime       string         `json:"start_time"`
-	DurationMinutes int32          `json:"duration_minutes"`
-	Modality        sql.NullString `json:"modality"`
-	Price           sql.NullString `json:"price"`
-	Active          sql.NullBool   `json:"active"`
-	StartDate       sql.NullTime   `json:"start_date"`
-	CreatedAt       sql.NullTime   `json:"created_at"`
-	ClientName      string         `json:"client_name"`
 }
 
-func (q *Queries) ListRecurringRules(ctx context.Context, professionalID int64) ([]ListRecurringRulesRow, error) {
-	rows, err := q.db.QueryContext(ctx, listRecurringRules, professionalID)
+func (q *Queries) SetAppointmentPaymentProof(ctx context.Context, arg SetAppointmentPaymentProofParams) error {
+	_, err := q.db.ExecContext(ctx, setAppointmentPaymentProof, arg.PaymentProofUrl, arg.ID, arg.ProfessionalID)
+	return err
+}
+
+const setClientAppointmentStatus = `-- name: SetClientAppointmentStatus :execrows
+UPDATE appointments SET status = $1, updated_at = NOW()
+WHERE id = $2 AND client_id = $3 AND professional_id = $4
+  AND status = 'scheduled'
+`
+
+type SetClientAppointmentStatusParams struct {
+	Status         sql.NullString `json:"status"`
+	ID             int64          `json:"id"`
+	ClientID       int64          `json:"client_id"`
+	ProfessionalID int64          `json:"professional_id"`
+}
+
+// Sólo sobre turnos todavía agendados: cancelar o reprogramar dos veces no hace nada
+func (q *Queries) SetClientAppointmentStatus(ctx context.Context, arg SetClientAppointmentStatusParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setClientAppointmentStatus,
+		arg.Status,
+		arg.ID,
+		arg.ClientID,
+		arg.ProfessionalID,
+	)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []ListRecurringRulesRow
-	for rows.Next() {
-		var i ListRecurringRulesRow
-		if err := rows.Scan(
-			&i.ID,
-			&i.ProfessionalID,
-			&i.ClientID,
-			&i.DayOfWeek,
-			&i.StartTime,
-			&i.DurationMinutes,
-			&i.Modality,
-			&i.Price,
-			&i.Active,
-			&i.StartDate,
-			&i.CreatedAt,
-			&i.ClientName,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
+		return 0, err
 	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+	return result.RowsAffected()
 }
 
-const listScheduleConfigs = `-- name: ListScheduleConfigs :many
-SELECT id, professional_id, day_of_week, start_time, end_time, created_at FROM schedule_configs
-WHERE professional_id = $1
-ORDER BY day_of_week, start_time
+const setInitialAssistantPassword = `-- name: SetInitialAssistantPassword :execrows
+INSERT INTO assistant_credentials (assistant_id, password_hash)
+VALUES ($1, $2)
+ON CONFLICT (assistant_id) DO NOTHING
 `
 
-func (q *Queries) ListScheduleConfigs(ctx context.Context, professionalID int64) ([]ScheduleConfig, error) {
-	rows, err := q.db.QueryContext(ctx, listScheduleConfigs, professionalID)
+type SetInitialAssistantPasswordParams struct {
+	AssistantID  int64  `json:"assistant_id"`
+	PasswordHash string `json:"password_hash"`
+}
+
+// Sólo la primera vez (al aceptar la invitación): reusar el enlace no cambia la contraseña
+func (q *Queries) SetInitialAssistantPassword(ctx context.Context, arg SetInitialAssistantPasswordParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setInitialAssistantPassword, arg.AssistantID, arg.PasswordHash)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []ScheduleConfig
-	for rows.Next() {
-		var i ScheduleConfig
-		if err := rows.Scan(
-			&i.ID,
-			&i.ProfessionalID,
-			&i.DayOfWeek,
-			&i.StartTime,
-			&i.EndTime,
-			&i.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
+		return 0, err
 	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+	return result.RowsAffected()
+}
+
+const setOrganizationTwoFactor = `-- name: SetOrganizationTwoFactor :execrows
+UPDATE organizations SET require_two_factor = $1
+WHERE id = $2
+`
+
+type SetOrganizationTwoFactorParams struct {
+	RequireTwoFactor bool  `json:"require_two_factor"`
+	ID               int64 `json:"id"`
+}
+
+func (q *Queries) SetOrganizationTwoFactor(ctx context.Context, arg SetOrganizationTwoFactorParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setOrganizationTwoFactor, arg.RequireTwoFactor, arg.ID)
+	if err != nil {
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected()
+}
+
+const setProfessionalPassword = `-- name: SetProfessionalPassword :exec
+INSERT INTO professional_credentials (professional_id, password_hash)
+VALUES ($1, $2)
+ON CONFLICT (professional_id) DO UPDATE
+SET password_hash = EXCLUDED.password_hash, updated_at = NOW()
+`
+
+type SetProfessionalPasswordParams struct {
+	ProfessionalID int64  `json:"professional_id"`
+	PasswordHash   string `json:"password_hash"`
+}
+
+// SECTION: Autenticación
+func (q *Queries) SetProfessionalPassword(ctx context.Context, arg SetProfessionalPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, setProfessionalPassword, arg.ProfessionalID, arg.PasswordHash)
+	return err
+}
+
+const setProfessionalPhoto = `-- name: SetProfessionalPhoto :one
+UPDATE professionals
+SET photo_url = $1
+WHERE id = $2
+RETURNING id, name, email, phone, slug, photo_url, title, license_number, bio, cancellation_window_hours, email_verified_at, created_at
+`
+
+type SetProfessionalPhotoParams struct {
+	PhotoUrl sql.NullString `json:"photo_url"`
+	ID       int64          `json:"id"`
+}
+
+func (q *Queries) SetProfessionalPhoto(ctx context.Context, arg SetProfessionalPhotoParams) (Professional, error) {
+	row := q.db.QueryRowContext(ctx, setProfessionalPhoto, arg.PhotoUrl, arg.ID)
+	var i Professional
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.Phone,
+		&i.Slug,
+		&i.PhotoUrl,
+		&i.Title,
+		&i.LicenseNumber,
+		&i.Bio,
+		&i.CancellationWindowHours,
+		&i.EmailVerifiedAt,
+		&i.CreatedAt,
+	)
+	return i, err
 }
 
 const signClinicalNote = `-- name: SignClinicalNote :one
 UPDATE clinical_notes
 SET status = 'signed', signed_at = NOW(), updated_at = NOW()
-WHERE id = $1
-RETURNING id, professional_id, client_id, appointment_id, type, content, key_version, status, signed_at, created_at, updated_at
+WHERE id = $1 AND professional_id = $2 AND status = 'draft'
+RETURNING id, professional_id, client_id, appointment_id, type, content, key_version, status, signed_at, created_at, updated_at, template_id, sections
 `
 
+type SignClinicalNoteParams struct {
+	ID             int64 `json:"id"`
+	ProfessionalID int64 `json:"professional_id"`
+}
+
 // "Firma" la nota: cambia estado a signed y pone fecha
-func (q *Queries) SignClinicalNote(ctx context.Context, id int64) (ClinicalNote, error) {
-	row := q.db.QueryRowContext(ctx, signClinicalNote, id)
+func (q *Queries) SignClinicalNote(ctx context.Context, arg SignClinicalNoteParams) (ClinicalNote, error) {
+	row := q.db.QueryRowContext(ctx, signClinicalNote, arg.ID, arg.ProfessionalID)
 	var i ClinicalNote
 	err := row.Scan(
 		&i.ID,
@@ -1140,6 +6032,43 @@ func (q *Queries) SignClinicalNote(ctx context.Context, id int64) (ClinicalNote,
 		&i.SignedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TemplateID,
+		&i.Sections,
+	)
+	return i, err
+}
+
+const startTwoFactorEnrollment = `-- name: StartTwoFactorEnrollment :one
+INSERT INTO professional_two_factor (professional_id, secret, key_version)
+VALUES ($1, $2, $3)
+ON CONFLICT (professional_id) DO UPDATE
+SET secret = EXCLUDED.secret, key_version = EXCLUDED.key_version, last_used_step = 0,
+    failed_attempts = 0, locked_until = NULL, created_at = NOW()
+WHERE professional_two_factor.confirmed_at IS NULL
+RETURNING id, professional_id, secret, key_version, confirmed_at, last_used_step, failed_attempts, locked_until, created_at
+`
+
+type StartTwoFactorEnrollmentParams struct {
+	ProfessionalID int64         `json:"professional_id"`
+	Secret         string        `json:"secret"`
+	KeyVersion     sql.NullInt32 `json:"key_version"`
+}
+
+// SECTION: Verificación en dos pasos
+// Guarda un secreto nuevo sin confirmar. Si la 2FA ya estaba confirmada no toca nada (no devuelve fila)
+func (q *Queries) StartTwoFactorEnrollment(ctx context.Context, arg StartTwoFactorEnrollmentParams) (ProfessionalTwoFactor, error) {
+	row := q.db.QueryRowContext(ctx, startTwoFactorEnrollment, arg.ProfessionalID, arg.Secret, arg.KeyVersion)
+	var i ProfessionalTwoFactor
+	err := row.Scan(
+		&i.ID,
+		&i.ProfessionalID,
+		&i.Secret,
+		&i.KeyVersion,
+		&i.ConfirmedAt,
+		&i.LastUsedStep,
+		&i.FailedAttempts,
+		&i.LockedUntil,
+		&i.CreatedAt,
 	)
 	return i, err
 }
@@ -1147,17 +6076,18 @@ func (q *Queries) SignClinicalNote(ctx context.Context, id int64) (ClinicalNote,
 const toggleRecurringRule = `-- name: ToggleRecurringRule :one
 UPDATE recurring_rules
 SET active = $1
-WHERE id = $2
+WHERE id = $2 AND professional_id = $3
 RETURNING id, professional_id, client_id, day_of_week, start_time, duration_minutes, modality, price, active, start_date, created_at
 `
 
 type ToggleRecurringRuleParams struct {
-	Active sql.NullBool `json:"active"`
-	ID     int64        `json:"id"`
+	Active         sql.NullBool `json:"active"`
+	ID             int64        `json:"id"`
+	ProfessionalID int64        `json:"professional_id"`
 }
 
 func (q *Queries) ToggleRecurringRule(ctx context.Context, arg ToggleRecurringRuleParams) (RecurringRule, error) {
-	row := q.db.QueryRowContext(ctx, toggleRecurringRule, arg.Active, arg.ID)
+	row := q.db.QueryRowContext(ctx, toggleRecurringRule, arg.Active, arg.ID, arg.ProfessionalID)
 	var i RecurringRule
 	err := row.Scan(
 		&i.ID,
@@ -1175,18 +6105,59 @@ func (q *Queries) ToggleRecurringRule(ctx context.Context, arg ToggleRecurringRu
 	return i, err
 }
 
+const updateActiveRecurringRulePrices = `-- name: UpdateActiveRecurringRulePrices :execrows
+UPDATE recurring_rules r
+SET price = $1
+WHERE r.professional_id = $2
+  AND COALESCE(r.modality, 'virtual') = $3::text
+  AND r.duration_minutes = $4
+  AND r.active = TRUE
+  AND NOT EXISTS (
+      SELECT 1 FROM price_list_entries n
+      WHERE n.professional_id = r.professional_id
+        AND n.modality = $3::text
+        AND n.duration_minutes = r.duration_minutes
+        AND n.effective_from > $5::date
+        AND n.effective_from <= CURRENT_DATE
+  )
+`
+
+type UpdateActiveRecurringRulePricesParams struct {
+	Price           sql.NullString `json:"price"`
+	ProfessionalID  int64          `json:"professional_id"`
+	Modality        string         `json:"modality"`
+	DurationMinutes int32          `json:"duration_minutes"`
+	EffectiveFrom   time.Time      `json:"effective_from"`
+}
+
+// Sólo si es el precio que rige hoy: si ya entró en vigencia uno más nuevo, las reglas quedan con ése
+func (q *Queries) UpdateActiveRecurringRulePrices(ctx context.Context, arg UpdateActiveRecurringRulePricesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateActiveRecurringRulePrices,
+		arg.Price,
+		arg.ProfessionalID,
+		arg.Modality,
+		arg.DurationMinutes,
+		arg.EffectiveFrom,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateAppointmentInvoice = `-- name: UpdateAppointmentInvoice :one
 UPDATE appointments
 SET invoice_status = $1, invoice_url = $2, invoice_cae = $3, updated_at = NOW()
-WHERE id = $4
+WHERE id = $4 AND professional_id = $5
 RETURNING id, professional_id, client_id, date, start_time, duration_minutes, status, modality, meeting_url, price, concept, payment_status, payment_method, payment_proof_url, payment_confirmed_at, invoice_status, invoice_url, invoice_cae, notes, rescheduled_from_id, recurring_rule_id, created_at, updated_at
 `
 
 type UpdateAppointmentInvoiceParams struct {
-	InvoiceStatus sql.NullString `json:"invoice_status"`
-	InvoiceUrl    sql.NullString `json:"invoice_url"`
-	InvoiceCae    sql.NullString `json:"invoice_cae"`
-	ID            int64          `json:"id"`
+	InvoiceStatus  sql.NullString `json:"invoice_status"`
+	InvoiceUrl     sql.NullString `json:"invoice_url"`
+	InvoiceCae     sql.NullString `json:"invoice_cae"`
+	ID             int64          `json:"id"`
+	ProfessionalID int64          `json:"professional_id"`
 }
 
 // Cuando se genera la factura en AFIP
@@ -1196,6 +6167,7 @@ func (q *Queries) UpdateAppointmentInvoice(ctx context.Context, arg UpdateAppoin
 		arg.InvoiceUrl,
 		arg.InvoiceCae,
 		arg.ID,
+		arg.ProfessionalID,
 	)
 	var i Appointment
 	err := row.Scan(
@@ -1229,18 +6201,19 @@ func (q *Queries) UpdateAppointmentInvoice(ctx context.Context, arg UpdateAppoin
 const updateAppointmentNotes = `-- name: UpdateAppointmentNotes :one
 UPDATE appointments
 SET notes = $1, updated_at = NOW()
-WHERE id = $2
+WHERE id = $2 AND professional_id = $3
 RETURNING id, professional_id, client_id, date, start_time, duration_minutes, status, modality, meeting_url, price, concept, payment_status, payment_method, payment_proof_url, payment_confirmed_at, invoice_status, invoice_url, invoice_cae, notes, rescheduled_from_id, recurring_rule_id, created_at, updated_at
 `
 
 type UpdateAppointmentNotesParams struct {
-	Notes sql.NullString `json:"notes"`
-	ID    int64          `json:"id"`
+	Notes          sql.NullString `json:"notes"`
+	ID             int64          `json:"id"`
+	ProfessionalID int64          `json:"professional_id"`
 }
 
 // Actualiza el comentario simple del turno (ej: "paciente llegó tarde")
 func (q *Queries) UpdateAppointmentNotes(ctx context.Context, arg UpdateAppointmentNotesParams) (Appointment, error) {
-	row := q.db.QueryRowContext(ctx, updateAppointmentNotes, arg.Notes, arg.ID)
+	row := q.db.QueryRowContext(ctx, updateAppointmentNotes, arg.Notes, arg.ID, arg.ProfessionalID)
 	var i Appointment
 	err := row.Scan(
 		&i.ID,
@@ -1275,7 +6248,7 @@ UPDATE appointments
 SET payment_status = $1, payment_method = $2, payment_proof_url = $3, 
     payment_confirmed_at = CASE WHEN $1 = 'paid' THEN NOW() ELSE NULL END,
     updated_at = NOW()
-WHERE id = $4
+WHERE id = $4 AND professional_id = $5
 RETURNING id, professional_id, client_id, date, start_time, duration_minutes, status, modality, meeting_url, price, concept, payment_status, payment_method, payment_proof_url, payment_confirmed_at, invoice_status, invoice_url, invoice_cae, notes, rescheduled_from_id, recurring_rule_id, created_at, updated_at
 `
 
@@ -1284,6 +6257,7 @@ type UpdateAppointmentPaymentParams struct {
 	PaymentMethod   sql.NullString `json:"payment_method"`
 	PaymentProofUrl sql.NullString `json:"payment_proof_url"`
 	ID              int64          `json:"id"`
+	ProfessionalID  int64          `json:"professional_id"`
 }
 
 // Cuando se recibe el webhook de Mercado Pago o se aprueba transferencia
@@ -1293,6 +6267,7 @@ func (q *Queries) UpdateAppointmentPayment(ctx context.Context, arg UpdateAppoin
 		arg.PaymentMethod,
 		arg.PaymentProofUrl,
 		arg.ID,
+		arg.ProfessionalID,
 	)
 	var i Appointment
 	err := row.Scan(
@@ -1326,17 +6301,18 @@ func (q *Queries) UpdateAppointmentPayment(ctx context.Context, arg UpdateAppoin
 const updateAppointmentStatus = `-- name: UpdateAppointmentStatus :one
 UPDATE appointments
 SET status = $1, updated_at = NOW()
-WHERE id = $2
+WHERE id = $2 AND professional_id = $3
 RETURNING id, professional_id, client_id, date, start_time, duration_minutes, status, modality, meeting_url, price, concept, payment_status, payment_method, payment_proof_url, payment_confirmed_at, invoice_status, invoice_url, invoice_cae, notes, rescheduled_from_id, recurring_rule_id, created_at, updated_at
 `
 
 type UpdateAppointmentStatusParams struct {
-	Status sql.NullString `json:"status"`
-	ID     int64          `json:"id"`
+	Status         sql.NullString `json:"status"`
+	ID             int64          `json:"id"`
+	ProfessionalID int64          `json:"professional_id"`
 }
 
 func (q *Queries) UpdateAppointmentStatus(ctx context.Context, arg UpdateAppointmentStatusParams) (Appointment, error) {
-	row := q.db.QueryRowContext(ctx, updateAppointmentStatus, arg.Status, arg.ID)
+	row := q.db.QueryRowContext(ctx, updateAppointmentStatus, arg.Status, arg.ID, arg.ProfessionalID)
 	var i Appointment
 	err := row.Scan(
 		&i.ID,
@@ -1371,7 +6347,7 @@ UPDATE clients
 SET name = $1, email = $2, phone = $3, 
     birth_date = $4, medications = $5, 
     emergency_contact_name = $6, emergency_contact_phone = $7
-WHERE id = $8
+WHERE id = $8 AND professional_id = $9
 RETURNING id, professional_id, name, email, phone, birth_date, medications, emergency_contact_name, emergency_contact_phone, active, created_at
 `
 
@@ -1384,6 +6360,7 @@ type UpdateClientParams struct {
 	EmergencyContactName  sql.NullString `json:"emergency_contact_name"`
 	EmergencyContactPhone sql.NullString `json:"emergency_contact_phone"`
 	ID                    int64          `json:"id"`
+	ProfessionalID        int64          `json:"professional_id"`
 }
 
 func (q *Queries) UpdateClient(ctx context.Context, arg UpdateClientParams) (Client, error) {
@@ -1396,6 +6373,7 @@ func (q *Queries) UpdateClient(ctx context.Context, arg UpdateClientParams) (Cli
 		arg.EmergencyContactName,
 		arg.EmergencyContactPhone,
 		arg.ID,
+		arg.ProfessionalID,
 	)
 	var i Client
 	err := row.Scan(
@@ -1416,19 +6394,28 @@ func (q *Queries) UpdateClient(ctx context.Context, arg UpdateClientParams) (Cli
 
 const updateClinicalNote = `-- name: UpdateClinicalNote :one
 UPDATE clinical_notes
-SET content = $1, updated_at = NOW()
-WHERE id = $2 AND status = 'draft'
-RETURNING id, professional_id, client_id, appointment_id, type, content, key_version, status, signed_at, created_at, updated_at
+SET content = $1, sections = $2, key_version = $3, updated_at = NOW()
+WHERE id = $4 AND professional_id = $5 AND status = 'draft'
+RETURNING id, professional_id, client_id, appointment_id, type, content, key_version, status, signed_at, created_at, updated_at, template_id, sections
 `
 
 type UpdateClinicalNoteParams struct {
-	Content string `json:"content"`
-	ID      int64  `json:"id"`
+	Content        string         `json:"content"`
+	Sections       sql.NullString `json:"sections"`
+	KeyVersion     sql.NullInt32  `json:"key_version"`
+	ID             int64          `json:"id"`
+	ProfessionalID int64          `json:"professional_id"`
 }
 
 // Solo permite editar si NO está firmada (controlar esto en backend también)
 func (q *Queries) UpdateClinicalNote(ctx context.Context, arg UpdateClinicalNoteParams) (ClinicalNote, error) {
-	row := q.db.QueryRowContext(ctx, updateClinicalNote, arg.Content, arg.ID)
+	row := q.db.QueryRowContext(ctx, updateClinicalNote,
+		arg.Content,
+		arg.Sections,
+		arg.KeyVersion,
+		arg.ID,
+		arg.ProfessionalID,
+	)
 	var i ClinicalNote
 	err := row.Scan(
 		&i.ID,
@@ -1442,10 +6429,223 @@ func (q *Queries) UpdateClinicalNote(ctx context.Context, arg UpdateClinicalNote
 		&i.SignedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TemplateID,
+		&i.Sections,
+	)
+	return i, err
+}
+
+const updateFutureAppointmentPrices = `-- name: UpdateFutureAppointmentPrices :execrows
+UPDATE appointments a
+SET price = $1, updated_at = NOW()
+WHERE a.professional_id = $2
+  AND COALESCE(a.modality, 'virtual') = $3::text
+  AND a.duration_minutes = $4
+  AND a.date >= GREATEST($5::date, CURRENT_DATE)
+  AND a.status = 'scheduled'
+  AND a.payment_status = 'pending'
+  AND NOT EXISTS (SELECT 1 FROM package_consumptions pc WHERE pc.appointment_id = a.id)
+  AND NOT EXISTS (SELECT 1 FROM appointment_coverages ac WHERE ac.appointment_id = a.id)
+  AND NOT EXISTS (
+      SELECT 1 FROM price_list_entries n
+      WHERE n.professional_id = a.professional_id
+        AND n.modality = $3::text
+        AND n.duration_minutes = a.duration_minutes
+        AND n.effective_from > $5::date
+        AND n.effective_from <= a.date
+  )
+`
+
+type UpdateFutureAppointmentPricesParams struct {
+	Price           sql.NullString `json:"price"`
+	ProfessionalID  int64          `json:"professional_id"`
+	Modality        string         `json:"modality"`
+	DurationMinutes int32          `json:"duration_minutes"`
+	EffectiveFrom   time.Time      `json:"effective_from"`
+}
+
+// Turnos futuros impagos de esa modalidad/duración (no toca paquetes ni obras sociales), desde
+// hoy o desde que rige el precio, y sólo hasta que entra en vigencia el siguiente: un precio
+// cargado con fecha pasada no pisa al que lo reemplazó
+func (q *Queries) UpdateFutureAppointmentPrices(ctx context.Context, arg UpdateFutureAppointmentPricesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateFutureAppointmentPrices,
+		arg.Price,
+		arg.ProfessionalID,
+		arg.Modality,
+		arg.DurationMinutes,
+		arg.EffectiveFrom,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateInsurer = `-- name: UpdateInsurer :one
+UPDATE insurers
+SET name = $1, kind = $2, code = $3, session_fee = $4, requires_authorization = $5, active = $6
+WHERE id = $7 AND professional_id = $8
+RETURNING id, professional_id, name, kind, code, session_fee, requires_authorization, active, created_at
+`
+
+type UpdateInsurerParams struct {
+	Name                  string         `json:"name"`
+	Kind                  sql.NullString `json:"kind"`
+	Code                  sql.NullString `json:"code"`
+	SessionFee            string         `json:"session_fee"`
+	RequiresAuthorization sql.NullBool   `json:"requires_authorization"`
+	Active                sql.NullBool   `json:"active"`
+	ID                    int64          `json:"id"`
+	ProfessionalID        int64          `json:"professional_id"`
+}
+
+func (q *Queries) UpdateInsurer(ctx context.Context, arg UpdateInsurerParams) (Insurer, error) {
+	row := q.db.QueryRowContext(ctx, updateInsurer,
+		arg.Name,
+		arg.Kind,
+		arg.Code,
+		arg.SessionFee,
+		arg.RequiresAuthorization,
+		arg.Active,
+		arg.ID,
+		arg.ProfessionalID,
+	)
+	var i Insurer
+	err := row.Scan(
+		&i.ID,
+		&i.ProfessionalID,
+		&i.Name,
+		&i.Kind,
+		&i.Code,
+		&i.SessionFee,
+		&i.RequiresAuthorization,
+		&i.Active,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const updateNoteTemplate = `-- name: UpdateNoteTemplate :one
+UPDATE note_templates
+SET name = $1, kind = $2, sections = $3::text::jsonb, active = $4, updated_at = NOW()
+WHERE id = $5 AND professional_id = $6
+RETURNING id, professional_id, name, kind, sections, active, created_at, updated_at
+`
+
+type UpdateNoteTemplateParams struct {
+	Name           string         `json:"name"`
+	Kind           sql.NullString `json:"kind"`
+	Sections       string         `json:"sections"`
+	Active         sql.NullBool   `json:"active"`
+	ID             int64          `json:"id"`
+	ProfessionalID int64          `json:"professional_id"`
+}
+
+func (q *Queries) UpdateNoteTemplate(ctx context.Context, arg UpdateNoteTemplateParams) (NoteTemplate, error) {
+	row := q.db.QueryRowContext(ctx, updateNoteTemplate,
+		arg.Name,
+		arg.Kind,
+		arg.Sections,
+		arg.Active,
+		arg.ID,
+		arg.ProfessionalID,
+	)
+	var i NoteTemplate
+	err := row.Scan(
+		&i.ID,
+		&i.ProfessionalID,
+		&i.Name,
+		&i.Kind,
+		&i.Sections,
+		&i.Active,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateOrganization = `-- name: UpdateOrganization :one
+UPDATE organizations
+SET name = $1, require_two_factor = $2,
+    logo_url = $3, brand_color = $4, address = $5, phone = $6, website = $7,
+    default_duration_minutes = $8, buffer_minutes = $9,
+    time_increment_minutes = $10, min_booking_notice_hours = $11,
+    booking_requires_approval = $12
+WHERE id = $13
+RETURNING id, name, require_two_factor, created_at, logo_url, brand_color, address, phone, website, default_duration_minutes, buffer_minutes, time_increment_minutes, min_booking_notice_hours, booking_requires_approval
+`
+
+type UpdateOrganizationParams struct {
+	Name                    string         `json:"name"`
+	RequireTwoFactor        bool           `json:"require_two_factor"`
+	LogoUrl                 sql.NullString `json:"logo_url"`
+	BrandColor              sql.NullString `json:"brand_color"`
+	Address                 sql.NullString `json:"address"`
+	Phone                   sql.NullString `json:"phone"`
+	Website                 sql.NullString `json:"website"`
+	DefaultDurationMinutes  sql.NullInt32  `json:"default_duration_minutes"`
+	BufferMinutes           sql.NullInt32  `json:"buffer_minutes"`
+	TimeIncrementMinutes    sql.NullInt32  `json:"time_increment_minutes"`
+	MinBookingNoticeHours   sql.NullInt32  `json:"min_booking_notice_hours"`
+	BookingRequiresApproval sql.NullBool   `json:"booking_requires_approval"`
+	ID                      int64          `json:"id"`
+}
+
+func (q *Queries) UpdateOrganization(ctx context.Context, arg UpdateOrganizationParams) (Organization, error) {
+	row := q.db.QueryRowContext(ctx, updateOrganization,
+		arg.Name,
+		arg.RequireTwoFactor,
+		arg.LogoUrl,
+		arg.BrandColor,
+		arg.Address,
+		arg.Phone,
+		arg.Website,
+		arg.DefaultDurationMinutes,
+		arg.BufferMinutes,
+		arg.TimeIncrementMinutes,
+		arg.MinBookingNoticeHours,
+		arg.BookingRequiresApproval,
+		arg.ID,
+	)
+	var i Organization
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.RequireTwoFactor,
+		&i.CreatedAt,
+		&i.LogoUrl,
+		&i.BrandColor,
+		&i.Address,
+		&i.Phone,
+		&i.Website,
+		&i.DefaultDurationMinutes,
+		&i.BufferMinutes,
+		&i.TimeIncrementMinutes,
+		&i.MinBookingNoticeHours,
+		&i.BookingRequiresApproval,
 	)
 	return i, err
 }
 
+const updateOrganizationMemberRole = `-- name: UpdateOrganizationMemberRole :execrows
+UPDATE organization_members SET role = $1
+WHERE organization_id = $2 AND professional_id = $3
+`
+
+type UpdateOrganizationMemberRoleParams struct {
+	Role           string `json:"role"`
+	OrganizationID int64  `json:"organization_id"`
+	ProfessionalID int64  `json:"professional_id"`
+}
+
+func (q *Queries) UpdateOrganizationMemberRole(ctx context.Context, arg UpdateOrganizationMemberRoleParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateOrganizationMemberRole, arg.Role, arg.OrganizationID, arg.ProfessionalID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateProfessionalProfile = `-- name: UpdateProfessionalProfile :one
 UPDATE professionals
 SET name = $1, phone = $2, slug = $3, photo_url = $4, title = $5, license_number = $6, bio = $7
@@ -1502,7 +6702,7 @@ SET
     modality = $4,
     price = $5,
     active = $6
-WHERE id = $7
+WHERE id = $7 AND professional_id = $8
 RETURNING id, professional_id, client_id, day_of_week, start_time, duration_minutes, modality, price, active, start_date, created_at
 `
 
@@ -1514,6 +6714,7 @@ type UpdateRecurringRuleParams struct {
 	Price           sql.NullString `json:"price"`
 	Active          sql.NullBool   `json:"active"`
 	ID              int64          `json:"id"`
+	ProfessionalID  int64          `json:"professional_id"`
 }
 
 // Edita una regla de recurrencia existente
@@ -1526,6 +6727,7 @@ func (q *Queries) UpdateRecurringRule(ctx context.Context, arg UpdateRecurringRu
 		arg.Price,
 		arg.Active,
 		arg.ID,
+		arg.ProfessionalID,
 	)
 	var i RecurringRule
 	err := row.Scan(
@@ -1544,8 +6746,143 @@ func (q *Queries) UpdateRecurringRule(ctx context.Context, arg UpdateRecurringRu
 	return i, err
 }
 
-const upsertProfessionalSettings = `-- name: UpsertProfessionalSettings :one
+const updateSessionPackagePayment = `-- name: UpdateSessionPackagePayment :one
+UPDATE session_packages
+SET payment_status = $1, payment_method = $2,
+    paid_at = CASE WHEN $1 = 'paid' THEN COALESCE(paid_at, NOW()) ELSE NULL END
+WHERE id = $3 AND professional_id = $4
+RETURNING id, professional_id, client_id, name, total_sessions, used_sessions, price, valid_from, valid_until, payment_status, payment_method, paid_at, created_at
+`
+
+type UpdateSessionPackagePaymentParams struct {
+	PaymentStatus  sql.NullString `json:"payment_status"`
+	PaymentMethod  sql.NullString `json:"payment_method"`
+	ID             int64          `json:"id"`
+	ProfessionalID int64          `json:"professional_id"`
+}
+
+// Volver a marcarlo pagado conserva la fecha del pago original
+func (q *Queries) UpdateSessionPackagePayment(ctx context.Context, arg UpdateSessionPackagePaymentParams) (SessionPackage, error) {
+	row := q.db.QueryRowContext(ctx, updateSessionPackagePayment,
+		arg.PaymentStatus,
+		arg.PaymentMethod,
+		arg.ID,
+		arg.ProfessionalID,
+	)
+	var i SessionPackage
+	err := row.Scan(
+		&i.ID,
+		&i.ProfessionalID,
+		&i.ClientID,
+		&i.Name,
+		&i.TotalSessions,
+		&i.UsedSessions,
+		&i.Price,
+		&i.ValidFrom,
+		&i.ValidUntil,
+		&i.PaymentStatus,
+		&i.PaymentMethod,
+		&i.PaidAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const upsertAppointmentCoverage = `-- name: UpsertAppointmentCoverage :one
+INSERT INTO appointment_coverages (
+    appointment_id, coverage_id, insurer_id, authorization_number, insurer_amount, copay_amount
+)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (appointment_id) DO UPDATE SET
+    coverage_id = EXCLUDED.coverage_id,
+    insurer_id = EXCLUDED.insurer_id,
+    authorization_number = EXCLUDED.authorization_number,
+    insurer_amount = EXCLUDED.insurer_amount,
+    copay_amount = EXCLUDED.copay_amount
+RETURNING appointment_id, coverage_id, insurer_id, authorization_number, insurer_amount, copay_amount, created_at
+`
+
+type UpsertAppointmentCoverageParams struct {
+	AppointmentID       int64          `json:"appointment_id"`
+	CoverageID          int64          `json:"coverage_id"`
+	InsurerID           int64          `json:"insurer_id"`
+	AuthorizationNumber sql.NullString `json:"authorization_number"`
+	InsurerAmount       string         `json:"insurer_amount"`
+	CopayAmount         string         `json:"copay_amount"`
+}
+
+func (q *Queries) UpsertAppointmentCoverage(ctx context.Context, arg UpsertAppointmentCoverageParams) (AppointmentCoverage, error) {
+	row := q.db.QueryRowContext(ctx, upsertAppointmentCoverage,
+		arg.AppointmentID,
+		arg.CoverageID,
+		arg.InsurerID,
+		arg.AuthorizationNumber,
+		arg.InsurerAmount,
+		arg.CopayAmount,
+	)
+	var i AppointmentCoverage
+	err := row.Scan(
+		&i.AppointmentID,
+		&i.CoverageID,
+		&i.InsurerID,
+		&i.AuthorizationNumber,
+		&i.InsurerAmount,
+		&i.CopayAmount,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const upsertPaymentProof = `-- name: UpsertPaymentProof :exec
+INSERT INTO payment_proofs (appointment_id, professional_id, client_id, filename, content_type, size_bytes, storage_key)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (appointment_id) DO UPDATE
+SET filename = EXCLUDED.filename, content_type = EXCLUDED.content_type,
+    size_bytes = EXCLUDED.size_bytes, storage_key = EXCLUDED.storage_key, created_at = NOW()
+`
+
+type UpsertPaymentProofParams struct {
+	AppointmentID  int64  `json:"appointment_id"`
+	ProfessionalID int64  `json:"professional_id"`
+	ClientID       int64  `json:"client_id"`
+	Filename       string `json:"filename"`
+	ContentType    string `json:"content_type"`
+	SizeBytes      int64  `json:"size_bytes"`
+	StorageKey     string `json:"storage_key"`
+}
+
+// Si el paciente vuelve a subirlo, reemplaza al anterior
+func (q *Queries) UpsertPaymentProof(ctx context.Context, arg UpsertPaymentProofParams) error {
+	_, err := q.db.ExecContext(ctx, upsertPaymentProof,
+		arg.AppointmentID,
+		arg.ProfessionalID,
+		arg.ClientID,
+		arg.Filename,
+		arg.ContentType,
+		arg.SizeBytes,
+		arg.StorageKey,
+	)
+	return err
+}
+
+const upsertPriceIndex = `-- name: UpsertPriceIndex :exec
+INSERT INTO price_indexes (series, period, value)
+VALUES ($1, $2, $3)
+ON CONFLICT (series, period) DO UPDATE SET value = EXCLUDED.value
+`
 
+type UpsertPriceIndexParams struct {
+	Series string    `json:"series"`
+	Period time.Time `json:"period"`
+	Value  string    `json:"value"`
+}
+
+func (q *Queries) UpsertPriceIndex(ctx context.Context, arg UpsertPriceIndexParams) error {
+	_, err := q.db.ExecContext(ctx, upsertPriceIndex, arg.Series, arg.Period, arg.Value)
+	return err
+}
+
+const upsertProfessionalSettings = `-- name: UpsertProfessionalSettings :one
 INSERT INTO professional_settings (
     professional_id, 
     default_duration_minutes, default_price, buffer_minutes, time_increment_minutes,
@@ -1553,9 +6890,9 @@ INSERT INTO professional_settings (
     bank_cbu, bank_alias, bank_name, bank_holder_name, send_alias_by_email,
     mp_access_token, mp_user_id,
     afip_crt_url, afip_key_url, afip_point_of_sale, 
-    notify_by_email, notify_by_whatsapp
+    notify_by_email, notify_by_whatsapp, booking_requires_approval
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20
 )
 ON CONFLICT (professional_id) DO UPDATE SET
     default_duration_minutes = EXCLUDED.default_duration_minutes,
@@ -1576,30 +6913,32 @@ ON CONFLICT (professional_id) DO UPDATE SET
     afip_point_of_sale = EXCLUDED.afip_point_of_sale,
     notify_by_email = EXCLUDED.notify_by_email,
     notify_by_whatsapp = EXCLUDED.notify_by_whatsapp,
+    booking_requires_approval = EXCLUDED.booking_requires_approval,
     updated_at = NOW()
-RETURNING professional_id, default_duration_minutes, default_price, buffer_minutes, time_increment_minutes, bank_cbu, bank_alias, bank_name, bank_holder_name, send_alias_by_email, mp_access_token, mp_user_id, afip_crt_url, afip_key_url, afip_point_of_sale, notify_by_email, notify_by_whatsapp, min_booking_notice_hours, max_daily_appointments, updated_at
+RETURNING professional_id, default_duration_minutes, default_price, buffer_minutes, time_increment_minutes, bank_cbu, bank_alias, bank_name, bank_holder_name, send_alias_by_email, mp_access_token, mp_user_id, afip_crt_url, afip_key_url, afip_point_of_sale, notify_by_email, notify_by_whatsapp, min_booking_notice_hours, max_daily_appointments, updated_at, booking_requires_approval
 `
 
 type UpsertProfessionalSettingsParams struct {
-	ProfessionalID         int64          `json:"professional_id"`
-	DefaultDurationMinutes sql.NullInt32  `json:"default_duration_minutes"`
-	DefaultPrice           sql.NullString `json:"default_price"`
-	BufferMinutes          sql.NullInt32  `json:"buffer_minutes"`
-	TimeIncrementMinutes   sql.NullInt32  `json:"time_increment_minutes"`
-	MinBookingNoticeHours  sql.NullInt32  `json:"min_booking_notice_hours"`
-	MaxDailyAppointments   sql.NullInt32  `json:"max_daily_appointments"`
-	BankCbu                sql.NullString `json:"bank_cbu"`
-	BankAlias              sql.NullString `json:"bank_alias"`
-	BankName               sql.NullString `json:"bank_name"`
-	BankHolderName         sql.NullString `json:"bank_holder_name"`
-	SendAliasByEmail       sql.NullBool   `json:"send_alias_by_email"`
-	MpAccessToken          sql.NullString `json:"mp_access_token"`
-	MpUserID               sql.NullString `json:"mp_user_id"`
-	AfipCrtUrl             sql.NullString `json:"afip_crt_url"`
-	AfipKeyUrl             sql.NullString `json:"afip_key_url"`
-	AfipPointOfSale        sql.NullInt32  `json:"afip_point_of_sale"`
-	NotifyByEmail          sql.NullBool   `json:"notify_by_email"`
-	NotifyByWhatsapp       sql.NullBool   `json:"notify_by_whatsapp"`
+	ProfessionalID          int64          `json:"professional_id"`
+	DefaultDurationMinutes  sql.NullInt32  `json:"default_duration_minutes"`
+	DefaultPrice            sql.NullString `json:"default_price"`
+	BufferMinutes           sql.NullInt32  `json:"buffer_minutes"`
+	TimeIncrementMinutes    sql.NullInt32  `json:"time_increment_minutes"`
+	MinBookingNoticeHours   sql.NullInt32  `json:"min_booking_notice_hours"`
+	MaxDailyAppointments    sql.NullInt32  `json:"max_daily_appointments"`
+	BankCbu                 sql.NullString `json:"bank_cbu"`
+	BankAlias               sql.NullString `json:"bank_alias"`
+	BankName                sql.NullString `json:"bank_name"`
+	BankHolderName          sql.NullString `json:"bank_holder_name"`
+	SendAliasByEmail        sql.NullBool   `json:"send_alias_by_email"`
+	MpAccessToken           sql.NullString `json:"mp_access_token"`
+	MpUserID                sql.NullString `json:"mp_user_id"`
+	AfipCrtUrl              sql.NullString `json:"afip_crt_url"`
+	AfipKeyUrl              sql.NullString `json:"afip_key_url"`
+	AfipPointOfSale         sql.NullInt32  `json:"afip_point_of_sale"`
+	NotifyByEmail           sql.NullBool   `json:"notify_by_email"`
+	NotifyByWhatsapp        sql.NullBool   `json:"notify_by_whatsapp"`
+	BookingRequiresApproval sql.NullBool   `json:"booking_requires_approval"`
 }
 
 // SECTION: Professional Settings
@@ -1625,6 +6964,7 @@ func (q *Queries) UpsertProfessionalSettings(ctx context.Context, arg UpsertProf
 		arg.AfipPointOfSale,
 		arg.NotifyByEmail,
 		arg.NotifyByWhatsapp,
+		arg.BookingRequiresApproval,
 	)
 	var i ProfessionalSetting
 	err := row.Scan(
@@ -1648,6 +6988,47 @@ func (q *Queries) UpsertProfessionalSettings(ctx context.Context, arg UpsertProf
 		&i.MinBookingNoticeHours,
 		&i.MaxDailyAppointments,
 		&i.UpdatedAt,
+		&i.BookingRequiresApproval,
 	)
 	return i, err
 }
+
+const useRecoveryCode = `-- name: UseRecoveryCode :execrows
+UPDATE two_factor_recovery_codes SET used_at = NOW()
+WHERE professional_id = $1 AND code_hash = $2 AND used_at IS NULL
+`
+
+type UseRecoveryCodeParams struct {
+	ProfessionalID int64  `json:"professional_id"`
+	CodeHash       string `json:"code_hash"`
+}
+
+func (q *Queries) UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, useRecoveryCode, arg.ProfessionalID, arg.CodeHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const useTwoFactorStep = `-- name: UseTwoFactorStep :execrows
+UPDATE professional_two_factor
+SET last_used_step = $1, failed_attempts = 0, locked_until = NULL,
+    confirmed_at = COALESCE(confirmed_at, NOW())
+WHERE professional_id = $2 AND last_used_step < $1
+`
+
+type UseTwoFactorStepParams struct {
+	Step           int64 `json:"step"`
+	ProfessionalID int64 `json:"professional_id"`
+}
+
+// Acepta el código de un intervalo posterior al último usado (y confirma el alta si estaba pendiente).
+// Dos usos simultáneos del mismo código no pueden ganar ambos
+func (q *Queries) UseTwoFactorStep(ctx context.Context, arg UseTwoFactorStepParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, useTwoFactorStep, arg.Step, arg.ProfessionalID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
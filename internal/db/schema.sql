@@ -16,6 +16,157 @@ CREATE TABLE IF NOT EXISTS professionals (
     created_at TIMESTAMPTZ DEFAULT NOW()
 );
 
+-- 1b. CREDENCIALES (aparte del perfil, para que ninguna consulta de perfil traiga el hash)
+CREATE TABLE IF NOT EXISTS professional_credentials (
+    professional_id BIGINT PRIMARY KEY,
+    password_hash TEXT NOT NULL, -- bcrypt
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    FOREIGN KEY (professional_id) REFERENCES professionals(id) ON DELETE CASCADE
+);
+
+-- 1c. SESIONES (tokens de renovación: se guarda sólo el hash, y cada uso lo rota)
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    professional_id BIGINT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE, -- SHA-256 del token
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    FOREIGN KEY (professional_id) REFERENCES professionals(id) ON DELETE CASCADE
+);
+
+-- 1d. RECUPERACIÓN DE CONTRASEÑA (un solo uso, vencen en una hora)
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    professional_id BIGINT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE, -- SHA-256 del token
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    FOREIGN KEY (professional_id) REFERENCES professionals(id) ON DELETE CASCADE
+);
+
+-- 1e. ORGANIZACIONES (centros o equipos que agrupan profesionales y fijan políticas comunes)
+CREATE TABLE IF NOT EXISTS organizations (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    require_two_factor BOOLEAN NOT NULL DEFAULT FALSE, -- Todos sus profesionales deben usar 2FA
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Identidad del centro: aparece en la página pública de cada uno de sus profesionales
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS logo_url TEXT;
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS brand_color TEXT; -- #RRGGBB
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS address TEXT;
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS phone TEXT;
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS website TEXT;
+
+-- Configuración común de la agenda: rige para los profesionales que no configuraron la suya
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS default_duration_minutes INTEGER;
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS buffer_minutes INTEGER;
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS time_increment_minutes INTEGER;
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS min_booking_notice_hours INTEGER;
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS booking_requires_approval BOOLEAN;
+
+CREATE TABLE IF NOT EXISTS organization_members (
+    professional_id BIGINT PRIMARY KEY, -- Un profesional pertenece a una sola organización
+    organization_id BIGINT NOT NULL,
+    role TEXT NOT NULL DEFAULT 'member', -- 'member', 'admin'
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    FOREIGN KEY (professional_id) REFERENCES professionals(id) ON DELETE CASCADE,
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+    CONSTRAINT valid_member_role CHECK (role IN ('member', 'admin'))
+);
+
+-- Invitaciones: un profesional sólo entra a una organización si acepta desde su propia sesión
+CREATE TABLE IF NOT EXISTS organization_invitations (
+    id BIGSERIAL PRIMARY KEY,
+    organization_id BIGINT NOT NULL,
+    professional_id BIGINT NOT NULL, -- El invitado
+    invited_by BIGINT,
+    role TEXT NOT NULL DEFAULT 'member',
+    token_hash TEXT NOT NULL UNIQUE, -- SHA-256 del token
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+    FOREIGN KEY (professional_id) REFERENCES professionals(id) ON DELETE CASCADE,
+    FOREIGN KEY (invited_by) REFERENCES professionals(id) ON DELETE SET NULL,
+    CONSTRAINT valid_invitation_role CHECK (role IN ('member', 'admin'))
+);
+
+-- 1f. VERIFICACIÓN EN DOS PASOS (TOTP, RFC 6238)
+CREATE TABLE IF NOT EXISTS professional_two_factor (
+    id BIGSERIAL PRIMARY KEY,
+    professional_id BIGINT NOT NULL UNIQUE,
+    secret TEXT NOT NULL, -- Encriptado con la clave maestra
+    key_version INTEGER,
+    confirmed_at TIMESTAMPTZ, -- NULL: alta iniciada, todavía no se validó un código
+    last_used_step BIGINT NOT NULL DEFAULT 0, -- Último intervalo aceptado: un código no se usa dos veces
+    failed_attempts INTEGER NOT NULL DEFAULT 0,
+    locked_until TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    FOREIGN KEY (professional_id) REFERENCES professionals(id) ON DELETE CASCADE
+);
+
+-- 1g. CÓDIGOS DE RECUPERACIÓN (un solo uso, se guarda sólo el hash)
+CREATE TABLE IF NOT EXISTS two_factor_recovery_codes (
+    id BIGSERIAL PRIMARY KEY,
+    professional_id BIGINT NOT NULL,
+    code_hash TEXT NOT NULL, -- SHA-256 del código normalizado
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    FOREIGN KEY (professional_id) REFERENCES professionals(id) ON DELETE CASCADE
+);
+
+-- 1h. ASISTENTES (secretarias/os que llevan la agenda o los cobros, sin acceso a la historia clínica)
+CREATE TABLE IF NOT EXISTS assistants (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL UNIQUE,
+    phone TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS assistant_credentials (
+    assistant_id BIGINT PRIMARY KEY,
+    password_hash TEXT NOT NULL, -- bcrypt
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    FOREIGN KEY (assistant_id) REFERENCES assistants(id) ON DELETE CASCADE
+);
+
+-- Un asistente puede trabajar para varios profesionales, con roles distintos en cada uno
+CREATE TABLE IF NOT EXISTS assistant_permissions (
+    assistant_id BIGINT NOT NULL,
+    professional_id BIGINT NOT NULL,
+    role TEXT NOT NULL, -- 'agenda', 'finances', 'read_only'
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    PRIMARY KEY (assistant_id, professional_id, role),
+    FOREIGN KEY (assistant_id) REFERENCES assistants(id) ON DELETE CASCADE,
+    FOREIGN KEY (professional_id) REFERENCES professionals(id) ON DELETE CASCADE,
+    CONSTRAINT valid_assistant_role CHECK (role IN ('agenda', 'finances', 'read_only'))
+);
+
+CREATE TABLE IF NOT EXISTS assistant_refresh_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    assistant_id BIGINT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE, -- SHA-256 del token
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    FOREIGN KEY (assistant_id) REFERENCES assistants(id) ON DELETE CASCADE
+);
+
 -- 2. CONFIGURACIÓN AVANZADA DEL PROFESIONAL
 CREATE TABLE IF NOT EXISTS professional_settings (
     professional_id BIGINT PRIMARY KEY,
@@ -54,6 +205,9 @@ CREATE TABLE IF NOT EXISTS professional_settings (
     FOREIGN KEY (professional_id) REFERENCES professionals(id)
 );
 
+-- Reserva desde la página pública: si es TRUE el turno queda pedido hasta que el profesional lo apruebe
+ALTER TABLE professional_settings ADD COLUMN IF NOT EXISTS booking_requires_approval BOOLEAN DEFAULT TRUE;
+
 -- 3. CLIENTES
 CREATE TABLE IF NOT EXISTS clients (
     id BIGSERIAL PRIMARY KEY,
@@ -73,6 +227,37 @@ CREATE TABLE IF NOT EXISTS clients (
     FOREIGN KEY (professional_id) REFERENCES professionals(id)
 );
 
+-- 3b. ACCESO DEL PACIENTE AL PORTAL (enlaces de ingreso por email: un solo uso, se guarda sólo el hash)
+-- Cada enlace abre la sesión de una ficha (clients.id): si el paciente se atiende con dos
+-- profesionales, recibe un enlace por cada uno.
+CREATE TABLE IF NOT EXISTS client_login_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    client_id BIGINT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    FOREIGN KEY (client_id) REFERENCES clients(id) ON DELETE CASCADE
+);
+
+-- 3c. FORMULARIOS DEL PACIENTE (admisión, cuestionarios: los pide el profesional y se completan en el portal)
+CREATE TABLE IF NOT EXISTS client_forms (
+    id BIGSERIAL PRIMARY KEY,
+    professional_id BIGINT NOT NULL,
+    client_id BIGINT NOT NULL,
+
+    title TEXT NOT NULL,
+    sections JSONB NOT NULL, -- Mismo formato que note_templates.sections
+    answers TEXT, -- Encriptado (JSON key -> valor). NULL = pendiente
+    key_version INTEGER,
+    completed_at TIMESTAMPTZ,
+
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    FOREIGN KEY (professional_id) REFERENCES professionals(id),
+    FOREIGN KEY (client_id) REFERENCES clients(id)
+);
+
 -- 4. CONFIGURACIÓN DE AGENDA
 CREATE TABLE IF NOT EXISTS schedule_configs (
     id BIGSERIAL PRIMARY KEY,
@@ -114,7 +299,7 @@ CREATE TABLE IF NOT EXISTS appointments (
     start_time TEXT NOT NULL,
     duration_minutes INTEGER NOT NULL,
     
-    status TEXT CHECK(status IN ('scheduled', 'cancelled', 'completed', 'rescheduled')) DEFAULT 'scheduled',
+    status TEXT CHECK(status IN ('scheduled', 'cancelled', 'completed', 'rescheduled', 'requested')) DEFAULT 'scheduled',
     
     modality TEXT CHECK(modality IN ('virtual', 'in_person', 'home')) DEFAULT 'virtual',
     meeting_url TEXT,
@@ -144,9 +329,50 @@ CREATE TABLE IF NOT EXISTS appointments (
     FOREIGN KEY (professional_id) REFERENCES professionals(id),
     FOREIGN KEY (client_id) REFERENCES clients(id),
     FOREIGN KEY (rescheduled_from_id) REFERENCES appointments(id),
-    FOREIGN KEY (recurring_rule_id) REFERENCES recurring_rules(id),
-    
-    UNIQUE(professional_id, date, start_time)
+    FOREIGN KEY (recurring_rule_id) REFERENCES recurring_rules(id)
+);
+
+-- 'requested': pedido desde la página pública, ocupa el horario hasta que el profesional lo
+-- apruebe (pasa a 'scheduled') o lo rechace ('cancelled'). Para las bases ya creadas:
+ALTER TABLE appointments DROP CONSTRAINT IF EXISTS appointments_status_check;
+ALTER TABLE appointments ADD CONSTRAINT appointments_status_check
+    CHECK(status IN ('scheduled', 'cancelled', 'completed', 'rescheduled', 'requested'));
+
+-- Un horario ocupado por profesional, sin contar los turnos cancelados o reprogramados: si no,
+-- un pedido rechazado bloquearía el horario para siempre. Reemplaza al UNIQUE original:
+ALTER TABLE appointments DROP CONSTRAINT IF EXISTS appointments_professional_id_date_start_time_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_appointments_slot ON appointments(professional_id, date, start_time)
+    WHERE status NOT IN ('cancelled', 'rescheduled');
+
+-- 6b. COMPROBANTES DE PAGO (los sube el paciente desde el portal, uno por turno)
+CREATE TABLE IF NOT EXISTS payment_proofs (
+    appointment_id BIGINT PRIMARY KEY,
+    professional_id BIGINT NOT NULL,
+    client_id BIGINT NOT NULL,
+
+    filename TEXT NOT NULL,
+    content_type TEXT NOT NULL, -- Detectado del contenido
+    size_bytes BIGINT NOT NULL,
+    storage_key TEXT NOT NULL UNIQUE,
+
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    FOREIGN KEY (appointment_id) REFERENCES appointments(id),
+    FOREIGN KEY (professional_id) REFERENCES professionals(id),
+    FOREIGN KEY (client_id) REFERENCES clients(id)
+);
+
+-- 6c. PEDIDOS DE TURNO DESDE LA PÁGINA PÚBLICA (para limitar los pedidos por IP y por email)
+CREATE TABLE IF NOT EXISTS booking_requests (
+    id BIGSERIAL PRIMARY KEY,
+    professional_id BIGINT NOT NULL,
+    client_id BIGINT NOT NULL,
+    appointment_id BIGINT NOT NULL,
+    email TEXT NOT NULL, -- Normalizado (minúsculas)
+    ip TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (professional_id) REFERENCES professionals(id),
+    FOREIGN KEY (client_id) REFERENCES clients(id),
+    FOREIGN KEY (appointment_id) REFERENCES appointments(id)
 );
 
 -- 7. NOTAS CLÍNICAS (HISTORIA CLÍNICA)
@@ -158,7 +384,7 @@ CREATE TABLE IF NOT EXISTS clinical_notes (
 
     type TEXT CHECK(type IN ('clinical', 'personal')) DEFAULT 'clinical',
     
-    content TEXT NOT NULL, -- Encriptado
+    content TEXT NOT NULL, -- Encriptado (sobre "enc:v1:..." con DEK envuelta por la clave maestra key_version)
     key_version INTEGER DEFAULT 1,
 
     status TEXT CHECK(status IN ('draft', 'signed')) DEFAULT 'draft',
@@ -172,10 +398,372 @@ CREATE TABLE IF NOT EXISTS clinical_notes (
     FOREIGN KEY (appointment_id) REFERENCES appointments(id)
 );
 
+-- 7b. ADENDAS (Correcciones fechadas y firmadas de una nota ya firmada)
+CREATE TABLE IF NOT EXISTS clinical_note_addenda (
+    id BIGSERIAL PRIMARY KEY,
+    note_id BIGINT NOT NULL,
+    professional_id BIGINT NOT NULL,
+
+    content TEXT NOT NULL, -- Encriptado, igual que clinical_notes.content
+    key_version INTEGER DEFAULT 1,
+
+    signed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(), -- La adenda nace firmada
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    FOREIGN KEY (note_id) REFERENCES clinical_notes(id),
+    FOREIGN KEY (professional_id) REFERENCES professionals(id)
+);
+
+-- 7c. REVISIONES (Versiones anteriores de una nota, guardadas en cada edición)
+CREATE TABLE IF NOT EXISTS clinical_note_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    note_id BIGINT NOT NULL,
+    revision_number INTEGER NOT NULL,
+
+    author_id BIGINT NOT NULL, -- Quién escribió esa versión
+    content TEXT NOT NULL, -- Encriptado
+    key_version INTEGER DEFAULT 1,
+
+    written_at TIMESTAMPTZ, -- Cuándo se guardó esa versión
+    created_at TIMESTAMPTZ DEFAULT NOW(), -- Cuándo fue reemplazada
+
+    FOREIGN KEY (note_id) REFERENCES clinical_notes(id),
+    FOREIGN KEY (author_id) REFERENCES professionals(id),
+    UNIQUE(note_id, revision_number)
+);
+
+-- 7d. PLANTILLAS DE NOTAS (SOAP, primera entrevista, alta, evaluación de riesgo...)
+CREATE TABLE IF NOT EXISTS note_templates (
+    id BIGSERIAL PRIMARY KEY,
+    professional_id BIGINT NOT NULL,
+
+    name TEXT NOT NULL,
+    kind TEXT CHECK(kind IN ('soap', 'intake', 'discharge', 'risk_assessment', 'custom')) DEFAULT 'custom',
+    sections JSONB NOT NULL, -- [{key, label, type, required, options, min, max}]
+    active BOOLEAN DEFAULT TRUE,
+
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    FOREIGN KEY (professional_id) REFERENCES professionals(id)
+);
+
+-- Notas estructuradas: los valores por sección van cifrados igual que el texto libre
+ALTER TABLE clinical_notes ADD COLUMN IF NOT EXISTS template_id BIGINT REFERENCES note_templates(id);
+ALTER TABLE clinical_notes ADD COLUMN IF NOT EXISTS sections TEXT; -- Encriptado (JSON key -> valor)
+ALTER TABLE clinical_note_revisions ADD COLUMN IF NOT EXISTS sections TEXT; -- Encriptado
+
+-- 7e. EXPORTACIONES DE HISTORIA CLÍNICA (Auditoría: Ley 26.529, pedidos del paciente y derivaciones)
+CREATE TABLE IF NOT EXISTS clinical_record_exports (
+    id BIGSERIAL PRIMARY KEY,
+    professional_id BIGINT NOT NULL,
+    client_id BIGINT NOT NULL,
+
+    purpose TEXT CHECK(purpose IN ('patient_request', 'transfer', 'legal', 'other')) DEFAULT 'patient_request',
+    recipient TEXT, -- A quién se entregó (ej: "Paciente", "Lic. Pérez")
+    note_count INTEGER NOT NULL,
+    document_sha256 TEXT NOT NULL, -- Hash del PDF entregado
+
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    FOREIGN KEY (professional_id) REFERENCES professionals(id),
+    FOREIGN KEY (client_id) REFERENCES clients(id)
+);
+
+-- 7f. ÍNDICE CIEGO DE BÚSQUEDA (HMAC de cada palabra normalizada, nunca el texto)
+CREATE TABLE IF NOT EXISTS clinical_note_search_tokens (
+    note_id BIGINT NOT NULL,
+    token TEXT NOT NULL,
+    PRIMARY KEY (note_id, token),
+    FOREIGN KEY (note_id) REFERENCES clinical_notes(id) ON DELETE CASCADE
+);
+
+-- 7g. ADJUNTOS (Consentimientos, estudios, dibujos. El archivo va cifrado al almacenamiento externo)
+CREATE TABLE IF NOT EXISTS attachments (
+    id BIGSERIAL PRIMARY KEY,
+    professional_id BIGINT NOT NULL,
+    client_id BIGINT NOT NULL,
+    note_id BIGINT, -- NULL = adjunto de la ficha del paciente
+
+    filename TEXT NOT NULL,
+    content_type TEXT NOT NULL, -- Detectado del contenido, no el que declara el navegador
+    size_bytes BIGINT NOT NULL,
+    sha256 TEXT NOT NULL, -- Del archivo original, se verifica al descargar
+
+    storage_key TEXT NOT NULL UNIQUE,
+    data_key TEXT NOT NULL, -- DEK del archivo, sellada con la clave maestra key_version
+    key_version INTEGER,
+
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    FOREIGN KEY (professional_id) REFERENCES professionals(id),
+    FOREIGN KEY (client_id) REFERENCES clients(id),
+    FOREIGN KEY (note_id) REFERENCES clinical_notes(id)
+);
+
+-- 8. PAGOS Y REINTEGROS (Cuenta corriente del paciente)
+CREATE TABLE IF NOT EXISTS client_payments (
+    id BIGSERIAL PRIMARY KEY,
+    professional_id BIGINT NOT NULL,
+    client_id BIGINT NOT NULL,
+    appointment_id BIGINT, -- Opcional: pago de una sesión puntual
+
+    kind TEXT CHECK(kind IN ('payment', 'refund')) NOT NULL DEFAULT 'payment',
+    amount DECIMAL(10, 2) NOT NULL,
+    method TEXT CHECK(method IN ('mercadopago', 'transfer', 'cash', 'insurance')) DEFAULT 'transfer',
+    paid_at DATE NOT NULL DEFAULT CURRENT_DATE,
+    notes TEXT,
+
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    FOREIGN KEY (professional_id) REFERENCES professionals(id),
+    FOREIGN KEY (client_id) REFERENCES clients(id),
+    FOREIGN KEY (appointment_id) REFERENCES appointments(id)
+);
+
+-- 9. CARGOS EXTRA (ej: cancelación tardía)
+CREATE TABLE IF NOT EXISTS client_fees (
+    id BIGSERIAL PRIMARY KEY,
+    professional_id BIGINT NOT NULL,
+    client_id BIGINT NOT NULL,
+    appointment_id BIGINT,
+
+    kind TEXT CHECK(kind IN ('late_cancellation', 'other')) NOT NULL DEFAULT 'other',
+    amount DECIMAL(10, 2) NOT NULL,
+    date DATE NOT NULL DEFAULT CURRENT_DATE,
+    description TEXT,
+
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    FOREIGN KEY (professional_id) REFERENCES professionals(id),
+    FOREIGN KEY (client_id) REFERENCES clients(id),
+    FOREIGN KEY (appointment_id) REFERENCES appointments(id)
+);
+
+-- 10. PAQUETES DE SESIONES (Bonos prepagos, ej: 4 sesiones al mes)
+CREATE TABLE IF NOT EXISTS session_packages (
+    id BIGSERIAL PRIMARY KEY,
+    professional_id BIGINT NOT NULL,
+    client_id BIGINT NOT NULL,
+
+    name TEXT NOT NULL DEFAULT 'Paquete de sesiones',
+    total_sessions INTEGER NOT NULL CHECK(total_sessions > 0),
+    used_sessions INTEGER NOT NULL DEFAULT 0,
+    price DECIMAL(10, 2) NOT NULL DEFAULT 0, -- Precio total del paquete
+    valid_from DATE NOT NULL,
+    valid_until DATE NOT NULL,
+
+    payment_status TEXT CHECK(payment_status IN ('pending', 'paid', 'refunded')) DEFAULT 'pending',
+    payment_method TEXT CHECK(payment_method IN ('mercadopago', 'transfer', 'cash', 'insurance')) DEFAULT 'transfer',
+    paid_at TIMESTAMPTZ,
+
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    FOREIGN KEY (professional_id) REFERENCES professionals(id),
+    FOREIGN KEY (client_id) REFERENCES clients(id),
+    CHECK(used_sessions <= total_sessions)
+);
+
+-- Qué turno consumió qué paquete (un turno consume a lo sumo un paquete)
+CREATE TABLE IF NOT EXISTS package_consumptions (
+    appointment_id BIGINT PRIMARY KEY,
+    package_id BIGINT NOT NULL,
+    consumed_at TIMESTAMPTZ DEFAULT NOW(),
+    FOREIGN KEY (appointment_id) REFERENCES appointments(id),
+    FOREIGN KEY (package_id) REFERENCES session_packages(id)
+);
+
+-- 11. OBRAS SOCIALES / PREPAGAS (con las que trabaja el profesional)
+CREATE TABLE IF NOT EXISTS insurers (
+    id BIGSERIAL PRIMARY KEY,
+    professional_id BIGINT NOT NULL,
+
+    name TEXT NOT NULL,
+    kind TEXT CHECK(kind IN ('obra_social', 'prepaga')) DEFAULT 'obra_social',
+    code TEXT, -- Código de prestador / RNOS
+    session_fee DECIMAL(10, 2) NOT NULL DEFAULT 0, -- Lo que paga la obra social por sesión
+    requires_authorization BOOLEAN DEFAULT FALSE,
+    active BOOLEAN DEFAULT TRUE,
+
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    FOREIGN KEY (professional_id) REFERENCES professionals(id),
+    UNIQUE(professional_id, name)
+);
+
+-- Cobertura de cada paciente (plan, número de afiliado y coseguro)
+CREATE TABLE IF NOT EXISTS client_coverages (
+    id BIGSERIAL PRIMARY KEY,
+    professional_id BIGINT NOT NULL,
+    client_id BIGINT NOT NULL,
+    insurer_id BIGINT NOT NULL,
+
+    plan TEXT,
+    member_number TEXT NOT NULL,
+    copay_amount DECIMAL(10, 2) NOT NULL DEFAULT 0, -- Coseguro que abona el paciente
+    active BOOLEAN DEFAULT TRUE,
+
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    FOREIGN KEY (professional_id) REFERENCES professionals(id),
+    FOREIGN KEY (client_id) REFERENCES clients(id),
+    FOREIGN KEY (insurer_id) REFERENCES insurers(id)
+);
+
+-- Cobertura aplicada a un turno: guardamos los montos del momento (el arancel puede cambiar)
+CREATE TABLE IF NOT EXISTS appointment_coverages (
+    appointment_id BIGINT PRIMARY KEY,
+    coverage_id BIGINT NOT NULL,
+    insurer_id BIGINT NOT NULL,
+
+    authorization_number TEXT,
+    insurer_amount DECIMAL(10, 2) NOT NULL, -- A facturar a la obra social
+    copay_amount DECIMAL(10, 2) NOT NULL,   -- A cobrar al paciente
+
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    FOREIGN KEY (appointment_id) REFERENCES appointments(id),
+    FOREIGN KEY (coverage_id) REFERENCES client_coverages(id),
+    FOREIGN KEY (insurer_id) REFERENCES insurers(id)
+);
+
+-- FINANZAS POR TURNO: si el turno se cubrió con un paquete, el precio es la parte proporcional
+-- del paquete y el estado de pago es el del paquete (no el del turno). Con obra social el precio
+-- es el coseguro: el arancel se le cobra a la obra social (ver appointment_coverages), no entra
+-- como ingreso cuando el paciente paga su parte.
+CREATE OR REPLACE VIEW appointment_finances AS
+SELECT a.id, a.professional_id, a.client_id, a.date, a.start_time, a.duration_minutes,
+       a.status, a.modality, a.concept,
+       CASE WHEN sp.id IS NOT NULL THEN ROUND(sp.price / sp.total_sessions, 2)
+            ELSE COALESCE(ac.copay_amount, a.price) END as price,
+       CASE WHEN sp.id IS NOT NULL THEN sp.payment_status ELSE a.payment_status END as payment_status,
+       CASE WHEN sp.id IS NOT NULL THEN sp.payment_method ELSE a.payment_method END as payment_method,
+       a.payment_proof_url,
+       CASE WHEN sp.id IS NOT NULL THEN sp.paid_at ELSE a.payment_confirmed_at END as payment_confirmed_at,
+       a.invoice_status, a.invoice_url, a.invoice_cae,
+       sp.id as package_id
+FROM appointments a
+LEFT JOIN package_consumptions pc ON pc.appointment_id = a.id
+LEFT JOIN session_packages sp ON sp.id = pc.package_id
+LEFT JOIN appointment_coverages ac ON ac.appointment_id = a.id;
+
+-- 12. LISTA DE PRECIOS (por modalidad y duración, con fecha de vigencia)
+CREATE TABLE IF NOT EXISTS price_list_entries (
+    id BIGSERIAL PRIMARY KEY,
+    professional_id BIGINT NOT NULL,
+
+    modality TEXT CHECK(modality IN ('virtual', 'in_person', 'home')) NOT NULL,
+    duration_minutes INTEGER NOT NULL,
+    price DECIMAL(10, 2) NOT NULL,
+    effective_from DATE NOT NULL,
+
+    index_series TEXT, -- Si el precio surgió de un ajuste por índice (ej: 'ipc')
+    applied_at TIMESTAMPTZ, -- Cuándo se propagó a turnos futuros y reglas recurrentes
+
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    FOREIGN KEY (professional_id) REFERENCES professionals(id),
+    UNIQUE(professional_id, modality, duration_minutes, effective_from)
+);
+
+-- 13. ÍNDICES DE PRECIOS (ej: serie mensual de IPC cargada desde CSV)
+CREATE TABLE IF NOT EXISTS price_indexes (
+    series TEXT NOT NULL,
+    period DATE NOT NULL, -- Primer día del mes
+    value DECIMAL(14, 4) NOT NULL,
+    PRIMARY KEY (series, period)
+);
+
+-- 14. REGISTRO DE AUDITORÍA (quién leyó o cambió qué. Sólo se agregan filas)
+-- Cada fila guarda el hash de la anterior del mismo profesional: si se altera o se borra
+-- una, la cadena deja de verificar desde ahí.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    professional_id BIGINT NOT NULL, -- Dueño de los datos (el del token o por quien actúa el asistente)
+    assistant_id BIGINT, -- NULL = lo hizo el profesional. Sin FK: el registro sobrevive al asistente
+
+    action TEXT CHECK(action IN ('read', 'create', 'update', 'delete')) NOT NULL,
+    resource_type TEXT NOT NULL, -- clients, appointments, payments, settings, clinical-notes...
+    resource_id BIGINT, -- NULL en listados y en recursos únicos (ej: settings)
+    route TEXT NOT NULL, -- Método y ruta, ej: "PUT /api/v1/clinical-notes/:id" (sin query: puede traer texto buscado)
+    status INTEGER NOT NULL, -- Código HTTP de la respuesta (0 = se registró antes de responder)
+    ip TEXT,
+    user_agent TEXT,
+
+    created_at TIMESTAMPTZ NOT NULL,
+    prev_hash TEXT NOT NULL, -- '' en la primera fila del profesional
+    hash TEXT NOT NULL UNIQUE, -- SHA-256 de prev_hash y los campos de la fila
+    FOREIGN KEY (professional_id) REFERENCES professionals(id)
+);
+
+CREATE OR REPLACE RULE audit_log_no_update AS ON UPDATE TO audit_log DO INSTEAD NOTHING;
+CREATE OR REPLACE RULE audit_log_no_delete AS ON DELETE TO audit_log DO INSTEAD NOTHING;
+
+-- Cambios que hace el paciente desde el portal (assistant_id queda NULL)
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS client_id BIGINT;
+
+-- CUENTA CORRIENTE: movimientos del paciente (debe = lo que adeuda, haber = lo que pagó)
+-- Los turnos marcados como pagados sin un registro en client_payments cuentan como pago implícito.
+-- Los turnos cubiertos por un paquete no generan cargo: se cobra el paquete completo.
+-- En los turnos con obra social al paciente sólo se le carga el coseguro.
+CREATE OR REPLACE VIEW client_ledger AS
+SELECT a.professional_id, a.client_id, 'charge'::TEXT as entry_type, a.id as source_id,
+       a.date as entry_date, COALESCE(a.concept, 'Sesión de Terapia')::TEXT as description,
+       COALESCE(ac.copay_amount, a.price, 0)::DECIMAL as debit, 0::DECIMAL as credit
+FROM appointments a
+LEFT JOIN appointment_coverages ac ON ac.appointment_id = a.id
+WHERE a.status IN ('scheduled', 'completed') AND a.date <= CURRENT_DATE
+  AND NOT EXISTS (SELECT 1 FROM package_consumptions pc WHERE pc.appointment_id = a.id)
+UNION ALL
+SELECT a.professional_id, a.client_id, 'payment'::TEXT, a.id,
+       COALESCE(a.payment_confirmed_at::DATE, a.date), ('Pago ' || COALESCE(a.concept, 'Sesión de Terapia'))::TEXT,
+       0::DECIMAL, COALESCE(ac.copay_amount, a.price, 0)::DECIMAL
+FROM appointments a
+LEFT JOIN appointment_coverages ac ON ac.appointment_id = a.id
+WHERE a.payment_status = 'paid'
+  AND NOT EXISTS (SELECT 1 FROM client_payments p WHERE p.appointment_id = a.id)
+  AND NOT EXISTS (SELECT 1 FROM package_consumptions pc WHERE pc.appointment_id = a.id)
+UNION ALL
+SELECT p.professional_id, p.client_id, p.kind, p.id,
+       p.paid_at, COALESCE(p.notes, CASE WHEN p.kind = 'refund' THEN 'Reintegro' ELSE 'Pago' END)::TEXT,
+       CASE WHEN p.kind = 'refund' THEN p.amount ELSE 0 END::DECIMAL,
+       CASE WHEN p.kind = 'payment' THEN p.amount ELSE 0 END::DECIMAL
+FROM client_payments p
+UNION ALL
+SELECT f.professional_id, f.client_id, f.kind, f.id,
+       f.date, COALESCE(f.description, 'Cargo por cancelación tardía')::TEXT,
+       f.amount::DECIMAL, 0::DECIMAL
+FROM client_fees f
+UNION ALL
+SELECT sp.professional_id, sp.client_id, 'package'::TEXT, sp.id,
+       sp.valid_from, sp.name, sp.price::DECIMAL, 0::DECIMAL
+FROM session_packages sp
+WHERE sp.payment_status IS DISTINCT FROM 'refunded' -- Reintegrado: sale de la cuenta (cargo y pago)
+UNION ALL
+SELECT sp.professional_id, sp.client_id, 'package_payment'::TEXT, sp.id,
+       COALESCE(sp.paid_at::DATE, sp.valid_from), ('Pago ' || sp.name)::TEXT, 0::DECIMAL, sp.price::DECIMAL
+FROM session_packages sp
+WHERE sp.payment_status = 'paid';
+
 -- ÍNDICES
 CREATE INDEX IF NOT EXISTS idx_appointments_calendar ON appointments(professional_id, date);
 CREATE INDEX IF NOT EXISTS idx_appointments_payment ON appointments(professional_id, payment_status);
 CREATE INDEX IF NOT EXISTS idx_clients_professional ON clients(professional_id);
 CREATE INDEX IF NOT EXISTS idx_notes_client ON clinical_notes(client_id);
 CREATE INDEX IF NOT EXISTS idx_notes_status ON clinical_notes(professional_id, status);
-CREATE INDEX IF NOT EXISTS idx_appointments_rule ON appointments(recurring_rule_id);
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS idx_appointments_rule ON appointments(recurring_rule_id);
+CREATE INDEX IF NOT EXISTS idx_payments_client ON client_payments(professional_id, client_id);
+CREATE INDEX IF NOT EXISTS idx_fees_client ON client_fees(professional_id, client_id);
+CREATE INDEX IF NOT EXISTS idx_packages_client ON session_packages(professional_id, client_id);
+CREATE INDEX IF NOT EXISTS idx_coverages_client ON client_coverages(professional_id, client_id);
+CREATE INDEX IF NOT EXISTS idx_appointment_coverages_insurer ON appointment_coverages(insurer_id);
+CREATE INDEX IF NOT EXISTS idx_price_list_pending ON price_list_entries(effective_from) WHERE applied_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_addenda_note ON clinical_note_addenda(note_id);
+CREATE INDEX IF NOT EXISTS idx_record_exports_client ON clinical_record_exports(professional_id, client_id);
+CREATE INDEX IF NOT EXISTS idx_note_search_token ON clinical_note_search_tokens(token);
+CREATE INDEX IF NOT EXISTS idx_attachments_client ON attachments(professional_id, client_id);
+CREATE INDEX IF NOT EXISTS idx_attachments_note ON attachments(note_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_professional ON refresh_tokens(professional_id) WHERE revoked_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_password_reset_professional ON password_reset_tokens(professional_id) WHERE used_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_organization_members_org ON organization_members(organization_id);
+CREATE INDEX IF NOT EXISTS idx_organization_invitations_professional ON organization_invitations(organization_id, professional_id) WHERE used_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_professional ON two_factor_recovery_codes(professional_id) WHERE used_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_assistant_permissions_professional ON assistant_permissions(professional_id);
+CREATE INDEX IF NOT EXISTS idx_assistant_refresh_tokens_assistant ON assistant_refresh_tokens(assistant_id) WHERE revoked_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_audit_log_professional ON audit_log(professional_id, id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_resource ON audit_log(professional_id, resource_type, resource_id);
+CREATE INDEX IF NOT EXISTS idx_client_login_tokens_client ON client_login_tokens(client_id) WHERE used_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_client_forms_client ON client_forms(professional_id, client_id);
+CREATE INDEX IF NOT EXISTS idx_clients_email ON clients(lower(email));
+CREATE INDEX IF NOT EXISTS idx_booking_requests_ip ON booking_requests(ip, created_at);
+CREATE INDEX IF NOT EXISTS idx_booking_requests_email ON booking_requests(professional_id, email, created_at);
@@ -0,0 +1,337 @@
+// Package keyring implementa el cifrado en sobre (envelope encryption) de datos sensibles:
+// cada dato se cifra con una clave propia (DEK) AES-256-GCM, y esa clave se guarda envuelta
+// con la clave maestra de la versión vigente. Rotar la maestra sólo requiere re-envolver DEKs.
+package keyring
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	envelopePrefix = "enc:v1:"
+	keySize        = 32
+)
+
+// AAD fija del envoltorio de la DEK: impide usar una DEK envuelta como si fuera un dato.
+var dekAAD = []byte("psiconexo:dek")
+
+var (
+	ErrNoMasterKey        = errors.New("no hay clave maestra configurada (NOTES_MASTER_KEYS o NOTES_KEY_FILE)")
+	ErrUnknownKeyVersion  = errors.New("versión de clave maestra desconocida")
+	ErrMalformedEnvelope  = errors.New("dato cifrado con formato inválido")
+	ErrDecryptionFailed   = errors.New("no se pudo descifrar el dato")
+	ErrInvalidMasterKey   = errors.New("la clave maestra debe ser de 32 bytes en base64")
+	ErrInvalidKeyVersion  = errors.New("versión de clave inválida")
+	ErrCurrentKeyNotFound = errors.New("la versión vigente no está entre las claves cargadas")
+	ErrNoIndexKey         = errors.New("no hay clave de índice de búsqueda configurada (NOTES_INDEX_KEY)")
+)
+
+// blindTokenSize es el largo (en bytes) de cada token del índice ciego: suficiente para que
+// no haya colisiones en la práctica y corto para no engordar la tabla.
+const blindTokenSize = 16
+
+// Keyring guarda las claves maestras por versión. Las versiones viejas se conservan para
+// poder abrir datos que todavía no se rotaron.
+type Keyring struct {
+	keys    map[int32][]byte
+	current int32
+	index   []byte // Clave HMAC del índice ciego; no rota con las maestras
+}
+
+// New arma un keyring con las claves dadas. current = 0 usa la versión más alta.
+func New(keys map[int32][]byte, current int32) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoMasterKey
+	}
+	var highest int32
+	for v, k := range keys {
+		if v <= 0 {
+			return nil, ErrInvalidKeyVersion
+		}
+		if len(k) != keySize {
+			return nil, fmt.Errorf("%w (versión %d)", ErrInvalidMasterKey, v)
+		}
+		highest = max(highest, v)
+	}
+	if current == 0 {
+		current = highest
+	}
+	if _, ok := keys[current]; !ok {
+		return nil, ErrCurrentKeyNotFound
+	}
+	return &Keyring{keys: keys, current: current}, nil
+}
+
+// FromEnv carga las claves desde NOTES_MASTER_KEYS ("1:base64,2:base64") o, si no está,
+// desde el archivo NOTES_KEY_FILE (una clave "versión:base64" por línea, # para comentarios).
+// NOTES_KEY_VERSION fija la versión vigente; por defecto, la más alta.
+func FromEnv() (*Keyring, error) {
+	var entries []string
+	if raw := os.Getenv("NOTES_MASTER_KEYS"); raw != "" {
+		entries = strings.Split(raw, ",")
+	} else if path := os.Getenv("NOTES_KEY_FILE"); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("error abriendo archivo de claves: %w", err)
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			entries = append(entries, sc.Text())
+		}
+		if err := sc.Err(); err != nil {
+			return nil, fmt.Errorf("error leyendo archivo de claves: %w", err)
+		}
+	} else {
+		return nil, ErrNoMasterKey
+	}
+
+	keys := make(map[int32][]byte)
+	for _, e := range entries {
+		e = strings.TrimSpace(e)
+		if e == "" || strings.HasPrefix(e, "#") {
+			continue
+		}
+		rawVersion, rawKey, ok := strings.Cut(e, ":")
+		if !ok {
+			return nil, fmt.Errorf("%w: se espera versión:clave", ErrInvalidMasterKey)
+		}
+		v, err := strconv.ParseInt(strings.TrimSpace(rawVersion), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidKeyVersion, rawVersion)
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(rawKey))
+		if err != nil {
+			return nil, fmt.Errorf("%w (versión %d)", ErrInvalidMasterKey, v)
+		}
+		keys[int32(v)] = key
+	}
+
+	var current int32
+	if raw := os.Getenv("NOTES_KEY_VERSION"); raw != "" {
+		v, err := strconv.ParseInt(raw, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidKeyVersion, raw)
+		}
+		current = int32(v)
+	}
+
+	kr, err := New(keys, current)
+	if err != nil {
+		return nil, err
+	}
+
+	if raw := os.Getenv("NOTES_INDEX_KEY"); raw != "" {
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("NOTES_INDEX_KEY: %w", ErrInvalidMasterKey)
+		}
+		if err := kr.SetIndexKey(key); err != nil {
+			return nil, err
+		}
+	}
+	return kr, nil
+}
+
+// GenerateKey devuelve una clave maestra nueva en base64, lista para NOTES_MASTER_KEYS.
+func GenerateKey() (string, error) {
+	key := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// SetIndexKey configura la clave del índice ciego de búsqueda. Es independiente de las maestras:
+// si cambiara habría que reconstruir todo el índice.
+func (k *Keyring) SetIndexKey(key []byte) error {
+	if len(key) != keySize {
+		return fmt.Errorf("clave de índice: %w", ErrInvalidMasterKey)
+	}
+	k.index = key
+	return nil
+}
+
+func (k *Keyring) HasIndexKey() bool {
+	return k.index != nil
+}
+
+// BlindToken devuelve el HMAC-SHA256 (truncado, en hex) de term dentro de scope. Con scopes
+// distintos (ej: un profesional y otro) la misma palabra da tokens distintos.
+func (k *Keyring) BlindToken(scope, term string) (string, error) {
+	if k.index == nil {
+		return "", ErrNoIndexKey
+	}
+	mac := hmac.New(sha256.New, k.index)
+	mac.Write([]byte(scope))
+	mac.Write([]byte{0})
+	mac.Write([]byte(term))
+	return hex.EncodeToString(mac.Sum(nil)[:blindTokenSize]), nil
+}
+
+func (k *Keyring) CurrentVersion() int32 {
+	return k.current
+}
+
+// IsSealed indica si el texto ya es un sobre cifrado (los datos previos al cifrado quedan en claro).
+func IsSealed(s string) bool {
+	return strings.HasPrefix(s, envelopePrefix)
+}
+
+// Seal cifra plaintext con una DEK nueva y la envuelve con la clave maestra vigente.
+// aad liga el dato a su contexto (ej: profesional y paciente) para que no se pueda trasplantar.
+func (k *Keyring) Seal(plaintext, aad []byte) (string, int32, error) {
+	dek := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, dek); err != nil {
+		return "", 0, err
+	}
+
+	ciphertext, err := gcmSeal(dek, plaintext, aad)
+	if err != nil {
+		return "", 0, err
+	}
+	wrapped, err := gcmSeal(k.keys[k.current], dek, dekAAD)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return encodeEnvelope(wrapped, ciphertext), k.current, nil
+}
+
+// Open descifra un sobre generado por Seal con la clave maestra de `version`.
+func (k *Keyring) Open(envelope string, version int32, aad []byte) ([]byte, error) {
+	wrapped, ciphertext, err := decodeEnvelope(envelope)
+	if err != nil {
+		return nil, err
+	}
+	dek, err := k.unwrap(wrapped, version)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcmOpen(dek, ciphertext, aad)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	return plaintext, nil
+}
+
+// Rewrap re-envuelve la DEK del sobre con la clave maestra vigente, sin tocar el dato cifrado.
+func (k *Keyring) Rewrap(envelope string, version int32) (string, int32, error) {
+	wrapped, ciphertext, err := decodeEnvelope(envelope)
+	if err != nil {
+		return "", 0, err
+	}
+	dek, err := k.unwrap(wrapped, version)
+	if err != nil {
+		return "", 0, err
+	}
+	rewrapped, err := gcmSeal(k.keys[k.current], dek, dekAAD)
+	if err != nil {
+		return "", 0, err
+	}
+	return encodeEnvelope(rewrapped, ciphertext), k.current, nil
+}
+
+// GenerateDataKey crea una DEK para cifrar un archivo. La DEK se guarda sellada con Seal
+// (así rota junto con el resto) y el archivo se cifra aparte con SealWithKey, en binario.
+func GenerateDataKey() ([]byte, error) {
+	dek := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, dek); err != nil {
+		return nil, err
+	}
+	return dek, nil
+}
+
+// SealWithKey cifra datos binarios con la DEK dada; devuelve nonce || ciphertext.
+func SealWithKey(dek, plaintext, aad []byte) ([]byte, error) {
+	return gcmSeal(dek, plaintext, aad)
+}
+
+func OpenWithKey(dek, sealed, aad []byte) ([]byte, error) {
+	plaintext, err := gcmOpen(dek, sealed, aad)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	return plaintext, nil
+}
+
+func (k *Keyring) unwrap(wrapped []byte, version int32) ([]byte, error) {
+	master, ok := k.keys[version]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownKeyVersion, version)
+	}
+	dek, err := gcmOpen(master, wrapped, dekAAD)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	return dek, nil
+}
+
+// Formato: enc:v1:<DEK envuelta>:<nonce+dato cifrado>, ambos en base64 estándar.
+func encodeEnvelope(wrapped, ciphertext []byte) string {
+	return envelopePrefix + base64.StdEncoding.EncodeToString(wrapped) + ":" + base64.StdEncoding.EncodeToString(ciphertext)
+}
+
+func decodeEnvelope(envelope string) ([]byte, []byte, error) {
+	if !IsSealed(envelope) {
+		return nil, nil, ErrMalformedEnvelope
+	}
+	rawWrapped, rawCiphertext, ok := strings.Cut(strings.TrimPrefix(envelope, envelopePrefix), ":")
+	if !ok {
+		return nil, nil, ErrMalformedEnvelope
+	}
+	wrapped, err1 := base64.StdEncoding.DecodeString(rawWrapped)
+	ciphertext, err2 := base64.StdEncoding.DecodeString(rawCiphertext)
+	if err1 != nil || err2 != nil {
+		return nil, nil, ErrMalformedEnvelope
+	}
+	return wrapped, ciphertext, nil
+}
+
+// gcmSeal devuelve nonce || ciphertext.
+func gcmSeal(key, plaintext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func gcmOpen(key, sealed, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, ErrMalformedEnvelope
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, aad)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,188 @@
+package keyring
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testKeyring(t *testing.T, current int32, versions ...int32) *Keyring {
+	t.Helper()
+	keys := make(map[int32][]byte, len(versions))
+	for _, v := range versions {
+		keys[v] = bytes.Repeat([]byte{byte(v)}, keySize)
+	}
+	k, err := New(keys, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func TestSealOpenRoundTrip(t *testing.T) {
+	k := testKeyring(t, 0, 1, 2)
+	aad := []byte("note:1:2")
+
+	cases := []struct {
+		name      string
+		plaintext []byte
+	}{
+		{"vacío", []byte{}},
+		{"texto", []byte("Primera entrevista")},
+		{"acentos", []byte("Refiere angustia, sueño irregular y pérdida de apetito")},
+		{"binario", []byte{0, 1, 2, 255, 254}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			envelope, version, err := k.Seal(tc.plaintext, aad)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if version != 2 {
+				t.Fatalf("se esperaba la versión vigente 2 y selló con %d", version)
+			}
+			if !IsSealed(envelope) {
+				t.Fatalf("el sobre no tiene el prefijo %q: %s", envelopePrefix, envelope)
+			}
+			if len(tc.plaintext) > 0 && strings.Contains(envelope, string(tc.plaintext)) {
+				t.Fatal("el sobre contiene el texto en claro")
+			}
+
+			got, err := k.Open(envelope, version, aad)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tc.plaintext) {
+				t.Fatalf("se esperaba %q y abrió %q", tc.plaintext, got)
+			}
+		})
+	}
+}
+
+func TestSealUsesFreshKeys(t *testing.T) {
+	k := testKeyring(t, 0, 1)
+	a, _, err := k.Seal([]byte("mismo texto"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _, err := k.Seal([]byte("mismo texto"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == b {
+		t.Fatal("dos sellados del mismo texto dieron el mismo sobre")
+	}
+}
+
+func TestOpenRejectsWrongContext(t *testing.T) {
+	k := testKeyring(t, 0, 1, 2)
+	envelope, version, err := k.Seal([]byte("Primera entrevista"), []byte("note:1:2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		version int32
+		aad     []byte
+		want    error
+	}{
+		{"otro paciente", version, []byte("note:1:3"), ErrDecryptionFailed},
+		{"sin AAD", version, nil, ErrDecryptionFailed},
+		{"otra versión cargada", 1, []byte("note:1:2"), ErrDecryptionFailed},
+		{"versión desconocida", 9, []byte("note:1:2"), ErrUnknownKeyVersion},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := k.Open(envelope, tc.version, tc.aad); !errors.Is(err, tc.want) {
+				t.Fatalf("se esperaba %v y volvió %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestOpenRejectsTamperedEnvelope(t *testing.T) {
+	k := testKeyring(t, 0, 1)
+	aad := []byte("note:1:2")
+	envelope, version, err := k.Seal([]byte("Primera entrevista"), aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rawWrapped, rawCiphertext, _ := strings.Cut(strings.TrimPrefix(envelope, envelopePrefix), ":")
+
+	flip := func(raw string) string {
+		b, err := base64.StdEncoding.DecodeString(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b[len(b)-1] ^= 0x01
+		return base64.StdEncoding.EncodeToString(b)
+	}
+
+	cases := []struct {
+		name     string
+		envelope string
+		want     error
+	}{
+		{"dato cifrado alterado", envelopePrefix + rawWrapped + ":" + flip(rawCiphertext), ErrDecryptionFailed},
+		{"DEK envuelta alterada", envelopePrefix + flip(rawWrapped) + ":" + rawCiphertext, ErrDecryptionFailed},
+		{"partes invertidas", envelopePrefix + rawCiphertext + ":" + rawWrapped, ErrDecryptionFailed},
+		{"sin prefijo", rawWrapped + ":" + rawCiphertext, ErrMalformedEnvelope},
+		{"sin separador", envelopePrefix + rawWrapped, ErrMalformedEnvelope},
+		{"base64 inválido", envelopePrefix + "%%%:" + rawCiphertext, ErrMalformedEnvelope},
+		{"texto en claro", "Primera entrevista", ErrMalformedEnvelope},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := k.Open(tc.envelope, version, aad); !errors.Is(err, tc.want) {
+				t.Fatalf("se esperaba %v y volvió %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestRewrapMovesToCurrentVersion(t *testing.T) {
+	aad := []byte("note:1:2")
+	old := testKeyring(t, 0, 1)
+	envelope, version, err := old.Seal([]byte("Primera entrevista"), aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated := testKeyring(t, 0, 1, 2)
+	rewrapped, newVersion, err := rotated.Rewrap(envelope, version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newVersion != 2 {
+		t.Fatalf("se esperaba la versión 2 y quedó %d", newVersion)
+	}
+
+	// El dato cifrado no cambia: sólo se re-envuelve la DEK
+	_, ciphertext, _ := strings.Cut(strings.TrimPrefix(envelope, envelopePrefix), ":")
+	_, rewrappedCiphertext, _ := strings.Cut(strings.TrimPrefix(rewrapped, envelopePrefix), ":")
+	if ciphertext != rewrappedCiphertext {
+		t.Fatal("Rewrap cambió el dato cifrado")
+	}
+
+	got, err := rotated.Open(rewrapped, newVersion, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "Primera entrevista" {
+		t.Fatalf("se esperaba el texto original y abrió %q", got)
+	}
+
+	// Sólo la clave nueva abre el sobre re-envuelto
+	if _, err := rotated.Open(rewrapped, version, aad); !errors.Is(err, ErrDecryptionFailed) {
+		t.Fatalf("con la clave vieja se esperaba ErrDecryptionFailed y volvió %v", err)
+	}
+	if _, _, err := old.Rewrap(rewrapped, newVersion); !errors.Is(err, ErrUnknownKeyVersion) {
+		t.Fatalf("sin la clave nueva se esperaba ErrUnknownKeyVersion y volvió %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+// Package mailer envía los emails transaccionales (recordatorios, verificaciones, etc.).
+// Sin SMTP configurado se usa LogMailer, que sólo deja el mensaje en el log.
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+var ErrInvalidRecipient = errors.New("destinatario de email inválido")
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string // Texto plano
+}
+
+// Mailer es la dependencia que recibe el servicio; permite cambiar de proveedor sin tocarlo.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SMTPMailer envía por SMTP con STARTTLS (lo negocia net/smtp si el servidor lo ofrece).
+type SMTPMailer struct {
+	Addr     string // host:puerto
+	From     string
+	Username string
+	Password string
+}
+
+// FromEnv arma un SMTPMailer con SMTP_ADDR, SMTP_FROM, SMTP_USER y SMTP_PASSWORD.
+// Si no hay SMTP_ADDR devuelve un LogMailer.
+func FromEnv() Mailer {
+	addr := os.Getenv("SMTP_ADDR")
+	if addr == "" {
+		return LogMailer{}
+	}
+	return &SMTPMailer{
+		Addr:     addr,
+		From:     os.Getenv("SMTP_FROM"),
+		Username: os.Getenv("SMTP_USER"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+	}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if msg.To == "" || strings.ContainsAny(msg.To, "\r\n") {
+		return ErrInvalidRecipient
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		host, _, err := net.SplitHostPort(m.Addr)
+		if err != nil {
+			return fmt.Errorf("SMTP_ADDR inválido: %w", err)
+		}
+		auth = smtp.PlainAuth("", m.Username, m.Password, host)
+	}
+
+	// net/smtp no acepta contexto: respetamos al menos la cancelación previa al envío.
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := smtp.SendMail(m.Addr, auth, m.From, []string{msg.To}, buildMessage(m.From, msg)); err != nil {
+		return fmt.Errorf("error enviando email a %s: %w", msg.To, err)
+	}
+	return nil
+}
+
+func buildMessage(from string, msg Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", strings.NewReplacer("\r", "", "\n", " ").Replace(msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// LogMailer no envía nada: registra el mensaje. Útil en desarrollo.
+type LogMailer struct{}
+
+func (LogMailer) Send(_ context.Context, msg Message) error {
+	log.Printf("[mailer] para=%s asunto=%q\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
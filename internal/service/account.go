@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/luciluz/psiconexo/internal/auth"
+	"github.com/luciluz/psiconexo/internal/db"
+	"github.com/luciluz/psiconexo/internal/mailer"
+)
+
+var (
+	ErrEmailAlreadyVerified     = errors.New("el email ya está verificado")
+	ErrInvalidVerificationToken = errors.New("el enlace de verificación es inválido o venció; pida uno nuevo")
+	ErrInvalidResetToken        = errors.New("el enlace para cambiar la contraseña es inválido, venció o ya se usó")
+)
+
+// passwordResetTTL es corto a propósito: el enlace da acceso total a la cuenta.
+const passwordResetTTL = time.Hour
+
+// SendEmailVerification manda (o reenvía) el enlace para verificar el email del profesional.
+func (s *Service) SendEmailVerification(ctx context.Context, profID int64) error {
+	if s.tokens == nil {
+		return ErrAuthUnavailable
+	}
+	if s.mailer == nil {
+		return ErrMailerUnavailable
+	}
+
+	prof, err := s.GetProfessional(ctx, profID)
+	if err != nil {
+		return err
+	}
+	if prof.EmailVerifiedAt.Valid {
+		return ErrEmailAlreadyVerified
+	}
+
+	token, expires, err := s.tokens.IssueEmailVerification(prof.ID, prof.Email)
+	if err != nil {
+		return fmt.Errorf("error emitiendo enlace de verificación: %w", err)
+	}
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      prof.Email,
+		Subject: "Confirmá tu email en Psiconexo",
+		Body: fmt.Sprintf("Hola %s,\n\nPara confirmar tu email entrá a este enlace:\n\n%s\n\n"+
+			"El enlace vence el %s. Si no creaste una cuenta en Psiconexo, ignorá este mensaje.\n",
+			prof.Name, s.appLink("/verify-email", token), expires.Format("02/01/2006 15:04")),
+	})
+}
+
+// VerifyEmail marca el email como verificado si el enlace es válido y sigue siendo el de la cuenta.
+func (s *Service) VerifyEmail(ctx context.Context, token string) (*db.Professional, error) {
+	if s.tokens == nil {
+		return nil, ErrAuthUnavailable
+	}
+	claims, err := s.tokens.VerifyEmailVerification(token)
+	if err != nil {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	updated, err := s.queries.MarkEmailVerified(ctx, db.MarkEmailVerifiedParams{
+		ID:    claims.ProfessionalID,
+		Email: claims.Email,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error verificando email: %w", err)
+	}
+	if updated == 0 {
+		return nil, ErrInvalidVerificationToken // Cambió el email o la cuenta ya no existe
+	}
+	return s.GetProfessional(ctx, claims.ProfessionalID)
+}
+
+// RequestPasswordReset manda el enlace para elegir una contraseña nueva. Si el email no tiene
+// cuenta no hace nada y no devuelve error: la respuesta no revela qué emails están registrados.
+func (s *Service) RequestPasswordReset(ctx context.Context, email string) error {
+	if s.mailer == nil {
+		return ErrMailerUnavailable
+	}
+
+	prof, err := s.queries.GetProfessionalByEmail(ctx, normalizeEmail(email))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error obteniendo profesional: %w", err)
+	}
+
+	token, hash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("error emitiendo enlace: %w", err)
+	}
+	expires := time.Now().Add(passwordResetTTL)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	qtx := s.queries.WithTx(tx)
+
+	if err := qtx.InvalidatePasswordResetTokens(ctx, prof.ID); err != nil {
+		return fmt.Errorf("error invalidando enlaces anteriores: %w", err)
+	}
+	if err := qtx.CreatePasswordResetToken(ctx, db.CreatePasswordResetTokenParams{
+		ProfessionalID: prof.ID,
+		TokenHash:      hash,
+		ExpiresAt:      expires,
+	}); err != nil {
+		return fmt.Errorf("error guardando enlace: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      prof.Email,
+		Subject: "Cambiá tu contraseña de Psiconexo",
+		Body: fmt.Sprintf("Hola %s,\n\nPediste cambiar la contraseña de tu cuenta. Elegí una nueva en este enlace:\n\n%s\n\n"+
+			"El enlace sirve una sola vez y vence en una hora. Si no lo pediste, ignorá este mensaje: "+
+			"tu contraseña actual sigue funcionando.\n",
+			prof.Name, s.appLink("/reset-password", token)),
+	})
+}
+
+// ResetPassword canjea el enlace por una contraseña nueva y cierra todas las sesiones abiertas.
+// Como el enlace llegó al email de la cuenta, también lo da por verificado.
+func (s *Service) ResetPassword(ctx context.Context, token, password string) error {
+	if err := validatePassword(password); err != nil {
+		return err
+	}
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("error procesando contraseña: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	qtx := s.queries.WithTx(tx)
+
+	profID, err := qtx.ConsumePasswordResetToken(ctx, auth.HashOpaqueToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidResetToken
+		}
+		return fmt.Errorf("error validando enlace: %w", err)
+	}
+	prof, err := qtx.GetProfessional(ctx, profID)
+	if err != nil {
+		return fmt.Errorf("error obteniendo profesional: %w", err)
+	}
+
+	if err := qtx.SetProfessionalPassword(ctx, db.SetProfessionalPasswordParams{
+		ProfessionalID: prof.ID,
+		PasswordHash:   hash,
+	}); err != nil {
+		return fmt.Errorf("error guardando contraseña: %w", err)
+	}
+	if err := qtx.InvalidatePasswordResetTokens(ctx, prof.ID); err != nil {
+		return fmt.Errorf("error invalidando enlaces: %w", err)
+	}
+	if err := qtx.RevokeProfessionalRefreshTokens(ctx, prof.ID); err != nil {
+		return fmt.Errorf("error cerrando sesiones: %w", err)
+	}
+	if _, err := qtx.MarkEmailVerified(ctx, db.MarkEmailVerifiedParams{ID: prof.ID, Email: prof.Email}); err != nil {
+		return fmt.Errorf("error verificando email: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// sendWelcomeVerification se llama al registrarse: si el email falla, la cuenta igual queda
+// creada y el profesional puede pedir el reenvío.
+func (s *Service) sendWelcomeVerification(ctx context.Context, profID int64) {
+	if s.mailer == nil {
+		return
+	}
+	if err := s.SendEmailVerification(ctx, profID); err != nil {
+		log.Printf("no se pudo enviar la verificación de email al profesional %d: %v", profID, err)
+	}
+}
+
+func (s *Service) appLink(path, token string) string {
+	return s.appURL + path + "?token=" + url.QueryEscape(token)
+}
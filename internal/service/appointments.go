@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -16,8 +17,8 @@ type CreateAppointmentRequest struct {
 	Date           time.Time
 	StartTime      string
 	Duration       int
-	Price          float64
-	Notes          string // <--- NUEVO CAMPO
+	Price          *float64 // nil = precio de lista vigente a la fecha del turno; 0 = sesión sin cargo
+	Notes          string   // <--- NUEVO CAMPO
 }
 
 type CreateRecurringRuleRequest struct {
@@ -26,7 +27,7 @@ type CreateRecurringRuleRequest struct {
 	DayOfWeek      int
 	StartTime      string
 	Duration       int
-	Price          float64
+	Price          *float64 // nil = precio de lista vigente; 0 = sesiones sin cargo
 	StartDate      time.Time
 	// No agregamos Notes a la regla recurrente por ahora
 }
@@ -64,12 +65,28 @@ func (s *Service) CheckAvailability(ctx context.Context, profID int64, date time
 }
 
 func (s *Service) CreateAppointment(ctx context.Context, req CreateAppointmentRequest) (*db.Appointment, error) {
+	if _, err := s.getOwnedClient(ctx, req.ProfessionalID, req.ClientID); err != nil {
+		return nil, err
+	}
+
 	// 1. Verificar disponibilidad
 	if err := s.CheckAvailability(ctx, req.ProfessionalID, req.Date, req.StartTime, req.Duration); err != nil {
 		return nil, err
 	}
 
-	priceStr := fmt.Sprintf("%.2f", req.Price)
+	var price float64
+	if req.Price != nil {
+		price = *req.Price
+	} else {
+		listPrice, ok, err := s.LookupListPrice(ctx, req.ProfessionalID, "", req.Duration, req.Date)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			price = listPrice
+		}
+	}
+	priceStr := fmt.Sprintf("%.2f", price)
 
 	// 2. Insertar turno
 	appt, err := s.queries.CreateAppointment(ctx, db.CreateAppointmentParams{
@@ -105,8 +122,24 @@ func (s *Service) ListAppointments(ctx context.Context, profID int64, start, end
 }
 
 func (s *Service) CreateRecurringRule(ctx context.Context, req CreateRecurringRuleRequest) (*db.RecurringRule, error) {
+	if _, err := s.getOwnedClient(ctx, req.ProfessionalID, req.ClientID); err != nil {
+		return nil, err
+	}
+
 	// Sin cambios en la lógica, solo en la materialización abajo
-	priceStr := fmt.Sprintf("%.2f", req.Price)
+	var price float64
+	if req.Price != nil {
+		price = *req.Price
+	} else {
+		listPrice, ok, err := s.LookupListPrice(ctx, req.ProfessionalID, "", req.Duration, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			price = listPrice
+		}
+	}
+	priceStr := fmt.Sprintf("%.2f", price)
 
 	var startDate sql.NullTime
 	if !req.StartDate.IsZero() {
@@ -196,3 +229,80 @@ func (s *Service) generateFutureAppointments(ctx context.Context, rule *db.Recur
 
 	return nil
 }
+
+type UpdateAppointmentStatusRequest struct {
+	ProfessionalID int64
+	AppointmentID  int64
+	Status         string // scheduled | cancelled | completed
+}
+
+// UpdateAppointmentStatus cambia el estado del turno. Al completarlo se consume una sesión
+// del paquete vigente del paciente (si tiene); si deja de estar completado, se libera.
+func (s *Service) UpdateAppointmentStatus(ctx context.Context, req UpdateAppointmentStatusRequest) (*db.Appointment, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	qtx := s.queries.WithTx(tx)
+
+	current, err := qtx.GetAppointmentForUpdate(ctx, db.GetAppointmentForUpdateParams{ID: req.AppointmentID, ProfessionalID: req.ProfessionalID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("error obteniendo turno: %w", err)
+	}
+
+	appt, err := qtx.UpdateAppointmentStatus(ctx, db.UpdateAppointmentStatusParams{
+		Status:         sql.NullString{String: req.Status, Valid: true},
+		ID:             req.AppointmentID,
+		ProfessionalID: req.ProfessionalID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error actualizando estado del turno: %w", err)
+	}
+
+	wasCompleted := current.Status.String == "completed"
+	isCompleted := req.Status == "completed"
+
+	switch {
+	case isCompleted && !wasCompleted:
+		if _, err := consumePackageSession(ctx, qtx, appt); err != nil {
+			return nil, err
+		}
+	case wasCompleted && !isCompleted:
+		if err := releasePackageSession(ctx, qtx, appt); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	// Pedido desde la página pública: el paciente se entera de la respuesta por email
+	if current.Status.String == "requested" && (req.Status == "scheduled" || req.Status == "cancelled") {
+		s.notifyBookingDecision(ctx, &appt, req.Status)
+	}
+	return &appt, nil
+}
+
+// getOwnedAppointment trae el turno sólo si es del profesional y, si clientID no es cero,
+// del paciente indicado.
+func (s *Service) getOwnedAppointment(ctx context.Context, profID, clientID, appointmentID int64) (*db.GetAppointmentRow, error) {
+	appt, err := s.queries.GetAppointment(ctx, db.GetAppointmentParams{ID: appointmentID, ProfessionalID: profID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("error obteniendo turno: %w", err)
+	}
+	if clientID != 0 && appt.ClientID != clientID {
+		return nil, ErrNotFound
+	}
+	return &appt, nil
+}
@@ -0,0 +1,502 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/luciluz/psiconexo/internal/auth"
+	"github.com/luciluz/psiconexo/internal/db"
+	"github.com/luciluz/psiconexo/internal/mailer"
+)
+
+// Roles que un profesional le puede dar a un asistente. Ninguno da acceso a la historia clínica.
+const (
+	RoleAgenda   = "agenda"    // Turnos, reglas de recurrencia, horarios y alta de pacientes
+	RoleFinances = "finances"  // Pagos, cargos, paquetes, obras sociales y precios
+	RoleReadOnly = "read_only" // Ver agenda y finanzas sin modificar nada
+)
+
+var (
+	ErrForbidden            = errors.New("su cuenta no tiene permiso para esta operación")
+	ErrInvalidAssistantRole = errors.New("rol inválido (use agenda, finances o read_only)")
+	ErrInvalidInvitation    = errors.New("la invitación es inválida o venció; pida una nueva")
+	ErrInvitationUsed       = errors.New("la invitación ya se usó; inicie sesión con su contraseña")
+	ErrAssistantActivated   = errors.New("el asistente ya activó su cuenta")
+)
+
+// AddAssistantRequest da acceso a un asistente. Si el email todavía no tiene cuenta se crea y
+// se le manda la invitación para elegir contraseña.
+type AddAssistantRequest struct {
+	ProfessionalID int64
+	Name           string
+	Email          string
+	Phone          string
+	Roles          []string
+}
+
+// AssistantSummary es un asistente visto desde el profesional que le dio acceso.
+type AssistantSummary struct {
+	ID        int64          `json:"id"`
+	Name      string         `json:"name"`
+	Email     string         `json:"email"`
+	Phone     sql.NullString `json:"phone"`
+	Roles     []string       `json:"roles"`
+	Activated bool           `json:"activated"` // Ya eligió su contraseña
+}
+
+// AssistantEmployer es un profesional para el que trabaja el asistente.
+type AssistantEmployer struct {
+	ID    int64    `json:"id"`
+	Name  string   `json:"name"`
+	Roles []string `json:"roles"`
+}
+
+// AssistantSession es lo que recibe el asistente al iniciar sesión o renovarla.
+type AssistantSession struct {
+	AccessToken      string              `json:"access_token"`
+	AccessExpiresAt  time.Time           `json:"access_expires_at"`
+	RefreshToken     string              `json:"refresh_token"`
+	RefreshExpiresAt time.Time           `json:"refresh_expires_at"`
+	Assistant        db.Assistant        `json:"assistant"`
+	Professionals    []AssistantEmployer `json:"professionals"`
+}
+
+// AssistantProfile es la cuenta del asistente con los profesionales para los que trabaja.
+type AssistantProfile struct {
+	Assistant     db.Assistant        `json:"assistant"`
+	Professionals []AssistantEmployer `json:"professionals"`
+}
+
+// actorKey guarda en el contexto quién hace el request cuando no es el propio profesional.
+type actorKey struct{}
+
+// WithAssistant marca el contexto como un request de un asistente, actuando por cuenta del
+// profesional. Sin esta marca el request es del profesional dueño.
+func WithAssistant(ctx context.Context, assistantID int64) context.Context {
+	return context.WithValue(ctx, actorKey{}, assistantID)
+}
+
+// ActingAssistantID devuelve el asistente que hace el request, o 0 si es el profesional.
+func ActingAssistantID(ctx context.Context) int64 {
+	id, _ := ctx.Value(actorKey{}).(int64)
+	return id
+}
+
+// requireOwner corta todo lo que es sólo del profesional (la historia clínica) aunque la ruta
+// lo haya dejado pasar.
+func requireOwner(ctx context.Context) error {
+	if ActingAssistantID(ctx) != 0 {
+		return ErrForbidden
+	}
+	return nil
+}
+
+func (s *Service) AddAssistant(ctx context.Context, req AddAssistantRequest) (*AssistantSummary, error) {
+	roles, err := normalizeAssistantRoles(req.Roles)
+	if err != nil {
+		return nil, err
+	}
+	email := normalizeEmail(req.Email)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	qtx := s.queries.WithTx(tx)
+
+	assistant, err := qtx.GetAssistantByEmail(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		assistant, err = qtx.CreateAssistant(ctx, db.CreateAssistantParams{
+			Name:  strings.TrimSpace(req.Name),
+			Email: email,
+			Phone: sql.NullString{String: req.Phone, Valid: req.Phone != ""},
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo asistente: %w", err)
+	}
+
+	if err := replaceAssistantRoles(ctx, qtx, assistant.ID, req.ProfessionalID, roles); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	activated, err := s.queries.AssistantHasCredentials(ctx, assistant.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo asistente: %w", err)
+	}
+	if !activated {
+		if err := s.sendAssistantInvitation(ctx, req.ProfessionalID, assistant); err != nil {
+			log.Printf("no se pudo enviar la invitación al asistente %d: %v", assistant.ID, err)
+		}
+	}
+
+	return &AssistantSummary{
+		ID:        assistant.ID,
+		Name:      assistant.Name,
+		Email:     assistant.Email,
+		Phone:     assistant.Phone,
+		Roles:     roles,
+		Activated: activated,
+	}, nil
+}
+
+func (s *Service) ListAssistants(ctx context.Context, profID int64) ([]AssistantSummary, error) {
+	rows, err := s.queries.ListAssistantsForProfessional(ctx, profID)
+	if err != nil {
+		return nil, fmt.Errorf("error listando asistentes: %w", err)
+	}
+	assistants := make([]AssistantSummary, len(rows))
+	for i, r := range rows {
+		assistants[i] = AssistantSummary{
+			ID:        r.ID,
+			Name:      r.Name,
+			Email:     r.Email,
+			Phone:     r.Phone,
+			Roles:     strings.Split(r.Roles, ","),
+			Activated: r.Activated,
+		}
+	}
+	return assistants, nil
+}
+
+// UpdateAssistantRoles reemplaza los roles del asistente con este profesional.
+func (s *Service) UpdateAssistantRoles(ctx context.Context, profID, assistantID int64, roles []string) error {
+	roles, err := normalizeAssistantRoles(roles)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	qtx := s.queries.WithTx(tx)
+
+	removed, err := qtx.RevokeAssistantRoles(ctx, db.RevokeAssistantRolesParams{AssistantID: assistantID, ProfessionalID: profID})
+	if err != nil {
+		return fmt.Errorf("error actualizando permisos: %w", err)
+	}
+	if removed == 0 {
+		return ErrNotFound // No trabaja para este profesional
+	}
+	if err := replaceAssistantRoles(ctx, qtx, assistantID, profID, roles); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RemoveAssistant le quita todo acceso al consultorio. Rige desde el próximo request: los
+// permisos se consultan en cada uno.
+func (s *Service) RemoveAssistant(ctx context.Context, profID, assistantID int64) error {
+	removed, err := s.queries.RevokeAssistantRoles(ctx, db.RevokeAssistantRolesParams{AssistantID: assistantID, ProfessionalID: profID})
+	if err != nil {
+		return fmt.Errorf("error quitando asistente: %w", err)
+	}
+	if removed == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ResendAssistantInvitation vuelve a mandar el enlace a un asistente que todavía no lo usó.
+func (s *Service) ResendAssistantInvitation(ctx context.Context, profID, assistantID int64) error {
+	roles, err := s.AssistantRoles(ctx, assistantID, profID)
+	if err != nil {
+		return err
+	}
+	if len(roles) == 0 {
+		return ErrNotFound
+	}
+	activated, err := s.queries.AssistantHasCredentials(ctx, assistantID)
+	if err != nil {
+		return fmt.Errorf("error obteniendo asistente: %w", err)
+	}
+	if activated {
+		return ErrAssistantActivated
+	}
+	assistant, err := s.queries.GetAssistant(ctx, assistantID)
+	if err != nil {
+		return fmt.Errorf("error obteniendo asistente: %w", err)
+	}
+	return s.sendAssistantInvitation(ctx, profID, assistant)
+}
+
+// AssistantRoles devuelve los roles del asistente con el profesional (vacío si no trabaja para él).
+func (s *Service) AssistantRoles(ctx context.Context, assistantID, profID int64) ([]string, error) {
+	roles, err := s.queries.ListAssistantRoles(ctx, db.ListAssistantRolesParams{AssistantID: assistantID, ProfessionalID: profID})
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo permisos: %w", err)
+	}
+	return roles, nil
+}
+
+// ListAssistantEmployers devuelve los profesionales para los que trabaja el asistente.
+func (s *Service) ListAssistantEmployers(ctx context.Context, assistantID int64) ([]AssistantEmployer, error) {
+	rows, err := s.queries.ListProfessionalsForAssistant(ctx, assistantID)
+	if err != nil {
+		return nil, fmt.Errorf("error listando profesionales: %w", err)
+	}
+	employers := make([]AssistantEmployer, len(rows))
+	for i, r := range rows {
+		employers[i] = AssistantEmployer{ID: r.ID, Name: r.Name, Roles: strings.Split(r.Roles, ",")}
+	}
+	return employers, nil
+}
+
+func (s *Service) GetAssistantProfile(ctx context.Context, assistantID int64) (*AssistantProfile, error) {
+	assistant, err := s.queries.GetAssistant(ctx, assistantID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("error obteniendo asistente: %w", err)
+	}
+	employers, err := s.ListAssistantEmployers(ctx, assistantID)
+	if err != nil {
+		return nil, err
+	}
+	return &AssistantProfile{Assistant: assistant, Professionals: employers}, nil
+}
+
+// AcceptAssistantInvitation fija la contraseña del asistente y abre su primera sesión.
+func (s *Service) AcceptAssistantInvitation(ctx context.Context, token, password string) (*AssistantSession, error) {
+	if s.tokens == nil {
+		return nil, ErrAuthUnavailable
+	}
+	claims, err := s.tokens.VerifyAssistantInvitation(token)
+	if err != nil {
+		return nil, ErrInvalidInvitation
+	}
+	if err := validatePassword(password); err != nil {
+		return nil, err
+	}
+
+	assistant, err := s.queries.GetAssistant(ctx, claims.AssistantID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidInvitation
+		}
+		return nil, fmt.Errorf("error obteniendo asistente: %w", err)
+	}
+	if !strings.EqualFold(assistant.Email, claims.Email) {
+		return nil, ErrInvalidInvitation
+	}
+
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return nil, fmt.Errorf("error procesando contraseña: %w", err)
+	}
+	created, err := s.queries.SetInitialAssistantPassword(ctx, db.SetInitialAssistantPasswordParams{
+		AssistantID:  assistant.ID,
+		PasswordHash: hash,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error guardando contraseña: %w", err)
+	}
+	if created == 0 {
+		return nil, ErrInvitationUsed
+	}
+	return s.openAssistantSession(ctx, assistant)
+}
+
+// AssistantLogin valida email y contraseña del asistente y abre una sesión nueva.
+func (s *Service) AssistantLogin(ctx context.Context, email, password string) (*AssistantSession, error) {
+	if s.tokens == nil {
+		return nil, ErrAuthUnavailable
+	}
+
+	creds, err := s.queries.GetAssistantCredentialsByEmail(ctx, normalizeEmail(email))
+	if errors.Is(err, sql.ErrNoRows) {
+		_ = auth.CheckPassword(dummyPasswordHash(), password)
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo credenciales: %w", err)
+	}
+	if err := auth.CheckPassword(creds.PasswordHash, password); err != nil {
+		if errors.Is(err, auth.ErrWrongPassword) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	assistant, err := s.queries.GetAssistant(ctx, creds.AssistantID)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo asistente: %w", err)
+	}
+	return s.openAssistantSession(ctx, assistant)
+}
+
+// RefreshAssistantSession rota el token de renovación del asistente, igual que RefreshSession.
+func (s *Service) RefreshAssistantSession(ctx context.Context, refreshToken string) (*AssistantSession, error) {
+	if s.tokens == nil {
+		return nil, ErrAuthUnavailable
+	}
+
+	stored, err := s.queries.GetAssistantRefreshToken(ctx, auth.HashOpaqueToken(refreshToken))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo sesión: %w", err)
+	}
+
+	if stored.RevokedAt.Valid {
+		if err := s.queries.RevokeAssistantRefreshTokens(ctx, stored.AssistantID); err != nil {
+			return nil, fmt.Errorf("error cerrando sesiones: %w", err)
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+	if !time.Now().Before(stored.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	revoked, err := s.queries.RevokeAssistantRefreshToken(ctx, stored.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error rotando sesión: %w", err)
+	}
+	if revoked == 0 {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	assistant, err := s.queries.GetAssistant(ctx, stored.AssistantID)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo asistente: %w", err)
+	}
+	return s.openAssistantSession(ctx, assistant)
+}
+
+func (s *Service) AssistantLogout(ctx context.Context, refreshToken string) error {
+	stored, err := s.queries.GetAssistantRefreshToken(ctx, auth.HashOpaqueToken(refreshToken))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error obteniendo sesión: %w", err)
+	}
+	if _, err := s.queries.RevokeAssistantRefreshToken(ctx, stored.ID); err != nil {
+		return fmt.Errorf("error cerrando sesión: %w", err)
+	}
+	return nil
+}
+
+// AuthenticateAssistant valida un token de acceso de asistente y devuelve su ID.
+func (s *Service) AuthenticateAssistant(accessToken string) (int64, error) {
+	if s.tokens == nil {
+		return 0, ErrAuthUnavailable
+	}
+	claims, err := s.tokens.VerifyAssistantAccess(accessToken)
+	if err != nil {
+		return 0, ErrUnauthenticated
+	}
+	return claims.AssistantID, nil
+}
+
+func (s *Service) openAssistantSession(ctx context.Context, assistant db.Assistant) (*AssistantSession, error) {
+	access, accessExp, err := s.tokens.IssueAssistantAccess(assistant.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error emitiendo token: %w", err)
+	}
+	refresh, refreshHash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("error emitiendo token: %w", err)
+	}
+
+	stored, err := s.queries.CreateAssistantRefreshToken(ctx, db.CreateAssistantRefreshTokenParams{
+		AssistantID: assistant.ID,
+		TokenHash:   refreshHash,
+		ExpiresAt:   time.Now().Add(s.tokens.RefreshTTL()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error guardando sesión: %w", err)
+	}
+
+	employers, err := s.ListAssistantEmployers(ctx, assistant.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AssistantSession{
+		AccessToken:      access,
+		AccessExpiresAt:  accessExp,
+		RefreshToken:     refresh,
+		RefreshExpiresAt: stored.ExpiresAt,
+		Assistant:        assistant,
+		Professionals:    employers,
+	}, nil
+}
+
+func (s *Service) sendAssistantInvitation(ctx context.Context, profID int64, assistant db.Assistant) error {
+	if s.tokens == nil {
+		return ErrAuthUnavailable
+	}
+	if s.mailer == nil {
+		return ErrMailerUnavailable
+	}
+	prof, err := s.GetProfessional(ctx, profID)
+	if err != nil {
+		return err
+	}
+
+	token, expires, err := s.tokens.IssueAssistantInvitation(assistant.ID, assistant.Email)
+	if err != nil {
+		return fmt.Errorf("error emitiendo invitación: %w", err)
+	}
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      assistant.Email,
+		Subject: fmt.Sprintf("%s te invitó a Psiconexo", prof.Name),
+		Body: fmt.Sprintf("Hola %s,\n\n%s te dio acceso a su consultorio en Psiconexo. "+
+			"Para elegir tu contraseña entrá a este enlace:\n\n%s\n\nEl enlace vence el %s.\n",
+			assistant.Name, prof.Name, s.appLink("/assistant-invitation", token), expires.Format("02/01/2006 15:04")),
+	})
+}
+
+func replaceAssistantRoles(ctx context.Context, qtx *db.Queries, assistantID, profID int64, roles []string) error {
+	if _, err := qtx.RevokeAssistantRoles(ctx, db.RevokeAssistantRolesParams{AssistantID: assistantID, ProfessionalID: profID}); err != nil {
+		return fmt.Errorf("error actualizando permisos: %w", err)
+	}
+	for _, role := range roles {
+		if err := qtx.GrantAssistantRole(ctx, db.GrantAssistantRoleParams{
+			AssistantID:    assistantID,
+			ProfessionalID: profID,
+			Role:           role,
+		}); err != nil {
+			return fmt.Errorf("error actualizando permisos: %w", err)
+		}
+	}
+	return nil
+}
+
+func normalizeAssistantRoles(roles []string) ([]string, error) {
+	if len(roles) == 0 {
+		return nil, ErrInvalidAssistantRole
+	}
+	normalized := make([]string, 0, len(roles))
+	for _, role := range roles {
+		switch role {
+		case RoleAgenda, RoleFinances, RoleReadOnly:
+			normalized = append(normalized, role)
+		default:
+			return nil, ErrInvalidAssistantRole
+		}
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized), nil
+}
@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/luciluz/psiconexo/internal/db"
+	"github.com/luciluz/psiconexo/internal/keyring"
+)
+
+var (
+	ErrStorageUnavailable  = errors.New("el almacenamiento de archivos no está configurado")
+	ErrEmptyAttachment     = errors.New("el archivo está vacío")
+	ErrAttachmentTooLarge  = errors.New("el archivo supera el tamaño máximo permitido")
+	ErrUnsupportedFileType = errors.New("tipo de archivo no permitido")
+	ErrAttachmentCorrupted = errors.New("el archivo almacenado no coincide con el original")
+)
+
+// MaxAttachmentBytes es el tamaño máximo de un adjunto (10 MB).
+const MaxAttachmentBytes = 10 << 20
+
+// Tipos aceptados, según lo que detecta http.DetectContentType sobre el contenido real.
+var allowedAttachmentTypes = map[string]bool{
+	"application/pdf": true,
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"text/plain":      true,
+}
+
+type UploadAttachmentRequest struct {
+	ProfessionalID int64
+	ClientID       int64  // Se toma de la nota si se adjunta a una nota
+	NoteID         *int64 // Opcional
+	Filename       string
+	Data           []byte
+}
+
+// attachmentAAD liga el archivo cifrado a su ubicación: no se puede cambiar un blob por otro.
+func attachmentAAD(storageKey string) []byte {
+	return []byte("attachment:" + storageKey)
+}
+
+func attachmentKeyAAD(storageKey string) []byte {
+	return []byte("attachment_key:" + storageKey)
+}
+
+// UploadAttachment cifra el archivo con una clave propia y lo sube al almacenamiento. La clave
+// del archivo queda sellada en la base con la clave maestra (y rota con rotate-note-keys).
+func (s *Service) UploadAttachment(ctx context.Context, req UploadAttachmentRequest) (*db.Attachment, error) {
+	if err := requireOwner(ctx); err != nil {
+		return nil, err
+	}
+
+	if s.blobs == nil {
+		return nil, ErrStorageUnavailable
+	}
+	if s.keys == nil {
+		return nil, ErrEncryptionUnavailable
+	}
+	if len(req.Data) == 0 {
+		return nil, ErrEmptyAttachment
+	}
+	if len(req.Data) > MaxAttachmentBytes {
+		return nil, ErrAttachmentTooLarge
+	}
+
+	contentType, _, err := mime.ParseMediaType(http.DetectContentType(req.Data))
+	if err != nil || !allowedAttachmentTypes[contentType] {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFileType, contentType)
+	}
+
+	clientID := req.ClientID
+	var noteID sql.NullInt64
+	if req.NoteID != nil {
+		note, err := s.getOwnedNote(ctx, req.ProfessionalID, *req.NoteID)
+		if err != nil {
+			return nil, err
+		}
+		clientID = note.ClientID
+		noteID = sql.NullInt64{Int64: note.ID, Valid: true}
+	} else {
+		if _, err := s.getOwnedClient(ctx, req.ProfessionalID, clientID); err != nil {
+			return nil, err
+		}
+	}
+
+	storageKey, err := newStorageKey("attachments", req.ProfessionalID)
+	if err != nil {
+		return nil, err
+	}
+	dek, err := keyring.GenerateDataKey()
+	if err != nil {
+		return nil, err
+	}
+	sealedFile, err := keyring.SealWithKey(dek, req.Data, attachmentAAD(storageKey))
+	if err != nil {
+		return nil, fmt.Errorf("error cifrando archivo: %w", err)
+	}
+	dataKey, version, err := s.keys.Seal(dek, attachmentKeyAAD(storageKey))
+	if err != nil {
+		return nil, fmt.Errorf("error cifrando archivo: %w", err)
+	}
+
+	if err := s.blobs.Put(ctx, storageKey, sealedFile, "application/octet-stream"); err != nil {
+		return nil, fmt.Errorf("error guardando archivo: %w", err)
+	}
+
+	sum := sha256.Sum256(req.Data)
+	attachment, err := s.queries.CreateAttachment(ctx, db.CreateAttachmentParams{
+		ProfessionalID: req.ProfessionalID,
+		ClientID:       clientID,
+		NoteID:         noteID,
+		Filename:       sanitizeFilename(req.Filename),
+		ContentType:    contentType,
+		SizeBytes:      int64(len(req.Data)),
+		Sha256:         hex.EncodeToString(sum[:]),
+		StorageKey:     storageKey,
+		DataKey:        dataKey,
+		KeyVersion:     sql.NullInt32{Int32: version, Valid: true},
+	})
+	if err != nil {
+		// Sin fila en la base el blob queda huérfano: se borra
+		if delErr := s.blobs.Delete(ctx, storageKey); delErr != nil {
+			log.Printf("no se pudo borrar el blob huérfano %s: %v", storageKey, delErr)
+		}
+		return nil, fmt.Errorf("error registrando adjunto: %w", err)
+	}
+
+	attachment.DataKey = ""
+	return &attachment, nil
+}
+
+// ListAttachments devuelve los adjuntos de un paciente (clientID) o de una nota (noteID).
+func (s *Service) ListAttachments(ctx context.Context, profID int64, clientID, noteID *int64) ([]db.Attachment, error) {
+	if err := requireOwner(ctx); err != nil {
+		return nil, err
+	}
+
+	attachments, err := s.queries.ListAttachments(ctx, db.ListAttachmentsParams{
+		ProfessionalID: profID,
+		ClientID:       nullInt64(clientID),
+		NoteID:         nullInt64(noteID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listando adjuntos: %w", err)
+	}
+	for i := range attachments {
+		attachments[i].DataKey = ""
+	}
+	return attachments, nil
+}
+
+// DownloadAttachment descifra el archivo y verifica que coincida con el hash original.
+func (s *Service) DownloadAttachment(ctx context.Context, profID, attachmentID int64) (*db.Attachment, []byte, error) {
+	if err := requireOwner(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	if s.blobs == nil {
+		return nil, nil, ErrStorageUnavailable
+	}
+	if s.keys == nil {
+		return nil, nil, ErrEncryptionUnavailable
+	}
+
+	attachment, err := s.getOwnedAttachment(ctx, profID, attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sealedFile, err := s.blobs.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error leyendo archivo: %w", err)
+	}
+	dek, err := s.keys.Open(attachment.DataKey, attachment.KeyVersion.Int32, attachmentKeyAAD(attachment.StorageKey))
+	if err != nil {
+		return nil, nil, fmt.Errorf("error descifrando clave del archivo %d: %w", attachment.ID, err)
+	}
+	data, err := keyring.OpenWithKey(dek, sealedFile, attachmentAAD(attachment.StorageKey))
+	if err != nil {
+		return nil, nil, ErrAttachmentCorrupted
+	}
+	if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != attachment.Sha256 {
+		return nil, nil, ErrAttachmentCorrupted
+	}
+
+	attachment.DataKey = ""
+	return attachment, data, nil
+}
+
+// DeleteAttachment borra un adjunto. Los de notas firmadas forman parte de la historia clínica
+// y no se pueden borrar.
+func (s *Service) DeleteAttachment(ctx context.Context, profID, attachmentID int64) error {
+	if err := requireOwner(ctx); err != nil {
+		return err
+	}
+
+	if s.blobs == nil {
+		return ErrStorageUnavailable
+	}
+
+	attachment, err := s.getOwnedAttachment(ctx, profID, attachmentID)
+	if err != nil {
+		return err
+	}
+	if attachment.NoteID.Valid {
+		note, err := s.getOwnedNote(ctx, profID, attachment.NoteID.Int64)
+		if err != nil {
+			return err
+		}
+		if note.Status.String == "signed" {
+			return ErrNoteSigned
+		}
+	}
+
+	if err := s.queries.DeleteAttachment(ctx, db.DeleteAttachmentParams{ID: attachment.ID, ProfessionalID: profID}); err != nil {
+		return fmt.Errorf("error borrando adjunto: %w", err)
+	}
+	// El blob va después: si falla queda huérfano pero cifrado, nunca una fila sin archivo.
+	if err := s.blobs.Delete(ctx, attachment.StorageKey); err != nil {
+		log.Printf("no se pudo borrar el blob %s: %v", attachment.StorageKey, err)
+	}
+	return nil
+}
+
+func (s *Service) getOwnedAttachment(ctx context.Context, profID, attachmentID int64) (*db.Attachment, error) {
+	attachment, err := s.queries.GetAttachment(ctx, db.GetAttachmentParams{ID: attachmentID, ProfessionalID: profID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("error obteniendo adjunto: %w", err)
+	}
+	return &attachment, nil
+}
+
+// newStorageKey genera una ubicación aleatoria bajo kind: el nombre original nunca llega al almacenamiento.
+func newStorageKey(kind string, profID int64) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%d/%s", kind, profID, hex.EncodeToString(b)), nil
+}
+
+// sanitizeFilename deja sólo el nombre (sin rutas ni caracteres de control), con un largo razonable.
+func sanitizeFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == "/" {
+		return "archivo"
+	}
+	return truncateRunes(name, 200)
+}
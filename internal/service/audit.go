@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/luciluz/psiconexo/internal/db"
+)
+
+// Acciones del registro de auditoría.
+const (
+	AuditRead   = "read"
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 200
+	auditVerifyBatch     = 500
+	maxAuditUserAgent    = 512
+)
+
+// AuditEvent es lo que queda registrado de un request.
+type AuditEvent struct {
+	ProfessionalID int64
+	AssistantID    int64 // 0 = lo hizo el profesional
+	ClientID       int64 // Distinto de 0 si lo hizo el paciente desde el portal
+	Action         string
+	ResourceType   string
+	ResourceID     int64 // 0 = sin recurso puntual (listados, configuración)
+	Route          string
+	Status         int
+	IP             string
+	UserAgent      string
+}
+
+// RecordAudit agrega el evento al final de la cadena del profesional.
+func (s *Service) RecordAudit(ctx context.Context, ev AuditEvent) error {
+	// El encabezado lo manda el cliente: se recorta y se limpia lo que no sea UTF-8 (Postgres lo rechaza)
+	userAgent := ev.UserAgent
+	if len(userAgent) > maxAuditUserAgent {
+		userAgent = userAgent[:maxAuditUserAgent]
+	}
+	userAgent = strings.ToValidUTF8(userAgent, "")
+
+	entry := db.AuditLog{
+		ProfessionalID: ev.ProfessionalID,
+		AssistantID:    sql.NullInt64{Int64: ev.AssistantID, Valid: ev.AssistantID != 0},
+		ClientID:       sql.NullInt64{Int64: ev.ClientID, Valid: ev.ClientID != 0},
+		Action:         ev.Action,
+		ResourceType:   ev.ResourceType,
+		ResourceID:     sql.NullInt64{Int64: ev.ResourceID, Valid: ev.ResourceID != 0},
+		Route:          ev.Route,
+		Status:         int32(ev.Status),
+		Ip:             sql.NullString{String: ev.IP, Valid: ev.IP != ""},
+		UserAgent:      sql.NullString{String: userAgent, Valid: userAgent != ""},
+		// Postgres guarda microsegundos: se trunca antes de calcular el hash para que verifique al leerlo
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	qtx := s.queries.WithTx(tx)
+
+	if err := qtx.LockAuditChain(ctx, ev.ProfessionalID); err != nil {
+		return fmt.Errorf("error bloqueando registro de auditoría: %w", err)
+	}
+	prev, err := qtx.GetLastAuditHash(ctx, ev.ProfessionalID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("error leyendo registro de auditoría: %w", err)
+	}
+	entry.PrevHash = prev
+	entry.Hash = auditHash(entry)
+
+	if _, err := qtx.CreateAuditEntry(ctx, db.CreateAuditEntryParams{
+		ProfessionalID: entry.ProfessionalID,
+		AssistantID:    entry.AssistantID,
+		ClientID:       entry.ClientID,
+		Action:         entry.Action,
+		ResourceType:   entry.ResourceType,
+		ResourceID:     entry.ResourceID,
+		Route:          entry.Route,
+		Status:         entry.Status,
+		Ip:             entry.Ip,
+		UserAgent:      entry.UserAgent,
+		CreatedAt:      entry.CreatedAt,
+		PrevHash:       entry.PrevHash,
+		Hash:           entry.Hash,
+	}); err != nil {
+		return fmt.Errorf("error guardando registro de auditoría: %w", err)
+	}
+	return tx.Commit()
+}
+
+// AuditFilter agrupa los filtros opcionales del registro. Los campos vacíos (nil / "") no filtran.
+type AuditFilter struct {
+	ProfessionalID int64
+	StartAt        *time.Time
+	EndAt          *time.Time // Exclusivo
+	Action         string
+	ResourceType   string
+	ResourceID     *int64
+	AssistantID    *int64
+}
+
+type ListAuditLogRequest struct {
+	AuditFilter
+	Cursor string // Opaco: lo devuelve la página anterior en NextCursor
+	Limit  int
+}
+
+type AuditPage struct {
+	Items      []db.AuditLog `json:"items"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// ListAuditLog devuelve el registro del profesional, más reciente primero.
+func (s *Service) ListAuditLog(ctx context.Context, req ListAuditLogRequest) (*AuditPage, error) {
+	if err := requireOwner(ctx); err != nil {
+		return nil, err
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultAuditPageSize
+	}
+	if limit > maxAuditPageSize {
+		limit = maxAuditPageSize
+	}
+
+	var cursorID sql.NullInt64
+	if req.Cursor != "" {
+		id, err := strconv.ParseInt(req.Cursor, 10, 64)
+		if err != nil || id <= 0 {
+			return nil, ErrInvalidCursor
+		}
+		cursorID = sql.NullInt64{Int64: id, Valid: true}
+	}
+
+	f := req.AuditFilter
+	items, err := s.queries.ListAuditEntries(ctx, db.ListAuditEntriesParams{
+		ProfessionalID: f.ProfessionalID,
+		StartAt:        nullTime(f.StartAt),
+		EndAt:          nullTime(f.EndAt),
+		Action:         sql.NullString{String: f.Action, Valid: f.Action != ""},
+		ResourceType:   sql.NullString{String: f.ResourceType, Valid: f.ResourceType != ""},
+		ResourceID:     nullInt64(f.ResourceID),
+		AssistantID:    nullInt64(f.AssistantID),
+		CursorID:       cursorID,
+		PageSize:       int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listando registro de auditoría: %w", err)
+	}
+
+	page := &AuditPage{Items: items}
+	if page.Items == nil {
+		page.Items = []db.AuditLog{}
+	}
+	// Si la página vino llena puede haber más resultados
+	if len(items) == limit {
+		page.NextCursor = strconv.FormatInt(items[len(items)-1].ID, 10)
+	}
+	return page, nil
+}
+
+// AuditVerification es el resultado de recorrer la cadena del profesional.
+type AuditVerification struct {
+	Valid    bool   `json:"valid"`
+	Checked  int    `json:"checked"`             // Filas verificadas
+	BrokenAt *int64 `json:"broken_at,omitempty"` // Primera fila que no coincide (alterada, o le borraron la anterior)
+	LastHash string `json:"last_hash"`           // Anotándolo afuera se detecta también si borran las últimas filas
+}
+
+// VerifyAuditLog recalcula los hashes de toda la cadena del profesional.
+func (s *Service) VerifyAuditLog(ctx context.Context, profID int64) (*AuditVerification, error) {
+	if err := requireOwner(ctx); err != nil {
+		return nil, err
+	}
+
+	result := &AuditVerification{Valid: true}
+	var afterID int64
+	for {
+		batch, err := s.queries.ListAuditChain(ctx, db.ListAuditChainParams{
+			ProfessionalID: profID,
+			AfterID:        afterID,
+			BatchSize:      auditVerifyBatch,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error leyendo registro de auditoría: %w", err)
+		}
+
+		for _, entry := range batch {
+			if entry.PrevHash != result.LastHash || entry.Hash != auditHash(entry) {
+				result.Valid = false
+				result.BrokenAt = &entry.ID
+				return result, nil
+			}
+			result.LastHash = entry.Hash
+			result.Checked++
+		}
+
+		if len(batch) < auditVerifyBatch {
+			return result, nil
+		}
+		afterID = batch[len(batch)-1].ID
+	}
+}
+
+// auditHash encadena la fila con la anterior: SHA-256 del JSON con prev_hash y todos los campos
+// registrados (salvo el ID, que lo asigna la base). Los campos agregados después van con
+// omitempty, para que las filas anteriores sigan verificando.
+func auditHash(e db.AuditLog) string {
+	payload, _ := json.Marshal(struct {
+		PrevHash       string `json:"prev_hash"`
+		ProfessionalID int64  `json:"professional_id"`
+		AssistantID    int64  `json:"assistant_id"`
+		ClientID       int64  `json:"client_id,omitempty"`
+		Action         string `json:"action"`
+		ResourceType   string `json:"resource_type"`
+		ResourceID     int64  `json:"resource_id"`
+		Route          string `json:"route"`
+		Status         int32  `json:"status"`
+		IP             string `json:"ip"`
+		UserAgent      string `json:"user_agent"`
+		CreatedAt      string `json:"created_at"`
+	}{
+		PrevHash:       e.PrevHash,
+		ProfessionalID: e.ProfessionalID,
+		AssistantID:    e.AssistantID.Int64,
+		ClientID:       e.ClientID.Int64,
+		Action:         e.Action,
+		ResourceType:   e.ResourceType,
+		ResourceID:     e.ResourceID.Int64,
+		Route:          e.Route,
+		Status:         e.Status,
+		IP:             e.Ip.String,
+		UserAgent:      e.UserAgent.String,
+		CreatedAt:      e.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
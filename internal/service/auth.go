@@ -0,0 +1,311 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/luciluz/psiconexo/internal/auth"
+	"github.com/luciluz/psiconexo/internal/db"
+)
+
+var (
+	ErrAuthUnavailable     = errors.New("la autenticación no está configurada (falta JWT_SECRET)")
+	ErrInvalidCredentials  = errors.New("email o contraseña incorrectos")
+	ErrEmailTaken          = errors.New("ya existe una cuenta con ese email")
+	ErrWeakPassword        = errors.New("la contraseña debe tener entre 10 y 72 caracteres")
+	ErrInvalidRefreshToken = errors.New("sesión inválida o vencida; vuelva a iniciar sesión")
+	ErrUnauthenticated     = errors.New("token de acceso inválido o vencido")
+)
+
+const (
+	minPasswordLength = 10
+	maxPasswordBytes  = 72 // Límite de bcrypt: lo que sigue se ignoraría
+)
+
+// Hash de una contraseña cualquiera: con email inexistente se compara igual, para que el
+// tiempo de respuesta no revele qué emails tienen cuenta.
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hash, _ := auth.HashPassword("psiconexo-dummy-password")
+	return hash
+})
+
+type RegisterRequest struct {
+	Name     string
+	Email    string
+	Phone    string
+	Password string
+	Slug     string // Dirección pública; si viene vacía se arma con el nombre
+}
+
+// Session es lo que recibe el cliente al iniciar sesión o renovarla.
+type Session struct {
+	AccessToken      string          `json:"access_token"`
+	AccessExpiresAt  time.Time       `json:"access_expires_at"`
+	RefreshToken     string          `json:"refresh_token"`
+	RefreshExpiresAt time.Time       `json:"refresh_expires_at"`
+	Professional     db.Professional `json:"professional"`
+}
+
+// AuthConfigured indica si se pueden emitir y verificar sesiones.
+func (s *Service) AuthConfigured() bool {
+	return s.tokens != nil
+}
+
+// Register crea la cuenta del profesional con su contraseña, le manda el enlace para verificar
+// el email y abre la primera sesión.
+func (s *Service) Register(ctx context.Context, req RegisterRequest) (*Session, error) {
+	if s.tokens == nil {
+		return nil, ErrAuthUnavailable
+	}
+	if err := validatePassword(req.Password); err != nil {
+		return nil, err
+	}
+	email := normalizeEmail(req.Email)
+
+	taken, err := s.queries.EmailExists(ctx, email)
+	if err != nil {
+		return nil, fmt.Errorf("error verificando email: %w", err)
+	}
+	if taken {
+		return nil, ErrEmailTaken
+	}
+
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		return nil, fmt.Errorf("error procesando contraseña: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	qtx := s.queries.WithTx(tx)
+
+	slug, err := registrationSlug(ctx, qtx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	prof, err := qtx.CreateProfessional(ctx, db.CreateProfessionalParams{
+		Name:                    req.Name,
+		Email:                   email,
+		Phone:                   sql.NullString{String: req.Phone, Valid: req.Phone != ""},
+		Slug:                    sql.NullString{String: slug, Valid: slug != ""},
+		CancellationWindowHours: sql.NullInt32{Int32: 24, Valid: true},
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, profileConflict(err)
+		}
+		return nil, fmt.Errorf("error creando profesional: %w", err)
+	}
+	if err := qtx.SetProfessionalPassword(ctx, db.SetProfessionalPasswordParams{
+		ProfessionalID: prof.ID,
+		PasswordHash:   hash,
+	}); err != nil {
+		return nil, fmt.Errorf("error guardando contraseña: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	s.sendWelcomeVerification(ctx, prof.ID)
+	return s.openSession(ctx, prof)
+}
+
+// Login valida email y contraseña y abre una sesión nueva. Si el profesional usa verificación
+// en dos pasos (o su organización la exige y todavía no la configuró) no hay sesión: devuelve
+// el paso que falta.
+func (s *Service) Login(ctx context.Context, email, password string) (*Session, *TwoFactorChallenge, error) {
+	if s.tokens == nil {
+		return nil, nil, ErrAuthUnavailable
+	}
+
+	creds, err := s.queries.GetCredentialsByEmail(ctx, normalizeEmail(email))
+	if errors.Is(err, sql.ErrNoRows) {
+		_ = auth.CheckPassword(dummyPasswordHash(), password)
+		return nil, nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("error obteniendo credenciales: %w", err)
+	}
+
+	if err := auth.CheckPassword(creds.PasswordHash, password); err != nil {
+		if errors.Is(err, auth.ErrWrongPassword) {
+			return nil, nil, ErrInvalidCredentials
+		}
+		return nil, nil, err
+	}
+
+	challenge, err := s.twoFactorChallenge(ctx, creds.ProfessionalID)
+	if err != nil || challenge != nil {
+		return nil, challenge, err
+	}
+
+	prof, err := s.queries.GetProfessional(ctx, creds.ProfessionalID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error obteniendo profesional: %w", err)
+	}
+	session, err := s.openSession(ctx, prof)
+	return session, nil, err
+}
+
+// RefreshSession canjea un token de renovación por una sesión nueva (el token se rota).
+// Si llega un token ya usado, alguien lo copió: se cierran todas las sesiones del profesional.
+func (s *Service) RefreshSession(ctx context.Context, refreshToken string) (*Session, error) {
+	if s.tokens == nil {
+		return nil, ErrAuthUnavailable
+	}
+
+	stored, err := s.queries.GetRefreshToken(ctx, auth.HashOpaqueToken(refreshToken))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo sesión: %w", err)
+	}
+
+	if stored.RevokedAt.Valid {
+		if err := s.queries.RevokeProfessionalRefreshTokens(ctx, stored.ProfessionalID); err != nil {
+			return nil, fmt.Errorf("error cerrando sesiones: %w", err)
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+	if !time.Now().Before(stored.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	revoked, err := s.queries.RevokeRefreshToken(ctx, stored.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error rotando sesión: %w", err)
+	}
+	if revoked == 0 {
+		return nil, ErrInvalidRefreshToken // Otra renovación simultánea ganó
+	}
+
+	if err := s.requireTwoFactorCompliance(ctx, stored.ProfessionalID); err != nil {
+		return nil, err
+	}
+
+	prof, err := s.queries.GetProfessional(ctx, stored.ProfessionalID)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo profesional: %w", err)
+	}
+	return s.openSession(ctx, prof)
+}
+
+// Logout revoca el token de renovación. Un token desconocido no es error: la sesión ya no existe.
+func (s *Service) Logout(ctx context.Context, refreshToken string) error {
+	stored, err := s.queries.GetRefreshToken(ctx, auth.HashOpaqueToken(refreshToken))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error obteniendo sesión: %w", err)
+	}
+	if _, err := s.queries.RevokeRefreshToken(ctx, stored.ID); err != nil {
+		return fmt.Errorf("error cerrando sesión: %w", err)
+	}
+	return nil
+}
+
+// Authenticate valida un token de acceso y devuelve el ID del profesional.
+func (s *Service) Authenticate(accessToken string) (int64, error) {
+	if s.tokens == nil {
+		return 0, ErrAuthUnavailable
+	}
+	claims, err := s.tokens.VerifyAccess(accessToken)
+	if err != nil {
+		return 0, ErrUnauthenticated
+	}
+	return claims.ProfessionalID, nil
+}
+
+// SetPassword fija la contraseña de un profesional existente (alta de cuentas previas al login)
+// y cierra sus sesiones abiertas.
+func (s *Service) SetPassword(ctx context.Context, email, password string) error {
+	if err := validatePassword(password); err != nil {
+		return err
+	}
+	prof, err := s.queries.GetProfessionalByEmail(ctx, normalizeEmail(email))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("error obteniendo profesional: %w", err)
+	}
+
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("error procesando contraseña: %w", err)
+	}
+	if err := s.queries.SetProfessionalPassword(ctx, db.SetProfessionalPasswordParams{
+		ProfessionalID: prof.ID,
+		PasswordHash:   hash,
+	}); err != nil {
+		return fmt.Errorf("error guardando contraseña: %w", err)
+	}
+	return s.queries.RevokeProfessionalRefreshTokens(ctx, prof.ID)
+}
+
+func (s *Service) openSession(ctx context.Context, prof db.Professional) (*Session, error) {
+	access, accessExp, err := s.tokens.IssueAccess(prof.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error emitiendo token: %w", err)
+	}
+	refresh, refreshHash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("error emitiendo token: %w", err)
+	}
+
+	stored, err := s.queries.CreateRefreshToken(ctx, db.CreateRefreshTokenParams{
+		ProfessionalID: prof.ID,
+		TokenHash:      refreshHash,
+		ExpiresAt:      time.Now().Add(s.tokens.RefreshTTL()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error guardando sesión: %w", err)
+	}
+
+	return &Session{
+		AccessToken:      access,
+		AccessExpiresAt:  accessExp,
+		RefreshToken:     refresh,
+		RefreshExpiresAt: stored.ExpiresAt,
+		Professional:     prof,
+	}, nil
+}
+
+func validatePassword(password string) error {
+	if len([]rune(password)) < minPasswordLength || len(password) > maxPasswordBytes {
+		return ErrWeakPassword
+	}
+	return nil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// isUniqueViolation detecta el error de Postgres por clave única duplicada.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// isForeignKeyViolation detecta el error de Postgres por referencia a una fila inexistente.
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
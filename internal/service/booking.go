@@ -0,0 +1,435 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/luciluz/psiconexo/internal/captcha"
+	"github.com/luciluz/psiconexo/internal/db"
+	"github.com/luciluz/psiconexo/internal/mailer"
+)
+
+var (
+	ErrInvalidBookingRange    = errors.New("rango de fechas inválido")
+	ErrTooManyBookingRequests = errors.New("se alcanzó el límite de pedidos de turno; intente más tarde")
+	ErrCaptchaFailed          = errors.New("no se pudo verificar que no sea un robot; vuelva a intentar")
+)
+
+// Límites de la reserva pública, para que no se pueda llenar la agenda de nadie con pedidos falsos.
+const (
+	maxSlotRangeDays       = 31
+	defaultSlotRangeDays   = 14
+	bookingRequestsPerIP   = 5 // Por hora, contando todos los profesionales
+	bookingRequestsPerMail = 3 // Por día y por profesional
+	maxPendingBookings     = 2 // Pedidos sin responder por paciente
+)
+
+// PublicProfile es lo que muestra la página pública del profesional. Nada de contacto directo.
+type PublicProfile struct {
+	Name             string `json:"name"`
+	Slug             string `json:"slug"`
+	Title            string `json:"title,omitempty"`
+	LicenseNumber    string `json:"license_number,omitempty"`
+	Bio              string `json:"bio,omitempty"`
+	PhotoURL         string `json:"photo_url,omitempty"`
+	DurationMinutes  int    `json:"duration_minutes"`
+	RequiresApproval bool   `json:"requires_approval"` // El turno queda pedido hasta que lo confirme
+	CaptchaRequired  bool   `json:"captcha_required"`
+
+	Organization *PublicOrganization `json:"organization,omitempty"` // Si atiende en un centro
+}
+
+// PublicOrganization es la identidad del centro en la página pública de sus profesionales.
+type PublicOrganization struct {
+	Name       string `json:"name"`
+	LogoURL    string `json:"logo_url,omitempty"`
+	BrandColor string `json:"brand_color,omitempty"`
+	Address    string `json:"address,omitempty"`
+	Phone      string `json:"phone,omitempty"`
+	Website    string `json:"website,omitempty"`
+}
+
+type AvailableDay struct {
+	Date  string   `json:"date"`  // YYYY-MM-DD
+	Times []string `json:"times"` // HH:MM
+}
+
+type AvailableSlots struct {
+	DurationMinutes int            `json:"duration_minutes"`
+	Days            []AvailableDay `json:"days"` // Sólo los días con algún horario libre
+}
+
+type PublicBookingRequest struct {
+	Slug         string
+	Name         string
+	Email        string
+	Phone        string
+	Date         time.Time
+	StartTime    string
+	CaptchaToken string
+	IP           string
+}
+
+type PublicBookingResult struct {
+	Status          string `json:"status"` // requested (espera aprobación) o scheduled
+	Date            string `json:"date"`
+	StartTime       string `json:"start_time"`
+	DurationMinutes int    `json:"duration_minutes"`
+
+	// Para el registro de auditoría; no se muestran a quien pidió el turno
+	ProfessionalID int64 `json:"-"`
+	ClientID       int64 `json:"-"`
+	AppointmentID  int64 `json:"-"`
+}
+
+// GetPublicProfile devuelve el perfil del profesional con ese slug (sólo cuentas verificadas).
+func (s *Service) GetPublicProfile(ctx context.Context, slug string) (*PublicProfile, error) {
+	prof, err := s.getPublicProfessional(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := s.loadBookingRules(ctx, prof.ID)
+	if err != nil {
+		return nil, err
+	}
+	org, _, err := s.organizationFor(ctx, prof.ID)
+	if err != nil && !errors.Is(err, ErrNoOrganization) {
+		return nil, err
+	}
+
+	profile := &PublicProfile{
+		Name:             prof.Name,
+		Slug:             prof.Slug.String,
+		Title:            prof.Title.String,
+		LicenseNumber:    prof.LicenseNumber.String,
+		Bio:              prof.Bio.String,
+		PhotoURL:         prof.PhotoUrl.String,
+		DurationMinutes:  rules.duration,
+		RequiresApproval: rules.approval,
+		CaptchaRequired:  s.captcha != nil,
+	}
+	if org != nil {
+		profile.Organization = &PublicOrganization{
+			Name:       org.Name,
+			LogoURL:    org.LogoUrl.String,
+			BrandColor: org.BrandColor.String,
+			Address:    org.Address.String,
+			Phone:      org.Phone.String,
+			Website:    org.Website.String,
+		}
+	}
+	return profile, nil
+}
+
+// ListAvailableSlots arma los horarios libres entre from y to (inclusive), según los bloques de
+// atención y las reglas de la agenda. Sin fechas, desde hoy y por dos semanas.
+func (s *Service) ListAvailableSlots(ctx context.Context, slug string, from, to *time.Time) (*AvailableSlots, error) {
+	prof, err := s.getPublicProfessional(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	start := today
+	if from != nil && from.After(today) {
+		start = *from
+	}
+	end := start.AddDate(0, 0, defaultSlotRangeDays-1)
+	if to != nil {
+		end = *to
+	}
+	if end.Before(start) || end.Sub(start) > maxSlotRangeDays*24*time.Hour {
+		return nil, fmt.Errorf("%w: hasta %d días a partir de hoy", ErrInvalidBookingRange, maxSlotRangeDays)
+	}
+
+	rules, err := s.loadBookingRules(ctx, prof.ID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.queries.ListBusySlots(ctx, db.ListBusySlotsParams{
+		ProfessionalID: prof.ID,
+		StartDate:      start,
+		EndDate:        end,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo agenda: %w", err)
+	}
+	busy := make(map[string][]busySlot)
+	for _, row := range rows {
+		apptStart, err := appointmentStart(row.Date, row.StartTime)
+		if err != nil {
+			continue
+		}
+		day := row.Date.Format("2006-01-02")
+		busy[day] = append(busy[day], busySlot{row.ID, apptStart, apptStart.Add(time.Duration(row.DurationMinutes) * time.Minute)})
+	}
+
+	result := &AvailableSlots{DurationMinutes: rules.duration, Days: []AvailableDay{}}
+	length := time.Duration(rules.duration) * time.Minute
+	for date := start; !date.After(end); date = date.AddDate(0, 0, 1) {
+		day := date.Format("2006-01-02")
+		var times []string
+		for _, block := range rules.blocks {
+			blockStart, err1 := appointmentStart(date, block.StartTime)
+			blockEnd, err2 := appointmentStart(date, block.EndTime)
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			for t := blockStart; !t.Add(length).After(blockEnd); t = t.Add(rules.increment) {
+				if rules.check(date, t, t.Add(length), busy[day], 0) == nil {
+					times = append(times, t.Format("15:04"))
+				}
+			}
+		}
+		if len(times) > 0 {
+			slices.Sort(times)
+			result.Days = append(result.Days, AvailableDay{Date: day, Times: slices.Compact(times)})
+		}
+	}
+	return result, nil
+}
+
+// RequestPublicBooking pide un turno desde la página pública. Si el email ya es de un paciente
+// del profesional se usa esa ficha; si no, se crea una. El turno queda 'requested' hasta que
+// el profesional lo apruebe, salvo que haya desactivado la aprobación.
+// Contra el abuso: captcha (si está configurado), tope por IP y por email, y pocos pedidos
+// pendientes por paciente.
+func (s *Service) RequestPublicBooking(ctx context.Context, req PublicBookingRequest) (*PublicBookingResult, error) {
+	prof, err := s.getPublicProfessional(ctx, req.Slug)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.captcha != nil {
+		if err := s.captcha.Verify(ctx, req.CaptchaToken, req.IP); err != nil {
+			if errors.Is(err, captcha.ErrFailed) {
+				return nil, ErrCaptchaFailed
+			}
+			return nil, err
+		}
+	}
+
+	email := normalizeEmail(req.Email)
+
+	rules, err := s.loadBookingRules(ctx, prof.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkSlotWithRules(ctx, rules, prof.ID, req.Date, req.StartTime, rules.duration, 0); err != nil {
+		return nil, err
+	}
+
+	price := 0.0
+	if listPrice, ok, err := s.LookupListPrice(ctx, prof.ID, "", rules.duration, req.Date); err != nil {
+		return nil, err
+	} else if ok {
+		price = listPrice
+	}
+
+	status := "scheduled"
+	if rules.approval {
+		status = "requested"
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	qtx := s.queries.WithTx(tx)
+
+	if err := checkBookingLimits(ctx, qtx, prof.ID, email, req.IP); err != nil {
+		return nil, err
+	}
+
+	client, err := qtx.FindClientByEmail(ctx, db.FindClientByEmailParams{ProfessionalID: prof.ID, Email: email})
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		client, err = qtx.CreateClient(ctx, db.CreateClientParams{
+			Name:           strings.TrimSpace(req.Name),
+			Email:          sql.NullString{String: email, Valid: true},
+			Phone:          sql.NullString{String: strings.TrimSpace(req.Phone), Valid: strings.TrimSpace(req.Phone) != ""},
+			ProfessionalID: prof.ID,
+			Active:         sql.NullBool{Bool: true, Valid: true},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error registrando paciente: %w", err)
+		}
+	case err != nil:
+		return nil, fmt.Errorf("error buscando paciente: %w", err)
+	default:
+		pending, err := qtx.CountRequestedAppointments(ctx, db.CountRequestedAppointmentsParams{
+			ProfessionalID: prof.ID, ClientID: client.ID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error contando pedidos: %w", err)
+		}
+		if pending >= maxPendingBookings {
+			return nil, fmt.Errorf("%w: ya tiene pedidos sin confirmar", ErrTooManyBookingRequests)
+		}
+	}
+
+	appt, err := qtx.CreateBookingAppointment(ctx, db.CreateBookingAppointmentParams{
+		ProfessionalID:  prof.ID,
+		ClientID:        client.ID,
+		Date:            req.Date,
+		StartTime:       req.StartTime,
+		DurationMinutes: int32(rules.duration),
+		Price:           sql.NullString{String: fmt.Sprintf("%.2f", price), Valid: true},
+		Status:          sql.NullString{String: status, Valid: true},
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("%w: ese horario se acaba de ocupar", ErrSlotUnavailable)
+		}
+		return nil, fmt.Errorf("error creando turno: %w", err)
+	}
+
+	if err := qtx.CreateBookingRequest(ctx, db.CreateBookingRequestParams{
+		ProfessionalID: prof.ID,
+		ClientID:       client.ID,
+		AppointmentID:  appt.ID,
+		Email:          email,
+		Ip:             req.IP,
+	}); err != nil {
+		return nil, fmt.Errorf("error registrando pedido: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	s.notifyBooking(ctx, prof, email, req.Name, appt)
+	return &PublicBookingResult{
+		Status:          status,
+		Date:            appt.Date.Format("2006-01-02"),
+		StartTime:       appt.StartTime,
+		DurationMinutes: int(appt.DurationMinutes),
+		ProfessionalID:  prof.ID,
+		ClientID:        client.ID,
+		AppointmentID:   appt.ID,
+	}, nil
+}
+
+// checkBookingLimits cuenta los pedidos recientes de la IP y del email dentro de la transacción
+// del alta. Los locks se toman siempre en el mismo orden (IP y después email) y duran hasta el
+// commit: dos pedidos simultáneos no pueden pasar ambos con el último lugar libre.
+func checkBookingLimits(ctx context.Context, q *db.Queries, profID int64, email, ip string) error {
+	if err := q.LockBookingRequester(ctx, "ip:"+ip); err != nil {
+		return fmt.Errorf("error bloqueando pedidos: %w", err)
+	}
+	if err := q.LockBookingRequester(ctx, fmt.Sprintf("email:%d:%s", profID, email)); err != nil {
+		return fmt.Errorf("error bloqueando pedidos: %w", err)
+	}
+
+	now := time.Now()
+	byIP, err := q.CountBookingRequestsByIP(ctx, db.CountBookingRequestsByIPParams{
+		Ip: ip, Since: now.Add(-time.Hour),
+	})
+	if err != nil {
+		return fmt.Errorf("error contando pedidos: %w", err)
+	}
+	if byIP >= bookingRequestsPerIP {
+		return ErrTooManyBookingRequests
+	}
+
+	byEmail, err := q.CountBookingRequestsByEmail(ctx, db.CountBookingRequestsByEmailParams{
+		ProfessionalID: profID, Email: email, Since: now.Add(-24 * time.Hour),
+	})
+	if err != nil {
+		return fmt.Errorf("error contando pedidos: %w", err)
+	}
+	if byEmail >= bookingRequestsPerMail {
+		return ErrTooManyBookingRequests
+	}
+	return nil
+}
+
+func (s *Service) getPublicProfessional(ctx context.Context, slug string) (*db.GetProfessionalBySlugRow, error) {
+	slug = normalizeSlug(slug)
+	if slug == "" {
+		return nil, ErrNotFound
+	}
+	prof, err := s.queries.GetProfessionalBySlug(ctx, sql.NullString{String: slug, Valid: true})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo profesional: %w", err)
+	}
+	return &prof, nil
+}
+
+// notifyBooking avisa al paciente y al profesional. Si falla sólo queda en el log: el turno ya
+// está en la agenda.
+func (s *Service) notifyBooking(ctx context.Context, prof *db.GetProfessionalBySlugRow, email, name string, appt db.Appointment) {
+	if s.mailer == nil {
+		return
+	}
+	when := fmt.Sprintf("el %s a las %s", appt.Date.Format("02/01/2006"), appt.StartTime)
+
+	patient := mailer.Message{To: email}
+	professional := mailer.Message{To: prof.Email}
+	if appt.Status.String == "requested" {
+		patient.Subject = fmt.Sprintf("Pediste un turno con %s", prof.Name)
+		patient.Body = fmt.Sprintf("Hola %s,\n\nRecibimos tu pedido de turno con %s %s.\n"+
+			"Te vamos a avisar por email cuando lo confirme.\n", name, prof.Name, when)
+		professional.Subject = "Nuevo pedido de turno"
+		professional.Body = fmt.Sprintf("Hola %s,\n\n%s (%s) pidió un turno %s desde tu página.\n"+
+			"Confirmalo o rechazalo desde la agenda.\n", prof.Name, name, email, when)
+	} else {
+		patient.Subject = fmt.Sprintf("Tu turno con %s", prof.Name)
+		patient.Body = fmt.Sprintf("Hola %s,\n\nTu turno con %s quedó confirmado %s.\n", name, prof.Name, when)
+		professional.Subject = "Nuevo turno desde tu página"
+		professional.Body = fmt.Sprintf("Hola %s,\n\n%s (%s) reservó un turno %s desde tu página.\n", prof.Name, name, email, when)
+	}
+
+	for _, msg := range []mailer.Message{patient, professional} {
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			log.Printf("no se pudo avisar del turno %d a %s: %v", appt.ID, msg.To, err)
+		}
+	}
+}
+
+// notifyBookingDecision le avisa al paciente si el profesional aprobó o rechazó su pedido.
+func (s *Service) notifyBookingDecision(ctx context.Context, appt *db.Appointment, status string) {
+	if s.mailer == nil {
+		return
+	}
+	client, err := s.queries.GetClient(ctx, db.GetClientParams{ID: appt.ClientID, ProfessionalID: appt.ProfessionalID})
+	if err != nil {
+		log.Printf("no se pudo avisar la respuesta del turno %d: %v", appt.ID, err)
+		return
+	}
+	if !client.Email.Valid {
+		return
+	}
+	prof, err := s.GetProfessional(ctx, appt.ProfessionalID)
+	if err != nil {
+		log.Printf("no se pudo avisar la respuesta del turno %d: %v", appt.ID, err)
+		return
+	}
+
+	when := fmt.Sprintf("el %s a las %s", appt.Date.Format("02/01/2006"), appt.StartTime)
+	msg := mailer.Message{To: client.Email.String}
+	if status == "scheduled" {
+		msg.Subject = fmt.Sprintf("%s confirmó tu turno", prof.Name)
+		msg.Body = fmt.Sprintf("Hola %s,\n\n%s confirmó tu turno %s.\n", client.Name, prof.Name, when)
+	} else {
+		msg.Subject = fmt.Sprintf("%s no pudo confirmar tu turno", prof.Name)
+		msg.Body = fmt.Sprintf("Hola %s,\n\n%s no puede atenderte %s. Podés pedir otro horario desde su página.\n",
+			client.Name, prof.Name, when)
+	}
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		log.Printf("no se pudo avisar la respuesta del turno %d: %v", appt.ID, err)
+	}
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/luciluz/psiconexo/internal/db"
+)
+
+var ErrFormCompleted = errors.New("el formulario ya fue completado")
+
+type ClientFormRequest struct {
+	ProfessionalID int64
+	ClientID       int64
+	Title          string            // Si viene vacío y hay plantilla, el nombre de la plantilla
+	TemplateID     *int64            // Toma las secciones de una plantilla propia...
+	Sections       []TemplateSection // ...o se definen acá
+}
+
+// ClientFormView es el formulario con las respuestas ya descifradas.
+type ClientFormView struct {
+	ID          int64             `json:"id"`
+	ClientID    int64             `json:"client_id"`
+	Title       string            `json:"title"`
+	Sections    []TemplateSection `json:"sections"`
+	Answers     map[string]any    `json:"answers,omitempty"`
+	CompletedAt *time.Time        `json:"completed_at,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+type SubmitClientFormRequest struct {
+	ProfessionalID int64
+	ClientID       int64
+	FormID         int64
+	Answers        map[string]any
+}
+
+// clientFormAAD liga las respuestas cifradas al formulario y al paciente que lo completó.
+func clientFormAAD(formID, clientID int64) []byte {
+	return fmt.Appendf(nil, "client_form:%d:%d", formID, clientID)
+}
+
+// CreateClientForm le asigna un formulario al paciente para que lo complete desde el portal.
+func (s *Service) CreateClientForm(ctx context.Context, req ClientFormRequest) (*ClientFormView, error) {
+	if err := requireOwner(ctx); err != nil {
+		return nil, err
+	}
+	if _, err := s.getOwnedClient(ctx, req.ProfessionalID, req.ClientID); err != nil {
+		return nil, err
+	}
+
+	title := strings.TrimSpace(req.Title)
+	sections := req.Sections
+	if req.TemplateID != nil {
+		tpl, err := s.getOwnedTemplate(ctx, req.ProfessionalID, *req.TemplateID)
+		if err != nil {
+			return nil, err
+		}
+		if sections, err = parseTemplateSections(tpl); err != nil {
+			return nil, err
+		}
+		if title == "" {
+			title = tpl.Name
+		}
+	}
+	if title == "" {
+		return nil, fmt.Errorf("%w: el formulario necesita un título", ErrInvalidTemplate)
+	}
+	if err := validateTemplateSections(sections); err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(sections)
+	if err != nil {
+		return nil, err
+	}
+
+	form, err := s.queries.CreateClientForm(ctx, db.CreateClientFormParams{
+		ProfessionalID: req.ProfessionalID,
+		ClientID:       req.ClientID,
+		Title:          title,
+		Sections:       string(raw),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creando formulario: %w", err)
+	}
+	return s.clientFormView(form, false)
+}
+
+// ListClientForms devuelve los formularios del paciente con sus respuestas.
+func (s *Service) ListClientForms(ctx context.Context, profID, clientID int64) ([]ClientFormView, error) {
+	if err := requireOwner(ctx); err != nil {
+		return nil, err
+	}
+	if _, err := s.getOwnedClient(ctx, profID, clientID); err != nil {
+		return nil, err
+	}
+	return s.listClientForms(ctx, profID, clientID, true)
+}
+
+// ListPortalForms devuelve los formularios del paciente. Las respuestas no vuelven a mostrarse:
+// una vez enviadas son parte de la historia clínica.
+func (s *Service) ListPortalForms(ctx context.Context, profID, clientID int64) ([]ClientFormView, error) {
+	return s.listClientForms(ctx, profID, clientID, false)
+}
+
+// SubmitPortalForm guarda, cifradas, las respuestas del paciente. Se envía una sola vez.
+func (s *Service) SubmitPortalForm(ctx context.Context, req SubmitClientFormRequest) (*ClientFormView, error) {
+	if s.keys == nil {
+		return nil, ErrEncryptionUnavailable
+	}
+
+	form, err := s.queries.GetClientForm(ctx, db.GetClientFormParams{
+		ID: req.FormID, ProfessionalID: req.ProfessionalID, ClientID: req.ClientID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo formulario: %w", err)
+	}
+	if form.CompletedAt.Valid {
+		return nil, ErrFormCompleted
+	}
+
+	sections, err := parseFormSections(form)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateSectionValues(sections, req.Answers); err != nil {
+		return nil, err
+	}
+	if missing := missingSections(sections, req.Answers); len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrMissingRequiredSections, strings.Join(missing, ", "))
+	}
+
+	raw, err := json.Marshal(req.Answers)
+	if err != nil {
+		return nil, err
+	}
+	sealed, version, err := s.keys.Seal(raw, clientFormAAD(form.ID, form.ClientID))
+	if err != nil {
+		return nil, fmt.Errorf("error cifrando respuestas: %w", err)
+	}
+
+	n, err := s.queries.CompleteClientForm(ctx, db.CompleteClientFormParams{
+		Answers:        sql.NullString{String: sealed, Valid: true},
+		KeyVersion:     sql.NullInt32{Int32: version, Valid: true},
+		ID:             form.ID,
+		ProfessionalID: req.ProfessionalID,
+		ClientID:       req.ClientID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error guardando respuestas: %w", err)
+	}
+	if n == 0 {
+		return nil, ErrFormCompleted // Lo envió otra pestaña al mismo tiempo
+	}
+
+	now := time.Now()
+	return &ClientFormView{
+		ID:          form.ID,
+		ClientID:    form.ClientID,
+		Title:       form.Title,
+		Sections:    sections,
+		CompletedAt: &now,
+		CreatedAt:   form.CreatedAt.Time,
+	}, nil
+}
+
+func (s *Service) listClientForms(ctx context.Context, profID, clientID int64, withAnswers bool) ([]ClientFormView, error) {
+	forms, err := s.queries.ListClientForms(ctx, db.ListClientFormsParams{
+		ProfessionalID: profID,
+		ClientID:       clientID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listando formularios: %w", err)
+	}
+
+	views := make([]ClientFormView, 0, len(forms))
+	for _, form := range forms {
+		view, err := s.clientFormView(form, withAnswers)
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, *view)
+	}
+	return views, nil
+}
+
+func (s *Service) clientFormView(form db.ClientForm, withAnswers bool) (*ClientFormView, error) {
+	sections, err := parseFormSections(form)
+	if err != nil {
+		return nil, err
+	}
+	view := &ClientFormView{
+		ID:        form.ID,
+		ClientID:  form.ClientID,
+		Title:     form.Title,
+		Sections:  sections,
+		CreatedAt: form.CreatedAt.Time,
+	}
+	if form.CompletedAt.Valid {
+		view.CompletedAt = &form.CompletedAt.Time
+	}
+	if withAnswers && form.Answers.Valid {
+		plain, err := s.openField(form.Answers.String, form.KeyVersion, clientFormAAD(form.ID, form.ClientID))
+		if err != nil {
+			return nil, fmt.Errorf("error descifrando formulario %d: %w", form.ID, err)
+		}
+		if err := json.Unmarshal([]byte(plain), &view.Answers); err != nil {
+			return nil, fmt.Errorf("formulario %d corrupto: %w", form.ID, err)
+		}
+	}
+	return view, nil
+}
+
+func parseFormSections(form db.ClientForm) ([]TemplateSection, error) {
+	var sections []TemplateSection
+	if err := json.Unmarshal(form.Sections, &sections); err != nil {
+		return nil, fmt.Errorf("formulario %d corrupto: %w", form.ID, err)
+	}
+	return sections, nil
+}
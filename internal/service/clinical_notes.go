@@ -3,68 +3,533 @@ package service
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"strings"
 
 	"github.com/luciluz/psiconexo/internal/db"
 )
 
+var (
+	ErrNoteSigned        = errors.New("la nota está firmada y no se puede modificar; agregue una adenda")
+	ErrNoteAlreadySigned = errors.New("la nota ya está firmada")
+	ErrNoteNotSigned     = errors.New("sólo se pueden agregar adendas a notas firmadas")
+	ErrEmptyNote         = errors.New("la nota necesita contenido o una plantilla")
+	ErrInvalidNoteType   = errors.New("tipo de nota inválido (use clinical o personal)")
+	ErrRecordRetention   = errors.New("las notas clínicas integran la historia clínica y deben conservarse; no se pueden borrar")
+)
+
+// Tipos de nota. Las clínicas integran la historia clínica: se exportan, se entregan al
+// paciente que la pide y se conservan durante el plazo legal (Ley 26.529, art. 18), así que
+// no se borran. Las personales (notas de proceso) son sólo del profesional: nunca salen en
+// exportaciones, accesos del paciente ni accesos compartidos, y se pueden borrar.
+const (
+	NoteTypeClinical = "clinical"
+	NoteTypePersonal = "personal"
+)
+
+// isRecordNote indica si la nota forma parte de la historia clínica. Todo acceso que no sea
+// el del propio autor (paciente, supervisión, colaboradores) debe filtrar con esto.
+func isRecordNote(note *db.ClinicalNote) bool {
+	return note.Type.String != NoteTypePersonal
+}
+
+// parseNoteType valida el tipo pedido; vacío es clínica.
+func parseNoteType(t string) (string, error) {
+	switch t {
+	case "", NoteTypeClinical:
+		return NoteTypeClinical, nil
+	case NoteTypePersonal:
+		return NoteTypePersonal, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidNoteType, t)
+	}
+}
+
 type CreateClinicalNoteRequest struct {
 	ProfessionalID int64
 	ClientID       int64
 	AppointmentID  *int64 // Puntero: puede ser nil (nota general, no vinculada a turno)
-	Content        string // Texto (idealmente encriptado desde el front)
+	Type           string // clinical (por defecto) | personal
+	Content        string // Texto plano: el servicio lo cifra antes de guardarlo
+	TemplateID     *int64 // Opcional: nota estructurada
+	Sections       map[string]any
 }
 
 type UpdateClinicalNoteRequest struct {
-	NoteID  int64
-	Content string
+	ProfessionalID int64
+	NoteID         int64
+	Content        string
+	Sections       map[string]any // nil = conservar las secciones actuales
+}
+
+type CreateAddendumRequest struct {
+	ProfessionalID int64
+	NoteID         int64
+	Content        string
 }
 
 func (s *Service) CreateClinicalNote(ctx context.Context, req CreateClinicalNoteRequest) (*db.ClinicalNote, error) {
+	if err := requireOwner(ctx); err != nil {
+		return nil, err
+	}
+
+	noteType, err := parseNoteType(req.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.getOwnedClient(ctx, req.ProfessionalID, req.ClientID); err != nil {
+		return nil, err
+	}
 
 	// Manejo de AppointmentID opcional (Nullable en DB)
 	var appID sql.NullInt64
 	if req.AppointmentID != nil {
+		if _, err := s.getOwnedAppointment(ctx, req.ProfessionalID, req.ClientID, *req.AppointmentID); err != nil {
+			return nil, err
+		}
 		appID = sql.NullInt64{Int64: *req.AppointmentID, Valid: true}
 	} else {
 		appID = sql.NullInt64{Valid: false}
 	}
 
-	// Asumimos is_encrypted = true por defecto, ya que la idea es que el front mande basura encriptada.
-	// Si mandas texto plano, igual se guarda, pero el flag queda en true indicando la intención del sistema.
-	note, err := s.queries.CreateClinicalNote(ctx, db.CreateClinicalNoteParams{
+	var templateID sql.NullInt64
+	if req.TemplateID != nil {
+		tpl, err := s.getOwnedTemplate(ctx, req.ProfessionalID, *req.TemplateID)
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("%w: la plantilla %d no existe", ErrInvalidTemplate, *req.TemplateID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !tpl.Active.Bool {
+			return nil, ErrTemplateInactive
+		}
+		defs, err := parseTemplateSections(tpl)
+		if err != nil {
+			return nil, err
+		}
+		if err := validateSectionValues(defs, req.Sections); err != nil {
+			return nil, err
+		}
+		templateID = sql.NullInt64{Int64: tpl.ID, Valid: true}
+	} else {
+		if len(req.Sections) > 0 {
+			return nil, fmt.Errorf("%w: la nota no usa plantilla", ErrInvalidSectionValue)
+		}
+		if req.Content == "" {
+			return nil, ErrEmptyNote
+		}
+	}
+
+	sealed, keyVersion, err := s.sealNote(req.ProfessionalID, req.ClientID, req.Content)
+	if err != nil {
+		return nil, err
+	}
+	sections, plainSections, err := s.sealSections(req.ProfessionalID, req.ClientID, templateID, req.Sections)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	qtx := s.queries.WithTx(tx)
+
+	// La nota nace como borrador; la firma se hace en un paso aparte.
+	note, err := qtx.CreateClinicalNote(ctx, db.CreateClinicalNoteParams{
 		ProfessionalID: req.ProfessionalID,
 		ClientID:       req.ClientID,
 		AppointmentID:  appID,
-		Content:        req.Content,
-		IsEncrypted:    sql.NullBool{Bool: true, Valid: true},
+		Type:           sql.NullString{String: noteType, Valid: true},
+		Content:        sealed,
+		KeyVersion:     keyVersion,
+		Status:         sql.NullString{String: "draft", Valid: true},
+		TemplateID:     templateID,
+		Sections:       sections,
 	})
 
 	if err != nil {
 		return nil, fmt.Errorf("error creando nota clínica: %w", err)
 	}
 
+	searchText := req.Content + "\n" + sectionsText(req.Sections)
+	if err := s.indexNote(ctx, qtx, req.ProfessionalID, note.ID, searchText, false); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	note.Content = req.Content
+	note.Sections = plainSections
 	return &note, nil
 }
 
-func (s *Service) ListClinicalNotes(ctx context.Context, clientID, professionalID int64) ([]db.ClinicalNote, error) {
+// ListClinicalNotes lista las notas del paciente para su autor. noteType filtra por tipo ("" = todas).
+func (s *Service) ListClinicalNotes(ctx context.Context, clientID, professionalID int64, noteType string) ([]db.ClinicalNote, error) {
+	if err := requireOwner(ctx); err != nil {
+		return nil, err
+	}
+
+	var typeFilter sql.NullString
+	if noteType != "" {
+		t, err := parseNoteType(noteType)
+		if err != nil {
+			return nil, err
+		}
+		typeFilter = sql.NullString{String: t, Valid: true}
+	}
+
 	notes, err := s.queries.ListClinicalNotes(ctx, db.ListClinicalNotesParams{
 		ClientID:       clientID,
 		ProfessionalID: professionalID,
+		Type:           typeFilter,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("error listando historial clínico: %w", err)
 	}
+	for i := range notes {
+		if err := s.openNote(&notes[i]); err != nil {
+			return nil, err
+		}
+	}
 	return notes, nil
 }
 
+// UpdateClinicalNote edita un borrador. La versión anterior queda guardada (cifrada) como revisión.
 func (s *Service) UpdateClinicalNote(ctx context.Context, req UpdateClinicalNoteRequest) (*db.ClinicalNote, error) {
-	note, err := s.queries.UpdateClinicalNote(ctx, db.UpdateClinicalNoteParams{
-		Content: req.Content,
-		ID:      req.NoteID,
+	if err := requireOwner(ctx); err != nil {
+		return nil, err
+	}
+
+	if s.keys == nil {
+		return nil, ErrEncryptionUnavailable
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	qtx := s.queries.WithTx(tx)
+
+	current, err := qtx.GetNoteForUpdate(ctx, db.GetNoteForUpdateParams{ID: req.NoteID, ProfessionalID: req.ProfessionalID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("error obteniendo nota clínica %d: %w", req.NoteID, err)
+	}
+	if current.Status.String == "signed" {
+		return nil, ErrNoteSigned
+	}
+
+	previous := current
+	if err := s.openNote(&previous); err != nil {
+		return nil, err
+	}
+
+	values := req.Sections
+	if values == nil && previous.Sections.Valid {
+		if err := json.Unmarshal([]byte(previous.Sections.String), &values); err != nil {
+			return nil, fmt.Errorf("error leyendo secciones de la nota %d: %w", current.ID, err)
+		}
+	}
+	if current.TemplateID.Valid {
+		defs, err := s.noteTemplateSections(ctx, req.ProfessionalID, current.TemplateID.Int64)
+		if err != nil {
+			return nil, err
+		}
+		if err := validateSectionValues(defs, values); err != nil {
+			return nil, err
+		}
+	} else if len(values) > 0 {
+		return nil, fmt.Errorf("%w: la nota no usa plantilla", ErrInvalidSectionValue)
+	} else if req.Content == "" {
+		return nil, ErrEmptyNote
+	}
+
+	sections, plainSections, err := s.sealSections(current.ProfessionalID, current.ClientID, current.TemplateID, values)
+	if err != nil {
+		return nil, err
+	}
+	if previous.Content == req.Content && previous.Sections == plainSections {
+		return &previous, nil // Sin cambios: no se genera revisión
+	}
+
+	revContent, revVersion, err := s.keys.Seal([]byte(previous.Content), revisionAAD(current.ID))
+	if err != nil {
+		return nil, fmt.Errorf("error cifrando revisión: %w", err)
+	}
+	var revSections sql.NullString
+	if previous.Sections.Valid {
+		sealedSections, _, err := s.keys.Seal([]byte(previous.Sections.String), sectionsAAD(revisionAAD(current.ID)))
+		if err != nil {
+			return nil, fmt.Errorf("error cifrando revisión: %w", err)
+		}
+		revSections = sql.NullString{String: sealedSections, Valid: true}
+	}
+	if _, err := qtx.CreateNoteRevision(ctx, db.CreateNoteRevisionParams{
+		NoteID:     current.ID,
+		AuthorID:   current.ProfessionalID,
+		Content:    revContent,
+		Sections:   revSections,
+		KeyVersion: sql.NullInt32{Int32: revVersion, Valid: true},
+		WrittenAt:  current.UpdatedAt,
+	}); err != nil {
+		return nil, fmt.Errorf("error guardando revisión: %w", err)
+	}
+
+	sealed, keyVersion, err := s.sealNote(current.ProfessionalID, current.ClientID, req.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	note, err := qtx.UpdateClinicalNote(ctx, db.UpdateClinicalNoteParams{
+		Content:        sealed,
+		Sections:       sections,
+		KeyVersion:     keyVersion,
+		ID:             req.NoteID,
+		ProfessionalID: req.ProfessionalID,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("error actualizando nota clínica %d: %w", req.NoteID, err)
 	}
+
+	searchText := req.Content + "\n" + sectionsText(values)
+	if err := s.indexNote(ctx, qtx, current.ProfessionalID, current.ID, searchText, true); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	note.Content = req.Content
+	note.Sections = plainSections
+	return &note, nil
+}
+
+// SignClinicalNote firma la nota: desde ese momento queda inmutable y las correcciones
+// se hacen con adendas.
+func (s *Service) SignClinicalNote(ctx context.Context, profID, noteID int64) (*db.ClinicalNote, error) {
+	if err := requireOwner(ctx); err != nil {
+		return nil, err
+	}
+
+	current, err := s.getOwnedNote(ctx, profID, noteID)
+	if err != nil {
+		return nil, err
+	}
+	if current.Status.String == "signed" {
+		return nil, ErrNoteAlreadySigned
+	}
+
+	// Las notas estructuradas sólo se firman con todas las secciones obligatorias completas
+	if current.TemplateID.Valid {
+		defs, err := s.noteTemplateSections(ctx, profID, current.TemplateID.Int64)
+		if err != nil {
+			return nil, err
+		}
+		opened := *current
+		if err := s.openNote(&opened); err != nil {
+			return nil, err
+		}
+		var values map[string]any
+		if opened.Sections.Valid {
+			if err := json.Unmarshal([]byte(opened.Sections.String), &values); err != nil {
+				return nil, fmt.Errorf("error leyendo secciones de la nota %d: %w", noteID, err)
+			}
+		}
+		if missing := missingSections(defs, values); len(missing) > 0 {
+			return nil, fmt.Errorf("%w: %s", ErrMissingRequiredSections, strings.Join(missing, ", "))
+		}
+	}
+
+	note, err := s.queries.SignClinicalNote(ctx, db.SignClinicalNoteParams{ID: noteID, ProfessionalID: profID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoteAlreadySigned
+		}
+		return nil, fmt.Errorf("error firmando nota clínica %d: %w", noteID, err)
+	}
+
+	if err := s.openNote(&note); err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
+// CreateAddendum agrega una corrección fechada y firmada a una nota ya firmada.
+func (s *Service) CreateAddendum(ctx context.Context, req CreateAddendumRequest) (*db.ClinicalNoteAddenda, error) {
+	if err := requireOwner(ctx); err != nil {
+		return nil, err
+	}
+
+	note, err := s.getOwnedNote(ctx, req.ProfessionalID, req.NoteID)
+	if err != nil {
+		return nil, err
+	}
+	if note.Status.String != "signed" {
+		return nil, ErrNoteNotSigned
+	}
+	if s.keys == nil {
+		return nil, ErrEncryptionUnavailable
+	}
+
+	sealed, version, err := s.keys.Seal([]byte(req.Content), addendumAAD(note.ID, req.ProfessionalID))
+	if err != nil {
+		return nil, fmt.Errorf("error cifrando adenda: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	qtx := s.queries.WithTx(tx)
+
+	addendum, err := qtx.CreateNoteAddendum(ctx, db.CreateNoteAddendumParams{
+		NoteID:         note.ID,
+		ProfessionalID: req.ProfessionalID,
+		Content:        sealed,
+		KeyVersion:     sql.NullInt32{Int32: version, Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creando adenda: %w", err)
+	}
+
+	// Las palabras de la adenda se suman al índice de la nota
+	if err := s.indexNote(ctx, qtx, req.ProfessionalID, note.ID, req.Content, false); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	addendum.Content = req.Content
+	return &addendum, nil
+}
+
+func (s *Service) ListAddenda(ctx context.Context, profID, noteID int64) ([]db.ClinicalNoteAddenda, error) {
+	if err := requireOwner(ctx); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.getOwnedNote(ctx, profID, noteID); err != nil {
+		return nil, err
+	}
+
+	addenda, err := s.queries.ListNoteAddenda(ctx, noteID)
+	if err != nil {
+		return nil, fmt.Errorf("error listando adendas: %w", err)
+	}
+	for i := range addenda {
+		if err := s.openAddendum(&addenda[i]); err != nil {
+			return nil, err
+		}
+	}
+	return addenda, nil
+}
+
+// DeleteClinicalNote borra una nota personal con sus adendas, revisiones y adjuntos.
+// Las notas clínicas no se borran (ErrRecordRetention).
+func (s *Service) DeleteClinicalNote(ctx context.Context, profID, noteID int64) error {
+	if err := requireOwner(ctx); err != nil {
+		return err
+	}
+
+	note, err := s.getOwnedNote(ctx, profID, noteID)
+	if err != nil {
+		return err
+	}
+	if isRecordNote(note) {
+		return ErrRecordRetention
+	}
+
+	attachments, err := s.queries.ListAttachments(ctx, db.ListAttachmentsParams{
+		ProfessionalID: profID,
+		NoteID:         sql.NullInt64{Int64: note.ID, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("error listando adjuntos: %w", err)
+	}
+	if len(attachments) > 0 && s.blobs == nil {
+		return ErrStorageUnavailable
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	qtx := s.queries.WithTx(tx)
+
+	if err := qtx.DeleteNoteAddenda(ctx, note.ID); err != nil {
+		return fmt.Errorf("error borrando adendas: %w", err)
+	}
+	if err := qtx.DeleteNoteRevisions(ctx, note.ID); err != nil {
+		return fmt.Errorf("error borrando revisiones: %w", err)
+	}
+	if err := qtx.DeleteNoteAttachments(ctx, note.ID); err != nil {
+		return fmt.Errorf("error borrando adjuntos: %w", err)
+	}
+	// La consulta sólo borra notas personales: la conservación no depende de este chequeo
+	deleted, err := qtx.DeleteClinicalNote(ctx, db.DeleteClinicalNoteParams{ID: note.ID, ProfessionalID: profID})
+	if err != nil {
+		return fmt.Errorf("error borrando nota %d: %w", note.ID, err)
+	}
+	if deleted == 0 {
+		return ErrRecordRetention
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, a := range attachments {
+		if err := s.blobs.Delete(ctx, a.StorageKey); err != nil {
+			log.Printf("no se pudo borrar el blob %s: %v", a.StorageKey, err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) getOwnedNote(ctx context.Context, profID, noteID int64) (*db.ClinicalNote, error) {
+	note, err := s.queries.GetNoteById(ctx, db.GetNoteByIdParams{ID: noteID, ProfessionalID: profID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("error obteniendo nota clínica %d: %w", noteID, err)
+	}
 	return &note, nil
 }
+
+func (s *Service) noteTemplateSections(ctx context.Context, profID, templateID int64) ([]TemplateSection, error) {
+	tpl, err := s.queries.GetNoteTemplate(ctx, db.GetNoteTemplateParams{ID: templateID, ProfessionalID: profID})
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo plantilla %d: %w", templateID, err)
+	}
+	return parseTemplateSections(&tpl)
+}
@@ -0,0 +1,302 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/luciluz/psiconexo/internal/db"
+)
+
+type ClinicalRecordExportRequest struct {
+	ProfessionalID int64
+	ClientID       int64
+	Purpose        string // patient_request | transfer | legal | other
+	Recipient      string
+}
+
+// ClinicalRecord es la historia clínica descifrada, lista para imprimir.
+type ClinicalRecord struct {
+	Professional db.Professional
+	Client       db.Client
+	Sessions     []db.ListClientSessionsRow
+	Notes        []ClinicalRecordNote
+	GeneratedAt  time.Time
+}
+
+type ClinicalRecordNote struct {
+	Note    db.ClinicalNote
+	Text    string // Texto tal como se imprime (secciones con sus etiquetas + cuerpo)
+	Addenda []db.ClinicalNoteAddenda
+	SHA256  string // Huella de la nota firmada para verificar que no se alteró
+}
+
+// ExportClinicalRecord genera el PDF de la historia clínica de un paciente, lo escribe en w
+// y deja registro de la exportación (con el hash del documento) en la auditoría.
+func (s *Service) ExportClinicalRecord(ctx context.Context, req ClinicalRecordExportRequest, w io.Writer) (*db.ClinicalRecordExport, error) {
+	if err := requireOwner(ctx); err != nil {
+		return nil, err
+	}
+
+	record, err := s.buildClinicalRecord(ctx, req.ProfessionalID, req.ClientID)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := RenderClinicalRecordPDF(record, &buf); err != nil {
+		return nil, fmt.Errorf("error generando PDF: %w", err)
+	}
+	sum := sha256.Sum256(buf.Bytes())
+
+	purpose := req.Purpose
+	if purpose == "" {
+		purpose = "patient_request"
+	}
+	export, err := s.queries.CreateClinicalRecordExport(ctx, db.CreateClinicalRecordExportParams{
+		ProfessionalID: req.ProfessionalID,
+		ClientID:       req.ClientID,
+		Purpose:        sql.NullString{String: purpose, Valid: true},
+		Recipient:      sql.NullString{String: req.Recipient, Valid: req.Recipient != ""},
+		NoteCount:      int32(len(record.Notes)),
+		DocumentSha256: hex.EncodeToString(sum[:]),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error registrando exportación: %w", err)
+	}
+
+	// Sólo se entrega el documento si quedó auditado.
+	if _, err := buf.WriteTo(w); err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (s *Service) ListClinicalRecordExports(ctx context.Context, profID, clientID int64) ([]db.ClinicalRecordExport, error) {
+	if err := requireOwner(ctx); err != nil {
+		return nil, err
+	}
+
+	exports, err := s.queries.ListClinicalRecordExports(ctx, db.ListClinicalRecordExportsParams{
+		ProfessionalID: profID,
+		ClientID:       clientID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listando exportaciones: %w", err)
+	}
+	return exports, nil
+}
+
+func (s *Service) buildClinicalRecord(ctx context.Context, profID, clientID int64) (*ClinicalRecord, error) {
+	client, err := s.getOwnedClient(ctx, profID, clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	prof, err := s.queries.GetProfessional(ctx, profID)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo profesional: %w", err)
+	}
+
+	sessions, err := s.queries.ListClientSessions(ctx, db.ListClientSessionsParams{
+		ProfessionalID: profID,
+		ClientID:       clientID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo sesiones: %w", err)
+	}
+
+	notes, err := s.queries.ListSignedNotesForRecord(ctx, db.ListSignedNotesForRecordParams{
+		ProfessionalID: profID,
+		ClientID:       clientID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo notas: %w", err)
+	}
+
+	record := &ClinicalRecord{
+		Professional: prof,
+		Client:       *client,
+		Sessions:     sessions,
+		GeneratedAt:  time.Now(),
+	}
+	templates := make(map[int64][]TemplateSection)
+
+	for i := range notes {
+		note := &notes[i]
+		if err := s.openNote(note); err != nil {
+			return nil, err
+		}
+
+		var defs []TemplateSection
+		if note.TemplateID.Valid {
+			if defs, err = s.cachedTemplateSections(ctx, templates, profID, note.TemplateID.Int64); err != nil {
+				return nil, err
+			}
+		}
+		text := recordNoteText(note.Content, note.Sections, defs)
+
+		addenda, err := s.queries.ListNoteAddenda(ctx, note.ID)
+		if err != nil {
+			return nil, fmt.Errorf("error obteniendo adendas: %w", err)
+		}
+		for j := range addenda {
+			if err := s.openAddendum(&addenda[j]); err != nil {
+				return nil, err
+			}
+		}
+
+		record.Notes = append(record.Notes, ClinicalRecordNote{
+			Note:    *note,
+			Text:    text,
+			Addenda: addenda,
+			SHA256:  noteIntegrityHash(note, text),
+		})
+	}
+	return record, nil
+}
+
+func (s *Service) cachedTemplateSections(ctx context.Context, cache map[int64][]TemplateSection, profID, templateID int64) ([]TemplateSection, error) {
+	if defs, ok := cache[templateID]; ok {
+		return defs, nil
+	}
+	defs, err := s.noteTemplateSections(ctx, profID, templateID)
+	if err != nil {
+		return nil, err
+	}
+	cache[templateID] = defs
+	return defs, nil
+}
+
+// recordNoteText arma el texto imprimible: cada sección con su etiqueta, en el orden de la plantilla.
+func recordNoteText(content string, sections sql.NullString, defs []TemplateSection) string {
+	if !sections.Valid || len(defs) == 0 {
+		return renderNoteText(content, sections)
+	}
+	var values map[string]any
+	if err := json.Unmarshal([]byte(sections.String), &values); err != nil {
+		return renderNoteText(content, sections)
+	}
+
+	var b strings.Builder
+	for _, sec := range defs {
+		v := values[sec.Key]
+		if isEmptySectionValue(v) {
+			continue
+		}
+		switch val := v.(type) {
+		case bool:
+			v = map[bool]string{true: "Sí", false: "No"}[val]
+		case float64:
+			v = fmt.Sprintf("%g", val)
+		}
+		fmt.Fprintf(&b, "%s: %v\n", sec.Label, v)
+	}
+	if content != "" {
+		b.WriteString("\n" + content)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// noteIntegrityHash es el SHA-256 de la nota firmada (id, fecha de firma y texto impreso).
+func noteIntegrityHash(note *db.ClinicalNote, text string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\n%s\n%s", note.ID, note.SignedAt.Time.UTC().Format(time.RFC3339), text)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// RenderClinicalRecordPDF genera la historia clínica paginada: datos del paciente, sesiones
+// y notas firmadas (con adendas y la huella de cada nota).
+func RenderClinicalRecordPDF(rec *ClinicalRecord, w io.Writer) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("") // UTF-8 -> cp1252 para tildes y ñ
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 18)
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont("Helvetica", "I", 8)
+		footer := fmt.Sprintf("Historia clínica de %s - Documento confidencial - Página %d de {nb}", rec.Client.Name, pdf.PageNo())
+		pdf.CellFormat(0, 8, tr(footer), "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 10, tr("Historia clínica"), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.CellFormat(0, 5, tr("Emitida el "+rec.GeneratedAt.Format("02/01/2006 15:04")+" (Ley 26.529)"), "", 1, "L", false, 0, "")
+	pdf.Ln(3)
+
+	field := func(label, value string) {
+		if value == "" {
+			return
+		}
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.CellFormat(45, 6, tr(label), "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.CellFormat(0, 6, tr(value), "", 1, "L", false, 0, "")
+	}
+	heading := func(title string) {
+		pdf.Ln(3)
+		pdf.SetFont("Helvetica", "B", 12)
+		pdf.SetFillColor(230, 230, 230)
+		pdf.CellFormat(0, 8, tr(title), "", 1, "L", true, 0, "")
+		pdf.Ln(1)
+	}
+
+	heading("Profesional")
+	prof := rec.Professional
+	field("Nombre", strings.TrimSpace(prof.Title.String+" "+prof.Name))
+	field("Matrícula", prof.LicenseNumber.String)
+	field("Email", prof.Email)
+
+	heading("Paciente")
+	cl := rec.Client
+	field("Nombre", cl.Name)
+	if cl.BirthDate.Valid {
+		field("Fecha de nacimiento", FormatARDate(cl.BirthDate.Time))
+	}
+	field("Email", cl.Email.String)
+	field("Teléfono", cl.Phone.String)
+	field("Medicación", cl.Medications.String)
+	if cl.EmergencyContactName.Valid {
+		field("Contacto de emergencia", strings.TrimSpace(cl.EmergencyContactName.String+" "+cl.EmergencyContactPhone.String))
+	}
+
+	heading(fmt.Sprintf("Sesiones realizadas (%d)", len(rec.Sessions)))
+	pdf.SetFont("Helvetica", "", 9)
+	for _, ses := range rec.Sessions {
+		line := fmt.Sprintf("%s  %s  %d min  %s", FormatARDate(ses.Date), ses.StartTime, ses.DurationMinutes, modalityLabels[ses.Modality.String])
+		pdf.CellFormat(0, 5, tr(line), "", 1, "L", false, 0, "")
+	}
+
+	heading(fmt.Sprintf("Notas clínicas firmadas (%d)", len(rec.Notes)))
+	for _, n := range rec.Notes {
+		pdf.SetFont("Helvetica", "B", 10)
+		title := fmt.Sprintf("Nota #%d - firmada el %s", n.Note.ID, n.Note.SignedAt.Time.Format("02/01/2006 15:04"))
+		pdf.CellFormat(0, 6, tr(title), "", 1, "L", false, 0, "")
+
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.MultiCell(0, 5, tr(n.Text), "", "L", false)
+
+		for _, a := range n.Addenda {
+			pdf.SetFont("Helvetica", "I", 9)
+			pdf.CellFormat(0, 5, tr("Adenda del "+a.SignedAt.Format("02/01/2006 15:04")), "", 1, "L", false, 0, "")
+			pdf.MultiCell(0, 5, tr(a.Content), "", "L", false)
+		}
+
+		pdf.SetFont("Courier", "", 7)
+		pdf.CellFormat(0, 5, "SHA-256: "+n.SHA256, "", 1, "L", false, 0, "")
+		pdf.Ln(3)
+	}
+
+	return pdf.Output(w)
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/luciluz/psiconexo/internal/db"
+	"github.com/luciluz/psiconexo/internal/mailer"
+)
+
+var ErrMailerUnavailable = errors.New("el envío de emails no está configurado")
+
+const (
+	DefaultDraftAgeDays   = 3  // Un borrador sin firmar de más de 3 días ya se considera atrasado
+	pendingLookbackDays   = 90 // Turnos sin nota: se miran los últimos 90 días por defecto
+	reminderMaxListedRows = 10
+)
+
+type PendingDocumentationRequest struct {
+	ProfessionalID int64
+	DraftAgeDays   int       // Borradores creados hace más de N días (0 = DefaultDraftAgeDays)
+	Since          time.Time // Turnos realizados desde esta fecha (cero = últimos 90 días)
+}
+
+// PendingDocumentation es lo que el profesional todavía debe documentar.
+type PendingDocumentation struct {
+	ProfessionalID int64                               `json:"professional_id"`
+	DraftAgeDays   int                                 `json:"draft_age_days"`
+	StaleDrafts    []db.GetDraftNotesRow               `json:"stale_drafts"`  // Borradores sin firmar
+	MissingNotes   []db.ListAppointmentsWithoutNoteRow `json:"missing_notes"` // Sesiones realizadas sin nota
+}
+
+func (p *PendingDocumentation) Total() int {
+	return len(p.StaleDrafts) + len(p.MissingNotes)
+}
+
+type ReminderStats struct {
+	Notified int `json:"notified"`
+	Skipped  int `json:"skipped"` // Sin pendientes
+	Failed   int `json:"failed"`
+}
+
+func (s *Service) GetPendingDocumentation(ctx context.Context, req PendingDocumentationRequest) (*PendingDocumentation, error) {
+	if err := requireOwner(ctx); err != nil {
+		return nil, err
+	}
+
+	days := req.DraftAgeDays
+	if days <= 0 {
+		days = DefaultDraftAgeDays
+	}
+	today := time.Now().Truncate(24 * time.Hour)
+	since := req.Since
+	if since.IsZero() {
+		since = today.AddDate(0, 0, -pendingLookbackDays)
+	}
+
+	drafts, err := s.queries.GetDraftNotes(ctx, db.GetDraftNotesParams{
+		ProfessionalID: req.ProfessionalID,
+		CreatedBefore:  time.Now().AddDate(0, 0, -days),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo borradores: %w", err)
+	}
+
+	missing, err := s.queries.ListAppointmentsWithoutNote(ctx, db.ListAppointmentsWithoutNoteParams{
+		ProfessionalID: req.ProfessionalID,
+		FromDate:       since,
+		ToDate:         today,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo sesiones sin nota: %w", err)
+	}
+
+	pending := &PendingDocumentation{
+		ProfessionalID: req.ProfessionalID,
+		DraftAgeDays:   days,
+		StaleDrafts:    drafts,
+		MissingNotes:   missing,
+	}
+	if pending.StaleDrafts == nil {
+		pending.StaleDrafts = []db.GetDraftNotesRow{}
+	}
+	if pending.MissingNotes == nil {
+		pending.MissingNotes = []db.ListAppointmentsWithoutNoteRow{}
+	}
+	return pending, nil
+}
+
+// SendDocumentationReminders avisa por email a cada profesional (con notify_by_email activo)
+// que tenga documentación pendiente. Pensado para correr por cron (ej: una vez por semana).
+func (s *Service) SendDocumentationReminders(ctx context.Context, draftAgeDays int) (ReminderStats, error) {
+	var stats ReminderStats
+	if s.mailer == nil {
+		return stats, ErrMailerUnavailable
+	}
+
+	professionals, err := s.queries.ListProfessionalsToNotify(ctx)
+	if err != nil {
+		return stats, fmt.Errorf("error listando profesionales: %w", err)
+	}
+
+	for _, p := range professionals {
+		pending, err := s.GetPendingDocumentation(ctx, PendingDocumentationRequest{
+			ProfessionalID: p.ID,
+			DraftAgeDays:   draftAgeDays,
+		})
+		if err != nil {
+			return stats, err
+		}
+		if pending.Total() == 0 {
+			stats.Skipped++
+			continue
+		}
+
+		// Un email que falla no corta el resto de los avisos.
+		if err := s.mailer.Send(ctx, documentationReminder(p, pending)); err != nil {
+			log.Printf("recordatorio de documentación al profesional %d: %v", p.ID, err)
+			stats.Failed++
+			continue
+		}
+		stats.Notified++
+	}
+	return stats, nil
+}
+
+// documentationReminder arma el email. Sólo lleva nombres y fechas, nunca contenido clínico.
+func documentationReminder(p db.ListProfessionalsToNotifyRow, pending *PendingDocumentation) mailer.Message {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Hola %s,\n\nTenés documentación clínica pendiente en Psiconexo:\n", p.Name)
+
+	if n := len(pending.MissingNotes); n > 0 {
+		fmt.Fprintf(&b, "\nSesiones realizadas sin nota (%d):\n", n)
+		for _, a := range pending.MissingNotes[:min(n, reminderMaxListedRows)] {
+			fmt.Fprintf(&b, "- %s %s - %s\n", FormatARDate(a.Date), a.StartTime, a.ClientName)
+		}
+		if n > reminderMaxListedRows {
+			fmt.Fprintf(&b, "- y %d más\n", n-reminderMaxListedRows)
+		}
+	}
+
+	if n := len(pending.StaleDrafts); n > 0 {
+		fmt.Fprintf(&b, "\nBorradores sin firmar hace más de %d días (%d):\n", pending.DraftAgeDays, n)
+		for _, d := range pending.StaleDrafts[:min(n, reminderMaxListedRows)] {
+			fmt.Fprintf(&b, "- %s - %s\n", FormatARDate(d.CreatedAt.Time), d.ClientName)
+		}
+		if n > reminderMaxListedRows {
+			fmt.Fprintf(&b, "- y %d más\n", n-reminderMaxListedRows)
+		}
+	}
+
+	return mailer.Message{
+		To:      p.Email,
+		Subject: fmt.Sprintf("Tenés %d notas clínicas pendientes", pending.Total()),
+		Body:    b.String(),
+	}
+}
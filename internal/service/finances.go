@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/luciluz/psiconexo/internal/db"
+)
+
+const (
+	defaultFinancesPageSize = 50
+	maxFinancesPageSize     = 200
+)
+
+var ErrInvalidCursor = errors.New("cursor de paginación inválido")
+
+// FinancesFilter agrupa los filtros opcionales de la pantalla de Finanzas.
+// Los campos vacíos (nil / "") no filtran.
+type FinancesFilter struct {
+	ProfessionalID int64
+	StartDate      *time.Time
+	EndDate        *time.Time
+	ClientID       *int64
+	PaymentStatus  string
+	PaymentMethod  string
+	InvoiceStatus  string
+}
+
+type ListFinancesRequest struct {
+	FinancesFilter
+	Cursor string // Opaco: lo devuelve la página anterior en NextCursor
+	Limit  int
+}
+
+type FinancesPage struct {
+	Items      []db.GetFinancesDashboardRow `json:"items"`
+	Totals     db.GetFinancesTotalsRow      `json:"totals"`
+	NextCursor string                       `json:"next_cursor,omitempty"`
+}
+
+func (s *Service) ListFinances(ctx context.Context, req ListFinancesRequest) (*FinancesPage, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultFinancesPageSize
+	}
+	if limit > maxFinancesPageSize {
+		limit = maxFinancesPageSize
+	}
+
+	var cursorDate sql.NullTime
+	var cursorID sql.NullInt64
+	if req.Cursor != "" {
+		date, id, err := decodeFinancesCursor(req.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		cursorDate = sql.NullTime{Time: date, Valid: true}
+		cursorID = sql.NullInt64{Int64: id, Valid: true}
+	}
+
+	f := req.FinancesFilter
+	items, err := s.queries.GetFinancesDashboard(ctx, db.GetFinancesDashboardParams{
+		ProfessionalID: f.ProfessionalID,
+		StartDate:      nullTime(f.StartDate),
+		EndDate:        nullTime(f.EndDate),
+		ClientID:       nullInt64(f.ClientID),
+		PaymentStatus:  sql.NullString{String: f.PaymentStatus, Valid: f.PaymentStatus != ""},
+		PaymentMethod:  sql.NullString{String: f.PaymentMethod, Valid: f.PaymentMethod != ""},
+		InvoiceStatus:  sql.NullString{String: f.InvoiceStatus, Valid: f.InvoiceStatus != ""},
+		CursorDate:     cursorDate,
+		CursorID:       cursorID,
+		PageSize:       int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo finanzas: %w", err)
+	}
+
+	totals, err := s.queries.GetFinancesTotals(ctx, db.GetFinancesTotalsParams{
+		ProfessionalID: f.ProfessionalID,
+		StartDate:      nullTime(f.StartDate),
+		EndDate:        nullTime(f.EndDate),
+		ClientID:       nullInt64(f.ClientID),
+		PaymentStatus:  sql.NullString{String: f.PaymentStatus, Valid: f.PaymentStatus != ""},
+		PaymentMethod:  sql.NullString{String: f.PaymentMethod, Valid: f.PaymentMethod != ""},
+		InvoiceStatus:  sql.NullString{String: f.InvoiceStatus, Valid: f.InvoiceStatus != ""},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error calculando totales de finanzas: %w", err)
+	}
+
+	page := &FinancesPage{Items: items, Totals: totals}
+	if page.Items == nil {
+		page.Items = []db.GetFinancesDashboardRow{}
+	}
+	// Si la página vino llena puede haber más resultados
+	if len(items) == limit {
+		last := items[len(items)-1]
+		page.NextCursor = encodeFinancesCursor(last.Date, last.ID)
+	}
+
+	return page, nil
+}
+
+// GetFinancialSummary calcula los KPIs de Finanzas para el período [start, end].
+func (s *Service) GetFinancialSummary(ctx context.Context, profID int64, start, end time.Time) (*db.GetFinancialSummaryRow, error) {
+	summary, err := s.queries.GetFinancialSummary(ctx, db.GetFinancialSummaryParams{
+		ProfessionalID: profID,
+		StartDate:      start,
+		EndDate:        end,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error calculando resumen financiero: %w", err)
+	}
+	return &summary, nil
+}
+
+// El cursor es "YYYY-MM-DD|id" en base64 url-safe, para que el front lo trate como opaco.
+func encodeFinancesCursor(date time.Time, id int64) string {
+	raw := date.Format("2006-01-02") + "|" + strconv.FormatInt(id, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeFinancesCursor(cursor string) (time.Time, int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	datePart, idPart, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	date, err := time.Parse("2006-01-02", datePart)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	id, err := strconv.ParseInt(idPart, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	return date, id, nil
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{Valid: false}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
+
+func nullInt64(v *int64) sql.NullInt64 {
+	if v == nil {
+		return sql.NullInt64{Valid: false}
+	}
+	return sql.NullInt64{Int64: *v, Valid: true}
+}
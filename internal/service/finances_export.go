@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/luciluz/psiconexo/internal/db"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatXLSX = "xlsx"
+)
+
+var (
+	ErrUnknownExportColumn     = errors.New("columna de exportación desconocida")
+	ErrUnsupportedExportFormat = errors.New("formato de exportación no soportado (use csv o xlsx)")
+)
+
+type cellKind int
+
+const (
+	cellText cellKind = iota
+	cellMoney
+	cellDate
+)
+
+type exportColumn struct {
+	Header string
+	Kind   cellKind
+	Value  func(r db.ListFinancesForExportRow) any // string, float64 o time.Time según Kind
+}
+
+// Columnas disponibles para el contador. La key es la que se pasa en ?columns=
+var financeExportColumns = map[string]exportColumn{
+	"date":           {"Fecha", cellDate, func(r db.ListFinancesForExportRow) any { return r.Date }},
+	"time":           {"Hora", cellText, func(r db.ListFinancesForExportRow) any { return r.StartTime }},
+	"client":         {"Paciente", cellText, func(r db.ListFinancesForExportRow) any { return r.ClientName }},
+	"client_email":   {"Email", cellText, func(r db.ListFinancesForExportRow) any { return r.ClientEmail.String }},
+	"concept":        {"Concepto", cellText, func(r db.ListFinancesForExportRow) any { return r.Concept.String }},
+	"modality":       {"Modalidad", cellText, func(r db.ListFinancesForExportRow) any { return modalityLabels[r.Modality.String] }},
+	"duration":       {"Duración (min)", cellText, func(r db.ListFinancesForExportRow) any { return strconv.Itoa(int(r.DurationMinutes)) }},
+	"amount":         {"Monto", cellMoney, func(r db.ListFinancesForExportRow) any { return parseAmount(r.Price.String) }},
+	"payment_status": {"Estado de pago", cellText, func(r db.ListFinancesForExportRow) any { return paymentStatusLabels[r.PaymentStatus.String] }},
+	"payment_method": {"Medio de pago", cellText, func(r db.ListFinancesForExportRow) any { return paymentMethodLabels[r.PaymentMethod.String] }},
+	"paid_at": {"Fecha de pago", cellDate, func(r db.ListFinancesForExportRow) any {
+		if !r.PaymentConfirmedAt.Valid {
+			return time.Time{}
+		}
+		return r.PaymentConfirmedAt.Time
+	}},
+	"invoice_status": {"Estado factura", cellText, func(r db.ListFinancesForExportRow) any { return invoiceStatusLabels[r.InvoiceStatus.String] }},
+	"invoice_cae":    {"CAE", cellText, func(r db.ListFinancesForExportRow) any { return r.InvoiceCae.String }},
+	"invoice_url":    {"Factura", cellText, func(r db.ListFinancesForExportRow) any { return r.InvoiceUrl.String }},
+	"package": {"Paquete", cellText, func(r db.ListFinancesForExportRow) any {
+		if !r.PackageID.Valid {
+			return ""
+		}
+		return fmt.Sprintf("#%d", r.PackageID.Int64)
+	}},
+}
+
+var DefaultFinanceExportColumns = []string{
+	"date", "client", "concept", "amount", "payment_status", "payment_method", "paid_at", "invoice_status", "invoice_cae",
+}
+
+var (
+	paymentStatusLabels = map[string]string{"pending": "Pendiente", "paid": "Pagado", "refunded": "Reintegrado"}
+	paymentMethodLabels = map[string]string{"mercadopago": "Mercado Pago", "transfer": "Transferencia", "cash": "Efectivo", "insurance": "Obra social"}
+	invoiceStatusLabels = map[string]string{"pending": "Pendiente", "invoiced": "Facturado", "error": "Error"}
+	modalityLabels      = map[string]string{"virtual": "Virtual", "in_person": "Presencial", "home": "Domicilio"}
+)
+
+type ExportFinancesRequest struct {
+	ProfessionalID int64
+	StartDate      time.Time
+	EndDate        time.Time
+	Format         string
+	Columns        []string // Vacío = DefaultFinanceExportColumns
+}
+
+// ExportFinances escribe en w la planilla de finanzas del rango pedido.
+func (s *Service) ExportFinances(ctx context.Context, req ExportFinancesRequest, w io.Writer) error {
+	keys := req.Columns
+	if len(keys) == 0 {
+		keys = DefaultFinanceExportColumns
+	}
+	cols := make([]exportColumn, 0, len(keys))
+	for _, k := range keys {
+		col, ok := financeExportColumns[strings.TrimSpace(k)]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownExportColumn, k)
+		}
+		cols = append(cols, col)
+	}
+
+	if req.Format != ExportFormatCSV && req.Format != ExportFormatXLSX {
+		return ErrUnsupportedExportFormat
+	}
+
+	rows, err := s.queries.ListFinancesForExport(ctx, db.ListFinancesForExportParams{
+		ProfessionalID: req.ProfessionalID,
+		StartDate:      req.StartDate,
+		EndDate:        req.EndDate,
+	})
+	if err != nil {
+		return fmt.Errorf("error obteniendo datos para exportar: %w", err)
+	}
+
+	if req.Format == ExportFormatXLSX {
+		return writeFinancesXLSX(w, cols, rows)
+	}
+	return writeFinancesCSV(w, cols, rows)
+}
+
+// CSV con ";" como separador: Excel en configuración regional es-AR usa "," como decimal.
+func writeFinancesCSV(w io.Writer, cols []exportColumn, rows []db.ListFinancesForExportRow) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = ';'
+
+	header := make([]string, len(cols))
+	for i, col := range cols {
+		header[i] = col.Header
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	record := make([]string, len(cols))
+	for _, r := range rows {
+		for i, col := range cols {
+			switch v := col.Value(r).(type) {
+			case float64:
+				record[i] = FormatARS(v)
+			case time.Time:
+				record[i] = FormatARDate(v)
+			case string:
+				record[i] = v
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeFinancesXLSX(w io.Writer, cols []exportColumn, rows []db.ListFinancesForExportRow) error {
+	f := excelize.NewFile()
+	defer func() {
+		_ = f.Close()
+	}()
+
+	sheet := "Finanzas"
+	if err := f.SetSheetName("Sheet1", sheet); err != nil {
+		return err
+	}
+
+	headerStyle, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+	moneyFmt := `"$" #,##0.00`
+	moneyStyle, err := f.NewStyle(&excelize.Style{CustomNumFmt: &moneyFmt})
+	if err != nil {
+		return err
+	}
+	dateFmt := "dd/mm/yyyy"
+	dateStyle, err := f.NewStyle(&excelize.Style{CustomNumFmt: &dateFmt})
+	if err != nil {
+		return err
+	}
+
+	for i, col := range cols {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		if err := f.SetCellValue(sheet, cell, col.Header); err != nil {
+			return err
+		}
+		if err := f.SetCellStyle(sheet, cell, cell, headerStyle); err != nil {
+			return err
+		}
+	}
+
+	for r, row := range rows {
+		for i, col := range cols {
+			cell, _ := excelize.CoordinatesToCellName(i+1, r+2)
+			value := col.Value(row)
+			if t, ok := value.(time.Time); ok && t.IsZero() {
+				continue
+			}
+			if err := f.SetCellValue(sheet, cell, value); err != nil {
+				return err
+			}
+			switch col.Kind {
+			case cellMoney:
+				err = f.SetCellStyle(sheet, cell, cell, moneyStyle)
+			case cellDate:
+				err = f.SetCellStyle(sheet, cell, cell, dateStyle)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return f.Write(w)
+}
+
+// FormatARS formatea un monto con separador de miles "." y decimales ",": 1234.5 -> "1.234,50".
+func FormatARS(amount float64) string {
+	raw := strconv.FormatFloat(amount, 'f', 2, 64)
+	sign := ""
+	if strings.HasPrefix(raw, "-") {
+		sign, raw = "-", raw[1:]
+	}
+	intPart, decPart, _ := strings.Cut(raw, ".")
+
+	var b strings.Builder
+	for i, d := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(d)
+	}
+	return sign + b.String() + "," + decPart
+}
+
+// FormatARDate formatea una fecha como dd/mm/aaaa. La fecha cero queda vacía.
+func FormatARDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("02/01/2006")
+}
+
+// parseAmount convierte un DECIMAL de Postgres (string) a float64; vacío = 0.
+func parseAmount(s string) float64 {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatARS(t *testing.T) {
+	cases := []struct {
+		amount float64
+		want   string
+	}{
+		{0, "0,00"},
+		{5, "5,00"},
+		{0.5, "0,50"},
+		{999.99, "999,99"},
+		{1000, "1.000,00"},
+		{1234.5, "1.234,50"},
+		{123456.78, "123.456,78"},
+		{1234567.891, "1.234.567,89"},
+		{-1234.5, "-1.234,50"},
+		{-0.25, "-0,25"},
+	}
+
+	for _, tc := range cases {
+		if got := FormatARS(tc.amount); got != tc.want {
+			t.Fatalf("FormatARS(%v) = %q, se esperaba %q", tc.amount, got, tc.want)
+		}
+	}
+}
+
+func TestFormatARDate(t *testing.T) {
+	cases := []struct {
+		date time.Time
+		want string
+	}{
+		{time.Time{}, ""},
+		{time.Date(2030, 1, 7, 0, 0, 0, 0, time.UTC), "07/01/2030"},
+		{time.Date(2024, 12, 31, 23, 59, 0, 0, time.UTC), "31/12/2024"},
+	}
+
+	for _, tc := range cases {
+		if got := FormatARDate(tc.date); got != tc.want {
+			t.Fatalf("FormatARDate(%v) = %q, se esperaba %q", tc.date, got, tc.want)
+		}
+	}
+}
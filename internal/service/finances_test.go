@@ -0,0 +1,68 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFinancesCursorRoundTrip(t *testing.T) {
+	cases := []struct {
+		date time.Time
+		id   int64
+	}{
+		{time.Date(2030, 1, 7, 0, 0, 0, 0, time.UTC), 1},
+		{time.Date(1999, 12, 31, 0, 0, 0, 0, time.UTC), 9223372036854775807},
+		{time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), 42},
+	}
+
+	for _, tc := range cases {
+		cursor := encodeFinancesCursor(tc.date, tc.id)
+		date, id, err := decodeFinancesCursor(cursor)
+		if err != nil {
+			t.Fatalf("decodeFinancesCursor(%q): %v", cursor, err)
+		}
+		if !date.Equal(tc.date) || id != tc.id {
+			t.Fatalf("cursor %q: se esperaba %s|%d y volvió %s|%d", cursor, tc.date.Format("2006-01-02"), tc.id, date.Format("2006-01-02"), id)
+		}
+	}
+}
+
+func TestFinancesCursorIgnoresTimeOfDay(t *testing.T) {
+	cursor := encodeFinancesCursor(time.Date(2030, 1, 7, 18, 30, 0, 0, time.UTC), 5)
+	date, _, err := decodeFinancesCursor(cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2030, 1, 7, 0, 0, 0, 0, time.UTC); !date.Equal(want) {
+		t.Fatalf("se esperaba %s y volvió %s", want, date)
+	}
+}
+
+func TestDecodeFinancesCursorRejectsInvalid(t *testing.T) {
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+
+	cases := []struct {
+		name   string
+		cursor string
+	}{
+		{"vacío", ""},
+		{"no es base64", "%%%"},
+		{"sin separador", encode("2030-01-07")},
+		{"fecha inválida", encode("2030-13-01|1")},
+		{"fecha con otro formato", encode("07/01/2030|1")},
+		{"id no numérico", encode("2030-01-07|abc")},
+		{"id vacío", encode("2030-01-07|")},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, _, err := decodeFinancesCursor(tc.cursor); !errors.Is(err, ErrInvalidCursor) {
+				t.Fatalf("decodeFinancesCursor(%q): se esperaba ErrInvalidCursor y volvió %v", tc.cursor, err)
+			}
+		})
+	}
+}
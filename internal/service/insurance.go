@@ -0,0 +1,290 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/luciluz/psiconexo/internal/db"
+)
+
+var (
+	ErrAuthorizationRequired = errors.New("la obra social requiere número de autorización")
+	ErrCoverageMismatch      = errors.New("la cobertura no corresponde al paciente del turno o está inactiva")
+	ErrInactiveInsurer       = errors.New("la obra social está dada de baja")
+)
+
+type InsurerRequest struct {
+	ProfessionalID        int64
+	InsurerID             int64 // Sólo para actualizar
+	Name                  string
+	Kind                  string // obra_social | prepaga
+	Code                  string
+	SessionFee            float64
+	RequiresAuthorization bool
+	Active                bool
+}
+
+type CreateCoverageRequest struct {
+	ProfessionalID int64
+	ClientID       int64
+	InsurerID      int64
+	Plan           string
+	MemberNumber   string
+	CopayAmount    float64
+}
+
+type ApplyCoverageRequest struct {
+	ProfessionalID      int64
+	AppointmentID       int64
+	CoverageID          int64
+	AuthorizationNumber string
+}
+
+// InsurerPresentation es la presentación mensual a una obra social: lo que se le factura
+// a la obra social separado del coseguro cobrado a cada paciente.
+type InsurerPresentation struct {
+	InsurerID     int64                          `json:"insurer_id"`
+	InsurerName   string                         `json:"insurer_name"`
+	InsurerCode   string                         `json:"insurer_code"`
+	StartDate     time.Time                      `json:"start_date"`
+	EndDate       time.Time                      `json:"end_date"`
+	SessionsCount int                            `json:"sessions_count"`
+	TotalInsurer  float64                        `json:"total_insurer"`
+	TotalCopay    float64                        `json:"total_copay"`
+	Items         []db.GetInsurerPresentationRow `json:"items"`
+}
+
+func (s *Service) CreateInsurer(ctx context.Context, req InsurerRequest) (*db.Insurer, error) {
+	insurer, err := s.queries.CreateInsurer(ctx, db.CreateInsurerParams{
+		ProfessionalID:        req.ProfessionalID,
+		Name:                  req.Name,
+		Kind:                  sql.NullString{String: req.Kind, Valid: req.Kind != ""},
+		Code:                  sql.NullString{String: req.Code, Valid: req.Code != ""},
+		SessionFee:            fmt.Sprintf("%.2f", req.SessionFee),
+		RequiresAuthorization: sql.NullBool{Bool: req.RequiresAuthorization, Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creando obra social: %w", err)
+	}
+	return &insurer, nil
+}
+
+func (s *Service) UpdateInsurer(ctx context.Context, req InsurerRequest) (*db.Insurer, error) {
+	if _, err := s.getOwnedInsurer(ctx, req.ProfessionalID, req.InsurerID); err != nil {
+		return nil, err
+	}
+
+	insurer, err := s.queries.UpdateInsurer(ctx, db.UpdateInsurerParams{
+		Name:                  req.Name,
+		Kind:                  sql.NullString{String: req.Kind, Valid: req.Kind != ""},
+		Code:                  sql.NullString{String: req.Code, Valid: req.Code != ""},
+		SessionFee:            fmt.Sprintf("%.2f", req.SessionFee),
+		RequiresAuthorization: sql.NullBool{Bool: req.RequiresAuthorization, Valid: true},
+		Active:                sql.NullBool{Bool: req.Active, Valid: true},
+		ID:                    req.InsurerID,
+		ProfessionalID:        req.ProfessionalID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("error actualizando obra social: %w", err)
+	}
+	return &insurer, nil
+}
+
+func (s *Service) ListInsurers(ctx context.Context, profID int64) ([]db.Insurer, error) {
+	insurers, err := s.queries.ListInsurers(ctx, profID)
+	if err != nil {
+		return nil, fmt.Errorf("error listando obras sociales: %w", err)
+	}
+	return insurers, nil
+}
+
+func (s *Service) CreateCoverage(ctx context.Context, req CreateCoverageRequest) (*db.ClientCoverage, error) {
+	if _, err := s.getOwnedClient(ctx, req.ProfessionalID, req.ClientID); err != nil {
+		return nil, err
+	}
+	if _, err := s.getOwnedInsurer(ctx, req.ProfessionalID, req.InsurerID); err != nil {
+		return nil, err
+	}
+
+	coverage, err := s.queries.CreateClientCoverage(ctx, db.CreateClientCoverageParams{
+		ProfessionalID: req.ProfessionalID,
+		ClientID:       req.ClientID,
+		InsurerID:      req.InsurerID,
+		Plan:           sql.NullString{String: req.Plan, Valid: req.Plan != ""},
+		MemberNumber:   req.MemberNumber,
+		CopayAmount:    fmt.Sprintf("%.2f", req.CopayAmount),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creando cobertura: %w", err)
+	}
+	return &coverage, nil
+}
+
+func (s *Service) ListClientCoverages(ctx context.Context, profID, clientID int64) ([]db.ListClientCoveragesRow, error) {
+	coverages, err := s.queries.ListClientCoverages(ctx, db.ListClientCoveragesParams{
+		ProfessionalID: profID,
+		ClientID:       clientID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listando coberturas: %w", err)
+	}
+	return coverages, nil
+}
+
+// ApplyCoverage imputa un turno a la obra social del paciente: congela el arancel vigente
+// y el coseguro, y deja el precio del turno como la suma de ambos.
+func (s *Service) ApplyCoverage(ctx context.Context, req ApplyCoverageRequest) (*db.AppointmentCoverage, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	qtx := s.queries.WithTx(tx)
+
+	appt, err := qtx.GetAppointment(ctx, db.GetAppointmentParams{ID: req.AppointmentID, ProfessionalID: req.ProfessionalID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("error obteniendo turno: %w", err)
+	}
+
+	coverage, err := qtx.GetClientCoverage(ctx, db.GetClientCoverageParams{ID: req.CoverageID, ProfessionalID: req.ProfessionalID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("error obteniendo cobertura: %w", err)
+	}
+	if coverage.ClientID != appt.ClientID || !coverage.Active.Bool {
+		return nil, ErrCoverageMismatch
+	}
+
+	insurer, err := qtx.GetInsurer(ctx, db.GetInsurerParams{ID: coverage.InsurerID, ProfessionalID: req.ProfessionalID})
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo obra social: %w", err)
+	}
+	if !insurer.Active.Bool {
+		return nil, ErrInactiveInsurer
+	}
+	if insurer.RequiresAuthorization.Bool && req.AuthorizationNumber == "" {
+		return nil, ErrAuthorizationRequired
+	}
+
+	applied, err := qtx.UpsertAppointmentCoverage(ctx, db.UpsertAppointmentCoverageParams{
+		AppointmentID:       appt.ID,
+		CoverageID:          coverage.ID,
+		InsurerID:           insurer.ID,
+		AuthorizationNumber: sql.NullString{String: req.AuthorizationNumber, Valid: req.AuthorizationNumber != ""},
+		InsurerAmount:       insurer.SessionFee,
+		CopayAmount:         coverage.CopayAmount,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error imputando turno a la obra social: %w", err)
+	}
+
+	total := parseAmount(insurer.SessionFee) + parseAmount(coverage.CopayAmount)
+	if _, err := qtx.SetAppointmentInsuranceBilling(ctx, db.SetAppointmentInsuranceBillingParams{
+		Price:          sql.NullString{String: fmt.Sprintf("%.2f", total), Valid: true},
+		ID:             appt.ID,
+		ProfessionalID: req.ProfessionalID,
+	}); err != nil {
+		return nil, fmt.Errorf("error actualizando precio del turno: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &applied, nil
+}
+
+// GetInsurerPresentation arma la presentación de la obra social para el mes de `month`.
+func (s *Service) GetInsurerPresentation(ctx context.Context, profID, insurerID int64, month time.Time) (*InsurerPresentation, error) {
+	insurer, err := s.getOwnedInsurer(ctx, profID, insurerID)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, -1)
+
+	items, err := s.queries.GetInsurerPresentation(ctx, db.GetInsurerPresentationParams{
+		ProfessionalID: profID,
+		InsurerID:      insurerID,
+		StartDate:      start,
+		EndDate:        end,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error armando presentación: %w", err)
+	}
+
+	p := &InsurerPresentation{
+		InsurerID:     insurer.ID,
+		InsurerName:   insurer.Name,
+		InsurerCode:   insurer.Code.String,
+		StartDate:     start,
+		EndDate:       end,
+		SessionsCount: len(items),
+		Items:         items,
+	}
+	if p.Items == nil {
+		p.Items = []db.GetInsurerPresentationRow{}
+	}
+	for _, it := range items {
+		p.TotalInsurer = roundCents(p.TotalInsurer + parseAmount(it.InsurerAmount))
+		p.TotalCopay = roundCents(p.TotalCopay + parseAmount(it.CopayAmount))
+	}
+
+	return p, nil
+}
+
+// WritePresentationCSV genera la planilla para adjuntar a la presentación (formato es-AR).
+func WritePresentationCSV(p *InsurerPresentation, w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = ';'
+
+	if err := cw.Write([]string{"Fecha", "Paciente", "Plan", "N° Afiliado", "Autorización", "Importe obra social", "Coseguro"}); err != nil {
+		return err
+	}
+	for _, it := range p.Items {
+		if err := cw.Write([]string{
+			FormatARDate(it.Date),
+			it.ClientName,
+			it.Plan.String,
+			it.MemberNumber,
+			it.AuthorizationNumber.String,
+			FormatARS(parseAmount(it.InsurerAmount)),
+			FormatARS(parseAmount(it.CopayAmount)),
+		}); err != nil {
+			return err
+		}
+	}
+	if err := cw.Write([]string{"Total", "", "", "", "", FormatARS(p.TotalInsurer), FormatARS(p.TotalCopay)}); err != nil {
+		return err
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func (s *Service) getOwnedInsurer(ctx context.Context, profID, insurerID int64) (*db.Insurer, error) {
+	insurer, err := s.queries.GetInsurer(ctx, db.GetInsurerParams{ID: insurerID, ProfessionalID: profID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("error obteniendo obra social: %w", err)
+	}
+	return &insurer, nil
+}
@@ -0,0 +1,355 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/luciluz/psiconexo/internal/db"
+)
+
+type RegisterPaymentRequest struct {
+	ProfessionalID int64
+	ClientID       int64
+	AppointmentID  *int64 // Opcional: si viene, el turno queda marcado como pagado/reintegrado
+	Kind           string // payment | refund
+	Amount         float64
+	Method         string
+	PaidAt         time.Time
+	Notes          string
+}
+
+type CreateFeeRequest struct {
+	ProfessionalID int64
+	ClientID       int64
+	AppointmentID  *int64
+	Kind           string // late_cancellation | other
+	Amount         float64
+	Date           time.Time
+	Description    string
+}
+
+type StatementEntry struct {
+	Date        time.Time `json:"date"`
+	Type        string    `json:"type"` // charge | payment | refund | late_cancellation | other
+	SourceID    int64     `json:"source_id"`
+	Description string    `json:"description"`
+	Debit       float64   `json:"debit"`
+	Credit      float64   `json:"credit"`
+	Balance     float64   `json:"balance"`
+}
+
+// ClientStatement es el estado de cuenta de un paciente para un período.
+// Balance positivo = el paciente adeuda; negativo = saldo a favor.
+type ClientStatement struct {
+	ProfessionalID   int64            `json:"professional_id"`
+	ProfessionalName string           `json:"professional_name"`
+	ClientID         int64            `json:"client_id"`
+	ClientName       string           `json:"client_name"`
+	StartDate        time.Time        `json:"start_date"`
+	EndDate          time.Time        `json:"end_date"`
+	OpeningBalance   float64          `json:"opening_balance"`
+	TotalDebit       float64          `json:"total_debit"`
+	TotalCredit      float64          `json:"total_credit"`
+	ClosingBalance   float64          `json:"closing_balance"`
+	Entries          []StatementEntry `json:"entries"`
+}
+
+func (s *Service) RegisterPayment(ctx context.Context, req RegisterPaymentRequest) (*db.ClientPayment, error) {
+	if _, err := s.getOwnedClient(ctx, req.ProfessionalID, req.ClientID); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	qtx := s.queries.WithTx(tx)
+
+	var appID sql.NullInt64
+	if req.AppointmentID != nil {
+		appt, err := qtx.GetAppointment(ctx, db.GetAppointmentParams{ID: *req.AppointmentID, ProfessionalID: req.ProfessionalID})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, ErrNotFound
+			}
+			return nil, fmt.Errorf("error obteniendo turno: %w", err)
+		}
+		if appt.ClientID != req.ClientID {
+			return nil, ErrNotFound
+		}
+		appID = sql.NullInt64{Int64: appt.ID, Valid: true}
+
+		status := "paid"
+		if req.Kind == "refund" {
+			status = "refunded"
+
+			// Si el turno figuraba pagado sin movimiento propio, la vista deducía el pago del
+			// estado. Al pasar a 'refunded' ese crédito desaparecería y el reintegro quedaría
+			// contado de más: se asienta primero el pago original.
+			implicit, err := qtx.GetImplicitAppointmentPayment(ctx, db.GetImplicitAppointmentPaymentParams{
+				ID:             appt.ID,
+				ProfessionalID: req.ProfessionalID,
+			})
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return nil, fmt.Errorf("error obteniendo pago del turno: %w", err)
+			}
+			if err == nil {
+				if _, err := qtx.CreateClientPayment(ctx, db.CreateClientPaymentParams{
+					ProfessionalID: req.ProfessionalID,
+					ClientID:       req.ClientID,
+					AppointmentID:  appID,
+					Kind:           "payment",
+					Amount:         implicit.Amount,
+					Method:         appt.PaymentMethod,
+					PaidAt:         implicit.PaidAt,
+				}); err != nil {
+					return nil, fmt.Errorf("error registrando pago original del turno: %w", err)
+				}
+			}
+		}
+
+		method := appt.PaymentMethod
+		if req.Method != "" {
+			method = sql.NullString{String: req.Method, Valid: true}
+		}
+		_, err = qtx.UpdateAppointmentPayment(ctx, db.UpdateAppointmentPaymentParams{
+			PaymentStatus:   sql.NullString{String: status, Valid: true},
+			PaymentMethod:   method,
+			PaymentProofUrl: appt.PaymentProofUrl,
+			ID:              appt.ID,
+			ProfessionalID:  req.ProfessionalID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error actualizando pago del turno: %w", err)
+		}
+	}
+
+	paidAt := req.PaidAt
+	if paidAt.IsZero() {
+		paidAt = time.Now()
+	}
+
+	payment, err := qtx.CreateClientPayment(ctx, db.CreateClientPaymentParams{
+		ProfessionalID: req.ProfessionalID,
+		ClientID:       req.ClientID,
+		AppointmentID:  appID,
+		Kind:           req.Kind,
+		Amount:         fmt.Sprintf("%.2f", req.Amount),
+		Method:         sql.NullString{String: req.Method, Valid: req.Method != ""},
+		PaidAt:         paidAt,
+		Notes:          sql.NullString{String: req.Notes, Valid: req.Notes != ""},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error registrando pago: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (s *Service) ListPayments(ctx context.Context, profID, clientID int64) ([]db.ClientPayment, error) {
+	payments, err := s.queries.ListClientPayments(ctx, db.ListClientPaymentsParams{
+		ProfessionalID: profID,
+		ClientID:       clientID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listando pagos: %w", err)
+	}
+	return payments, nil
+}
+
+func (s *Service) CreateFee(ctx context.Context, req CreateFeeRequest) (*db.ClientFee, error) {
+	if _, err := s.getOwnedClient(ctx, req.ProfessionalID, req.ClientID); err != nil {
+		return nil, err
+	}
+	if req.AppointmentID != nil {
+		if _, err := s.getOwnedAppointment(ctx, req.ProfessionalID, req.ClientID, *req.AppointmentID); err != nil {
+			return nil, err
+		}
+	}
+
+	date := req.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	fee, err := s.queries.CreateClientFee(ctx, db.CreateClientFeeParams{
+		ProfessionalID: req.ProfessionalID,
+		ClientID:       req.ClientID,
+		AppointmentID:  nullInt64(req.AppointmentID),
+		Kind:           req.Kind,
+		Amount:         fmt.Sprintf("%.2f", req.Amount),
+		Date:           date,
+		Description:    sql.NullString{String: req.Description, Valid: req.Description != ""},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error registrando cargo: %w", err)
+	}
+	return &fee, nil
+}
+
+// ListClientBalances devuelve los pacientes con saldo distinto de cero.
+func (s *Service) ListClientBalances(ctx context.Context, profID int64) ([]db.ListClientBalancesRow, error) {
+	balances, err := s.queries.ListClientBalances(ctx, profID)
+	if err != nil {
+		return nil, fmt.Errorf("error calculando saldos: %w", err)
+	}
+	return balances, nil
+}
+
+// GetClientStatement arma el estado de cuenta: saldo inicial (todo lo anterior a start)
+// y los movimientos del período con el saldo acumulado.
+func (s *Service) GetClientStatement(ctx context.Context, profID, clientID int64, start, end time.Time) (*ClientStatement, error) {
+	client, err := s.getOwnedClient(ctx, profID, clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	prof, err := s.queries.GetProfessional(ctx, profID)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo profesional: %w", err)
+	}
+
+	ledger, err := s.queries.ListClientLedger(ctx, db.ListClientLedgerParams{
+		ProfessionalID: profID,
+		ClientID:       clientID,
+		EndDate:        end,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo cuenta corriente: %w", err)
+	}
+
+	st := &ClientStatement{
+		ProfessionalID:   profID,
+		ProfessionalName: prof.Name,
+		ClientID:         clientID,
+		ClientName:       client.Name,
+		StartDate:        start,
+		EndDate:          end,
+		Entries:          []StatementEntry{},
+	}
+
+	balance := 0.0
+	for _, l := range ledger {
+		debit, credit := parseAmount(l.Debit), parseAmount(l.Credit)
+		balance = roundCents(balance + debit - credit)
+
+		if l.EntryDate.Before(start) {
+			st.OpeningBalance = balance
+			continue
+		}
+
+		st.TotalDebit = roundCents(st.TotalDebit + debit)
+		st.TotalCredit = roundCents(st.TotalCredit + credit)
+		st.Entries = append(st.Entries, StatementEntry{
+			Date:        l.EntryDate,
+			Type:        l.EntryType,
+			SourceID:    l.SourceID,
+			Description: l.Description,
+			Debit:       debit,
+			Credit:      credit,
+			Balance:     balance,
+		})
+	}
+	st.ClosingBalance = balance
+
+	return st, nil
+}
+
+// RenderStatementPDF genera la versión imprimible del estado de cuenta para enviar al paciente.
+func RenderStatementPDF(st *ClientStatement, w io.Writer) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("") // UTF-8 -> cp1252 para tildes y ñ
+	pdf.SetMargins(12, 15, 12)
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.CellFormat(0, 8, tr(fmt.Sprintf("Página %d de {nb}", pdf.PageNo())), "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 10, "Estado de cuenta", "", 1, "L", false, 0, "")
+
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(0, 6, tr("Profesional: "+st.ProfessionalName), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, tr("Paciente: "+st.ClientName), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, tr(fmt.Sprintf("Período: %s al %s", FormatARDate(st.StartDate), FormatARDate(st.EndDate))), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	widths := []float64{24, 82, 26, 26, 28}
+	headers := []string{"Fecha", "Concepto", "Debe", "Haber", "Saldo"}
+
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.SetFillColor(230, 230, 230)
+	for i, h := range headers {
+		align := "R"
+		if i < 2 {
+			align = "L"
+		}
+		pdf.CellFormat(widths[i], 7, h, "B", 0, align, true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.CellFormat(widths[0], 6, "", "", 0, "L", false, 0, "")
+	pdf.CellFormat(widths[1], 6, "Saldo anterior", "", 0, "L", false, 0, "")
+	pdf.CellFormat(widths[2]+widths[3], 6, "", "", 0, "R", false, 0, "")
+	pdf.CellFormat(widths[4], 6, "$ "+FormatARS(st.OpeningBalance), "", 1, "R", false, 0, "")
+
+	for _, e := range st.Entries {
+		pdf.CellFormat(widths[0], 6, FormatARDate(e.Date), "", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[1], 6, tr(truncateRunes(e.Description, 48)), "", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[2], 6, formatOptionalAmount(e.Debit), "", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[3], 6, formatOptionalAmount(e.Credit), "", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[4], 6, "$ "+FormatARS(e.Balance), "", 1, "R", false, 0, "")
+	}
+
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.CellFormat(widths[0]+widths[1], 7, "Totales", "T", 0, "L", false, 0, "")
+	pdf.CellFormat(widths[2], 7, "$ "+FormatARS(st.TotalDebit), "T", 0, "R", false, 0, "")
+	pdf.CellFormat(widths[3], 7, "$ "+FormatARS(st.TotalCredit), "T", 0, "R", false, 0, "")
+	pdf.CellFormat(widths[4], 7, "", "T", 1, "R", false, 0, "")
+	pdf.Ln(4)
+
+	label := "Saldo adeudado"
+	if st.ClosingBalance < 0 {
+		label = "Saldo a favor"
+	}
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(0, 8, tr(fmt.Sprintf("%s al %s: $ %s", label, FormatARDate(st.EndDate), FormatARS(math.Abs(st.ClosingBalance)))), "", 1, "R", false, 0, "")
+
+	return pdf.Output(w)
+}
+
+func formatOptionalAmount(v float64) string {
+	if v == 0 {
+		return ""
+	}
+	return "$ " + FormatARS(v)
+}
+
+func truncateRunes(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max-1]) + "…"
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,338 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/luciluz/psiconexo/internal/db"
+	"github.com/luciluz/psiconexo/internal/keyring"
+)
+
+var ErrEncryptionUnavailable = errors.New("el cifrado de la historia clínica no está configurado")
+
+// KeyRotationStats resume una corrida de rotate-note-keys.
+type KeyRotationStats struct {
+	Rewrapped int // Registros que pasaron a la versión vigente de la clave maestra
+	Encrypted int // Registros que estaban en claro (previos al cifrado del servidor)
+	Skipped   int // Registros modificados durante la rotación; se toman en la próxima corrida
+}
+
+// noteAAD liga el contenido cifrado al profesional y paciente dueños de la nota.
+func noteAAD(profID, clientID int64) []byte {
+	return fmt.Appendf(nil, "clinical_note:%d:%d", profID, clientID)
+}
+
+func (s *Service) sealNote(profID, clientID int64, content string) (string, sql.NullInt32, error) {
+	if s.keys == nil {
+		return "", sql.NullInt32{}, ErrEncryptionUnavailable
+	}
+	sealed, version, err := s.keys.Seal([]byte(content), noteAAD(profID, clientID))
+	if err != nil {
+		return "", sql.NullInt32{}, fmt.Errorf("error cifrando nota: %w", err)
+	}
+	return sealed, sql.NullInt32{Int32: version, Valid: true}, nil
+}
+
+// sectionsAAD distingue las secciones estructuradas del texto libre del mismo registro.
+func sectionsAAD(aad []byte) []byte {
+	return append(aad[:len(aad):len(aad)], ":sections"...)
+}
+
+// sealSections cifra los valores de una nota estructurada. Devuelve también el JSON en claro
+// para responder sin volver a descifrar.
+func (s *Service) sealSections(profID, clientID int64, templateID sql.NullInt64, values map[string]any) (sql.NullString, sql.NullString, error) {
+	if !templateID.Valid {
+		return sql.NullString{}, sql.NullString{}, nil
+	}
+	if values == nil {
+		values = map[string]any{}
+	}
+	if s.keys == nil {
+		return sql.NullString{}, sql.NullString{}, ErrEncryptionUnavailable
+	}
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return sql.NullString{}, sql.NullString{}, err
+	}
+	sealed, _, err := s.keys.Seal(raw, sectionsAAD(noteAAD(profID, clientID)))
+	if err != nil {
+		return sql.NullString{}, sql.NullString{}, fmt.Errorf("error cifrando secciones: %w", err)
+	}
+	return sql.NullString{String: sealed, Valid: true}, sql.NullString{String: string(raw), Valid: true}, nil
+}
+
+// openNote reemplaza en el lugar el contenido cifrado por el texto plano.
+// Las notas anteriores al cifrado del servidor se devuelven tal cual.
+func (s *Service) openNote(note *db.ClinicalNote) error {
+	aad := noteAAD(note.ProfessionalID, note.ClientID)
+	content, err := s.openField(note.Content, note.KeyVersion, aad)
+	if err != nil {
+		return fmt.Errorf("error descifrando nota %d: %w", note.ID, err)
+	}
+	note.Content = content
+
+	if note.Sections.Valid {
+		sections, err := s.openField(note.Sections.String, note.KeyVersion, sectionsAAD(aad))
+		if err != nil {
+			return fmt.Errorf("error descifrando secciones de la nota %d: %w", note.ID, err)
+		}
+		note.Sections.String = sections
+	}
+	return nil
+}
+
+// openField descifra un campo si está cifrado; el texto previo al cifrado se devuelve tal cual.
+func (s *Service) openField(value string, version sql.NullInt32, aad []byte) (string, error) {
+	if !keyring.IsSealed(value) {
+		return value, nil
+	}
+	if s.keys == nil {
+		return "", ErrEncryptionUnavailable
+	}
+	plain, err := s.keys.Open(value, version.Int32, aad)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+func (s *Service) openAddendum(a *db.ClinicalNoteAddenda) error {
+	content, err := s.openField(a.Content, a.KeyVersion, addendumAAD(a.NoteID, a.ProfessionalID))
+	if err != nil {
+		return fmt.Errorf("error descifrando adenda %d: %w", a.ID, err)
+	}
+	a.Content = content
+	return nil
+}
+
+// addendumAAD liga la adenda a su nota y a quien la firmó.
+func addendumAAD(noteID, profID int64) []byte {
+	return fmt.Appendf(nil, "clinical_note_addendum:%d:%d", noteID, profID)
+}
+
+// revisionAAD liga la versión anterior a su nota.
+func revisionAAD(noteID int64) []byte {
+	return fmt.Appendf(nil, "clinical_note_revision:%d", noteID)
+}
+
+func (s *Service) openRevision(r *db.ClinicalNoteRevision) error {
+	aad := revisionAAD(r.NoteID)
+	content, err := s.openField(r.Content, r.KeyVersion, aad)
+	if err != nil {
+		return fmt.Errorf("error descifrando revisión %d: %w", r.ID, err)
+	}
+	r.Content = content
+
+	if r.Sections.Valid {
+		sections, err := s.openField(r.Sections.String, r.KeyVersion, sectionsAAD(aad))
+		if err != nil {
+			return fmt.Errorf("error descifrando revisión %d: %w", r.ID, err)
+		}
+		r.Sections.String = sections
+	}
+	return nil
+}
+
+// sealedRecord es cualquier fila con contenido cifrado por el keyring (notas, adendas, ...).
+type sealedRecord struct {
+	ID         int64
+	Content    string
+	Sections   sql.NullString // Sólo notas y revisiones estructuradas
+	KeyVersion sql.NullInt32
+	AAD        []byte
+}
+
+// sealedTable describe cómo listar por lotes y re-guardar las filas cifradas de una tabla.
+type sealedTable struct {
+	list func(ctx context.Context, afterID int64, current int32, batchSize int32) ([]sealedRecord, error)
+	// save sólo escribe si la fila sigue como se leyó (versión y contenido): si no, devuelve 0
+	save func(ctx context.Context, r sealedRecord, content string, sections sql.NullString, newVersion sql.NullInt32) (int64, error)
+}
+
+func (s *Service) sealedTables() []sealedTable {
+	return []sealedTable{
+		{
+			list: func(ctx context.Context, afterID int64, current, batchSize int32) ([]sealedRecord, error) {
+				notes, err := s.queries.ListClinicalNotesForRotation(ctx, db.ListClinicalNotesForRotationParams{
+					AfterID: afterID, KeyVersion: current, BatchSize: batchSize,
+				})
+				records := make([]sealedRecord, len(notes))
+				for i, n := range notes {
+					records[i] = sealedRecord{n.ID, n.Content, n.Sections, n.KeyVersion, noteAAD(n.ProfessionalID, n.ClientID)}
+				}
+				return records, err
+			},
+			save: func(ctx context.Context, r sealedRecord, content string, sections sql.NullString, newVersion sql.NullInt32) (int64, error) {
+				return s.queries.RewrapClinicalNote(ctx, db.RewrapClinicalNoteParams{
+					Content: content, Sections: sections, NewKeyVersion: newVersion, ID: r.ID, OldKeyVersion: r.KeyVersion,
+					OldContent: r.Content, OldSections: r.Sections,
+				})
+			},
+		},
+		{
+			list: func(ctx context.Context, afterID int64, current, batchSize int32) ([]sealedRecord, error) {
+				addenda, err := s.queries.ListNoteAddendaForRotation(ctx, db.ListNoteAddendaForRotationParams{
+					AfterID: afterID, KeyVersion: current, BatchSize: batchSize,
+				})
+				records := make([]sealedRecord, len(addenda))
+				for i, a := range addenda {
+					records[i] = sealedRecord{a.ID, a.Content, sql.NullString{}, a.KeyVersion, addendumAAD(a.NoteID, a.ProfessionalID)}
+				}
+				return records, err
+			},
+			save: func(ctx context.Context, r sealedRecord, content string, _ sql.NullString, newVersion sql.NullInt32) (int64, error) {
+				return s.queries.RewrapNoteAddendum(ctx, db.RewrapNoteAddendumParams{
+					Content: content, NewKeyVersion: newVersion, ID: r.ID, OldKeyVersion: r.KeyVersion, OldContent: r.Content,
+				})
+			},
+		},
+		{
+			list: func(ctx context.Context, afterID int64, current, batchSize int32) ([]sealedRecord, error) {
+				revisions, err := s.queries.ListNoteRevisionsForRotation(ctx, db.ListNoteRevisionsForRotationParams{
+					AfterID: afterID, KeyVersion: current, BatchSize: batchSize,
+				})
+				records := make([]sealedRecord, len(revisions))
+				for i, r := range revisions {
+					records[i] = sealedRecord{r.ID, r.Content, r.Sections, r.KeyVersion, revisionAAD(r.NoteID)}
+				}
+				return records, err
+			},
+			save: func(ctx context.Context, r sealedRecord, content string, sections sql.NullString, newVersion sql.NullInt32) (int64, error) {
+				return s.queries.RewrapNoteRevision(ctx, db.RewrapNoteRevisionParams{
+					Content: content, Sections: sections, NewKeyVersion: newVersion, ID: r.ID, OldKeyVersion: r.KeyVersion,
+					OldContent: r.Content, OldSections: r.Sections,
+				})
+			},
+		},
+		{
+			// De los adjuntos sólo se re-envuelve la clave del archivo; el blob no se toca.
+			list: func(ctx context.Context, afterID int64, current, batchSize int32) ([]sealedRecord, error) {
+				attachments, err := s.queries.ListAttachmentsForRotation(ctx, db.ListAttachmentsForRotationParams{
+					AfterID: afterID, KeyVersion: current, BatchSize: batchSize,
+				})
+				records := make([]sealedRecord, len(attachments))
+				for i, a := range attachments {
+					records[i] = sealedRecord{a.ID, a.DataKey, sql.NullString{}, a.KeyVersion, attachmentKeyAAD(a.StorageKey)}
+				}
+				return records, err
+			},
+			save: func(ctx context.Context, r sealedRecord, content string, _ sql.NullString, newVersion sql.NullInt32) (int64, error) {
+				return s.queries.RewrapAttachmentKey(ctx, db.RewrapAttachmentKeyParams{
+					DataKey: content, NewKeyVersion: newVersion, ID: r.ID, OldKeyVersion: r.KeyVersion, OldDataKey: r.Content,
+				})
+			},
+		},
+		{
+			list: func(ctx context.Context, afterID int64, current, batchSize int32) ([]sealedRecord, error) {
+				secrets, err := s.queries.ListTwoFactorForRotation(ctx, db.ListTwoFactorForRotationParams{
+					AfterID: afterID, KeyVersion: current, BatchSize: batchSize,
+				})
+				records := make([]sealedRecord, len(secrets))
+				for i, tf := range secrets {
+					records[i] = sealedRecord{tf.ID, tf.Secret, sql.NullString{}, tf.KeyVersion, twoFactorAAD(tf.ProfessionalID)}
+				}
+				return records, err
+			},
+			save: func(ctx context.Context, r sealedRecord, content string, _ sql.NullString, newVersion sql.NullInt32) (int64, error) {
+				return s.queries.RewrapTwoFactorSecret(ctx, db.RewrapTwoFactorSecretParams{
+					Secret: content, NewKeyVersion: newVersion, ID: r.ID, OldKeyVersion: r.KeyVersion, OldSecret: r.Content,
+				})
+			},
+		},
+		{
+			list: func(ctx context.Context, afterID int64, current, batchSize int32) ([]sealedRecord, error) {
+				forms, err := s.queries.ListClientFormsForRotation(ctx, db.ListClientFormsForRotationParams{
+					AfterID: afterID, KeyVersion: current, BatchSize: batchSize,
+				})
+				records := make([]sealedRecord, len(forms))
+				for i, f := range forms {
+					records[i] = sealedRecord{f.ID, f.Answers.String, sql.NullString{}, f.KeyVersion, clientFormAAD(f.ID, f.ClientID)}
+				}
+				return records, err
+			},
+			save: func(ctx context.Context, r sealedRecord, content string, _ sql.NullString, newVersion sql.NullInt32) (int64, error) {
+				return s.queries.RewrapClientFormAnswers(ctx, db.RewrapClientFormAnswersParams{
+					Answers: content, NewKeyVersion: newVersion, ID: r.ID, OldKeyVersion: r.KeyVersion, OldAnswers: r.Content,
+				})
+			},
+		},
+	}
+}
+
+// RotateNoteKeys re-envuelve con la clave maestra vigente todo el contenido de la historia
+// clínica (notas, adendas, revisiones, claves de adjuntos y formularios del paciente) y los
+// secretos de la verificación en dos pasos cifrados con una versión anterior, y cifra lo que
+// quedó en claro.
+// Procesa por lotes de batchSize.
+func (s *Service) RotateNoteKeys(ctx context.Context, batchSize int) (*KeyRotationStats, error) {
+	if s.keys == nil {
+		return nil, ErrEncryptionUnavailable
+	}
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	stats := &KeyRotationStats{}
+	for _, table := range s.sealedTables() {
+		if err := s.rotateTable(ctx, table, int32(batchSize), stats); err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+func (s *Service) rotateTable(ctx context.Context, table sealedTable, batchSize int32, stats *KeyRotationStats) error {
+	current := s.keys.CurrentVersion()
+	var afterID int64
+
+	for {
+		records, err := table.list(ctx, afterID, current, batchSize)
+		if err != nil {
+			return fmt.Errorf("error listando datos a rotar: %w", err)
+		}
+		if len(records) == 0 {
+			return nil
+		}
+
+		for _, r := range records {
+			afterID = r.ID
+
+			content, version, err := s.rotateField(r.Content, r.KeyVersion, r.AAD)
+			if err != nil {
+				return fmt.Errorf("error rotando registro %d: %w", r.ID, err)
+			}
+			sections := r.Sections
+			if sections.Valid {
+				if sections.String, _, err = s.rotateField(sections.String, r.KeyVersion, sectionsAAD(r.AAD)); err != nil {
+					return fmt.Errorf("error rotando registro %d: %w", r.ID, err)
+				}
+			}
+			encrypted := !keyring.IsSealed(r.Content)
+
+			n, err := table.save(ctx, r, content, sections, sql.NullInt32{Int32: version, Valid: true})
+			if err != nil {
+				return fmt.Errorf("error guardando registro %d: %w", r.ID, err)
+			}
+
+			switch {
+			case n == 0:
+				stats.Skipped++
+			case encrypted:
+				stats.Encrypted++
+			default:
+				stats.Rewrapped++
+			}
+		}
+	}
+}
+
+// rotateField re-envuelve un campo cifrado o cifra uno que estaba en claro.
+func (s *Service) rotateField(value string, version sql.NullInt32, aad []byte) (string, int32, error) {
+	if keyring.IsSealed(value) {
+		return s.keys.Rewrap(value, version.Int32)
+	}
+	return s.keys.Seal([]byte(value), aad)
+}
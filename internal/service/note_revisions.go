@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/luciluz/psiconexo/internal/db"
+)
+
+// CurrentRevision identifica a la versión vigente de la nota al pedir un diff.
+const CurrentRevision = 0
+
+// maxDiffLines acota el tramo editado que se compara (sin el principio ni el final en común):
+// la tabla del diff crece con el producto de las líneas de cada lado.
+const maxDiffLines = 2000
+
+var ErrDiffTooLarge = errors.New("las versiones difieren en demasiadas líneas para compararlas")
+
+type DiffLine struct {
+	Op   string `json:"op"` // equal | insert | delete
+	Text string `json:"text"`
+}
+
+// NoteDiff compara dos versiones de una nota línea por línea.
+type NoteDiff struct {
+	NoteID  int64      `json:"note_id"`
+	From    int32      `json:"from"`
+	To      int32      `json:"to"` // 0 = versión vigente
+	Added   int        `json:"added"`
+	Removed int        `json:"removed"`
+	Lines   []DiffLine `json:"lines"`
+}
+
+func (s *Service) ListNoteRevisions(ctx context.Context, profID, noteID int64) ([]db.ListNoteRevisionsRow, error) {
+	if err := requireOwner(ctx); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.getOwnedNote(ctx, profID, noteID); err != nil {
+		return nil, err
+	}
+
+	revisions, err := s.queries.ListNoteRevisions(ctx, noteID)
+	if err != nil {
+		return nil, fmt.Errorf("error listando revisiones: %w", err)
+	}
+	return revisions, nil
+}
+
+func (s *Service) GetNoteRevision(ctx context.Context, profID, noteID int64, number int32) (*db.ClinicalNoteRevision, error) {
+	if err := requireOwner(ctx); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.getOwnedNote(ctx, profID, noteID); err != nil {
+		return nil, err
+	}
+	return s.getRevision(ctx, noteID, number)
+}
+
+// DiffNoteRevisions compara las versiones `from` y `to` (CurrentRevision = la vigente).
+func (s *Service) DiffNoteRevisions(ctx context.Context, profID, noteID int64, from, to int32) (*NoteDiff, error) {
+	if err := requireOwner(ctx); err != nil {
+		return nil, err
+	}
+
+	note, err := s.getOwnedNote(ctx, profID, noteID)
+	if err != nil {
+		return nil, err
+	}
+
+	contentOf := func(number int32) (string, error) {
+		if number == CurrentRevision {
+			if err := s.openNote(note); err != nil {
+				return "", err
+			}
+			return note.Content, nil
+		}
+		rev, err := s.getRevision(ctx, noteID, number)
+		if err != nil {
+			return "", err
+		}
+		return rev.Content, nil
+	}
+
+	oldText, err := contentOf(from)
+	if err != nil {
+		return nil, err
+	}
+	newText, err := contentOf(to)
+	if err != nil {
+		return nil, err
+	}
+
+	lines, err := diffLines(oldText, newText)
+	if err != nil {
+		return nil, err
+	}
+	d := &NoteDiff{NoteID: noteID, From: from, To: to, Lines: lines}
+	for _, l := range d.Lines {
+		switch l.Op {
+		case "insert":
+			d.Added++
+		case "delete":
+			d.Removed++
+		}
+	}
+	return d, nil
+}
+
+func (s *Service) getRevision(ctx context.Context, noteID int64, number int32) (*db.ClinicalNoteRevision, error) {
+	rev, err := s.queries.GetNoteRevision(ctx, db.GetNoteRevisionParams{
+		NoteID:         noteID,
+		RevisionNumber: number,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("error obteniendo revisión: %w", err)
+	}
+	if err := s.openRevision(&rev); err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
+
+// diffLines arma el diff por líneas a partir de la subsecuencia común más larga. El principio y
+// el final que no cambiaron se recortan antes: la tabla O(n*m) es sólo para el tramo editado,
+// y si ese tramo pasa de maxDiffLines líneas de un lado devuelve ErrDiffTooLarge.
+func diffLines(oldText, newText string) ([]DiffLine, error) {
+	a := strings.Split(oldText, "\n")
+	b := strings.Split(newText, "\n")
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	lines := make([]DiffLine, 0, max(len(a), len(b)))
+	for _, l := range a[:prefix] {
+		lines = append(lines, DiffLine{"equal", l})
+	}
+
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(midA) > maxDiffLines || len(midB) > maxDiffLines {
+		return nil, ErrDiffTooLarge
+	}
+
+	lcs := make([][]int32, len(midA)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(midB)+1)
+	}
+	for i := len(midA) - 1; i >= 0; i-- {
+		for j := len(midB) - 1; j >= 0; j-- {
+			if midA[i] == midB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(midA) && j < len(midB) {
+		switch {
+		case midA[i] == midB[j]:
+			lines = append(lines, DiffLine{"equal", midA[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, DiffLine{"delete", midA[i]})
+			i++
+		default:
+			lines = append(lines, DiffLine{"insert", midB[j]})
+			j++
+		}
+	}
+	for ; i < len(midA); i++ {
+		lines = append(lines, DiffLine{"delete", midA[i]})
+	}
+	for ; j < len(midB); j++ {
+		lines = append(lines, DiffLine{"insert", midB[j]})
+	}
+
+	for _, l := range a[len(a)-suffix:] {
+		lines = append(lines, DiffLine{"equal", l})
+	}
+	return lines, nil
+}
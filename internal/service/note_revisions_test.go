@@ -0,0 +1,66 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	cases := []struct {
+		name    string
+		oldText string
+		newText string
+		want    []DiffLine
+	}{
+		{"iguales", "a\nb", "a\nb", []DiffLine{{"equal", "a"}, {"equal", "b"}}},
+		{"línea agregada al final", "a\nb", "a\nb\nc", []DiffLine{{"equal", "a"}, {"equal", "b"}, {"insert", "c"}}},
+		{"línea borrada al principio", "a\nb\nc", "b\nc", []DiffLine{{"delete", "a"}, {"equal", "b"}, {"equal", "c"}}},
+		{"línea cambiada en el medio", "a\nb\nc", "a\nx\nc", []DiffLine{{"equal", "a"}, {"delete", "b"}, {"insert", "x"}, {"equal", "c"}}},
+		{"cambios separados", "a\nb\nc\nd\ne", "a\nB\nc\nd\nE", []DiffLine{
+			{"equal", "a"}, {"delete", "b"}, {"insert", "B"}, {"equal", "c"}, {"equal", "d"}, {"delete", "e"}, {"insert", "E"},
+		}},
+		{"de vacío a texto", "", "a", []DiffLine{{"delete", ""}, {"insert", "a"}}},
+		// El principio y el final en común no se solapan aunque se repitan líneas
+		{"línea repetida", "a\na", "a\na\na", []DiffLine{{"equal", "a"}, {"equal", "a"}, {"insert", "a"}}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := diffLines(tc.oldText, tc.newText)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Fatalf("se esperaba %v y dio %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestDiffLinesLimit(t *testing.T) {
+	lines := func(prefix string, n int) []string {
+		out := make([]string, n)
+		for i := range out {
+			out[i] = prefix + strings.Repeat("x", i%7)
+		}
+		return out
+	}
+	common := strings.Join(lines("igual ", 3*maxDiffLines), "\n")
+
+	// Un cambio chico en una nota larga: sólo se compara el tramo editado
+	got, err := diffLines(common+"\nantes\n"+common, common+"\ndespués\n"+common)
+	if err != nil {
+		t.Fatalf("un cambio de una línea en una nota larga devolvió %v", err)
+	}
+	if len(got) != 6*maxDiffLines+2 {
+		t.Fatalf("se esperaban %d líneas y dio %d", 6*maxDiffLines+2, len(got))
+	}
+
+	// Reescrita entera: el tramo distinto supera el máximo
+	rewritten := strings.Join(lines("otra ", maxDiffLines+1), "\n")
+	if _, err := diffLines(common, rewritten); !errors.Is(err, ErrDiffTooLarge) {
+		t.Fatalf("se esperaba ErrDiffTooLarge y volvió %v", err)
+	}
+}
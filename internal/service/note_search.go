@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/luciluz/psiconexo/internal/db"
+)
+
+var (
+	ErrSearchUnavailable = errors.New("la búsqueda en notas no está configurada (falta NOTES_INDEX_KEY)")
+	ErrEmptySearch       = errors.New("la búsqueda necesita al menos una palabra de 3 letras o más")
+)
+
+const (
+	searchMinTermRunes = 3
+	searchMaxTerms     = 8
+	searchMaxResults   = 50
+)
+
+// Palabras demasiado frecuentes para servir de filtro; no se indexan.
+var searchStopwords = map[string]bool{
+	"que": true, "los": true, "las": true, "del": true, "por": true, "con": true, "una": true,
+	"uno": true, "para": true, "como": true, "pero": true, "sus": true, "mas": true, "este": true,
+	"esta": true, "esto": true, "ese": true, "esa": true, "eso": true, "muy": true, "sin": true,
+	"sobre": true, "tambien": true, "fue": true, "era": true, "hay": true, "son": true, "ser": true,
+	"porque": true, "cuando": true, "donde": true, "entre": true, "hasta": true, "desde": true,
+	"todo": true, "nos": true, "les": true, "ella": true, "ello": true, "mismo": true, "otro": true,
+	"otra": true, "solo": true, "tiene": true,
+}
+
+var accentFolding = strings.NewReplacer(
+	"á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u", "ñ", "n",
+)
+
+type SearchNotesRequest struct {
+	ProfessionalID int64
+	ClientID       *int64 // Opcional: sólo las notas de un paciente
+	Query          string
+}
+
+// SearchClinicalNotes busca notas que contengan todas las palabras de la consulta usando el
+// índice ciego: se comparan HMACs, así que ni la base ni los logs ven el texto buscado.
+func (s *Service) SearchClinicalNotes(ctx context.Context, req SearchNotesRequest) ([]db.ClinicalNote, error) {
+	if err := requireOwner(ctx); err != nil {
+		return nil, err
+	}
+
+	if s.keys == nil || !s.keys.HasIndexKey() {
+		return nil, ErrSearchUnavailable
+	}
+
+	terms := searchTerms(req.Query)
+	if len(terms) == 0 {
+		return nil, ErrEmptySearch
+	}
+	if len(terms) > searchMaxTerms {
+		terms = terms[:searchMaxTerms]
+	}
+	tokens, err := s.blindTokens(req.ProfessionalID, terms)
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := s.queries.SearchClinicalNotes(ctx, db.SearchClinicalNotesParams{
+		ProfessionalID: req.ProfessionalID,
+		ClientID:       nullInt64(req.ClientID),
+		Tokens:         tokens,
+		TokenCount:     int32(len(tokens)),
+		MaxResults:     searchMaxResults,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error buscando notas: %w", err)
+	}
+	for i := range notes {
+		if err := s.openNote(&notes[i]); err != nil {
+			return nil, err
+		}
+	}
+	return notes, nil
+}
+
+// ReindexNotes reconstruye el índice de búsqueda de todas las notas (incluye sus adendas).
+// Se usa al activar la búsqueda sobre notas ya existentes o si cambia NOTES_INDEX_KEY.
+func (s *Service) ReindexNotes(ctx context.Context, batchSize int) (int, error) {
+	if s.keys == nil || !s.keys.HasIndexKey() {
+		return 0, ErrSearchUnavailable
+	}
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	indexed := 0
+	var afterID int64
+	for {
+		notes, err := s.queries.ListNotesForIndexing(ctx, db.ListNotesForIndexingParams{
+			AfterID:   afterID,
+			BatchSize: int32(batchSize),
+		})
+		if err != nil {
+			return indexed, fmt.Errorf("error listando notas: %w", err)
+		}
+		if len(notes) == 0 {
+			return indexed, nil
+		}
+
+		for i := range notes {
+			note := &notes[i]
+			afterID = note.ID
+			if err := s.openNote(note); err != nil {
+				return indexed, err
+			}
+
+			parts := []string{note.Content, storedSectionsText(note.Sections)}
+			addenda, err := s.queries.ListNoteAddenda(ctx, note.ID)
+			if err != nil {
+				return indexed, fmt.Errorf("error obteniendo adendas: %w", err)
+			}
+			for j := range addenda {
+				if err := s.openAddendum(&addenda[j]); err != nil {
+					return indexed, err
+				}
+				parts = append(parts, addenda[j].Content)
+			}
+
+			if err := s.indexNote(ctx, s.queries, note.ProfessionalID, note.ID, strings.Join(parts, "\n"), true); err != nil {
+				return indexed, err
+			}
+			indexed++
+		}
+	}
+}
+
+// indexNote guarda los tokens de text para la nota. replace borra antes los anteriores (edición);
+// sin replace se suman (adendas). Si la búsqueda no está configurada no hace nada: las notas se
+// pueden indexar después con el comando reindex-notes.
+func (s *Service) indexNote(ctx context.Context, q *db.Queries, profID, noteID int64, text string, replace bool) error {
+	if s.keys == nil || !s.keys.HasIndexKey() {
+		return nil
+	}
+
+	if replace {
+		if err := q.DeleteNoteSearchTokens(ctx, noteID); err != nil {
+			return fmt.Errorf("error limpiando índice de la nota %d: %w", noteID, err)
+		}
+	}
+
+	tokens, err := s.blindTokens(profID, searchTerms(text))
+	if err != nil || len(tokens) == 0 {
+		return err
+	}
+	if err := q.AddNoteSearchTokens(ctx, db.AddNoteSearchTokensParams{NoteID: noteID, Tokens: tokens}); err != nil {
+		return fmt.Errorf("error indexando nota %d: %w", noteID, err)
+	}
+	return nil
+}
+
+// blindTokens usa al profesional como scope: la misma palabra genera tokens distintos en
+// consultorios distintos, así no se pueden cruzar frecuencias entre cuentas.
+func (s *Service) blindTokens(profID int64, terms []string) ([]string, error) {
+	scope := "prof:" + strconv.FormatInt(profID, 10)
+	tokens := make([]string, 0, len(terms))
+	for _, t := range terms {
+		tok, err := s.keys.BlindToken(scope, t)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, tok)
+	}
+	return tokens, nil
+}
+
+// searchTerms normaliza el texto en palabras únicas: minúsculas, sin tildes, sin stopwords ni
+// palabras cortas, y reducidas a una raíz simple (stemTerm).
+func searchTerms(text string) []string {
+	words := strings.FieldsFunc(accentFolding.Replace(strings.ToLower(text)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	seen := make(map[string]bool, len(words))
+	terms := make([]string, 0, len(words))
+	for _, w := range words {
+		if searchStopwords[w] {
+			continue
+		}
+		w = stemTerm(w)
+		if len([]rune(w)) < searchMinTermRunes || searchStopwords[w] {
+			continue
+		}
+		if !seen[w] {
+			seen[w] = true
+			terms = append(terms, w)
+		}
+	}
+	return terms
+}
+
+// stemTerm recorta plural y vocal final para que singular y plural coincidan:
+// "sesiones" y "sesion" -> "sesion", "padres" y "padre" -> "padr".
+func stemTerm(w string) string {
+	switch {
+	case len(w) > 5 && strings.HasSuffix(w, "es"):
+		w = strings.TrimSuffix(w, "es")
+	case len(w) > 4 && strings.HasSuffix(w, "s"):
+		w = strings.TrimSuffix(w, "s")
+	}
+	if len(w) > 4 && strings.ContainsAny(w[len(w)-1:], "aeo") {
+		w = w[:len(w)-1]
+	}
+	return w
+}
+
+// sectionsText junta los valores de texto de una nota estructurada para indexarlos.
+func sectionsText(values map[string]any) string {
+	var parts []string
+	for _, v := range values {
+		if str, ok := v.(string); ok {
+			parts = append(parts, str)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+func storedSectionsText(sections sql.NullString) string {
+	if !sections.Valid {
+		return ""
+	}
+	var values map[string]any
+	if err := json.Unmarshal([]byte(sections.String), &values); err != nil {
+		return ""
+	}
+	return sectionsText(values)
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestStemTerm(t *testing.T) {
+	cases := []struct {
+		word string
+		want string
+	}{
+		{"sesiones", "sesion"},
+		{"sesion", "sesion"},
+		{"padres", "padr"},
+		{"padre", "padr"},
+		{"miedos", "mied"},
+		{"miedo", "mied"},
+		{"casas", "casa"},
+		{"casa", "casa"},
+		{"ansiedad", "ansiedad"},
+		{"crisis", "crisi"},
+		{"mes", "mes"},
+		{"", ""},
+	}
+
+	for _, tc := range cases {
+		if got := stemTerm(tc.word); got != tc.want {
+			t.Fatalf("stemTerm(%q) = %q, se esperaba %q", tc.word, got, tc.want)
+		}
+	}
+}
+
+func TestSearchTerms(t *testing.T) {
+	cases := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"vacío", "", []string{}},
+		{"sólo stopwords y palabras cortas", "que los de a y", []string{}},
+		{"singular y plural coinciden", "Sesiones con los padres", []string{"sesion", "padr"}},
+		{"sin repetidos ni mayúsculas", "Ansiedad, ANSIEDAD y ansiedad.", []string{"ansiedad"}},
+		{"sin tildes", "Refiere pérdida de apetito", []string{"refier", "perdid", "apetit"}},
+		{"eñe y números cortos", "Niño de 12 años", []string{"nino", "anos"}},
+		{"separadores", "miedo/miedos;angustia", []string{"mied", "angusti"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := searchTerms(tc.text); !slices.Equal(got, tc.want) {
+				t.Fatalf("searchTerms(%q) = %q, se esperaba %q", tc.text, got, tc.want)
+			}
+		})
+	}
+}
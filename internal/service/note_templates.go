@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/luciluz/psiconexo/internal/db"
+)
+
+var (
+	ErrInvalidTemplate         = errors.New("plantilla inválida")
+	ErrInvalidSectionValue     = errors.New("valor de sección inválido")
+	ErrMissingRequiredSections = errors.New("faltan completar secciones obligatorias")
+	ErrTemplateInactive        = errors.New("la plantilla está desactivada")
+)
+
+const (
+	SectionText     = "text"
+	SectionLongText = "long_text"
+	SectionNumber   = "number"
+	SectionBoolean  = "boolean"
+	SectionDate     = "date"
+	SectionSelect   = "select"
+	SectionScale    = "scale" // Entero entre Min y Max (ej: riesgo 0-10)
+)
+
+// TemplateSection define un campo de la plantilla. Es lo que se guarda en note_templates.sections.
+type TemplateSection struct {
+	Key      string   `json:"key"`
+	Label    string   `json:"label"`
+	Type     string   `json:"type"`
+	Required bool     `json:"required"`
+	Options  []string `json:"options,omitempty"` // Para select
+	Min      *float64 `json:"min,omitempty"`     // Para scale
+	Max      *float64 `json:"max,omitempty"`
+}
+
+type NoteTemplateRequest struct {
+	ProfessionalID int64
+	TemplateID     int64 // Sólo para actualizar
+	Name           string
+	Kind           string // soap | intake | discharge | risk_assessment | custom
+	Sections       []TemplateSection
+	Active         bool
+}
+
+// noteTemplatePresets son las plantillas de partida: si al crear una plantilla no se mandan
+// secciones, se usan las del tipo elegido.
+var noteTemplatePresets = map[string][]TemplateSection{
+	"soap": {
+		{Key: "subjective", Label: "Subjetivo", Type: SectionLongText, Required: true},
+		{Key: "objective", Label: "Objetivo", Type: SectionLongText, Required: true},
+		{Key: "assessment", Label: "Evaluación", Type: SectionLongText, Required: true},
+		{Key: "plan", Label: "Plan", Type: SectionLongText, Required: true},
+	},
+	"intake": {
+		{Key: "reason", Label: "Motivo de consulta", Type: SectionLongText, Required: true},
+		{Key: "referral", Label: "Derivado por", Type: SectionText},
+		{Key: "history", Label: "Antecedentes", Type: SectionLongText},
+		{Key: "family", Label: "Grupo familiar", Type: SectionLongText},
+		{Key: "medication", Label: "Medicación actual", Type: SectionText},
+		{Key: "previous_treatment", Label: "Tratamientos previos", Type: SectionBoolean},
+		{Key: "initial_impression", Label: "Impresión diagnóstica inicial", Type: SectionLongText, Required: true},
+	},
+	"discharge": {
+		{Key: "discharge_date", Label: "Fecha de alta", Type: SectionDate, Required: true},
+		{Key: "reason", Label: "Motivo del alta", Type: SectionSelect, Required: true,
+			Options: []string{"Objetivos cumplidos", "Derivación", "Abandono", "Decisión del paciente", "Otro"}},
+		{Key: "summary", Label: "Resumen del tratamiento", Type: SectionLongText, Required: true},
+		{Key: "recommendations", Label: "Recomendaciones", Type: SectionLongText},
+	},
+	"risk_assessment": {
+		{Key: "suicidal_ideation", Label: "Ideación suicida", Type: SectionBoolean, Required: true},
+		{Key: "self_harm", Label: "Autolesiones", Type: SectionBoolean, Required: true},
+		{Key: "harm_to_others", Label: "Riesgo para terceros", Type: SectionBoolean, Required: true},
+		{Key: "risk_level", Label: "Nivel de riesgo (0-10)", Type: SectionScale, Required: true, Min: ptr(0.0), Max: ptr(10.0)},
+		{Key: "protective_factors", Label: "Factores protectores", Type: SectionLongText},
+		{Key: "action_plan", Label: "Plan de acción", Type: SectionLongText, Required: true},
+	},
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// NoteTemplatePresets devuelve las secciones predefinidas por tipo de plantilla.
+func NoteTemplatePresets() map[string][]TemplateSection {
+	return noteTemplatePresets
+}
+
+func (s *Service) CreateNoteTemplate(ctx context.Context, req NoteTemplateRequest) (*db.NoteTemplate, error) {
+	if err := requireOwner(ctx); err != nil {
+		return nil, err
+	}
+
+	sections, err := templateSectionsJSON(req)
+	if err != nil {
+		return nil, err
+	}
+
+	tpl, err := s.queries.CreateNoteTemplate(ctx, db.CreateNoteTemplateParams{
+		ProfessionalID: req.ProfessionalID,
+		Name:           req.Name,
+		Kind:           sql.NullString{String: req.Kind, Valid: req.Kind != ""},
+		Sections:       sections,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creando plantilla: %w", err)
+	}
+	return &tpl, nil
+}
+
+func (s *Service) UpdateNoteTemplate(ctx context.Context, req NoteTemplateRequest) (*db.NoteTemplate, error) {
+	if err := requireOwner(ctx); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.getOwnedTemplate(ctx, req.ProfessionalID, req.TemplateID); err != nil {
+		return nil, err
+	}
+
+	sections, err := templateSectionsJSON(req)
+	if err != nil {
+		return nil, err
+	}
+
+	tpl, err := s.queries.UpdateNoteTemplate(ctx, db.UpdateNoteTemplateParams{
+		Name:           req.Name,
+		Kind:           sql.NullString{String: req.Kind, Valid: req.Kind != ""},
+		Sections:       sections,
+		Active:         sql.NullBool{Bool: req.Active, Valid: true},
+		ID:             req.TemplateID,
+		ProfessionalID: req.ProfessionalID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("error actualizando plantilla: %w", err)
+	}
+	return &tpl, nil
+}
+
+func (s *Service) ListNoteTemplates(ctx context.Context, profID int64) ([]db.NoteTemplate, error) {
+	if err := requireOwner(ctx); err != nil {
+		return nil, err
+	}
+
+	templates, err := s.queries.ListNoteTemplates(ctx, profID)
+	if err != nil {
+		return nil, fmt.Errorf("error listando plantillas: %w", err)
+	}
+	return templates, nil
+}
+
+func (s *Service) getOwnedTemplate(ctx context.Context, profID, templateID int64) (*db.NoteTemplate, error) {
+	tpl, err := s.queries.GetNoteTemplate(ctx, db.GetNoteTemplateParams{ID: templateID, ProfessionalID: profID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("error obteniendo plantilla: %w", err)
+	}
+	return &tpl, nil
+}
+
+func templateSectionsJSON(req NoteTemplateRequest) (string, error) {
+	sections := req.Sections
+	if len(sections) == 0 {
+		sections = noteTemplatePresets[req.Kind]
+	}
+	if err := validateTemplateSections(sections); err != nil {
+		return "", err
+	}
+	raw, err := json.Marshal(sections)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
+func validateTemplateSections(sections []TemplateSection) error {
+	if len(sections) == 0 {
+		return fmt.Errorf("%w: debe tener al menos una sección", ErrInvalidTemplate)
+	}
+	seen := make(map[string]bool, len(sections))
+	for _, sec := range sections {
+		if sec.Key == "" || sec.Label == "" {
+			return fmt.Errorf("%w: cada sección necesita key y label", ErrInvalidTemplate)
+		}
+		if seen[sec.Key] {
+			return fmt.Errorf("%w: sección repetida %q", ErrInvalidTemplate, sec.Key)
+		}
+		seen[sec.Key] = true
+
+		switch sec.Type {
+		case SectionText, SectionLongText, SectionNumber, SectionBoolean, SectionDate:
+		case SectionSelect:
+			if len(sec.Options) == 0 {
+				return fmt.Errorf("%w: la sección %q no tiene opciones", ErrInvalidTemplate, sec.Key)
+			}
+		case SectionScale:
+			if sec.Min == nil || sec.Max == nil || *sec.Min >= *sec.Max {
+				return fmt.Errorf("%w: la escala %q necesita min < max", ErrInvalidTemplate, sec.Key)
+			}
+		default:
+			return fmt.Errorf("%w: tipo desconocido %q", ErrInvalidTemplate, sec.Type)
+		}
+	}
+	return nil
+}
+
+func parseTemplateSections(tpl *db.NoteTemplate) ([]TemplateSection, error) {
+	var sections []TemplateSection
+	if err := json.Unmarshal(tpl.Sections, &sections); err != nil {
+		return nil, fmt.Errorf("plantilla %d corrupta: %w", tpl.ID, err)
+	}
+	return sections, nil
+}
+
+// validateSectionValues controla que cada valor respete el tipo de su sección. Los borradores
+// pueden quedar incompletos; las obligatorias se exigen recién al firmar (missingSections).
+func validateSectionValues(sections []TemplateSection, values map[string]any) error {
+	defs := make(map[string]TemplateSection, len(sections))
+	for _, sec := range sections {
+		defs[sec.Key] = sec
+	}
+
+	for key, value := range values {
+		sec, ok := defs[key]
+		if !ok {
+			return fmt.Errorf("%w: la plantilla no tiene la sección %q", ErrInvalidSectionValue, key)
+		}
+		if isEmptySectionValue(value) {
+			continue
+		}
+
+		valid := false
+		switch sec.Type {
+		case SectionText, SectionLongText:
+			_, valid = value.(string)
+		case SectionNumber:
+			_, valid = value.(float64)
+		case SectionBoolean:
+			_, valid = value.(bool)
+		case SectionDate:
+			if str, ok := value.(string); ok {
+				_, err := time.Parse("2006-01-02", str)
+				valid = err == nil
+			}
+		case SectionSelect:
+			if str, ok := value.(string); ok {
+				valid = slices.Contains(sec.Options, str)
+			}
+		case SectionScale:
+			if n, ok := value.(float64); ok {
+				valid = n == math.Trunc(n) && n >= *sec.Min && n <= *sec.Max
+			}
+		}
+		if !valid {
+			return fmt.Errorf("%w: %q (%s)", ErrInvalidSectionValue, sec.Label, sec.Type)
+		}
+	}
+	return nil
+}
+
+// missingSections devuelve las etiquetas de las secciones obligatorias sin completar.
+func missingSections(sections []TemplateSection, values map[string]any) []string {
+	var missing []string
+	for _, sec := range sections {
+		if sec.Required && isEmptySectionValue(values[sec.Key]) {
+			missing = append(missing, sec.Label)
+		}
+	}
+	return missing
+}
+
+func isEmptySectionValue(v any) bool {
+	if v == nil {
+		return true
+	}
+	if str, ok := v.(string); ok {
+		return strings.TrimSpace(str) == ""
+	}
+	return false
+}
+
+// renderNoteText arma el texto comparable de una nota (secciones + cuerpo) para los diffs.
+func renderNoteText(content string, sections sql.NullString) string {
+	if !sections.Valid || sections.String == "" {
+		return content
+	}
+	var values map[string]any
+	if err := json.Unmarshal([]byte(sections.String), &values); err != nil {
+		return content
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&b, "[%s] %v\n", k, values[k])
+	}
+	if content != "" {
+		b.WriteString("\n" + content)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,627 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/luciluz/psiconexo/internal/auth"
+	"github.com/luciluz/psiconexo/internal/db"
+	"github.com/luciluz/psiconexo/internal/mailer"
+)
+
+var (
+	ErrInvalidMemberRole     = errors.New("rol inválido (use member o admin)")
+	ErrNoOrganization        = errors.New("no pertenece a ninguna organización")
+	ErrAlreadyInOrganization = errors.New("el profesional ya pertenece a una organización")
+	ErrLastOrganizationAdmin = errors.New("la organización necesita al menos un administrador")
+	ErrInvalidOrganization   = errors.New("datos de la organización inválidos")
+)
+
+const (
+	MemberRoleMember = "member"
+	MemberRoleAdmin  = "admin"
+)
+
+// maxOrganizationCalendarDays limita la agenda del centro a un mes por consulta.
+const maxOrganizationCalendarDays = 31
+
+// organizationInvitationTTL da tiempo a que el invitado lo vea y lo consulte.
+const organizationInvitationTTL = 7 * 24 * time.Hour
+
+var brandColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+type CreateOrganizationRequest struct {
+	ProfessionalID   int64 // Queda como administrador
+	Name             string
+	RequireTwoFactor bool
+}
+
+// UpdateOrganizationRequest reemplaza la identidad y la configuración común del centro. Los
+// valores de agenda en nil no se imponen: cada profesional usa los suyos o los del sistema.
+type UpdateOrganizationRequest struct {
+	ProfessionalID          int64
+	Name                    string
+	RequireTwoFactor        bool
+	LogoURL                 string
+	BrandColor              string
+	Address                 string
+	Phone                   string
+	Website                 string
+	DefaultDurationMinutes  *int
+	BufferMinutes           *int
+	TimeIncrementMinutes    *int
+	MinBookingNoticeHours   *int
+	BookingRequiresApproval *bool
+}
+
+type AddOrganizationMemberRequest struct {
+	ProfessionalID int64 // El administrador que lo invita
+	Email          string
+	Role           string
+}
+
+// OrganizationView es la organización como la ve uno de sus profesionales.
+type OrganizationView struct {
+	db.Organization
+	Role    string                          `json:"role"` // El del profesional que consulta
+	Members []db.ListOrganizationMembersRow `json:"members"`
+}
+
+// OrganizationCalendarEntry es un turno en la agenda del centro. El paciente sólo figura para
+// su profesional y para los administradores (la recepción); el resto ve el horario ocupado.
+type OrganizationCalendarEntry struct {
+	ID               int64  `json:"id"`
+	ProfessionalID   int64  `json:"professional_id"`
+	ProfessionalName string `json:"professional_name"`
+	ClientID         int64  `json:"client_id,omitempty"`
+	ClientName       string `json:"client_name,omitempty"`
+	Date             string `json:"date"` // YYYY-MM-DD
+	StartTime        string `json:"start_time"`
+	DurationMinutes  int32  `json:"duration_minutes"`
+	Modality         string `json:"modality,omitempty"`
+	Status           string `json:"status"`
+}
+
+// OrganizationFinances son los KPIs de Finanzas de cada profesional y los del centro.
+type OrganizationFinances struct {
+	StartDate     string                                  `json:"start_date"`
+	EndDate       string                                  `json:"end_date"`
+	Professionals []db.GetOrganizationFinancialSummaryRow `json:"professionals"`
+	Totals        OrganizationFinanceTotals               `json:"totals"`
+}
+
+type OrganizationFinanceTotals struct {
+	SessionsCount     int64  `json:"sessions_count"`
+	Income            string `json:"income"`
+	PendingCollection string `json:"pending_collection"`
+	PendingInvoicing  string `json:"pending_invoicing"`
+}
+
+// CreateOrganization da de alta un centro o equipo. requireTwoFactor obliga a todos sus
+// profesionales a usar verificación en dos pasos.
+func (s *Service) CreateOrganization(ctx context.Context, name string, requireTwoFactor bool) (*db.Organization, error) {
+	org, err := s.queries.CreateOrganization(ctx, db.CreateOrganizationParams{
+		Name:             strings.TrimSpace(name),
+		RequireTwoFactor: requireTwoFactor,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creando organización: %w", err)
+	}
+	return &org, nil
+}
+
+// SetOrganizationTwoFactor activa o quita la exigencia de 2FA. Quien no la tenga configurada
+// deberá darla de alta al renovar la sesión o en el próximo login.
+func (s *Service) SetOrganizationTwoFactor(ctx context.Context, orgID int64, required bool) error {
+	updated, err := s.queries.SetOrganizationTwoFactor(ctx, db.SetOrganizationTwoFactorParams{
+		RequireTwoFactor: required,
+		ID:               orgID,
+	})
+	if err != nil {
+		return fmt.Errorf("error actualizando organización: %w", err)
+	}
+	if updated == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// AddOrganizationMember suma al profesional (por email) a la organización.
+func (s *Service) AddOrganizationMember(ctx context.Context, orgID int64, email, role string) error {
+	if role != "member" && role != "admin" {
+		return ErrInvalidMemberRole
+	}
+	prof, err := s.queries.GetProfessionalByEmail(ctx, normalizeEmail(email))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("error obteniendo profesional: %w", err)
+	}
+
+	if err := s.queries.AddOrganizationMember(ctx, db.AddOrganizationMemberParams{
+		OrganizationID: orgID,
+		ProfessionalID: prof.ID,
+		Role:           role,
+	}); err != nil {
+		if isForeignKeyViolation(err) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("error agregando profesional a la organización: %w", err)
+	}
+	return nil
+}
+
+// CreateOwnOrganization da de alta un centro con el profesional que lo crea como administrador.
+func (s *Service) CreateOwnOrganization(ctx context.Context, req CreateOrganizationRequest) (*OrganizationView, error) {
+	if err := requireOwner(ctx); err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: el nombre es obligatorio", ErrInvalidOrganization)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	qtx := s.queries.WithTx(tx)
+
+	org, err := qtx.CreateOrganization(ctx, db.CreateOrganizationParams{
+		Name:             name,
+		RequireTwoFactor: req.RequireTwoFactor,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creando organización: %w", err)
+	}
+	added, err := qtx.JoinOrganization(ctx, db.JoinOrganizationParams{
+		OrganizationID: org.ID,
+		ProfessionalID: req.ProfessionalID,
+		Role:           MemberRoleAdmin,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error agregando profesional a la organización: %w", err)
+	}
+	if added == 0 {
+		return nil, ErrAlreadyInOrganization
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.GetOrganization(ctx, req.ProfessionalID)
+}
+
+// GetOrganization devuelve la organización del profesional, con sus miembros.
+func (s *Service) GetOrganization(ctx context.Context, profID int64) (*OrganizationView, error) {
+	if err := requireOwner(ctx); err != nil {
+		return nil, err
+	}
+	org, member, err := s.organizationFor(ctx, profID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.queries.ListOrganizationMembers(ctx, org.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error listando miembros de la organización: %w", err)
+	}
+	return &OrganizationView{Organization: *org, Role: member.Role, Members: members}, nil
+}
+
+// UpdateOrganization cambia la identidad, la configuración común y la política de 2FA. Sólo
+// administradores.
+func (s *Service) UpdateOrganization(ctx context.Context, req UpdateOrganizationRequest) (*OrganizationView, error) {
+	org, err := s.requireOrganizationAdmin(ctx, req.ProfessionalID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: el nombre es obligatorio", ErrInvalidOrganization)
+	}
+	brandColor := strings.TrimSpace(req.BrandColor)
+	if brandColor != "" && !brandColorPattern.MatchString(brandColor) {
+		return nil, fmt.Errorf("%w: el color debe tener el formato #RRGGBB", ErrInvalidOrganization)
+	}
+	logoURL, err := organizationURL("logo_url", req.LogoURL)
+	if err != nil {
+		return nil, err
+	}
+	website, err := organizationURL("website", req.Website)
+	if err != nil {
+		return nil, err
+	}
+	if req.DefaultDurationMinutes != nil && (*req.DefaultDurationMinutes <= 0 || *req.DefaultDurationMinutes > 480) {
+		return nil, fmt.Errorf("%w: la duración debe estar entre 1 y 480 minutos", ErrInvalidOrganization)
+	}
+	if req.TimeIncrementMinutes != nil && *req.TimeIncrementMinutes <= 0 {
+		return nil, fmt.Errorf("%w: el intervalo entre horarios debe ser mayor a cero", ErrInvalidOrganization)
+	}
+	for _, v := range []*int{req.BufferMinutes, req.MinBookingNoticeHours} {
+		if v != nil && *v < 0 {
+			return nil, fmt.Errorf("%w: el descanso y la anticipación no pueden ser negativos", ErrInvalidOrganization)
+		}
+	}
+
+	address := strings.TrimSpace(req.Address)
+	phone := strings.TrimSpace(req.Phone)
+	var approval sql.NullBool
+	if req.BookingRequiresApproval != nil {
+		approval = sql.NullBool{Bool: *req.BookingRequiresApproval, Valid: true}
+	}
+
+	if _, err := s.queries.UpdateOrganization(ctx, db.UpdateOrganizationParams{
+		Name:                    name,
+		RequireTwoFactor:        req.RequireTwoFactor,
+		LogoUrl:                 sql.NullString{String: logoURL, Valid: logoURL != ""},
+		BrandColor:              sql.NullString{String: brandColor, Valid: brandColor != ""},
+		Address:                 sql.NullString{String: address, Valid: address != ""},
+		Phone:                   sql.NullString{String: phone, Valid: phone != ""},
+		Website:                 sql.NullString{String: website, Valid: website != ""},
+		DefaultDurationMinutes:  optionalInt32(req.DefaultDurationMinutes),
+		BufferMinutes:           optionalInt32(req.BufferMinutes),
+		TimeIncrementMinutes:    optionalInt32(req.TimeIncrementMinutes),
+		MinBookingNoticeHours:   optionalInt32(req.MinBookingNoticeHours),
+		BookingRequiresApproval: approval,
+		ID:                      org.ID,
+	}); err != nil {
+		return nil, fmt.Errorf("error actualizando organización: %w", err)
+	}
+	return s.GetOrganization(ctx, req.ProfessionalID)
+}
+
+// InviteToOrganization manda por email la invitación a un profesional que ya tiene cuenta. No
+// entra a la organización (ni su agenda ni sus finanzas se comparten) hasta que la acepta desde
+// su propia sesión. Si ya es miembro de ésta, sólo se le cambia el rol.
+func (s *Service) InviteToOrganization(ctx context.Context, req AddOrganizationMemberRequest) error {
+	if req.Role != MemberRoleMember && req.Role != MemberRoleAdmin {
+		return ErrInvalidMemberRole
+	}
+	org, err := s.requireOrganizationAdmin(ctx, req.ProfessionalID)
+	if err != nil {
+		return err
+	}
+	if s.mailer == nil {
+		return ErrMailerUnavailable
+	}
+	prof, err := s.queries.GetProfessionalByEmail(ctx, normalizeEmail(req.Email))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("error obteniendo profesional: %w", err)
+	}
+
+	member, err := s.queries.GetOrganizationMembership(ctx, prof.ID)
+	switch {
+	case err == nil && member.OrganizationID == org.ID:
+		return s.UpdateOrganizationMemberRole(ctx, req.ProfessionalID, prof.ID, req.Role)
+	case err == nil:
+		return ErrAlreadyInOrganization
+	case !errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("error obteniendo organización: %w", err)
+	}
+
+	token, hash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("error emitiendo invitación: %w", err)
+	}
+	expires := time.Now().Add(organizationInvitationTTL)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	qtx := s.queries.WithTx(tx)
+
+	if err := qtx.InvalidateOrganizationInvitations(ctx, db.InvalidateOrganizationInvitationsParams{
+		OrganizationID: org.ID,
+		ProfessionalID: prof.ID,
+	}); err != nil {
+		return fmt.Errorf("error invalidando invitaciones anteriores: %w", err)
+	}
+	if err := qtx.CreateOrganizationInvitation(ctx, db.CreateOrganizationInvitationParams{
+		OrganizationID: org.ID,
+		ProfessionalID: prof.ID,
+		InvitedBy:      sql.NullInt64{Int64: req.ProfessionalID, Valid: true},
+		Role:           req.Role,
+		TokenHash:      hash,
+		ExpiresAt:      expires,
+	}); err != nil {
+		return fmt.Errorf("error guardando invitación: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      prof.Email,
+		Subject: fmt.Sprintf("Te invitaron a %s en Psiconexo", org.Name),
+		Body: fmt.Sprintf("Hola %s,\n\nTe invitaron a sumarte a %s en Psiconexo. Si aceptás, los "+
+			"administradores del centro van a ver tu agenda (con los nombres de tus pacientes) y tus "+
+			"números de facturación; tu historia clínica sigue siendo sólo tuya.\n\n"+
+			"Para aceptar, entrá con tu cuenta a este enlace:\n\n%s\n\n"+
+			"El enlace vence el %s. Si no esperabas esta invitación, ignorá este mensaje.\n",
+			prof.Name, org.Name, s.appLink("/organization-invitation", token), expires.Format("02/01/2006 15:04")),
+	})
+}
+
+// AcceptOrganizationInvitation suma al profesional autenticado a la organización que lo invitó.
+// La invitación es suya: no la puede usar otra cuenta aunque tenga el enlace.
+func (s *Service) AcceptOrganizationInvitation(ctx context.Context, profID int64, token string) (*OrganizationView, error) {
+	if err := requireOwner(ctx); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	qtx := s.queries.WithTx(tx)
+
+	invitation, err := qtx.ConsumeOrganizationInvitation(ctx, db.ConsumeOrganizationInvitationParams{
+		TokenHash:      auth.HashOpaqueToken(token),
+		ProfessionalID: profID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidInvitation
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error validando invitación: %w", err)
+	}
+	added, err := qtx.JoinOrganization(ctx, db.JoinOrganizationParams{
+		OrganizationID: invitation.OrganizationID,
+		ProfessionalID: profID,
+		Role:           invitation.Role,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error agregando profesional a la organización: %w", err)
+	}
+	if added == 0 {
+		return nil, ErrAlreadyInOrganization // Se sumó a otra mientras tanto: la invitación sigue sin usar
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.GetOrganization(ctx, profID)
+}
+
+// UpdateOrganizationMemberRole cambia el rol de un miembro. Siempre queda al menos un administrador.
+func (s *Service) UpdateOrganizationMemberRole(ctx context.Context, profID, memberID int64, role string) error {
+	if role != MemberRoleMember && role != MemberRoleAdmin {
+		return ErrInvalidMemberRole
+	}
+	org, err := s.requireOrganizationAdmin(ctx, profID)
+	if err != nil {
+		return err
+	}
+	if role == MemberRoleMember {
+		if err := s.keepOneAdmin(ctx, org.ID, memberID); err != nil {
+			return err
+		}
+	}
+
+	updated, err := s.queries.UpdateOrganizationMemberRole(ctx, db.UpdateOrganizationMemberRoleParams{
+		Role:           role,
+		OrganizationID: org.ID,
+		ProfessionalID: memberID,
+	})
+	if err != nil {
+		return fmt.Errorf("error actualizando rol: %w", err)
+	}
+	if updated == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RemoveOrganizationMember saca a un profesional del centro: lo hace un administrador o el propio
+// profesional. Sus pacientes, su agenda y su historia clínica siguen siendo suyos.
+func (s *Service) RemoveOrganizationMember(ctx context.Context, profID, memberID int64) error {
+	if err := requireOwner(ctx); err != nil {
+		return err
+	}
+	org, member, err := s.organizationFor(ctx, profID)
+	if err != nil {
+		return err
+	}
+	if memberID != profID && member.Role != MemberRoleAdmin {
+		return ErrForbidden
+	}
+	if err := s.keepOneAdmin(ctx, org.ID, memberID); err != nil {
+		return err
+	}
+
+	removed, err := s.queries.RemoveOrganizationMember(ctx, db.RemoveOrganizationMemberParams{
+		OrganizationID: org.ID,
+		ProfessionalID: memberID,
+	})
+	if err != nil {
+		return fmt.Errorf("error quitando profesional de la organización: %w", err)
+	}
+	if removed == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListOrganizationCalendar arma la agenda de todos los profesionales del centro entre start y
+// end (inclusive), opcionalmente de uno solo. Nunca incluye notas ni datos clínicos.
+func (s *Service) ListOrganizationCalendar(ctx context.Context, profID int64, start, end time.Time, professionalID *int64) ([]OrganizationCalendarEntry, error) {
+	if err := requireOwner(ctx); err != nil {
+		return nil, err
+	}
+	if end.Before(start) || end.Sub(start) >= maxOrganizationCalendarDays*24*time.Hour {
+		return nil, fmt.Errorf("%w: hasta %d días", ErrInvalidBookingRange, maxOrganizationCalendarDays)
+	}
+	org, member, err := s.organizationFor(ctx, profID)
+	if err != nil {
+		return nil, err
+	}
+
+	var filter sql.NullInt64
+	if professionalID != nil {
+		filter = sql.NullInt64{Int64: *professionalID, Valid: true}
+	}
+	rows, err := s.queries.ListOrganizationAppointments(ctx, db.ListOrganizationAppointmentsParams{
+		OrganizationID: org.ID,
+		StartDate:      start,
+		EndDate:        end,
+		ProfessionalID: filter,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo agenda de la organización: %w", err)
+	}
+
+	entries := make([]OrganizationCalendarEntry, 0, len(rows))
+	for _, row := range rows {
+		entry := OrganizationCalendarEntry{
+			ID:               row.ID,
+			ProfessionalID:   row.ProfessionalID,
+			ProfessionalName: row.ProfessionalName,
+			Date:             row.Date.Format("2006-01-02"),
+			StartTime:        row.StartTime,
+			DurationMinutes:  row.DurationMinutes,
+			Modality:         row.Modality.String,
+			Status:           row.Status.String,
+		}
+		if member.Role == MemberRoleAdmin || row.ProfessionalID == profID {
+			entry.ClientID = row.ClientID
+			entry.ClientName = row.ClientName
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// GetOrganizationFinancialSummary calcula los KPIs de Finanzas del período [start, end] por
+// profesional y para todo el centro. Sólo administradores.
+func (s *Service) GetOrganizationFinancialSummary(ctx context.Context, profID int64, start, end time.Time) (*OrganizationFinances, error) {
+	org, err := s.requireOrganizationAdmin(ctx, profID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.queries.GetOrganizationFinancialSummary(ctx, db.GetOrganizationFinancialSummaryParams{
+		StartDate:      start,
+		EndDate:        end,
+		OrganizationID: org.ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error calculando resumen financiero de la organización: %w", err)
+	}
+
+	var sessions int64
+	var income, pending, uninvoiced float64
+	for _, row := range rows {
+		sessions += row.SessionsCount
+		income += parseAmount(row.Income)
+		pending += parseAmount(row.PendingCollection)
+		uninvoiced += parseAmount(row.PendingInvoicing)
+	}
+	return &OrganizationFinances{
+		StartDate:     start.Format("2006-01-02"),
+		EndDate:       end.Format("2006-01-02"),
+		Professionals: rows,
+		Totals: OrganizationFinanceTotals{
+			SessionsCount:     sessions,
+			Income:            strconv.FormatFloat(income, 'f', 2, 64),
+			PendingCollection: strconv.FormatFloat(pending, 'f', 2, 64),
+			PendingInvoicing:  strconv.FormatFloat(uninvoiced, 'f', 2, 64),
+		},
+	}, nil
+}
+
+// organizationFor devuelve la organización del profesional y su membresía.
+func (s *Service) organizationFor(ctx context.Context, profID int64) (*db.Organization, *db.OrganizationMember, error) {
+	member, err := s.queries.GetOrganizationMembership(ctx, profID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, ErrNoOrganization
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("error obteniendo organización: %w", err)
+	}
+	org, err := s.queries.GetOrganization(ctx, member.OrganizationID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error obteniendo organización: %w", err)
+	}
+	return &org, &member, nil
+}
+
+func (s *Service) requireOrganizationAdmin(ctx context.Context, profID int64) (*db.Organization, error) {
+	if err := requireOwner(ctx); err != nil {
+		return nil, err
+	}
+	org, member, err := s.organizationFor(ctx, profID)
+	if err != nil {
+		return nil, err
+	}
+	if member.Role != MemberRoleAdmin {
+		return nil, ErrForbidden
+	}
+	return org, nil
+}
+
+// keepOneAdmin impide que el último administrador deje de serlo (o se vaya).
+func (s *Service) keepOneAdmin(ctx context.Context, orgID, memberID int64) error {
+	member, err := s.queries.GetOrganizationMembership(ctx, memberID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && member.OrganizationID != orgID) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("error obteniendo miembro: %w", err)
+	}
+	if member.Role != MemberRoleAdmin {
+		return nil
+	}
+	admins, err := s.queries.CountOrganizationAdmins(ctx, orgID)
+	if err != nil {
+		return fmt.Errorf("error contando administradores: %w", err)
+	}
+	if admins <= 1 {
+		return ErrLastOrganizationAdmin
+	}
+	return nil
+}
+
+// organizationURL acepta sólo enlaces http(s) absolutos; vacío borra el valor.
+func organizationURL(field, raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("%w: %s debe ser una URL http(s)", ErrInvalidOrganization, field)
+	}
+	return u.String(), nil
+}
+
+func optionalInt32(v *int) sql.NullInt32 {
+	if v == nil {
+		return sql.NullInt32{}
+	}
+	return sql.NullInt32{Int32: int32(*v), Valid: true}
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/luciluz/psiconexo/internal/db"
+)
+
+var ErrInvalidPackageDates = errors.New("la fecha de fin del paquete no puede ser anterior a la de inicio")
+
+type CreatePackageRequest struct {
+	ProfessionalID int64
+	ClientID       int64
+	Name           string
+	TotalSessions  int
+	Price          float64 // Precio total del paquete
+	ValidFrom      time.Time
+	ValidUntil     time.Time
+	Paid           bool // Si el paciente ya lo abonó al momento de la venta
+	PaymentMethod  string
+}
+
+func (s *Service) CreatePackage(ctx context.Context, req CreatePackageRequest) (*db.SessionPackage, error) {
+	if req.ValidUntil.Before(req.ValidFrom) {
+		return nil, ErrInvalidPackageDates
+	}
+
+	if _, err := s.getOwnedClient(ctx, req.ProfessionalID, req.ClientID); err != nil {
+		return nil, err
+	}
+
+	name := req.Name
+	if name == "" {
+		name = fmt.Sprintf("Paquete de %d sesiones", req.TotalSessions)
+	}
+
+	status := "pending"
+	var paidAt sql.NullTime
+	if req.Paid {
+		status = "paid"
+		paidAt = sql.NullTime{Time: time.Now(), Valid: true}
+	}
+
+	pkg, err := s.queries.CreateSessionPackage(ctx, db.CreateSessionPackageParams{
+		ProfessionalID: req.ProfessionalID,
+		ClientID:       req.ClientID,
+		Name:           name,
+		TotalSessions:  int32(req.TotalSessions),
+		Price:          fmt.Sprintf("%.2f", req.Price),
+		ValidFrom:      req.ValidFrom,
+		ValidUntil:     req.ValidUntil,
+		PaymentStatus:  sql.NullString{String: status, Valid: true},
+		PaymentMethod:  sql.NullString{String: req.PaymentMethod, Valid: req.PaymentMethod != ""},
+		PaidAt:         paidAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creando paquete: %w", err)
+	}
+	return &pkg, nil
+}
+
+// ListPackages lista los paquetes del profesional (opcionalmente de un paciente) con las sesiones restantes.
+func (s *Service) ListPackages(ctx context.Context, profID int64, clientID *int64) ([]db.ListSessionPackagesRow, error) {
+	pkgs, err := s.queries.ListSessionPackages(ctx, db.ListSessionPackagesParams{
+		ProfessionalID: profID,
+		ClientID:       nullInt64(clientID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listando paquetes: %w", err)
+	}
+	return pkgs, nil
+}
+
+func (s *Service) UpdatePackagePayment(ctx context.Context, profID, packageID int64, status, method string) (*db.SessionPackage, error) {
+	pkg, err := s.queries.GetSessionPackage(ctx, db.GetSessionPackageParams{ID: packageID, ProfessionalID: profID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("error obteniendo paquete: %w", err)
+	}
+
+	if method == "" {
+		method = pkg.PaymentMethod.String
+	}
+
+	updated, err := s.queries.UpdateSessionPackagePayment(ctx, db.UpdateSessionPackagePaymentParams{
+		PaymentStatus:  sql.NullString{String: status, Valid: true},
+		PaymentMethod:  sql.NullString{String: method, Valid: method != ""},
+		ID:             packageID,
+		ProfessionalID: profID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error actualizando pago del paquete: %w", err)
+	}
+	return &updated, nil
+}
+
+// consumePackageSession descuenta una sesión del paquete vigente del paciente, si tiene uno.
+// Devuelve el paquete consumido o nil si el turno se cobra por separado.
+func consumePackageSession(ctx context.Context, qtx *db.Queries, appt db.Appointment) (*db.SessionPackage, error) {
+	pkg, err := qtx.FindPackageForAppointment(ctx, db.FindPackageForAppointmentParams{
+		ProfessionalID: appt.ProfessionalID,
+		ClientID:       appt.ClientID,
+		Date:           appt.Date,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error buscando paquete vigente: %w", err)
+	}
+
+	if err := qtx.CreatePackageConsumption(ctx, db.CreatePackageConsumptionParams{
+		AppointmentID: appt.ID,
+		PackageID:     pkg.ID,
+	}); err != nil {
+		return nil, fmt.Errorf("error registrando consumo del paquete: %w", err)
+	}
+	if err := qtx.AddPackageUsage(ctx, db.AddPackageUsageParams{Delta: 1, ID: pkg.ID, ProfessionalID: appt.ProfessionalID}); err != nil {
+		return nil, fmt.Errorf("error actualizando sesiones usadas: %w", err)
+	}
+
+	pkg.UsedSessions++
+	return &pkg, nil
+}
+
+// releasePackageSession devuelve la sesión al paquete si el turno deja de estar completado.
+func releasePackageSession(ctx context.Context, qtx *db.Queries, appt db.Appointment) error {
+	consumption, err := qtx.GetPackageConsumption(ctx, appt.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("error obteniendo consumo del paquete: %w", err)
+	}
+
+	if err := qtx.DeletePackageConsumption(ctx, appt.ID); err != nil {
+		return fmt.Errorf("error liberando sesión del paquete: %w", err)
+	}
+	return qtx.AddPackageUsage(ctx, db.AddPackageUsageParams{Delta: -1, ID: consumption.PackageID, ProfessionalID: appt.ProfessionalID})
+}
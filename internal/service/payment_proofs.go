@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+
+	"github.com/luciluz/psiconexo/internal/db"
+)
+
+var ErrPaymentAlreadyConfirmed = errors.New("el pago del turno ya está registrado")
+
+// MaxPaymentProofBytes es el tamaño máximo de un comprobante (5 MB).
+const MaxPaymentProofBytes = 5 << 20
+
+// Comprobantes: capturas o PDFs del homebanking.
+var allowedPaymentProofTypes = map[string]bool{
+	"application/pdf": true,
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/webp":      true,
+}
+
+type UploadPaymentProofRequest struct {
+	ProfessionalID int64
+	ClientID       int64
+	AppointmentID  int64
+	Filename       string
+	Data           []byte
+}
+
+// UploadPaymentProof guarda el comprobante que sube el paciente y lo deja enlazado en el turno
+// para que el profesional lo revise. Si ya había uno, lo reemplaza.
+func (s *Service) UploadPaymentProof(ctx context.Context, req UploadPaymentProofRequest) (*db.PaymentProof, error) {
+	if s.blobs == nil {
+		return nil, ErrStorageUnavailable
+	}
+	if len(req.Data) == 0 {
+		return nil, ErrEmptyAttachment
+	}
+	if len(req.Data) > MaxPaymentProofBytes {
+		return nil, ErrAttachmentTooLarge
+	}
+
+	contentType, _, err := mime.ParseMediaType(http.DetectContentType(req.Data))
+	if err != nil || !allowedPaymentProofTypes[contentType] {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFileType, contentType)
+	}
+
+	appt, err := s.getClientAppointment(ctx, req.ProfessionalID, req.ClientID, req.AppointmentID)
+	if err != nil {
+		return nil, err
+	}
+	switch appt.Status.String {
+	case "cancelled", "rescheduled":
+		return nil, ErrAppointmentNotScheduled
+	}
+	if appt.PaymentStatus.String == "paid" {
+		return nil, ErrPaymentAlreadyConfirmed
+	}
+
+	previous, err := s.queries.GetPaymentProof(ctx, db.GetPaymentProofParams{
+		AppointmentID: appt.ID, ProfessionalID: req.ProfessionalID,
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("error obteniendo comprobante: %w", err)
+	}
+
+	storageKey, err := newStorageKey("payment-proofs", req.ProfessionalID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.blobs.Put(ctx, storageKey, req.Data, contentType); err != nil {
+		return nil, fmt.Errorf("error guardando archivo: %w", err)
+	}
+
+	proof := db.PaymentProof{
+		AppointmentID:  appt.ID,
+		ProfessionalID: req.ProfessionalID,
+		ClientID:       req.ClientID,
+		Filename:       sanitizeFilename(req.Filename),
+		ContentType:    contentType,
+		SizeBytes:      int64(len(req.Data)),
+		StorageKey:     storageKey,
+	}
+	if err := s.savePaymentProof(ctx, proof); err != nil {
+		if delErr := s.blobs.Delete(ctx, storageKey); delErr != nil {
+			log.Printf("no se pudo borrar el blob huérfano %s: %v", storageKey, delErr)
+		}
+		return nil, err
+	}
+
+	// El anterior ya no está referenciado: si no se puede borrar sólo ocupa lugar
+	if previous.StorageKey != "" {
+		if err := s.blobs.Delete(ctx, previous.StorageKey); err != nil {
+			log.Printf("no se pudo borrar el comprobante reemplazado %s: %v", previous.StorageKey, err)
+		}
+	}
+	return &proof, nil
+}
+
+func (s *Service) savePaymentProof(ctx context.Context, proof db.PaymentProof) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	qtx := s.queries.WithTx(tx)
+
+	if err := qtx.UpsertPaymentProof(ctx, db.UpsertPaymentProofParams{
+		AppointmentID:  proof.AppointmentID,
+		ProfessionalID: proof.ProfessionalID,
+		ClientID:       proof.ClientID,
+		Filename:       proof.Filename,
+		ContentType:    proof.ContentType,
+		SizeBytes:      proof.SizeBytes,
+		StorageKey:     proof.StorageKey,
+	}); err != nil {
+		return fmt.Errorf("error registrando comprobante: %w", err)
+	}
+	// payment_proof_url apunta a la descarga autenticada, no al almacenamiento
+	if err := qtx.SetAppointmentPaymentProof(ctx, db.SetAppointmentPaymentProofParams{
+		PaymentProofUrl: sql.NullString{String: fmt.Sprintf("/api/v1/appointments/%d/payment-proof", proof.AppointmentID), Valid: true},
+		ID:              proof.AppointmentID,
+		ProfessionalID:  proof.ProfessionalID,
+	}); err != nil {
+		return fmt.Errorf("error registrando comprobante: %w", err)
+	}
+	return tx.Commit()
+}
+
+// DownloadPaymentProof devuelve el comprobante que subió el paciente para ese turno.
+func (s *Service) DownloadPaymentProof(ctx context.Context, profID, appointmentID int64) (*db.PaymentProof, []byte, error) {
+	if s.blobs == nil {
+		return nil, nil, ErrStorageUnavailable
+	}
+
+	proof, err := s.queries.GetPaymentProof(ctx, db.GetPaymentProofParams{
+		AppointmentID: appointmentID, ProfessionalID: profID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("error obteniendo comprobante: %w", err)
+	}
+
+	data, err := s.blobs.Get(ctx, proof.StorageKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error leyendo archivo: %w", err)
+	}
+	return &proof, data, nil
+}
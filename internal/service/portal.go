@@ -0,0 +1,439 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/luciluz/psiconexo/internal/auth"
+	"github.com/luciluz/psiconexo/internal/db"
+	"github.com/luciluz/psiconexo/internal/mailer"
+)
+
+var (
+	ErrInvalidLoginLink          = errors.New("el enlace de ingreso es inválido, venció o ya se usó; pida uno nuevo")
+	ErrAppointmentNotScheduled   = errors.New("el turno no está agendado")
+	ErrOutsideCancellationWindow = errors.New("el turno ya no se puede cancelar ni reprogramar desde el portal; comuníquese con su profesional")
+	ErrNoInvoice                 = errors.New("el turno todavía no tiene factura")
+)
+
+// portalLinkTTL es corto: el enlace abre la sesión sin pedir nada más.
+const portalLinkTTL = 15 * time.Minute
+
+// PortalClient es lo que el portal sabe del paciente y de su profesional. Nada clínico.
+type PortalClient struct {
+	ID                      int64  `json:"id"`
+	Name                    string `json:"name"`
+	ProfessionalID          int64  `json:"professional_id"`
+	ProfessionalName        string `json:"professional_name"`
+	ProfessionalTitle       string `json:"professional_title,omitempty"`
+	CancellationWindowHours int    `json:"cancellation_window_hours"` // Hasta cuántas horas antes puede cancelar o reprogramar
+}
+
+type PortalSession struct {
+	AccessToken string       `json:"access_token"`
+	ExpiresAt   time.Time    `json:"expires_at"`
+	Client      PortalClient `json:"client"`
+}
+
+// PortalAppointment es el turno tal como lo ve el paciente (sin el comentario interno).
+type PortalAppointment struct {
+	db.ListPortalAppointmentsRow
+	CanChange bool `json:"can_change"` // Todavía se puede cancelar o reprogramar desde el portal
+}
+
+type PortalAppointments struct {
+	Upcoming []PortalAppointment `json:"upcoming"` // Del más próximo al más lejano
+	Past     []PortalAppointment `json:"past"`     // Del más reciente al más viejo
+}
+
+type PortalRescheduleRequest struct {
+	ProfessionalID int64
+	ClientID       int64
+	AppointmentID  int64
+	Date           time.Time
+	StartTime      string
+}
+
+// RequestPortalLink manda un enlace de ingreso por cada ficha activa con ese email. Si no hay
+// ninguna no hace nada y no devuelve error: la respuesta no revela quién es paciente.
+func (s *Service) RequestPortalLink(ctx context.Context, email string) error {
+	if s.mailer == nil {
+		return ErrMailerUnavailable
+	}
+
+	clients, err := s.queries.ListPortalClientsByEmail(ctx, normalizeEmail(email))
+	if err != nil {
+		return fmt.Errorf("error buscando paciente: %w", err)
+	}
+
+	// Si falla un enlace o un envío se sigue con los demás: el paciente puede volver a pedirlo,
+	// y cortar acá le diría a quien pregunta que el email existe
+	for _, client := range clients {
+		token, err := s.createPortalLink(ctx, client.ID)
+		if err != nil {
+			log.Printf("no se pudo emitir el enlace del portal al paciente %d: %v", client.ID, err)
+			continue
+		}
+		if err := s.mailer.Send(ctx, mailer.Message{
+			To:      normalizeEmail(email),
+			Subject: fmt.Sprintf("Tu acceso a los turnos con %s", client.ProfessionalName),
+			Body: fmt.Sprintf("Hola %s,\n\nPara ver tus turnos con %s entrá a este enlace:\n\n%s\n\n"+
+				"El enlace sirve una sola vez y vence en 15 minutos. Si no lo pediste, ignorá este mensaje.\n",
+				client.Name, client.ProfessionalName, s.appLink("/portal/login", token)),
+		}); err != nil {
+			log.Printf("no se pudo enviar el enlace del portal al paciente %d: %v", client.ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) createPortalLink(ctx context.Context, clientID int64) (string, error) {
+	token, hash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return "", fmt.Errorf("error emitiendo enlace: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	qtx := s.queries.WithTx(tx)
+
+	if err := qtx.InvalidateClientLoginTokens(ctx, clientID); err != nil {
+		return "", fmt.Errorf("error invalidando enlaces anteriores: %w", err)
+	}
+	if err := qtx.CreateClientLoginToken(ctx, db.CreateClientLoginTokenParams{
+		ClientID:  clientID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(portalLinkTTL),
+	}); err != nil {
+		return "", fmt.Errorf("error guardando enlace: %w", err)
+	}
+	return token, tx.Commit()
+}
+
+// VerifyPortalLink canjea el enlace del email por la sesión del paciente.
+func (s *Service) VerifyPortalLink(ctx context.Context, token string) (*PortalSession, error) {
+	if s.tokens == nil {
+		return nil, ErrAuthUnavailable
+	}
+
+	clientID, err := s.queries.ConsumeClientLoginToken(ctx, auth.HashOpaqueToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidLoginLink
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error validando enlace: %w", err)
+	}
+
+	client, err := s.GetPortalClient(ctx, clientID)
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrInvalidLoginLink // Lo dieron de baja después de mandarle el enlace
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	access, expires, err := s.tokens.IssueClientAccess(client.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error emitiendo token: %w", err)
+	}
+	return &PortalSession{AccessToken: access, ExpiresAt: expires, Client: *client}, nil
+}
+
+// AuthenticateClient valida el token del portal y devuelve la ficha del paciente.
+func (s *Service) AuthenticateClient(accessToken string) (int64, error) {
+	if s.tokens == nil {
+		return 0, ErrAuthUnavailable
+	}
+	claims, err := s.tokens.VerifyClientAccess(accessToken)
+	if err != nil {
+		return 0, ErrUnauthenticated
+	}
+	return claims.ClientID, nil
+}
+
+// GetPortalClient devuelve la ficha del paciente; si la dieron de baja, ErrNotFound.
+func (s *Service) GetPortalClient(ctx context.Context, clientID int64) (*PortalClient, error) {
+	row, err := s.queries.GetPortalClient(ctx, clientID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo paciente: %w", err)
+	}
+	if !row.Active.Valid || !row.Active.Bool {
+		return nil, ErrNotFound
+	}
+	return &PortalClient{
+		ID:                      row.ID,
+		Name:                    row.Name,
+		ProfessionalID:          row.ProfessionalID,
+		ProfessionalName:        row.ProfessionalName,
+		ProfessionalTitle:       row.ProfessionalTitle.String,
+		CancellationWindowHours: int(row.CancellationWindowHours),
+	}, nil
+}
+
+// ListPortalAppointments separa los turnos del paciente en próximos y pasados.
+func (s *Service) ListPortalAppointments(ctx context.Context, profID, clientID int64) (*PortalAppointments, error) {
+	client, err := s.GetPortalClient(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.queries.ListPortalAppointments(ctx, db.ListPortalAppointmentsParams{
+		ClientID:       clientID,
+		ProfessionalID: profID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo turnos: %w", err)
+	}
+
+	result := &PortalAppointments{Upcoming: []PortalAppointment{}, Past: []PortalAppointment{}}
+	now := time.Now()
+	for _, row := range rows {
+		start, err := appointmentStart(row.Date, row.StartTime)
+		if err != nil {
+			return nil, fmt.Errorf("turno %d con hora inválida: %w", row.ID, err)
+		}
+		appt := PortalAppointment{ListPortalAppointmentsRow: row}
+		if start.After(now) {
+			appt.CanChange = row.Status.String == "scheduled" && withinCancellationWindow(start, client.CancellationWindowHours)
+			result.Upcoming = append(result.Upcoming, appt)
+		} else {
+			result.Past = append(result.Past, appt)
+		}
+	}
+	// La consulta viene del más nuevo al más viejo: los próximos se muestran al revés
+	slices.Reverse(result.Upcoming)
+	return result, nil
+}
+
+// CancelPortalAppointment cancela el turno si todavía está dentro de la política del profesional.
+func (s *Service) CancelPortalAppointment(ctx context.Context, profID, clientID, appointmentID int64) error {
+	client, appt, err := s.changeablePortalAppointment(ctx, profID, clientID, appointmentID)
+	if err != nil {
+		return err
+	}
+
+	n, err := s.queries.SetClientAppointmentStatus(ctx, db.SetClientAppointmentStatusParams{
+		Status:         sql.NullString{String: "cancelled", Valid: true},
+		ID:             appt.ID,
+		ClientID:       clientID,
+		ProfessionalID: profID,
+	})
+	if err != nil {
+		return fmt.Errorf("error cancelando turno: %w", err)
+	}
+	if n == 0 {
+		return ErrAppointmentNotScheduled
+	}
+
+	s.notifyPortalChange(ctx, client, fmt.Sprintf("%s canceló su turno del %s a las %s.",
+		client.Name, appt.Date.Format("02/01/2006"), appt.StartTime))
+	return nil
+}
+
+// ReschedulePortalAppointment mueve el turno a otro horario libre: el original queda como
+// reprogramado y el nuevo apunta a él (rescheduled_from_id), con la misma duración y precio,
+// y se lleva la cobertura y el pago del original.
+func (s *Service) ReschedulePortalAppointment(ctx context.Context, req PortalRescheduleRequest) (*db.Appointment, error) {
+	client, appt, err := s.changeablePortalAppointment(ctx, req.ProfessionalID, req.ClientID, req.AppointmentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkBookableSlot(ctx, req.ProfessionalID, req.Date, req.StartTime, int(appt.DurationMinutes), appt.ID); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	qtx := s.queries.WithTx(tx)
+
+	n, err := qtx.SetClientAppointmentStatus(ctx, db.SetClientAppointmentStatusParams{
+		Status:         sql.NullString{String: "rescheduled", Valid: true},
+		ID:             appt.ID,
+		ClientID:       req.ClientID,
+		ProfessionalID: req.ProfessionalID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reprogramando turno: %w", err)
+	}
+	if n == 0 {
+		return nil, ErrAppointmentNotScheduled
+	}
+
+	moved, err := qtx.CreateAppointment(ctx, db.CreateAppointmentParams{
+		ProfessionalID:    req.ProfessionalID,
+		ClientID:          req.ClientID,
+		Date:              req.Date,
+		StartTime:         req.StartTime,
+		DurationMinutes:   appt.DurationMinutes,
+		Modality:          appt.Modality,
+		MeetingUrl:        appt.MeetingUrl,
+		Price:             appt.Price,
+		Concept:           appt.Concept,
+		PaymentMethod:     appt.PaymentMethod,
+		RescheduledFromID: sql.NullInt64{Int64: appt.ID, Valid: true},
+		RecurringRuleID:   appt.RecurringRuleID,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("%w: ese horario ya fue usado por otro turno", ErrSlotUnavailable)
+		}
+		return nil, fmt.Errorf("error reprogramando turno: %w", err)
+	}
+	if err := carryOverAppointment(ctx, qtx, req.ProfessionalID, appt, moved.ID); err != nil {
+		return nil, fmt.Errorf("error reprogramando turno: %w", err)
+	}
+	moved, err = qtx.GetClientAppointment(ctx, db.GetClientAppointmentParams{
+		ID:             moved.ID,
+		ClientID:       req.ClientID,
+		ProfessionalID: req.ProfessionalID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reprogramando turno: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	s.notifyPortalChange(ctx, client, fmt.Sprintf("%s reprogramó su turno del %s a las %s para el %s a las %s.",
+		client.Name, appt.Date.Format("02/01/2006"), appt.StartTime, req.Date.Format("02/01/2006"), req.StartTime))
+	return &moved, nil
+}
+
+// carryOverAppointment pasa al turno reprogramado la cobertura y el pago del original. El pago
+// (estado, registros en client_payments y comprobante) se mueve en vez de copiarse: si quedara
+// también en el original, la cuenta corriente lo contaría dos veces.
+func carryOverAppointment(ctx context.Context, qtx *db.Queries, profID int64, old *db.Appointment, newID int64) error {
+	if err := qtx.CopyAppointmentCoverage(ctx, db.CopyAppointmentCoverageParams{
+		NewAppointmentID: newID,
+		OldAppointmentID: old.ID,
+	}); err != nil {
+		return err
+	}
+	if err := qtx.MoveAppointmentPayment(ctx, db.MoveAppointmentPaymentParams{
+		NewAppointmentID: newID,
+		OldAppointmentID: old.ID,
+		ProfessionalID:   profID,
+	}); err != nil {
+		return err
+	}
+	if _, err := qtx.UpdateAppointmentPayment(ctx, db.UpdateAppointmentPaymentParams{
+		PaymentStatus:  sql.NullString{String: "pending", Valid: true},
+		PaymentMethod:  old.PaymentMethod,
+		ID:             old.ID,
+		ProfessionalID: profID,
+	}); err != nil {
+		return err
+	}
+	if err := qtx.MoveAppointmentClientPayments(ctx, db.MoveAppointmentClientPaymentsParams{
+		NewAppointmentID: newID,
+		OldAppointmentID: old.ID,
+		ProfessionalID:   profID,
+	}); err != nil {
+		return err
+	}
+	return qtx.MovePaymentProof(ctx, db.MovePaymentProofParams{
+		NewAppointmentID: newID,
+		OldAppointmentID: old.ID,
+		ProfessionalID:   profID,
+	})
+}
+
+// PortalInvoiceURL devuelve la factura del turno, si ya se emitió.
+func (s *Service) PortalInvoiceURL(ctx context.Context, profID, clientID, appointmentID int64) (string, error) {
+	appt, err := s.getClientAppointment(ctx, profID, clientID, appointmentID)
+	if err != nil {
+		return "", err
+	}
+	if appt.InvoiceStatus.String != "invoiced" {
+		return "", ErrNoInvoice
+	}
+	// El portal redirige ahí: sólo enlaces http(s) absolutos
+	u, err := url.Parse(appt.InvoiceUrl.String)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return "", ErrNoInvoice
+	}
+	return u.String(), nil
+}
+
+// changeablePortalAppointment trae el turno si el paciente todavía puede cancelarlo o reprogramarlo.
+func (s *Service) changeablePortalAppointment(ctx context.Context, profID, clientID, appointmentID int64) (*PortalClient, *db.Appointment, error) {
+	client, err := s.GetPortalClient(ctx, clientID)
+	if err != nil {
+		return nil, nil, err
+	}
+	appt, err := s.getClientAppointment(ctx, profID, clientID, appointmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if appt.Status.String != "scheduled" {
+		return nil, nil, ErrAppointmentNotScheduled
+	}
+	start, err := appointmentStart(appt.Date, appt.StartTime)
+	if err != nil {
+		return nil, nil, fmt.Errorf("turno %d con hora inválida: %w", appt.ID, err)
+	}
+	if !withinCancellationWindow(start, client.CancellationWindowHours) {
+		return nil, nil, ErrOutsideCancellationWindow
+	}
+	return client, appt, nil
+}
+
+// getClientAppointment trae el turno sólo si es de esa ficha del paciente.
+func (s *Service) getClientAppointment(ctx context.Context, profID, clientID, appointmentID int64) (*db.Appointment, error) {
+	appt, err := s.queries.GetClientAppointment(ctx, db.GetClientAppointmentParams{
+		ID:             appointmentID,
+		ClientID:       clientID,
+		ProfessionalID: profID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo turno: %w", err)
+	}
+	return &appt, nil
+}
+
+func withinCancellationWindow(start time.Time, windowHours int) bool {
+	return time.Now().Add(time.Duration(windowHours) * time.Hour).Before(start)
+}
+
+// notifyPortalChange avisa al profesional por email de lo que hizo el paciente. Si falla sólo
+// queda en el log: el cambio ya está hecho y se ve en la agenda.
+func (s *Service) notifyPortalChange(ctx context.Context, client *PortalClient, text string) {
+	if s.mailer == nil {
+		return
+	}
+	prof, err := s.GetProfessional(ctx, client.ProfessionalID)
+	if err == nil {
+		err = s.mailer.Send(ctx, mailer.Message{
+			To:      prof.Email,
+			Subject: "Cambio de turno desde el portal",
+			Body:    fmt.Sprintf("Hola %s,\n\n%s\n", prof.Name, text),
+		})
+	}
+	if err != nil {
+		log.Printf("no se pudo avisar al profesional %d de un cambio en el portal: %v", client.ProfessionalID, err)
+	}
+}
@@ -0,0 +1,367 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/luciluz/psiconexo/internal/db"
+)
+
+var (
+	ErrPriceIndexNotFound = errors.New("no hay valor cargado del índice para el período pedido")
+	ErrInvalidPriceIndex  = errors.New("archivo de índice inválido")
+	ErrEmptyPriceList     = errors.New("el profesional no tiene precios vigentes para ajustar")
+)
+
+const defaultPriceListModality = "virtual" // Los turnos sin modalidad se cobran como virtuales
+
+type CreatePriceRequest struct {
+	ProfessionalID int64
+	Modality       string // virtual | in_person | home
+	Duration       int
+	Price          float64
+	EffectiveFrom  time.Time
+}
+
+// AdjustPricesRequest genera una nueva lista de precios aplicando la variación de un índice
+// (ej: IPC) sobre los precios vigentes.
+type AdjustPricesRequest struct {
+	ProfessionalID int64
+	Series         string
+	BasePeriod     time.Time // Cero = mes en que entró en vigencia cada precio
+	TargetPeriod   time.Time // Cero = último período cargado de la serie
+	EffectiveFrom  time.Time
+	RoundTo        float64 // Redondeo del precio final (ej: 100); 0 = centavos
+}
+
+// PriceUpdate es el resultado de cargar un precio: si ya está vigente se propaga en el momento
+// a los turnos futuros impagos y a las reglas recurrentes activas.
+type PriceUpdate struct {
+	Entry               db.PriceListEntry `json:"entry"`
+	Applied             bool              `json:"applied"`
+	AppointmentsUpdated int64             `json:"appointments_updated"`
+	RulesUpdated        int64             `json:"rules_updated"`
+}
+
+func (s *Service) CreatePrice(ctx context.Context, req CreatePriceRequest) (*PriceUpdate, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	qtx := s.queries.WithTx(tx)
+
+	update, err := createPriceEntry(ctx, qtx, req, sql.NullString{})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return update, nil
+}
+
+func (s *Service) ListPriceList(ctx context.Context, profID int64) ([]db.PriceListEntry, error) {
+	entries, err := s.queries.ListPriceListEntries(ctx, profID)
+	if err != nil {
+		return nil, fmt.Errorf("error listando precios: %w", err)
+	}
+	return entries, nil
+}
+
+// GetCurrentPriceList devuelve el precio vigente a `date` de cada modalidad/duración.
+func (s *Service) GetCurrentPriceList(ctx context.Context, profID int64, date time.Time) ([]db.PriceListEntry, error) {
+	entries, err := s.queries.GetCurrentPriceList(ctx, db.GetCurrentPriceListParams{
+		ProfessionalID: profID,
+		Date:           date,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo lista de precios vigente: %w", err)
+	}
+	return entries, nil
+}
+
+// AdjustPricesByIndex actualiza todos los precios vigentes por la variación del índice entre
+// el período base y el período destino. Los nuevos precios rigen desde req.EffectiveFrom.
+func (s *Service) AdjustPricesByIndex(ctx context.Context, req AdjustPricesRequest) ([]PriceUpdate, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	qtx := s.queries.WithTx(tx)
+
+	current, err := qtx.GetCurrentPriceList(ctx, db.GetCurrentPriceListParams{
+		ProfessionalID: req.ProfessionalID,
+		Date:           req.EffectiveFrom.AddDate(0, 0, -1),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo lista de precios vigente: %w", err)
+	}
+	if len(current) == 0 {
+		return nil, ErrEmptyPriceList
+	}
+
+	var target db.PriceIndex
+	if req.TargetPeriod.IsZero() {
+		target, err = qtx.GetLatestPriceIndex(ctx, req.Series)
+	} else {
+		target, err = qtx.GetPriceIndex(ctx, db.GetPriceIndexParams{Series: req.Series, Period: monthStart(req.TargetPeriod)})
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPriceIndexNotFound
+		}
+		return nil, fmt.Errorf("error obteniendo índice: %w", err)
+	}
+
+	series := sql.NullString{String: req.Series, Valid: true}
+	updates := make([]PriceUpdate, 0, len(current))
+	for _, entry := range current {
+		basePeriod := monthStart(entry.EffectiveFrom)
+		if !req.BasePeriod.IsZero() {
+			basePeriod = monthStart(req.BasePeriod)
+		}
+		base, err := qtx.GetPriceIndex(ctx, db.GetPriceIndexParams{Series: req.Series, Period: basePeriod})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, fmt.Errorf("%w (%s %s)", ErrPriceIndexNotFound, req.Series, basePeriod.Format("2006-01"))
+			}
+			return nil, fmt.Errorf("error obteniendo índice: %w", err)
+		}
+		if parseAmount(base.Value) <= 0 {
+			return nil, fmt.Errorf("%w: valor base en cero", ErrInvalidPriceIndex)
+		}
+
+		factor := parseAmount(target.Value) / parseAmount(base.Value)
+		update, err := createPriceEntry(ctx, qtx, CreatePriceRequest{
+			ProfessionalID: req.ProfessionalID,
+			Modality:       entry.Modality,
+			Duration:       int(entry.DurationMinutes),
+			Price:          roundPrice(parseAmount(entry.Price)*factor, req.RoundTo),
+			EffectiveFrom:  req.EffectiveFrom,
+		}, series)
+		if err != nil {
+			return nil, err
+		}
+		updates = append(updates, *update)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return updates, nil
+}
+
+// ApplyDuePriceUpdates propaga los precios que entraron en vigencia desde la última corrida.
+// Pensado para ejecutarse a diario (comando apply-price-updates).
+func (s *Service) ApplyDuePriceUpdates(ctx context.Context) ([]PriceUpdate, error) {
+	pending, err := s.queries.ListPendingPriceUpdates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo precios pendientes: %w", err)
+	}
+
+	updates := make([]PriceUpdate, 0, len(pending))
+	for _, entry := range pending {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return updates, err
+		}
+		update, err := applyPriceEntry(ctx, s.queries.WithTx(tx), entry)
+		if err != nil {
+			_ = tx.Rollback()
+			return updates, err
+		}
+		if err := tx.Commit(); err != nil {
+			return updates, err
+		}
+		updates = append(updates, *update)
+	}
+	return updates, nil
+}
+
+// LoadPriceIndexCSV carga (o corrige) los valores de una serie desde un CSV "período;valor".
+// Acepta períodos YYYY-MM, YYYY-MM-DD o MM/YYYY y decimales con coma o punto. Si la primera
+// fila no se puede interpretar se toma como encabezado.
+func (s *Service) LoadPriceIndexCSV(ctx context.Context, series string, r io.Reader) (int, error) {
+	cr := csv.NewReader(r)
+	cr.Comma = ';'
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidPriceIndex, err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	qtx := s.queries.WithTx(tx)
+
+	loaded := 0
+	for i, rec := range records {
+		if len(rec) == 1 && strings.Contains(rec[0], ",") {
+			rec = strings.Split(rec[0], ",") // CSV separado por coma
+		}
+		if len(rec) < 2 {
+			continue
+		}
+		period, value, err := parseIndexRow(rec[0], rec[1])
+		if err != nil {
+			if i == 0 {
+				continue // Encabezado
+			}
+			return 0, fmt.Errorf("%w: fila %d: %v", ErrInvalidPriceIndex, i+1, err)
+		}
+		if err := qtx.UpsertPriceIndex(ctx, db.UpsertPriceIndexParams{
+			Series: series,
+			Period: period,
+			Value:  strconv.FormatFloat(value, 'f', 4, 64),
+		}); err != nil {
+			return 0, fmt.Errorf("error guardando índice: %w", err)
+		}
+		loaded++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return loaded, nil
+}
+
+// LookupListPrice devuelve el precio de lista vigente a `date`; ok=false si no hay uno cargado.
+func (s *Service) LookupListPrice(ctx context.Context, profID int64, modality string, duration int, date time.Time) (float64, bool, error) {
+	if modality == "" {
+		modality = defaultPriceListModality
+	}
+	entry, err := s.queries.GetPriceFor(ctx, db.GetPriceForParams{
+		ProfessionalID:  profID,
+		Modality:        modality,
+		DurationMinutes: int32(duration),
+		Date:            date,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("error obteniendo precio de lista: %w", err)
+	}
+	return parseAmount(entry.Price), true, nil
+}
+
+func createPriceEntry(ctx context.Context, qtx *db.Queries, req CreatePriceRequest, series sql.NullString) (*PriceUpdate, error) {
+	entry, err := qtx.CreatePriceListEntry(ctx, db.CreatePriceListEntryParams{
+		ProfessionalID:  req.ProfessionalID,
+		Modality:        req.Modality,
+		DurationMinutes: int32(req.Duration),
+		Price:           fmt.Sprintf("%.2f", req.Price),
+		EffectiveFrom:   req.EffectiveFrom,
+		IndexSeries:     series,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error guardando precio: %w", err)
+	}
+
+	// Si ya está vigente se aplica ahora; si no, lo toma apply-price-updates el día que corresponda.
+	if entry.EffectiveFrom.After(time.Now()) {
+		return &PriceUpdate{Entry: entry}, nil
+	}
+	return applyPriceEntry(ctx, qtx, entry)
+}
+
+// applyPriceEntry propaga el precio sólo dentro de su período de vigencia: turnos desde hoy (o
+// desde que rige) hasta que entra el siguiente precio, y reglas sólo si es el que rige hoy. Así
+// una corrección con fecha pasada, o varios pendientes en la misma corrida, no pisan al vigente.
+func applyPriceEntry(ctx context.Context, qtx *db.Queries, entry db.PriceListEntry) (*PriceUpdate, error) {
+	price := sql.NullString{String: entry.Price, Valid: true}
+
+	appts, err := qtx.UpdateFutureAppointmentPrices(ctx, db.UpdateFutureAppointmentPricesParams{
+		Price:           price,
+		ProfessionalID:  entry.ProfessionalID,
+		Modality:        entry.Modality,
+		DurationMinutes: entry.DurationMinutes,
+		EffectiveFrom:   entry.EffectiveFrom,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error actualizando turnos futuros: %w", err)
+	}
+
+	rules, err := qtx.UpdateActiveRecurringRulePrices(ctx, db.UpdateActiveRecurringRulePricesParams{
+		Price:           price,
+		ProfessionalID:  entry.ProfessionalID,
+		Modality:        entry.Modality,
+		DurationMinutes: entry.DurationMinutes,
+		EffectiveFrom:   entry.EffectiveFrom,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error actualizando reglas recurrentes: %w", err)
+	}
+
+	if err := qtx.MarkPriceListEntryApplied(ctx, entry.ID); err != nil {
+		return nil, fmt.Errorf("error marcando precio como aplicado: %w", err)
+	}
+	entry.AppliedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+	return &PriceUpdate{
+		Entry:               entry,
+		Applied:             true,
+		AppointmentsUpdated: appts,
+		RulesUpdated:        rules,
+	}, nil
+}
+
+func parseIndexRow(rawPeriod, rawValue string) (time.Time, float64, error) {
+	rawPeriod = strings.TrimSpace(rawPeriod)
+	var period time.Time
+	var err error
+	for _, layout := range []string{"2006-01", "2006-01-02", "01/2006"} {
+		if period, err = time.Parse(layout, rawPeriod); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("período inválido %q", rawPeriod)
+	}
+
+	rawValue = strings.TrimSpace(rawValue)
+	if strings.Contains(rawValue, ",") {
+		rawValue = strings.ReplaceAll(strings.ReplaceAll(rawValue, ".", ""), ",", ".")
+	}
+	value, err := strconv.ParseFloat(rawValue, 64)
+	if err != nil || value <= 0 {
+		return time.Time{}, 0, fmt.Errorf("valor inválido %q", rawValue)
+	}
+	return monthStart(period), value, nil
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func roundPrice(v, step float64) float64 {
+	if step <= 0 {
+		return roundCents(v)
+	}
+	return math.Round(v/step) * step
+}
@@ -0,0 +1,385 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/luciluz/psiconexo/internal/db"
+)
+
+var (
+	ErrInvalidSlug    = errors.New("la dirección pública debe tener entre 3 y 60 letras, números o guiones")
+	ErrReservedSlug   = errors.New("esa dirección pública está reservada, elija otra")
+	ErrSlugTaken      = errors.New("esa dirección pública ya está en uso")
+	ErrPhoneTaken     = errors.New("ya existe una cuenta con ese teléfono")
+	ErrInvalidLicense = errors.New("matrícula inválida: use el número, con la jurisdicción adelante si corresponde (ej: MN 12345)")
+	ErrNameRequired   = errors.New("el nombre es obligatorio")
+)
+
+// MaxProfilePhotoBytes es el tamaño máximo de la foto de perfil (2 MB).
+const MaxProfilePhotoBytes = 2 << 20
+
+const (
+	minSlugLength = 3
+	maxSlugLength = 60
+
+	profilePhotoKind = "profile-photos"
+	// Las fotos se sirven sin sesión porque aparecen en la página pública
+	profilePhotoPath = "/api/v1/"
+)
+
+var allowedProfilePhotoTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+}
+
+// reservedSlugs no pueden usarse como dirección pública: chocan con rutas de la aplicación o
+// se prestan a confusión con la plataforma.
+var reservedSlugs = map[string]bool{
+	"admin": true, "administrador": true, "api": true, "app": true, "assets": true, "auth": true,
+	"ayuda": true, "blog": true, "config": true, "configuracion": true, "contacto": true,
+	"dashboard": true, "help": true, "login": true, "logout": true, "me": true, "panel": true,
+	"perfil": true, "portal": true, "profile": true, "psiconexo": true, "public": true,
+	"register": true, "registro": true, "settings": true, "soporte": true, "static": true,
+	"support": true, "turnos": true, "www": true,
+}
+
+var slugAccents = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ä", "a", "ã", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "ö", "o", "õ", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ñ", "n", "ç", "c",
+)
+
+// Matrícula: número de 3 a 8 dígitos, con la jurisdicción opcional adelante (MN, MP, M.N., Mat.)
+var licensePattern = regexp.MustCompile(`^(?:[A-Z]+\.?\s?){0,3}\d{3,8}$`)
+
+type UpdateProfileRequest struct {
+	ProfessionalID int64
+	Name           string
+	Phone          string
+	Slug           string // Vacío: sin página pública
+	Title          string
+	LicenseNumber  string
+	Bio            string
+}
+
+// SlugAvailability es la respuesta al chequeo de la dirección pública mientras se escribe.
+type SlugAvailability struct {
+	Slug      string `json:"slug"` // Como quedaría guardado
+	Available bool   `json:"available"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// normalizeSlug arma la dirección pública: sin acentos, en minúsculas y con guiones en lugar
+// de espacios o símbolos. "Lucía Pérez" → "lucia-perez".
+func normalizeSlug(raw string) string {
+	lowered := slugAccents.Replace(strings.ToLower(strings.TrimSpace(raw)))
+
+	var b strings.Builder
+	dash := false
+	for _, r := range lowered {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	slug := strings.TrimSuffix(b.String(), "-")
+	if len(slug) > maxSlugLength {
+		slug = strings.TrimSuffix(slug[:maxSlugLength], "-")
+	}
+	return slug
+}
+
+// validateSlug normaliza y verifica que la dirección se pueda usar, sin mirar si está tomada.
+func validateSlug(raw string) (string, error) {
+	slug := normalizeSlug(raw)
+	if len(slug) < minSlugLength {
+		return "", ErrInvalidSlug
+	}
+	if reservedSlugs[slug] {
+		return "", ErrReservedSlug
+	}
+	return slug, nil
+}
+
+// normalizeLicense deja la matrícula en mayúsculas y con espacios simples.
+func normalizeLicense(raw string) (string, error) {
+	license := strings.Join(strings.Fields(strings.ToUpper(raw)), " ")
+	if license == "" {
+		return "", nil
+	}
+	if !licensePattern.MatchString(license) {
+		return "", ErrInvalidLicense
+	}
+	return license, nil
+}
+
+// GetProfile devuelve el perfil público del profesional tal como lo edita.
+func (s *Service) GetProfile(ctx context.Context, profID int64) (*db.Professional, error) {
+	if err := requireOwner(ctx); err != nil {
+		return nil, err
+	}
+	return s.GetProfessional(ctx, profID)
+}
+
+// CheckSlug dice si la dirección pública está libre para este profesional (la propia cuenta).
+// Las direcciones inválidas o reservadas vuelven como no disponibles, con el motivo.
+func (s *Service) CheckSlug(ctx context.Context, profID int64, raw string) (*SlugAvailability, error) {
+	slug, err := validateSlug(raw)
+	if err != nil {
+		return &SlugAvailability{Slug: normalizeSlug(raw), Reason: err.Error()}, nil
+	}
+
+	available, err := s.queries.CheckSlugAvailability(ctx, db.CheckSlugAvailabilityParams{
+		Slug: sql.NullString{String: slug, Valid: true},
+		ID:   profID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error verificando dirección pública: %w", err)
+	}
+	result := &SlugAvailability{Slug: slug, Available: available}
+	if !available {
+		result.Reason = ErrSlugTaken.Error()
+	}
+	return result, nil
+}
+
+// UpdateProfile actualiza los datos que se muestran en la página pública. La foto se cambia
+// aparte, con UploadProfilePhoto.
+func (s *Service) UpdateProfile(ctx context.Context, req UpdateProfileRequest) (*db.Professional, error) {
+	if err := requireOwner(ctx); err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, ErrNameRequired
+	}
+	license, err := normalizeLicense(req.LicenseNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	var slug string
+	if strings.TrimSpace(req.Slug) != "" {
+		if slug, err = validateSlug(req.Slug); err != nil {
+			return nil, err
+		}
+		available, err := s.queries.CheckSlugAvailability(ctx, db.CheckSlugAvailabilityParams{
+			Slug: sql.NullString{String: slug, Valid: true},
+			ID:   req.ProfessionalID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error verificando dirección pública: %w", err)
+		}
+		if !available {
+			return nil, ErrSlugTaken
+		}
+	}
+
+	current, err := s.GetProfessional(ctx, req.ProfessionalID)
+	if err != nil {
+		return nil, err
+	}
+
+	phone := strings.TrimSpace(req.Phone)
+	title := strings.TrimSpace(req.Title)
+	bio := strings.TrimSpace(req.Bio)
+	prof, err := s.queries.UpdateProfessionalProfile(ctx, db.UpdateProfessionalProfileParams{
+		Name:          name,
+		Phone:         sql.NullString{String: phone, Valid: phone != ""},
+		Slug:          sql.NullString{String: slug, Valid: slug != ""},
+		PhotoUrl:      current.PhotoUrl,
+		Title:         sql.NullString{String: title, Valid: title != ""},
+		LicenseNumber: sql.NullString{String: license, Valid: license != ""},
+		Bio:           sql.NullString{String: bio, Valid: bio != ""},
+		ID:            req.ProfessionalID,
+	})
+	if err != nil {
+		return nil, profileConflict(err)
+	}
+	return &prof, nil
+}
+
+// UploadProfilePhoto guarda la foto nueva y reemplaza a la anterior. Cada foto tiene su propia
+// URL, así que los navegadores no se quedan con la vieja en caché.
+func (s *Service) UploadProfilePhoto(ctx context.Context, profID int64, data []byte) (*db.Professional, error) {
+	if err := requireOwner(ctx); err != nil {
+		return nil, err
+	}
+	if s.blobs == nil {
+		return nil, ErrStorageUnavailable
+	}
+	if len(data) == 0 {
+		return nil, ErrEmptyAttachment
+	}
+	if len(data) > MaxProfilePhotoBytes {
+		return nil, ErrAttachmentTooLarge
+	}
+
+	contentType, _, err := mime.ParseMediaType(http.DetectContentType(data))
+	if err != nil || !allowedProfilePhotoTypes[contentType] {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFileType, contentType)
+	}
+
+	current, err := s.GetProfessional(ctx, profID)
+	if err != nil {
+		return nil, err
+	}
+
+	storageKey, err := newStorageKey(profilePhotoKind, profID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.blobs.Put(ctx, storageKey, data, contentType); err != nil {
+		return nil, fmt.Errorf("error guardando archivo: %w", err)
+	}
+
+	prof, err := s.queries.SetProfessionalPhoto(ctx, db.SetProfessionalPhotoParams{
+		PhotoUrl: sql.NullString{String: profilePhotoPath + storageKey, Valid: true},
+		ID:       profID,
+	})
+	if err != nil {
+		if delErr := s.blobs.Delete(ctx, storageKey); delErr != nil {
+			log.Printf("no se pudo borrar el blob huérfano %s: %v", storageKey, delErr)
+		}
+		return nil, fmt.Errorf("error guardando foto de perfil: %w", err)
+	}
+
+	s.deleteProfilePhoto(ctx, current.PhotoUrl)
+	return &prof, nil
+}
+
+// DeleteProfilePhoto saca la foto del perfil.
+func (s *Service) DeleteProfilePhoto(ctx context.Context, profID int64) error {
+	if err := requireOwner(ctx); err != nil {
+		return err
+	}
+	current, err := s.GetProfessional(ctx, profID)
+	if err != nil {
+		return err
+	}
+	if !current.PhotoUrl.Valid {
+		return ErrNotFound
+	}
+
+	if _, err := s.queries.SetProfessionalPhoto(ctx, db.SetProfessionalPhotoParams{ID: profID}); err != nil {
+		return fmt.Errorf("error borrando foto de perfil: %w", err)
+	}
+	s.deleteProfilePhoto(ctx, current.PhotoUrl)
+	return nil
+}
+
+// GetProfilePhoto devuelve la foto vigente del profesional. Las reemplazadas no se sirven
+// aunque alguien conserve la URL.
+func (s *Service) GetProfilePhoto(ctx context.Context, profID int64, file string) ([]byte, string, error) {
+	if s.blobs == nil {
+		return nil, "", ErrStorageUnavailable
+	}
+	prof, err := s.GetProfessional(ctx, profID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	storageKey := fmt.Sprintf("%s/%d/%s", profilePhotoKind, profID, file)
+	if prof.PhotoUrl.String != profilePhotoPath+storageKey {
+		return nil, "", ErrNotFound
+	}
+	data, err := s.blobs.Get(ctx, storageKey)
+	if err != nil {
+		return nil, "", fmt.Errorf("error leyendo archivo: %w", err)
+	}
+	return data, http.DetectContentType(data), nil
+}
+
+// deleteProfilePhoto borra del almacenamiento una foto que ya no está referenciada. Si falla
+// sólo ocupa lugar; las URLs externas (cargadas antes de que existiera la subida) se ignoran.
+func (s *Service) deleteProfilePhoto(ctx context.Context, photoURL sql.NullString) {
+	storageKey, ok := strings.CutPrefix(photoURL.String, profilePhotoPath)
+	if !ok || !strings.HasPrefix(storageKey, profilePhotoKind+"/") {
+		return
+	}
+	if err := s.blobs.Delete(ctx, storageKey); err != nil {
+		log.Printf("no se pudo borrar la foto reemplazada %s: %v", storageKey, err)
+	}
+}
+
+// registrationSlug valida la dirección que eligió al registrarse o, si no eligió ninguna, propone
+// una con el nombre.
+func registrationSlug(ctx context.Context, q *db.Queries, req RegisterRequest) (string, error) {
+	if strings.TrimSpace(req.Slug) == "" {
+		return defaultSlug(ctx, q, req.Name)
+	}
+	slug, err := validateSlug(req.Slug)
+	if err != nil {
+		return "", err
+	}
+	available, err := q.CheckSlugAvailability(ctx, db.CheckSlugAvailabilityParams{
+		Slug: sql.NullString{String: slug, Valid: true},
+	})
+	if err != nil {
+		return "", fmt.Errorf("error verificando dirección pública: %w", err)
+	}
+	if !available {
+		return "", ErrSlugTaken
+	}
+	return slug, nil
+}
+
+// defaultSlug propone una dirección pública libre a partir del nombre: "lucia-perez",
+// "lucia-perez-2"... Si el nombre no sirve o están todas tomadas, queda sin dirección.
+func defaultSlug(ctx context.Context, q *db.Queries, name string) (string, error) {
+	base, err := validateSlug(name)
+	if err != nil {
+		return "", nil
+	}
+	for i := 1; i <= 9; i++ {
+		slug := base
+		if i > 1 {
+			slug = fmt.Sprintf("%s-%d", strings.TrimSuffix(base[:min(len(base), maxSlugLength-2)], "-"), i)
+		}
+		available, err := q.CheckSlugAvailability(ctx, db.CheckSlugAvailabilityParams{
+			Slug: sql.NullString{String: slug, Valid: true},
+		})
+		if err != nil {
+			return "", fmt.Errorf("error verificando dirección pública: %w", err)
+		}
+		if available {
+			return slug, nil
+		}
+	}
+	return "", nil
+}
+
+// profileConflict traduce las restricciones UNIQUE del perfil a errores de negocio.
+func profileConflict(err error) error {
+	var pqErr *pq.Error
+	if isUniqueViolation(err) && errors.As(err, &pqErr) {
+		switch pqErr.Constraint {
+		case "professionals_slug_key":
+			return ErrSlugTaken // Otro la tomó entre el chequeo y el guardado
+		case "professionals_phone_key":
+			return ErrPhoneTaken
+		case "professionals_email_key":
+			return ErrEmailTaken
+		}
+	}
+	return fmt.Errorf("error actualizando perfil: %w", err)
+}
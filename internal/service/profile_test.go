@@ -0,0 +1,91 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeSlug(t *testing.T) {
+	cases := []struct {
+		raw  string
+		want string
+	}{
+		{"Lucía  Pérez!", "lucia-perez"},
+		{"  Dra. Ana María Núñez ", "dra-ana-maria-nunez"},
+		{"--hola--", "hola"},
+		{"ÑANDÚ 2024", "nandu-2024"},
+		{"lucia-perez", "lucia-perez"},
+		{"!!!", ""},
+		{"", ""},
+		{strings.Repeat("a", 59) + " b", strings.Repeat("a", 59)},
+	}
+
+	for _, tc := range cases {
+		if got := normalizeSlug(tc.raw); got != tc.want {
+			t.Fatalf("normalizeSlug(%q) = %q, se esperaba %q", tc.raw, got, tc.want)
+		}
+	}
+}
+
+func TestValidateSlug(t *testing.T) {
+	cases := []struct {
+		raw     string
+		want    string
+		wantErr error
+	}{
+		{"Lucía Pérez", "lucia-perez", nil},
+		{"ab", "", ErrInvalidSlug},
+		{"¡¡a!!", "", ErrInvalidSlug},
+		{"Admin", "", ErrReservedSlug},
+		{"Turnos!", "", ErrReservedSlug},
+		{"configuración", "", ErrReservedSlug},
+	}
+
+	for _, tc := range cases {
+		got, err := validateSlug(tc.raw)
+		if !errors.Is(err, tc.wantErr) || got != tc.want {
+			t.Fatalf("validateSlug(%q) = %q, %v; se esperaba %q, %v", tc.raw, got, err, tc.want, tc.wantErr)
+		}
+	}
+}
+
+// Las reservadas tienen que estar escritas como quedan normalizadas: si no, nunca coinciden.
+func TestReservedSlugsAreNormalized(t *testing.T) {
+	for slug := range reservedSlugs {
+		if got := normalizeSlug(slug); got != slug {
+			t.Fatalf("la reservada %q se normaliza como %q", slug, got)
+		}
+		if _, err := validateSlug(slug); !errors.Is(err, ErrReservedSlug) && !errors.Is(err, ErrInvalidSlug) {
+			t.Fatalf("validateSlug(%q) aceptó una dirección reservada", slug)
+		}
+	}
+}
+
+func TestNormalizeLicense(t *testing.T) {
+	cases := []struct {
+		raw     string
+		want    string
+		wantErr error
+	}{
+		{"M.N. 12345", "M.N. 12345", nil},
+		{"mp  4567", "MP 4567", nil},
+		{"Mat. 123", "MAT. 123", nil},
+		{"MN 12345678", "MN 12345678", nil},
+		{"12345", "12345", nil},
+		{"  ", "", nil},
+		{"", "", nil},
+		{"12", "", ErrInvalidLicense},
+		{"123456789", "", ErrInvalidLicense},
+		{"MN-1234", "", ErrInvalidLicense},
+		{"1234 MN", "", ErrInvalidLicense},
+		{"matrícula", "", ErrInvalidLicense},
+	}
+
+	for _, tc := range cases {
+		got, err := normalizeLicense(tc.raw)
+		if !errors.Is(err, tc.wantErr) || got != tc.want {
+			t.Fatalf("normalizeLicense(%q) = %q, %v; se esperaba %q, %v", tc.raw, got, err, tc.want, tc.wantErr)
+		}
+	}
+}
@@ -2,10 +2,24 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/luciluz/psiconexo/internal/db"
 )
 
+// ErrSlotUnavailable se devuelve cuando el horario que pide el paciente no se puede agendar.
+var ErrSlotUnavailable = errors.New("el horario elegido no está disponible")
+
+// Valores por defecto de professional_settings, para quien todavía no la configuró.
+const (
+	defaultMinBookingNoticeHours = 24
+	defaultBufferMinutes         = 0
+	defaultTimeIncrementMinutes  = 30
+	defaultDurationMinutes       = 50
+)
+
 type ScheduleBlock struct {
 	DayOfWeek int
 	StartTime string
@@ -53,3 +67,178 @@ func (s *Service) UpdateSchedule(ctx context.Context, req UpdateScheduleRequest)
 func (s *Service) ListSchedule(ctx context.Context, professionalID int64) ([]db.ScheduleConfig, error) {
 	return s.queries.ListScheduleConfigs(ctx, professionalID)
 }
+
+// bookingRules son las reglas de la agenda del profesional para los turnos que pide el paciente
+// (no para los que agenda el profesional, que los pone donde quiera).
+type bookingRules struct {
+	notice    time.Duration // Anticipación mínima
+	buffer    time.Duration // Margen entre turnos
+	maxDaily  int           // 0 = sin tope
+	increment time.Duration // Cada cuánto se ofrecen horarios dentro de un bloque
+	duration  int           // Duración en minutos de un turno nuevo
+	approval  bool          // Los pedidos de la página pública esperan la aprobación del profesional
+	blocks    []db.ScheduleConfig
+}
+
+// busySlot es un turno que ocupa la agenda (cualquier estado salvo cancelado o reprogramado).
+type busySlot struct {
+	id         int64
+	start, end time.Time
+}
+
+func (s *Service) loadBookingRules(ctx context.Context, profID int64) (*bookingRules, error) {
+	settings, err := s.GetSettings(ctx, profID)
+	if err != nil {
+		return nil, err
+	}
+	rules := &bookingRules{
+		notice:    defaultMinBookingNoticeHours * time.Hour,
+		buffer:    defaultBufferMinutes * time.Minute,
+		increment: defaultTimeIncrementMinutes * time.Minute,
+		duration:  defaultDurationMinutes,
+		approval:  true,
+	}
+	// La configuración común del centro pisa los valores del sistema; la propia, a las dos
+	org, _, err := s.organizationFor(ctx, profID)
+	if err != nil && !errors.Is(err, ErrNoOrganization) {
+		return nil, err
+	}
+	if org != nil {
+		if org.MinBookingNoticeHours.Valid {
+			rules.notice = time.Duration(org.MinBookingNoticeHours.Int32) * time.Hour
+		}
+		if org.BufferMinutes.Valid {
+			rules.buffer = time.Duration(org.BufferMinutes.Int32) * time.Minute
+		}
+		if org.TimeIncrementMinutes.Valid && org.TimeIncrementMinutes.Int32 > 0 {
+			rules.increment = time.Duration(org.TimeIncrementMinutes.Int32) * time.Minute
+		}
+		if org.DefaultDurationMinutes.Valid && org.DefaultDurationMinutes.Int32 > 0 {
+			rules.duration = int(org.DefaultDurationMinutes.Int32)
+		}
+		if org.BookingRequiresApproval.Valid {
+			rules.approval = org.BookingRequiresApproval.Bool
+		}
+	}
+	if settings != nil {
+		if settings.MinBookingNoticeHours.Valid {
+			rules.notice = time.Duration(settings.MinBookingNoticeHours.Int32) * time.Hour
+		}
+		if settings.BufferMinutes.Valid {
+			rules.buffer = time.Duration(settings.BufferMinutes.Int32) * time.Minute
+		}
+		if settings.MaxDailyAppointments.Valid {
+			rules.maxDaily = int(settings.MaxDailyAppointments.Int32)
+		}
+		if settings.TimeIncrementMinutes.Valid && settings.TimeIncrementMinutes.Int32 > 0 {
+			rules.increment = time.Duration(settings.TimeIncrementMinutes.Int32) * time.Minute
+		}
+		if settings.DefaultDurationMinutes.Valid && settings.DefaultDurationMinutes.Int32 > 0 {
+			rules.duration = int(settings.DefaultDurationMinutes.Int32)
+		}
+		if settings.BookingRequiresApproval.Valid {
+			rules.approval = settings.BookingRequiresApproval.Bool
+		}
+	}
+
+	rules.blocks, err = s.queries.ListScheduleConfigs(ctx, profID)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo horarios de atención: %w", err)
+	}
+	return rules, nil
+}
+
+// check valida [start, end) contra las reglas y los turnos de ese día. excludeID es el turno
+// que se está reprogramando, que todavía ocupa su horario.
+func (r *bookingRules) check(date, start, end time.Time, busy []busySlot, excludeID int64) error {
+	if start.Before(time.Now().Add(r.notice)) {
+		return fmt.Errorf("%w: se necesitan al menos %d horas de anticipación", ErrSlotUnavailable, int(r.notice.Hours()))
+	}
+	if !withinSchedule(r.blocks, date, start, end) {
+		return fmt.Errorf("%w: fuera del horario de atención", ErrSlotUnavailable)
+	}
+
+	booked := 0
+	for _, b := range busy {
+		if b.id == excludeID {
+			continue
+		}
+		booked++
+		if start.Before(b.end.Add(r.buffer)) && end.Add(r.buffer).After(b.start) {
+			return fmt.Errorf("%w: se superpone con otro turno", ErrSlotUnavailable)
+		}
+	}
+	if r.maxDaily > 0 && booked >= r.maxDaily {
+		return fmt.Errorf("%w: no quedan turnos ese día", ErrSlotUnavailable)
+	}
+	return nil
+}
+
+// checkBookableSlot valida un horario que pide el paciente: dentro de un bloque de atención,
+// con la anticipación mínima, sin pasar el máximo diario y sin chocar con otro turno
+// (respetando el margen entre turnos).
+func (s *Service) checkBookableSlot(ctx context.Context, profID int64, date time.Time, startTime string, duration int, excludeID int64) error {
+	rules, err := s.loadBookingRules(ctx, profID)
+	if err != nil {
+		return err
+	}
+	return s.checkSlotWithRules(ctx, rules, profID, date, startTime, duration, excludeID)
+}
+
+func (s *Service) checkSlotWithRules(ctx context.Context, rules *bookingRules, profID int64, date time.Time, startTime string, duration int, excludeID int64) error {
+	start, err := appointmentStart(date, startTime)
+	if err != nil {
+		return fmt.Errorf("%w: hora inválida (use HH:MM)", ErrSlotUnavailable)
+	}
+	end := start.Add(time.Duration(duration) * time.Minute)
+
+	dayAppts, err := s.queries.GetDayAppointments(ctx, db.GetDayAppointmentsParams{
+		ProfessionalID: profID,
+		Column2:        date,
+	})
+	if err != nil {
+		return fmt.Errorf("error obteniendo agenda del día: %w", err)
+	}
+	busy := make([]busySlot, 0, len(dayAppts))
+	for _, appt := range dayAppts {
+		apptStart, err := appointmentStart(date, appt.StartTime)
+		if err != nil {
+			continue
+		}
+		busy = append(busy, busySlot{appt.ID, apptStart, apptStart.Add(time.Duration(appt.DurationMinutes) * time.Minute)})
+	}
+	return rules.check(date, start, end, busy, excludeID)
+}
+
+// withinSchedule dice si [start, end) cae entero dentro de alguno de los bloques de ese día.
+// Los días van de 1 (lunes) a 7 (domingo).
+func withinSchedule(blocks []db.ScheduleConfig, date, start, end time.Time) bool {
+	weekday := int(date.Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+	for _, b := range blocks {
+		if int(b.DayOfWeek) != weekday {
+			continue
+		}
+		blockStart, err1 := appointmentStart(date, b.StartTime)
+		blockEnd, err2 := appointmentStart(date, b.EndTime)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		if !start.Before(blockStart) && !end.After(blockEnd) {
+			return true
+		}
+	}
+	return false
+}
+
+// appointmentStart combina la fecha del turno con su hora "HH:MM" en la hora local del servidor,
+// la misma con la que se materializan los turnos recurrentes.
+func appointmentStart(date time.Time, hhmm string) (time.Time, error) {
+	t, err := time.Parse("15:04", hhmm)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), 0, 0, time.Local), nil
+}
@@ -2,18 +2,84 @@ package service
 
 import (
 	"database/sql"
+	"errors"
+	"strings"
 
+	"github.com/luciluz/psiconexo/internal/auth"
+	"github.com/luciluz/psiconexo/internal/blobstore"
+	"github.com/luciluz/psiconexo/internal/captcha"
 	"github.com/luciluz/psiconexo/internal/db"
+	"github.com/luciluz/psiconexo/internal/keyring"
+	"github.com/luciluz/psiconexo/internal/mailer"
 )
 
+// ErrNotFound se devuelve cuando el recurso no existe o no pertenece al profesional.
+var ErrNotFound = errors.New("recurso no encontrado")
+
 type Service struct {
 	queries *db.Queries
 	db      *sql.DB
+	keys    *keyring.Keyring // Cifrado de la historia clínica; nil = no configurado
+	mailer  mailer.Mailer    // Avisos por email; nil = no se envían
+	blobs   blobstore.Store  // Adjuntos; nil = no configurado
+	tokens  *auth.Tokens     // Sesiones de profesionales y asistentes; nil = no configurado
+	captcha captcha.Verifier // Desafío anti-bots de la reserva pública; nil = sólo los límites por IP y email
+	appURL  string           // Base de los enlaces que van en los emails
+}
+
+// Option configura dependencias opcionales del servicio.
+type Option func(*Service)
+
+// WithKeyring habilita el cifrado en sobre de las notas clínicas.
+func WithKeyring(kr *keyring.Keyring) Option {
+	return func(s *Service) {
+		s.keys = kr
+	}
+}
+
+// WithMailer habilita el envío de emails (recordatorios, avisos).
+func WithMailer(m mailer.Mailer) Option {
+	return func(s *Service) {
+		s.mailer = m
+	}
+}
+
+// WithBlobStore habilita los adjuntos (disco local o S3).
+func WithBlobStore(store blobstore.Store) Option {
+	return func(s *Service) {
+		s.blobs = store
+	}
 }
 
-func NewService(queries *db.Queries, dbConn *sql.DB) *Service {
-	return &Service{
+// WithTokens habilita el login y la firma de las sesiones.
+func WithTokens(t *auth.Tokens) Option {
+	return func(s *Service) {
+		s.tokens = t
+	}
+}
+
+// WithCaptcha exige resolver el captcha para pedir turnos desde la página pública.
+func WithCaptcha(v captcha.Verifier) Option {
+	return func(s *Service) {
+		s.captcha = v
+	}
+}
+
+// WithAppURL fija la dirección de la aplicación web, para armar los enlaces de los emails.
+func WithAppURL(url string) Option {
+	return func(s *Service) {
+		s.appURL = strings.TrimRight(url, "/")
+	}
+}
+
+func NewService(queries *db.Queries, dbConn *sql.DB, opts ...Option) *Service {
+	s := &Service{
 		queries: queries,
 		db:      dbConn,
+		appURL:  "http://localhost:8080",
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
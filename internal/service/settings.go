@@ -9,12 +9,13 @@ import (
 )
 
 type UpdateSettingsRequest struct {
-	ProfessionalID         int64
-	DefaultDurationMinutes int
-	BufferMinutes          int
-	TimeIncrementMinutes   int
-	MinBookingNoticeHours  int
-	MaxDailyAppointments   *int
+	ProfessionalID          int64
+	DefaultDurationMinutes  int
+	BufferMinutes           int
+	TimeIncrementMinutes    int
+	MinBookingNoticeHours   int
+	MaxDailyAppointments    *int
+	BookingRequiresApproval *bool // nil = sí (los pedidos de la página pública esperan aprobación)
 }
 
 func (s *Service) UpdateSettings(ctx context.Context, req UpdateSettingsRequest) (*db.ProfessionalSetting, error) {
@@ -27,14 +28,20 @@ func (s *Service) UpdateSettings(ctx context.Context, req UpdateSettingsRequest)
 		maxDaily = sql.NullInt32{Valid: false}
 	}
 
+	requiresApproval := true
+	if req.BookingRequiresApproval != nil {
+		requiresApproval = *req.BookingRequiresApproval
+	}
+
 	// Usamos Upsert: Crea o Actualiza
 	settings, err := s.queries.UpsertProfessionalSettings(ctx, db.UpsertProfessionalSettingsParams{
-		ProfessionalID:         req.ProfessionalID,
-		DefaultDurationMinutes: sql.NullInt32{Int32: int32(req.DefaultDurationMinutes), Valid: true},
-		BufferMinutes:          sql.NullInt32{Int32: int32(req.BufferMinutes), Valid: true},
-		TimeIncrementMinutes:   sql.NullInt32{Int32: int32(req.TimeIncrementMinutes), Valid: true},
-		MinBookingNoticeHours:  sql.NullInt32{Int32: int32(req.MinBookingNoticeHours), Valid: true},
-		MaxDailyAppointments:   maxDaily,
+		ProfessionalID:          req.ProfessionalID,
+		DefaultDurationMinutes:  sql.NullInt32{Int32: int32(req.DefaultDurationMinutes), Valid: true},
+		BufferMinutes:           sql.NullInt32{Int32: int32(req.BufferMinutes), Valid: true},
+		TimeIncrementMinutes:    sql.NullInt32{Int32: int32(req.TimeIncrementMinutes), Valid: true},
+		MinBookingNoticeHours:   sql.NullInt32{Int32: int32(req.MinBookingNoticeHours), Valid: true},
+		MaxDailyAppointments:    maxDaily,
+		BookingRequiresApproval: sql.NullBool{Bool: requiresApproval, Valid: true},
 	})
 
 	if err != nil {
@@ -0,0 +1,431 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/luciluz/psiconexo/internal/auth"
+	"github.com/luciluz/psiconexo/internal/db"
+)
+
+var (
+	ErrTwoFactorAlreadyEnabled   = errors.New("la verificación en dos pasos ya está activada")
+	ErrTwoFactorNotEnabled       = errors.New("la verificación en dos pasos no está activada")
+	ErrTwoFactorRequired         = errors.New("su organización exige verificación en dos pasos; vuelva a iniciar sesión para configurarla")
+	ErrInvalidTwoFactorCode      = errors.New("código de verificación incorrecto")
+	ErrInvalidTwoFactorChallenge = errors.New("el inicio de sesión venció; vuelva a ingresar email y contraseña")
+	ErrTwoFactorLocked           = errors.New("demasiados códigos incorrectos; espere unos minutos y vuelva a intentar")
+)
+
+const (
+	twoFactorIssuer      = "Psiconexo"
+	recoveryCodeCount    = 10
+	maxTwoFactorAttempts = 5
+	twoFactorLockout     = 15 * time.Minute
+)
+
+// Pasos que puede pedir el login después de validar la contraseña.
+const (
+	TwoFactorStepVerify = "verify"
+	TwoFactorStepEnroll = "enroll" // La organización exige 2FA y el profesional no la configuró
+)
+
+// TwoFactorChallenge es la respuesta del login cuando la contraseña no alcanza.
+type TwoFactorChallenge struct {
+	Step           string    `json:"two_factor_step"`
+	ChallengeToken string    `json:"challenge_token"`
+	ExpiresAt      time.Time `json:"challenge_expires_at"`
+}
+
+// TwoFactorEnrollment es lo que se muestra al dar de alta la 2FA: el QR se genera con
+// ProvisioningURI y Secret queda para cargarlo a mano.
+type TwoFactorEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type TwoFactorStatus struct {
+	Enabled                bool         `json:"enabled"`
+	ConfirmedAt            sql.NullTime `json:"confirmed_at"`
+	RecoveryCodesRemaining int64        `json:"recovery_codes_remaining"`
+	RequiredByOrganization bool         `json:"required_by_organization"`
+}
+
+// EnrolledSession es la sesión que se abre al terminar el alta obligatoria, junto con los
+// códigos de recuperación (se muestran una sola vez).
+type EnrolledSession struct {
+	*Session
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// twoFactorAAD liga el secreto cifrado a su profesional.
+func twoFactorAAD(profID int64) []byte {
+	return fmt.Appendf(nil, "two_factor:%d", profID)
+}
+
+// GetTwoFactorStatus informa si la 2FA está activa, cuántos códigos de recuperación quedan y
+// si la organización la exige.
+func (s *Service) GetTwoFactorStatus(ctx context.Context, profID int64) (*TwoFactorStatus, error) {
+	status := &TwoFactorStatus{}
+
+	tf, err := s.queries.GetTwoFactor(ctx, profID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("error obteniendo verificación en dos pasos: %w", err)
+	}
+	if err == nil && tf.ConfirmedAt.Valid {
+		status.Enabled = true
+		status.ConfirmedAt = tf.ConfirmedAt
+		if status.RecoveryCodesRemaining, err = s.queries.CountRecoveryCodes(ctx, profID); err != nil {
+			return nil, fmt.Errorf("error contando códigos de recuperación: %w", err)
+		}
+	}
+
+	if status.RequiredByOrganization, err = s.queries.OrganizationRequiresTwoFactor(ctx, profID); err != nil {
+		return nil, fmt.Errorf("error obteniendo política de la organización: %w", err)
+	}
+	return status, nil
+}
+
+// BeginTwoFactorEnrollment genera un secreto nuevo. La 2FA no queda activa hasta confirmarla
+// con un código de la app; repetir el alta antes de confirmar reemplaza el secreto.
+func (s *Service) BeginTwoFactorEnrollment(ctx context.Context, profID int64) (*TwoFactorEnrollment, error) {
+	if s.keys == nil {
+		return nil, ErrEncryptionUnavailable
+	}
+	prof, err := s.GetProfessional(ctx, profID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := auth.NewTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("error generando secreto: %w", err)
+	}
+	sealed, version, err := s.keys.Seal([]byte(secret), twoFactorAAD(profID))
+	if err != nil {
+		return nil, fmt.Errorf("error cifrando secreto: %w", err)
+	}
+
+	_, err = s.queries.StartTwoFactorEnrollment(ctx, db.StartTwoFactorEnrollmentParams{
+		ProfessionalID: profID,
+		Secret:         sealed,
+		KeyVersion:     sql.NullInt32{Int32: version, Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTwoFactorAlreadyEnabled
+		}
+		return nil, fmt.Errorf("error guardando secreto: %w", err)
+	}
+
+	return &TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningURI: auth.TOTPProvisioningURI(twoFactorIssuer, prof.Email, secret),
+	}, nil
+}
+
+// ConfirmTwoFactorEnrollment activa la 2FA con el primer código de la app y devuelve los
+// códigos de recuperación.
+func (s *Service) ConfirmTwoFactorEnrollment(ctx context.Context, profID int64, code string) ([]string, error) {
+	tf, err := s.queries.GetTwoFactor(ctx, profID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTwoFactorNotEnabled
+		}
+		return nil, fmt.Errorf("error obteniendo verificación en dos pasos: %w", err)
+	}
+	if tf.ConfirmedAt.Valid {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if !auth.IsTOTPCode(code) {
+		return nil, ErrInvalidTwoFactorCode // Durante el alta todavía no hay códigos de recuperación
+	}
+	if err := s.checkTwoFactorCode(ctx, &tf, code); err != nil {
+		return nil, err
+	}
+	return s.replaceRecoveryCodes(ctx, profID)
+}
+
+// RegenerateRecoveryCodes invalida los códigos de recuperación anteriores y emite otros.
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, profID int64, code string) ([]string, error) {
+	if err := s.verifyTwoFactor(ctx, profID, code); err != nil {
+		return nil, err
+	}
+	return s.replaceRecoveryCodes(ctx, profID)
+}
+
+// DisableTwoFactor apaga la 2FA (pide un código vigente). No se puede si la organización la exige.
+func (s *Service) DisableTwoFactor(ctx context.Context, profID int64, code string) error {
+	required, err := s.queries.OrganizationRequiresTwoFactor(ctx, profID)
+	if err != nil {
+		return fmt.Errorf("error obteniendo política de la organización: %w", err)
+	}
+	if required {
+		return ErrTwoFactorRequired
+	}
+	if err := s.verifyTwoFactor(ctx, profID, code); err != nil {
+		return err
+	}
+	return s.removeTwoFactor(ctx, profID)
+}
+
+// ResetTwoFactor la borra sin pedir código (soporte, ante la pérdida del teléfono y de los
+// códigos de recuperación) y cierra las sesiones abiertas.
+func (s *Service) ResetTwoFactor(ctx context.Context, email string) error {
+	prof, err := s.queries.GetProfessionalByEmail(ctx, normalizeEmail(email))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("error obteniendo profesional: %w", err)
+	}
+	if err := s.removeTwoFactor(ctx, prof.ID); err != nil {
+		return err
+	}
+	return s.queries.RevokeProfessionalRefreshTokens(ctx, prof.ID)
+}
+
+// CompleteTwoFactorLogin canjea el paso intermedio del login y un código (de la app o de
+// recuperación) por la sesión.
+func (s *Service) CompleteTwoFactorLogin(ctx context.Context, challengeToken, code string) (*Session, error) {
+	if s.tokens == nil {
+		return nil, ErrAuthUnavailable
+	}
+	claims, err := s.tokens.VerifyTwoFactorChallenge(challengeToken)
+	if err != nil {
+		return nil, ErrInvalidTwoFactorChallenge
+	}
+	if err := s.verifyTwoFactor(ctx, claims.ProfessionalID, code); err != nil {
+		if errors.Is(err, ErrTwoFactorNotEnabled) {
+			return nil, ErrInvalidTwoFactorChallenge // La apagaron desde otra sesión: que vuelva a entrar
+		}
+		return nil, err
+	}
+
+	prof, err := s.queries.GetProfessional(ctx, claims.ProfessionalID)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo profesional: %w", err)
+	}
+	return s.openSession(ctx, prof)
+}
+
+// BeginRequiredEnrollment es el alta de 2FA durante el login, cuando la organización la exige.
+func (s *Service) BeginRequiredEnrollment(ctx context.Context, enrollmentToken string) (*TwoFactorEnrollment, error) {
+	profID, err := s.verifyEnrollmentToken(enrollmentToken)
+	if err != nil {
+		return nil, err
+	}
+	return s.BeginTwoFactorEnrollment(ctx, profID)
+}
+
+// CompleteRequiredEnrollment confirma el alta obligatoria y abre la sesión.
+func (s *Service) CompleteRequiredEnrollment(ctx context.Context, enrollmentToken, code string) (*EnrolledSession, error) {
+	profID, err := s.verifyEnrollmentToken(enrollmentToken)
+	if err != nil {
+		return nil, err
+	}
+	codes, err := s.ConfirmTwoFactorEnrollment(ctx, profID, code)
+	if err != nil {
+		return nil, err
+	}
+
+	prof, err := s.queries.GetProfessional(ctx, profID)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo profesional: %w", err)
+	}
+	session, err := s.openSession(ctx, prof)
+	if err != nil {
+		return nil, err
+	}
+	return &EnrolledSession{Session: session, RecoveryCodes: codes}, nil
+}
+
+func (s *Service) verifyEnrollmentToken(token string) (int64, error) {
+	if s.tokens == nil {
+		return 0, ErrAuthUnavailable
+	}
+	claims, err := s.tokens.VerifyTwoFactorEnrollment(token)
+	if err != nil {
+		return 0, ErrInvalidTwoFactorChallenge
+	}
+	return claims.ProfessionalID, nil
+}
+
+// twoFactorChallenge decide, con la contraseña ya validada, si falta un segundo paso.
+// Devuelve nil si se puede abrir la sesión directamente.
+func (s *Service) twoFactorChallenge(ctx context.Context, profID int64) (*TwoFactorChallenge, error) {
+	tf, err := s.queries.GetTwoFactor(ctx, profID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("error obteniendo verificación en dos pasos: %w", err)
+	}
+	if err == nil && tf.ConfirmedAt.Valid {
+		token, expires, err := s.tokens.IssueTwoFactorChallenge(profID)
+		if err != nil {
+			return nil, fmt.Errorf("error emitiendo token: %w", err)
+		}
+		return &TwoFactorChallenge{Step: TwoFactorStepVerify, ChallengeToken: token, ExpiresAt: expires}, nil
+	}
+
+	required, err := s.queries.OrganizationRequiresTwoFactor(ctx, profID)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo política de la organización: %w", err)
+	}
+	if !required {
+		return nil, nil
+	}
+	token, expires, err := s.tokens.IssueTwoFactorEnrollment(profID)
+	if err != nil {
+		return nil, fmt.Errorf("error emitiendo token: %w", err)
+	}
+	return &TwoFactorChallenge{Step: TwoFactorStepEnroll, ChallengeToken: token, ExpiresAt: expires}, nil
+}
+
+// requireTwoFactorCompliance rechaza renovar sesiones de quien no cumple la política de su
+// organización (por ejemplo, si la empezó a exigir después de que inició sesión).
+func (s *Service) requireTwoFactorCompliance(ctx context.Context, profID int64) error {
+	required, err := s.queries.OrganizationRequiresTwoFactor(ctx, profID)
+	if err != nil {
+		return fmt.Errorf("error obteniendo política de la organización: %w", err)
+	}
+	if !required {
+		return nil
+	}
+	tf, err := s.queries.GetTwoFactor(ctx, profID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !tf.ConfirmedAt.Valid) {
+		return ErrTwoFactorRequired
+	}
+	if err != nil {
+		return fmt.Errorf("error obteniendo verificación en dos pasos: %w", err)
+	}
+	return nil
+}
+
+// verifyTwoFactor valida un código de la app o, si no tiene esa forma, de recuperación.
+func (s *Service) verifyTwoFactor(ctx context.Context, profID int64, code string) error {
+	tf, err := s.queries.GetTwoFactor(ctx, profID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrTwoFactorNotEnabled
+		}
+		return fmt.Errorf("error obteniendo verificación en dos pasos: %w", err)
+	}
+	if !tf.ConfirmedAt.Valid {
+		return ErrTwoFactorNotEnabled
+	}
+	return s.checkTwoFactorCode(ctx, &tf, code)
+}
+
+// checkTwoFactorCode cuenta los intentos fallidos: después de maxTwoFactorAttempts seguidos
+// bloquea la 2FA por twoFactorLockout, así no se pueden probar los códigos por fuerza bruta.
+func (s *Service) checkTwoFactorCode(ctx context.Context, tf *db.ProfessionalTwoFactor, code string) error {
+	if tf.LockedUntil.Valid && time.Now().Before(tf.LockedUntil.Time) {
+		return ErrTwoFactorLocked
+	}
+
+	ok, err := s.matchTwoFactorCode(ctx, tf, code)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return nil
+	}
+
+	if err := s.queries.RecordTwoFactorFailure(ctx, db.RecordTwoFactorFailureParams{
+		MaxAttempts:    maxTwoFactorAttempts,
+		LockedUntil:    time.Now().Add(twoFactorLockout),
+		ProfessionalID: tf.ProfessionalID,
+	}); err != nil {
+		return fmt.Errorf("error registrando intento fallido: %w", err)
+	}
+	return ErrInvalidTwoFactorCode
+}
+
+func (s *Service) matchTwoFactorCode(ctx context.Context, tf *db.ProfessionalTwoFactor, code string) (bool, error) {
+	if !auth.IsTOTPCode(code) {
+		used, err := s.queries.UseRecoveryCode(ctx, db.UseRecoveryCodeParams{
+			ProfessionalID: tf.ProfessionalID,
+			CodeHash:       auth.HashRecoveryCode(code),
+		})
+		if err != nil {
+			return false, fmt.Errorf("error validando código de recuperación: %w", err)
+		}
+		if used == 0 {
+			return false, nil
+		}
+		if err := s.queries.ResetTwoFactorFailures(ctx, tf.ProfessionalID); err != nil {
+			return false, fmt.Errorf("error registrando código de recuperación: %w", err)
+		}
+		return true, nil
+	}
+
+	secret, err := s.openField(tf.Secret, tf.KeyVersion, twoFactorAAD(tf.ProfessionalID))
+	if err != nil {
+		return false, fmt.Errorf("error descifrando secreto: %w", err)
+	}
+	step, ok := auth.ValidateTOTP(secret, code, time.Now(), tf.LastUsedStep)
+	if !ok {
+		return false, nil
+	}
+	used, err := s.queries.UseTwoFactorStep(ctx, db.UseTwoFactorStepParams{Step: step, ProfessionalID: tf.ProfessionalID})
+	if err != nil {
+		return false, fmt.Errorf("error registrando código: %w", err)
+	}
+	return used > 0, nil // 0: otro request usó el mismo código primero
+}
+
+func (s *Service) replaceRecoveryCodes(ctx context.Context, profID int64) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		var err error
+		if codes[i], hashes[i], err = auth.NewRecoveryCode(); err != nil {
+			return nil, fmt.Errorf("error generando códigos de recuperación: %w", err)
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	qtx := s.queries.WithTx(tx)
+
+	if err := qtx.DeleteRecoveryCodes(ctx, profID); err != nil {
+		return nil, fmt.Errorf("error borrando códigos anteriores: %w", err)
+	}
+	for _, hash := range hashes {
+		if err := qtx.CreateRecoveryCode(ctx, db.CreateRecoveryCodeParams{ProfessionalID: profID, CodeHash: hash}); err != nil {
+			return nil, fmt.Errorf("error guardando códigos de recuperación: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *Service) removeTwoFactor(ctx context.Context, profID int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	qtx := s.queries.WithTx(tx)
+
+	if err := qtx.DeleteRecoveryCodes(ctx, profID); err != nil {
+		return fmt.Errorf("error borrando códigos de recuperación: %w", err)
+	}
+	if err := qtx.DeleteTwoFactor(ctx, profID); err != nil {
+		return fmt.Errorf("error desactivando verificación en dos pasos: %w", err)
+	}
+	return tx.Commit()
+}
@@ -3,18 +3,12 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/luciluz/psiconexo/internal/db"
 )
 
-type CreateProfessionalRequest struct {
-	Name                    string
-	Email                   string
-	Phone                   string
-	CancellationWindowHours int
-}
-
 type CreateClientRequest struct {
 	Name           string
 	Email          string
@@ -22,20 +16,6 @@ type CreateClientRequest struct {
 	ProfessionalID int64
 }
 
-func (s *Service) CreateProfessional(ctx context.Context, req CreateProfessionalRequest) (*db.Professional, error) {
-
-	prof, err := s.queries.CreateProfessional(ctx, db.CreateProfessionalParams{
-		Name:                    req.Name,
-		Email:                   req.Email,
-		Phone:                   sql.NullString{String: req.Phone, Valid: req.Phone != ""},
-		CancellationWindowHours: sql.NullInt32{Int32: int32(req.CancellationWindowHours), Valid: true},
-	})
-	if err != nil {
-		return nil, fmt.Errorf("error creando profesional: %w", err)
-	}
-	return &prof, nil
-}
-
 func (s *Service) CreateClient(ctx context.Context, req CreateClientRequest) (*db.Client, error) {
 
 	client, err := s.queries.CreateClient(ctx, db.CreateClientParams{
@@ -51,12 +31,15 @@ func (s *Service) CreateClient(ctx context.Context, req CreateClientRequest) (*d
 	return &client, nil
 }
 
-func (s *Service) ListProfessionals(ctx context.Context) ([]db.ListProfessionalsRow, error) {
-	profs, err := s.queries.ListProfessionals(ctx)
+func (s *Service) GetProfessional(ctx context.Context, profID int64) (*db.Professional, error) {
+	prof, err := s.queries.GetProfessional(ctx, profID)
 	if err != nil {
-		return nil, fmt.Errorf("error listando profesionales: %w", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("error obteniendo profesional %d: %w", profID, err)
 	}
-	return profs, nil
+	return &prof, nil
 }
 
 func (s *Service) ListClients(ctx context.Context, professionalID int64) ([]db.Client, error) {
@@ -64,5 +47,24 @@ func (s *Service) ListClients(ctx context.Context, professionalID int64) ([]db.C
 	if err != nil {
 		return nil, fmt.Errorf("error listando clientes para profesional %d: %w", professionalID, err)
 	}
+	// La medicación es dato de salud: los asistentes ven sólo los datos de contacto
+	if ActingAssistantID(ctx) != 0 {
+		for i := range clients {
+			clients[i].Medications = sql.NullString{}
+		}
+	}
 	return clients, nil
 }
+
+// getOwnedClient trae el paciente sólo si es del profesional; si no, ErrNotFound (no se
+// distingue "no existe" de "es de otro consultorio").
+func (s *Service) getOwnedClient(ctx context.Context, profID, clientID int64) (*db.Client, error) {
+	client, err := s.queries.GetClient(ctx, db.GetClientParams{ID: clientID, ProfessionalID: profID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("error obteniendo paciente: %w", err)
+	}
+	return &client, nil
+}
@@ -2,11 +2,18 @@ package main
 
 import (
 	"database/sql"
+	"errors"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/luciluz/psiconexo/internal/api"
+	"github.com/luciluz/psiconexo/internal/auth"
+	"github.com/luciluz/psiconexo/internal/blobstore"
+	"github.com/luciluz/psiconexo/internal/captcha"
 	"github.com/luciluz/psiconexo/internal/db"
+	"github.com/luciluz/psiconexo/internal/keyring"
+	"github.com/luciluz/psiconexo/internal/mailer"
 	"github.com/luciluz/psiconexo/internal/service"
 
 	_ "github.com/lib/pq"
@@ -14,25 +21,19 @@ import (
 
 func main() {
 
-	dbSource := os.Getenv("DB_SOURCE")
-	if dbSource == "" {
-		log.Fatal("FATAL: La variable de entorno DB_SOURCE es obligatoria.")
+	// 1. Subcomandos de línea de comandos (ej: psiconexo export-finances ...)
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1], os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
 
-	// 2. Abrimos la conexión con el driver "postgres"
-	conn, err := sql.Open("postgres", dbSource)
-	if err != nil {
-		log.Fatal("Error abriendo conexión a DB:", err)
-	}
+	conn := openDB()
 	defer func() {
 		_ = conn.Close()
 	}()
 
-	// 3. Verificamos que la base de datos responda (Ping)
-	if err := conn.Ping(); err != nil {
-		log.Fatal("No se pudo conectar a la base de datos Postgres:", err)
-	}
-
 	log.Println("Verificando estructura de base de datos...")
 
 	// Leemos el archivo SQL
@@ -49,13 +50,100 @@ func main() {
 
 	// 4. Inicialización de Capas
 	queries := db.New(conn)
-	svc := service.NewService(queries, conn)
+	svc := service.NewService(queries, conn, serviceOptions()...)
+	if !svc.AuthConfigured() {
+		log.Fatal("FATAL: La variable de entorno JWT_SECRET es obligatoria para levantar el servidor.")
+	}
 	handler := api.NewHandler(svc)
 
 	r := api.NewRouter(handler)
+	if err := r.SetTrustedProxies(trustedProxies()); err != nil {
+		log.Fatal("Error configurando TRUSTED_PROXIES: ", err)
+	}
 
 	log.Println("Servidor corriendo en http://localhost:8080")
 	if err := r.Run(":8080"); err != nil {
 		log.Fatal(err)
 	}
 }
+
+func openDB() *sql.DB {
+	dbSource := os.Getenv("DB_SOURCE")
+	if dbSource == "" {
+		log.Fatal("FATAL: La variable de entorno DB_SOURCE es obligatoria.")
+	}
+
+	// 2. Abrimos la conexión con el driver "postgres"
+	conn, err := sql.Open("postgres", dbSource)
+	if err != nil {
+		log.Fatal("Error abriendo conexión a DB:", err)
+	}
+
+	// 3. Verificamos que la base de datos responda (Ping)
+	if err := conn.Ping(); err != nil {
+		log.Fatal("No se pudo conectar a la base de datos Postgres:", err)
+	}
+
+	return conn
+}
+
+// trustedProxies lee TRUSTED_PROXIES: IPs o rangos CIDR separados por coma (ej: el balanceador).
+// Sólo de ellos se acepta X-Forwarded-For; sin la variable no se confía en ninguno.
+func trustedProxies() []string {
+	var proxies []string
+	for _, p := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	return proxies
+}
+
+// serviceOptions arma las dependencias opcionales del servicio a partir del entorno.
+func serviceOptions() []service.Option {
+	var opts []service.Option
+
+	keys, err := keyring.FromEnv()
+	switch {
+	case err == nil:
+		opts = append(opts, service.WithKeyring(keys))
+	case errors.Is(err, keyring.ErrNoMasterKey):
+		log.Println("ADVERTENCIA: sin clave maestra configurada, la historia clínica no estará disponible.")
+	default:
+		log.Fatal("Error cargando claves de cifrado: ", err)
+	}
+
+	opts = append(opts, service.WithMailer(mailer.FromEnv()))
+	if appURL := os.Getenv("APP_URL"); appURL != "" {
+		opts = append(opts, service.WithAppURL(appURL))
+	}
+
+	// Los subcomandos no la necesitan: el servidor verifica aparte que esté
+	tokens, err := auth.FromEnv()
+	switch {
+	case err == nil:
+		opts = append(opts, service.WithTokens(tokens))
+	case !errors.Is(err, auth.ErrNoSecret):
+		log.Fatal("Error configurando sesiones: ", err)
+	}
+
+	verifier, err := captcha.FromEnv()
+	switch {
+	case err != nil:
+		log.Fatal("Error configurando captcha: ", err)
+	case verifier != nil:
+		opts = append(opts, service.WithCaptcha(verifier))
+	}
+
+	store, err := blobstore.FromEnv()
+	switch {
+	case err != nil:
+		log.Fatal("Error configurando almacenamiento de archivos: ", err)
+	case store == nil:
+		log.Println("ADVERTENCIA: sin BLOB_STORE configurado, los adjuntos no estarán disponibles.")
+	default:
+		opts = append(opts, service.WithBlobStore(store))
+	}
+
+	return opts
+}